@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// HeaderAPIKey carries the API key of server-to-server integrations.
+	HeaderAPIKey = "X-API-Key"
+	// HeaderBuyerID names the partner's customer a request made with an API key acts for.
+	HeaderBuyerID = "X-Buyer-ID"
+)
+
+type APIKeyHandler struct {
+	apiKeyUC *uc.APIKeyUC
+}
+
+func NewAPIKeyHandler(apiKeyUC *uc.APIKeyUC) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyUC: apiKeyUC,
+	}
+}
+
+// APIKeyMiddleware authenticates requests carrying an X-API-Key header and puts the key's claims on the context.
+// Requests without the header are left to AuthMiddleware.
+func (rc *APIKeyHandler) APIKeyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		plain := c.Request().Header.Get(HeaderAPIKey)
+		if plain == "" {
+			return next(c)
+		}
+
+		key, err := rc.apiKeyUC.Authenticate(c.Request().Context(), plain)
+		if err != nil {
+			return HandleEchoError(c, err)
+		}
+
+		c.Set(claimsKey, &models.Claims{
+			UserID: "api-key:" + strconv.FormatInt(key.ID, 10),
+			APIKey: key,
+		})
+
+		return next(c)
+	}
+}
+
+// CreateAPIKey godoc
+//
+//	@Summary		CreateAPIKey issues a new API key
+//	@Description	This endpoint issues an API key with the given scopes. The key is only returned once.
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			body			body		models.CreateAPIKeyRequest	true	"API key creation input"
+//	@Success		201				{object}	models.CreateAPIKeyResponse	"Created API key with its plain value"
+//	@Failure		400				{object}	models.FailureResponse		"Error message including details on failure"
+//	@Failure		403				{object}	models.FailureResponse		"Caller is not an admin"
+//	@Router			/api-keys [post]
+func (rc *APIKeyHandler) CreateAPIKey(c echo.Context) error {
+	var request models.CreateAPIKeyRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+	request.CreatedBy = claimsFromContext(c).UserID
+
+	key, plain, err := rc.apiKeyUC.Create(c.Request().Context(), &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := models.CreateAPIKeyResponse{
+		APIKeyResponse: *fillAPIKeyResponse(key),
+		Key:            plain,
+	}
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// ListAPIKeys godoc
+//
+//	@Summary		List API keys
+//	@Description	Retrieves every issued API key, newest first. Plain key values are never returned.
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Success		200				{array}		models.APIKeyResponse	"Issued API keys"
+//	@Failure		403				{object}	models.FailureResponse	"Caller is not an admin"
+//	@Router			/api-keys [get]
+func (rc *APIKeyHandler) ListAPIKeys(c echo.Context) error {
+	keys, err := rc.apiKeyUC.List(c.Request().Context())
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := make([]*models.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		response = append(response, fillAPIKeyResponse(&keys[i]))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// RevokeAPIKey godoc
+//
+//	@Summary		RevokeAPIKey revokes an API key
+//	@Description	This endpoint permanently disables an API key.
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string					true	"ID of the API key"
+//	@Success		200				{object}	models.APIKeyResponse	"Revoked API key"
+//	@Failure		404				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Failure		409				{object}	models.FailureResponse	"API key was already revoked"
+//	@Router			/api-keys/{id} [delete]
+func (rc *APIKeyHandler) RevokeAPIKey(c echo.Context) error {
+	id := c.Param("id")
+
+	key, err := rc.apiKeyUC.Revoke(c.Request().Context(), id)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillAPIKeyResponse(key)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func fillAPIKeyResponse(key *models.APIKey) *models.APIKeyResponse {
+	if key == nil {
+		return &models.APIKeyResponse{}
+	}
+
+	return &models.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedBy:  key.CreatedBy,
+		ExpiresAt:  optionalTime(key.ExpiresAt),
+		LastUsedAt: optionalTime(key.LastUsedAt),
+		RevokedAt:  optionalTime(key.RevokedAt),
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// optionalTime returns nil for the zero time so unset timestamps are omitted from responses.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// buyerOf returns the user the caller buys, holds, sells and looks up seats as. Access tokens act for their own user,
+// API keys for the customer of the partner named in X-Buyer-ID, kept apart from the users of access tokens and from
+// the customers of other keys. Every customer has their own per-user limits and sees only their own purchases.
+func buyerOf(c echo.Context) (string, error) {
+	claims := claimsFromContext(c)
+	buyerID := c.Request().Header.Get(HeaderBuyerID)
+	if claims.APIKey == nil {
+		if buyerID != "" {
+			return "", pkg.NewError(errors.New("buyer id without api key"), "X-Buyer-ID is only accepted with an API key", http.StatusBadRequest)
+		}
+		return claims.UserID, nil
+	}
+
+	if buyerID == "" {
+		return "", pkg.NewError(errors.New("missing buyer id"), "X-Buyer-ID is required with an API key", http.StatusBadRequest)
+	}
+	if len(buyerID) > 64 || strings.ContainsFunc(buyerID, func(r rune) bool { return r < ' ' || r > '~' }) {
+		return "", pkg.NewError(errors.New("invalid buyer id"), "X-Buyer-ID must be up to 64 printable ASCII characters", http.StatusBadRequest)
+	}
+
+	return claims.UserID + ":" + buyerID, nil
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CheckinHandler struct {
+	checkinUC *uc.CheckinUC
+}
+
+func NewCheckinHandler(checkinUC *uc.CheckinUC) *CheckinHandler {
+	return &CheckinHandler{
+		checkinUC: checkinUC,
+	}
+}
+
+// CreateCheckin godoc
+//
+//	@Summary		CreateCheckin checks a scanned credential in
+//	@Description	This endpoint verifies a scanned credential and marks it used, so the same code can't admit two people. The result tells the scanner whether to let the holder in: valid, already_used with the first scan's time and gate, revoked or forged.
+//	@Tags			checkins
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			body			body		models.CheckinRequest	true	"Scanned payload and gate"
+//	@Success		200				{object}	models.CheckinResponse	"Result of the scan"
+//	@Failure		400				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Failure		403				{object}	models.FailureResponse	"Caller is not door staff"
+//	@Router			/checkins [post]
+func (rc *CheckinHandler) CreateCheckin(c echo.Context) error {
+	var request models.CheckinRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+	request.ScannedBy = claimsFromContext(c).UserID
+
+	checkin, err := rc.checkinUC.Check(c.Request().Context(), &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillCheckinResponse(checkin)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetCheckinStats godoc
+//
+//	@Summary		Get check-in stats of a ticket
+//	@Description	Retrieves the live attendance of a ticket, overall and per gate, against the seats sold.
+//	@Tags			checkins
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string					true	"ID of the ticket"
+//	@Success		200				{object}	models.CheckinStats		"Attendance of the ticket"
+//	@Failure		403				{object}	models.FailureResponse	"Caller is not staff"
+//	@Failure		404				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Router			/tickets/{id}/checkins/stats [get]
+func (rc *CheckinHandler) GetCheckinStats(c echo.Context) error {
+	id := c.Param("id")
+
+	stats, err := rc.checkinUC.Stats(c.Request().Context(), id)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+// GetScannerSnapshot godoc
+//
+//	@Summary		Get the scanner snapshot of a ticket
+//	@Description	Retrieves the valid credentials of a ticket, signed with the credentials key, so a scanner can keep checking people in while offline. The snapshot is base64url encoded JSON, verify its signature with the public key before using it.
+//	@Tags			checkins
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string					true	"ID of the ticket"
+//	@Success		200				{object}	models.SignedSnapshot	"Signed snapshot of the ticket's credentials"
+//	@Failure		403				{object}	models.FailureResponse	"Caller is not door staff"
+//	@Failure		404				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Router			/tickets/{id}/scanner/snapshot [get]
+func (rc *CheckinHandler) GetScannerSnapshot(c echo.Context) error {
+	id := c.Param("id")
+
+	snapshot, err := rc.checkinUC.Snapshot(c.Request().Context(), id)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, snapshot)
+}
+
+// SyncScans godoc
+//
+//	@Summary		SyncScans uploads the scans of an offline scanner
+//	@Description	This endpoint records a batch of scans made while a scanner was offline, timed by the device. The earliest scan of a credential lets it in, ties going to the lowest gate, so the verdicts don't depend on which device syncs first. Other scans of the same credential are reported as conflicts.
+//	@Tags			checkins
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string						true	"ID of the ticket"
+//	@Param			body			body		models.ScannerSyncRequest	true	"Device and its offline scans"
+//	@Success		200				{object}	models.ScannerSyncResponse	"Verdict of every scan and the conflicts found"
+//	@Failure		400				{object}	models.FailureResponse		"Error message including details on failure"
+//	@Failure		403				{object}	models.FailureResponse		"Caller is not door staff"
+//	@Failure		404				{object}	models.FailureResponse		"Error message including details on failure"
+//	@Router			/tickets/{id}/scanner/sync [post]
+func (rc *CheckinHandler) SyncScans(c echo.Context) error {
+	id := c.Param("id")
+
+	var request models.ScannerSyncRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+	request.ScannedBy = claimsFromContext(c).UserID
+
+	sync, err := rc.checkinUC.Sync(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := models.ScannerSyncResponse{
+		Results:   make([]models.CheckinResponse, 0, len(sync.Checkins)),
+		Conflicts: sync.Conflicts,
+	}
+	for i := range sync.Checkins {
+		response.Results = append(response.Results, *fillCheckinResponse(&sync.Checkins[i]))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func fillCheckinResponse(checkin *models.Checkin) *models.CheckinResponse {
+	if checkin == nil {
+		return &models.CheckinResponse{}
+	}
+
+	response := models.CheckinResponse{
+		Result:       checkin.Result,
+		CredentialID: checkin.CredentialID,
+		TicketID:     checkin.TicketID,
+		Gate:         checkin.Gate,
+		ScannedAt:    checkin.ScannedAt,
+	}
+	if credential := checkin.Credential; credential != nil {
+		response.PurchaseID = credential.PurchaseID
+		response.Seat = credential.Seat
+		if checkin.Result == models.CheckinResultAlreadyUsed {
+			response.FirstScannedAt = optionalTime(credential.UsedAt)
+			response.FirstGate = credential.UsedGate
+		}
+	}
+
+	return &response
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CredentialHandler struct {
+	credentialUC *uc.CredentialUC
+}
+
+func NewCredentialHandler(credentialUC *uc.CredentialUC) *CredentialHandler {
+	return &CredentialHandler{
+		credentialUC: credentialUC,
+	}
+}
+
+// GetQR godoc
+//
+//	@Summary		Get the QR code of a credential
+//	@Description	Renders the signed payload of a valid credential as a PNG QR code to show at the door.
+//	@Tags			credentials
+//	@Produce		png
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string					false	"Customer the API key acts for, required with an API key"
+//	@Param			id				path		string					true	"ID of the credential"
+//	@Success		200				{file}		binary					"QR code of the credential"
+//	@Failure		404				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Failure		410				{object}	models.FailureResponse	"Credential was revoked"
+//	@Router			/credentials/{id}/qr [get]
+func (rc *CredentialHandler) GetQR(c echo.Context) error {
+	id := c.Param("id")
+
+	credential, err := rc.credentialUC.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	// customers only see their own credentials, others are reported as missing
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+	if !claimsFromContext(c).HasRole(staffRoles...) && credential.UserID != buyer {
+		return HandleEchoError(c, pkg.NewError(errors.New("credential belongs to another user"), "failed to find credential", http.StatusNotFound))
+	}
+
+	png, err := rc.credentialUC.QR(credential)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	return c.Blob(http.StatusOK, "image/png", png)
+}
+
+// GetPublicKey godoc
+//
+//	@Summary		Get the credential verification key
+//	@Description	Returns the Ed25519 public key scanners verify credential payloads with, so they can check them offline.
+//	@Tags			credentials
+//	@Produce		json
+//	@Param			Authorization	header		string							true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Success		200				{object}	models.CredentialKeyResponse	"Public key, base64 encoded"
+//	@Router			/credentials/public-key [get]
+func (rc *CredentialHandler) GetPublicKey(c echo.Context) error {
+	response := models.CredentialKeyResponse{
+		Algorithm: "Ed25519",
+		PublicKey: base64.StdEncoding.EncodeToString(rc.credentialUC.PublicKey()),
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func fillCredentialResponses(credentials []models.Credential) []models.CredentialResponse {
+	response := make([]models.CredentialResponse, 0, len(credentials))
+	for _, credential := range credentials {
+		response = append(response, models.CredentialResponse{
+			ID:        credential.ID,
+			Seat:      credential.Seat,
+			Status:    credential.Status,
+			Payload:   credential.Payload,
+			RevokedAt: optionalTime(credential.RevokedAt),
+			UsedAt:    optionalTime(credential.UsedAt),
+		})
+	}
+
+	return response
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"errors"
+	"net/http"
 
 	"github.com/fleimkeipa/tickets-api/pkg"
 
@@ -14,6 +15,8 @@ func HandleEchoError(c echo.Context, err error) error {
 
 	if errors.As(err, &pe) {
 		return c.JSON(pe.StatusCode(), pe.Message())
+	} else if errors.Is(err, pkg.ErrVersionConflict) {
+		return c.JSON(http.StatusPreconditionFailed, "resource was modified by another request")
 	} else {
 		// Log the error
 		return c.JSON(500, "Internal Server Error")
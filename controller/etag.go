@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fleimkeipa/tickets-api/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderETag    = "ETag"
+	HeaderIfMatch = "If-Match"
+)
+
+// setETag exposes the version of a resource as a strong ETag.
+func setETag(c echo.Context, version int) {
+	c.Response().Header().Set(HeaderETag, fmt.Sprintf(`"%d"`, version))
+}
+
+// parseIfMatch returns the version required by the If-Match header, or nil when any version is accepted.
+// A header that can't match any version yields a version conflict.
+func parseIfMatch(c echo.Context) (*int, error) {
+	header := strings.TrimSpace(c.Request().Header.Get(HeaderIfMatch))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	version, err := strconv.Atoi(strings.Trim(tag, `"`))
+	if err != nil {
+		return nil, pkg.NewError(errors.Join(pkg.ErrVersionConflict, err), "If-Match header does not match any version", http.StatusPreconditionFailed)
+	}
+
+	return &version, nil
+}
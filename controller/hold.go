@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/labstack/echo/v4"
+)
+
+type HoldHandler struct {
+	holdUC *uc.HoldUC
+}
+
+func NewHoldHandler(holdUC *uc.HoldUC) *HoldHandler {
+	return &HoldHandler{
+		holdUC: holdUC,
+	}
+}
+
+// CreateHold godoc
+//
+//	@Summary		CreateHold reserves seats of a ticket
+//	@Description	This endpoint reserves a quantity of a ticket for a user for the given number of minutes.
+//	@Tags			holds
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string					false	"Customer the API key acts for, required with an API key"
+//	@Param			id				path		string					true	"ID of the ticket"
+//	@Param			body			body		models.HoldRequest		true	"Ticket hold input"
+//	@Success		201				{object}	models.HoldResponse		"Created hold details"
+//	@Failure		400				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Failure		409				{object}	models.FailureResponse	"Ticket is not on sale or its sales window has not opened"
+//	@Failure		410				{object}	models.FailureResponse	"Ticket sales have closed"
+//	@Router			/tickets/{id}/holds [post]
+func (rc *HoldHandler) CreateHold(c echo.Context) error {
+	id := c.Param("id")
+
+	var request models.HoldRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+	request.UserID = buyer
+
+	hold, err := rc.holdUC.Create(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillHoldResponse(hold)
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// ConfirmHold godoc
+//
+//	@Summary		ConfirmHold turns a hold into a purchase
+//	@Description	This endpoint confirms an active hold and records the purchase of the held seats.
+//	@Tags			holds
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string						false	"Customer the API key acts for, required with an API key"
+//	@Param			id				path		string						true	"ID of the hold"
+//	@Param			body			body		models.ConfirmHoldRequest	false	"Hold confirmation input"
+//	@Success		201				{object}	models.PurchaseResponse		"Created purchase details"
+//	@Failure		409				{object}	models.FailureResponse		"Hold was already confirmed or expired"
+//	@Router			/holds/{id}/confirm [post]
+func (rc *HoldHandler) ConfirmHold(c echo.Context) error {
+	id := c.Param("id")
+
+	var request models.ConfirmHoldRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+	request.UserID = buyer
+
+	purchase, err := rc.holdUC.Confirm(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillPurchaseResponse(purchase)
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+func fillHoldResponse(hold *models.Hold) *models.HoldResponse {
+	if hold == nil {
+		return &models.HoldResponse{}
+	}
+
+	return &models.HoldResponse{
+		ID:         hold.ID,
+		TicketID:   hold.TicketID,
+		TierID:     hold.TierID,
+		UserID:     hold.UserID,
+		Quantity:   hold.Quantity,
+		Status:     hold.Status,
+		UnitPrice:  hold.UnitPrice,
+		Currency:   hold.Currency,
+		PurchaseID: hold.PurchaseID,
+		ExpiresAt:  hold.ExpiresAt,
+		CreatedAt:  hold.CreatedAt,
+	}
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// HeaderIdempotencyKey is sent by clients that want a request to be applied at most once.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks responses that were served from a stored result.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+type IdempotencyHandler struct {
+	idempotencyUC *uc.IdempotencyUC
+}
+
+func NewIdempotencyHandler(idempotencyUC *uc.IdempotencyUC) *IdempotencyHandler {
+	return &IdempotencyHandler{
+		idempotencyUC: idempotencyUC,
+	}
+}
+
+// captureWriter copies the response body while it is written to the client.
+type captureWriter struct {
+	http.ResponseWriter
+	body *bytes.Buffer
+}
+
+// Write captures the response body while continuing to write to the original writer.
+func (rc *captureWriter) Write(b []byte) (int, error) {
+	rc.body.Write(b)
+	return rc.ResponseWriter.Write(b)
+}
+
+// IdempotencyMiddleware replays the stored response of requests carrying an already used Idempotency-Key.
+// Requests without the header are passed through untouched.
+func (rc *IdempotencyHandler) IdempotencyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		key := c.Request().Header.Get(HeaderIdempotencyKey)
+		if key == "" {
+			return next(c)
+		}
+
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return HandleEchoError(c, err)
+		}
+		c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+		// the outcome is stored even when the client goes away mid-request
+		ctx := context.WithoutCancel(c.Request().Context())
+
+		stored, err := rc.idempotencyUC.Begin(ctx, key, requestFingerprint(c, body))
+		if err != nil {
+			return HandleEchoError(c, err)
+		}
+
+		if stored != nil {
+			c.Response().Header().Set(HeaderIdempotentReplayed, "true")
+			return c.Blob(stored.StatusCode, echo.MIMEApplicationJSONCharsetUTF8, stored.ResponseBody)
+		}
+
+		writer := &captureWriter{
+			ResponseWriter: c.Response().Writer,
+			body:           new(bytes.Buffer),
+		}
+		c.Response().Writer = writer
+
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+
+		// server side failures are not stored, the client is free to retry them
+		if c.Response().Status >= http.StatusInternalServerError {
+			return rc.idempotencyUC.Release(ctx, key)
+		}
+
+		return rc.idempotencyUC.Complete(ctx, key, c.Response().Status, writer.body.Bytes())
+	}
+}
+
+// requestFingerprint identifies a request by its caller, method, path and body, so a key
+// reused by another user is rejected instead of replaying someone else's response.
+func requestFingerprint(c echo.Context, body []byte) string {
+	var userID string
+	if claims := claimsFromContext(c); claims != nil {
+		userID = claims.UserID
+	}
+
+	req := c.Request()
+	hash := sha256.New()
+	hash.Write([]byte(userID + "\n"))
+	hash.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ListingHandler struct {
+	listingUC *uc.ListingUC
+}
+
+func NewListingHandler(listingUC *uc.ListingUC) *ListingHandler {
+	return &ListingHandler{
+		listingUC: listingUC,
+	}
+}
+
+// CreateListing godoc
+//
+//	@Summary		CreateListing puts a seat up for resale
+//	@Description	This endpoint lists a purchased seat, named by its credential, at an asking price capped at a configured percentage of its face value. Seats can only be resold while the ticket is in its sales window.
+//	@Tags			listings
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string						false	"Customer the API key acts for, required with an API key"
+//	@Param			body			body		models.CreateListingRequest	true	"Seat and asking price"
+//	@Success		201				{object}	models.ListingResponse		"Active listing"
+//	@Failure		400				{object}	models.FailureResponse		"Price above the cap or invalid request"
+//	@Failure		403				{object}	models.FailureResponse		"Seat belongs to another user"
+//	@Failure		409				{object}	models.FailureResponse		"Seat is already listed, can't be resold or the ticket is outside its sales window"
+//	@Router			/listings [post]
+func (rc *ListingHandler) CreateListing(c echo.Context) error {
+	var request models.CreateListingRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+	request.SellerID = buyer
+
+	listing, err := rc.listingUC.Create(c.Request().Context(), &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillListingResponse(listing)
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// SearchListings godoc
+//
+//	@Summary		Search resale listings
+//	@Description	Retrieves a page of active resale listings, optionally filtered by ticket, tier and price, cheapest first by default.
+//	@Tags			listings
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			ticket_id		query		int							false	"Only listings of this ticket"
+//	@Param			tier_id			query		int							false	"Only listings of this tier"
+//	@Param			max_price		query		int							false	"Highest asking price in minor units"
+//	@Param			sort			query		string						false	"Sort field"	Enums(price, id)
+//	@Param			limit			query		int							false	"Page size, 20 by default and 100 at most"
+//	@Param			cursor			query		string						false	"next_cursor of the previous page"
+//	@Success		200				{object}	models.ListingListResponse	"Page of listings"
+//	@Failure		400				{object}	models.FailureResponse		"Error message including details on failure"
+//	@Router			/listings [get]
+func (rc *ListingHandler) SearchListings(c echo.Context) error {
+	var request models.ListingSearchRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	return rc.search(c, &request)
+}
+
+// ListTicketListings godoc
+//
+//	@Summary		List resale listings of a ticket
+//	@Description	Retrieves a page of active resale listings of a ticket, cheapest first by default.
+//	@Tags			listings
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string						true	"ID of the ticket"
+//	@Param			sort			query		string						false	"Sort field"	Enums(price, id)
+//	@Param			limit			query		int							false	"Page size, 20 by default and 100 at most"
+//	@Param			cursor			query		string						false	"next_cursor of the previous page"
+//	@Success		200				{object}	models.ListingListResponse	"Page of listings"
+//	@Failure		400				{object}	models.FailureResponse		"Error message including details on failure"
+//	@Router			/tickets/{id}/listings [get]
+func (rc *ListingHandler) ListTicketListings(c echo.Context) error {
+	var request models.ListingSearchRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	ticketID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return HandleEchoError(c, pkg.NewError(err, "invalid ticket id", http.StatusBadRequest))
+	}
+	request.TicketID = ticketID
+
+	return rc.search(c, &request)
+}
+
+// GetListing godoc
+//
+//	@Summary		Get a resale listing by ID
+//	@Description	Retrieves a resale listing, whatever its status.
+//	@Tags			listings
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string					true	"ID of the listing"
+//	@Success		200				{object}	models.ListingResponse	"Details of the listing"
+//	@Failure		404				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Router			/listings/{id} [get]
+func (rc *ListingHandler) GetListing(c echo.Context) error {
+	id := c.Param("id")
+
+	listing, err := rc.listingUC.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillListingResponse(listing)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// BuyListing godoc
+//
+//	@Summary		BuyListing buys a resale listing
+//	@Description	This endpoint buys a listed seat at its asking price. The seat moves to a new purchase of the buyer with a new credential, the seller's credential is revoked and the listing is closed, all at once.
+//	@Tags			listings
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string					false	"Customer the API key acts for, required with an API key"
+//	@Param			id				path		string					true	"ID of the listing"
+//	@Success		200				{object}	models.ListingResponse	"Sold listing with the buyer's purchase"
+//	@Failure		400				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Failure		402				{object}	models.FailureResponse	"Payment was declined, the listing stays on sale"
+//	@Failure		404				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Failure		409				{object}	models.FailureResponse	"Listing is not available anymore or the ticket is outside its sales window"
+//	@Router			/listings/{id}/buy [post]
+func (rc *ListingHandler) BuyListing(c echo.Context) error {
+	id := c.Param("id")
+
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	request := models.BuyListingRequest{BuyerID: buyer}
+
+	listing, err := rc.listingUC.Buy(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillListingResponse(listing)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// CancelListing godoc
+//
+//	@Summary		CancelListing takes a listing off sale
+//	@Description	This endpoint withdraws an active resale listing, the seat stays with the seller.
+//	@Tags			listings
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string					false	"Customer the API key acts for, required with an API key"
+//	@Param			id				path		string					true	"ID of the listing"
+//	@Success		200				{object}	models.ListingResponse	"Cancelled listing"
+//	@Failure		403				{object}	models.FailureResponse	"Listing belongs to another user"
+//	@Failure		404				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Failure		409				{object}	models.FailureResponse	"Listing was already sold or cancelled"
+//	@Router			/listings/{id} [delete]
+func (rc *ListingHandler) CancelListing(c echo.Context) error {
+	id := c.Param("id")
+
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	// staff may take any listing off sale
+	var request models.CancelListingRequest
+	if !claimsFromContext(c).HasRole(staffRoles...) {
+		request.UserID = buyer
+	}
+
+	listing, err := rc.listingUC.Cancel(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillListingResponse(listing)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (rc *ListingHandler) search(c echo.Context, request *models.ListingSearchRequest) error {
+	listings, nextCursor, err := rc.listingUC.Search(c.Request().Context(), request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := models.ListingListResponse{
+		Listings:   make([]models.ListingResponse, 0, len(listings)),
+		NextCursor: nextCursor,
+	}
+	for i := range listings {
+		response.Listings = append(response.Listings, *fillListingResponse(&listings[i]))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func fillListingResponse(listing *models.Listing) *models.ListingResponse {
+	if listing == nil {
+		return &models.ListingResponse{}
+	}
+
+	response := &models.ListingResponse{
+		ID:              listing.ID,
+		TicketID:        listing.TicketID,
+		TierID:          listing.TierID,
+		Seat:            listing.Seat,
+		SellerID:        listing.SellerID,
+		Price:           listing.Price,
+		FaceValue:       listing.FaceValue,
+		Currency:        listing.Currency,
+		Status:          listing.Status,
+		BuyerID:         listing.BuyerID,
+		BuyerPurchaseID: listing.BuyerPurchaseID,
+		SoldAt:          optionalTime(listing.SoldAt),
+		CreatedAt:       listing.CreatedAt,
+	}
+	if listing.Purchase != nil {
+		response.Purchase = fillPurchaseResponse(listing.Purchase)
+	}
+
+	return response
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderPaymentSignature carries the HMAC signature of payment provider webhooks.
+const HeaderPaymentSignature = "X-Payment-Signature"
+
+type PaymentHandler struct {
+	paymentUC *uc.PaymentUC
+}
+
+func NewPaymentHandler(paymentUC *uc.PaymentUC) *PaymentHandler {
+	return &PaymentHandler{
+		paymentUC: paymentUC,
+	}
+}
+
+// HandleWebhook godoc
+//
+//	@Summary		HandleWebhook receives payment provider webhooks
+//	@Description	This endpoint records captures and refunds reported by the payment provider. It takes no access token, the raw body must be signed in the X-Payment-Signature header.
+//	@Tags			payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Payment-Signature	header		string					true	"HMAC-SHA256 signature of the body"
+//	@Param			body				body		pkg.PaymentEvent		true	"Payment event"
+//	@Success		204					"Event processed, no content"
+//	@Failure		400					{object}	models.FailureResponse	"Error message including details on failure"
+//	@Failure		401					{object}	models.FailureResponse	"Invalid signature"
+//	@Router			/payments/webhook [post]
+func (rc *PaymentHandler) HandleWebhook(c echo.Context) error {
+	// the signature covers the exact bytes that were sent, so the body is read raw
+	payload, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return HandleEchoError(c, pkg.NewError(err, "failed to read webhook", http.StatusBadRequest))
+	}
+
+	signature := c.Request().Header.Get(HeaderPaymentSignature)
+	if err := rc.paymentUC.HandleWebhook(c.Request().Context(), payload, signature); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PromoCodeHandler struct {
+	promoCodeUC *uc.PromoCodeUC
+}
+
+func NewPromoCodeHandler(promoCodeUC *uc.PromoCodeUC) *PromoCodeHandler {
+	return &PromoCodeHandler{
+		promoCodeUC: promoCodeUC,
+	}
+}
+
+// CreatePromoCode godoc
+//
+//	@Summary		CreatePromoCode adds a promo code
+//	@Description	This endpoint adds a percent or fixed amount promo code with optional caps, validity window and ticket scope.
+//	@Tags			promo-codes
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string							true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			body			body		models.CreatePromoCodeRequest	true	"Promo code creation input"
+//	@Success		201				{object}	models.PromoCodeResponse		"Created promo code"
+//	@Failure		400				{object}	models.FailureResponse			"Error message including details on failure"
+//	@Failure		403				{object}	models.FailureResponse			"Caller is not an admin"
+//	@Failure		409				{object}	models.FailureResponse			"A promo code with the same code exists"
+//	@Router			/promo-codes [post]
+func (rc *PromoCodeHandler) CreatePromoCode(c echo.Context) error {
+	var request models.CreatePromoCodeRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	promo, err := rc.promoCodeUC.Create(c.Request().Context(), &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillPromoCodeResponse(promo)
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// ListPromoCodes godoc
+//
+//	@Summary		List promo codes
+//	@Description	Retrieves every promo code with its redemption count, newest first.
+//	@Tags			promo-codes
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Success		200				{array}		models.PromoCodeResponse	"Promo codes"
+//	@Failure		403				{object}	models.FailureResponse		"Caller is not an admin"
+//	@Router			/promo-codes [get]
+func (rc *PromoCodeHandler) ListPromoCodes(c echo.Context) error {
+	promos, err := rc.promoCodeUC.List(c.Request().Context())
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := make([]*models.PromoCodeResponse, 0, len(promos))
+	for i := range promos {
+		response = append(response, fillPromoCodeResponse(&promos[i]))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetPromoCode godoc
+//
+//	@Summary		Get a promo code by ID
+//	@Description	Retrieves a promo code with its redemption count.
+//	@Tags			promo-codes
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string						true	"ID of the promo code"
+//	@Success		200				{object}	models.PromoCodeResponse	"Promo code details"
+//	@Failure		404				{object}	models.FailureResponse		"Error message including details on failure"
+//	@Router			/promo-codes/{id} [get]
+func (rc *PromoCodeHandler) GetPromoCode(c echo.Context) error {
+	id := c.Param("id")
+
+	promo, err := rc.promoCodeUC.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillPromoCodeResponse(promo)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// UpdatePromoCode godoc
+//
+//	@Summary		UpdatePromoCode partially updates a promo code
+//	@Description	This endpoint updates the value, caps, validity window and ticket scope of a promo code, omitted fields are kept.
+//	@Tags			promo-codes
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string							true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string							true	"ID of the promo code"
+//	@Param			body			body		models.UpdatePromoCodeRequest	true	"Promo code update input"
+//	@Success		200				{object}	models.PromoCodeResponse		"Updated promo code"
+//	@Failure		400				{object}	models.FailureResponse			"Error message including details on failure"
+//	@Failure		404				{object}	models.FailureResponse			"Promo code not found"
+//	@Router			/promo-codes/{id} [patch]
+func (rc *PromoCodeHandler) UpdatePromoCode(c echo.Context) error {
+	id := c.Param("id")
+
+	var request models.UpdatePromoCodeRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	promo, err := rc.promoCodeUC.Update(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillPromoCodeResponse(promo)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// DeletePromoCode godoc
+//
+//	@Summary		DeletePromoCode deletes a promo code
+//	@Description	This endpoint deletes a promo code so it can't be redeemed anymore, purchases made with it are kept.
+//	@Tags			promo-codes
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string					true	"ID of the promo code"
+//	@Success		204				"Promo code deleted, no content"
+//	@Failure		404				{object}	models.FailureResponse	"Promo code not found"
+//	@Router			/promo-codes/{id} [delete]
+func (rc *PromoCodeHandler) DeletePromoCode(c echo.Context) error {
+	id := c.Param("id")
+
+	if err := rc.promoCodeUC.Delete(c.Request().Context(), id); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func fillPromoCodeResponse(promo *models.PromoCode) *models.PromoCodeResponse {
+	if promo == nil {
+		return &models.PromoCodeResponse{}
+	}
+
+	ticketIDs := promo.TicketIDs
+	if ticketIDs == nil {
+		ticketIDs = []int64{}
+	}
+
+	return &models.PromoCodeResponse{
+		ID:             promo.ID,
+		Code:           promo.Code,
+		DiscountType:   promo.DiscountType,
+		Value:          promo.Value,
+		Currency:       promo.Currency,
+		MaxRedemptions: promo.MaxRedemptions,
+		MaxPerUser:     promo.MaxPerUser,
+		Redemptions:    promo.Redemptions,
+		ValidFrom:      optionalTime(promo.ValidFrom),
+		ValidUntil:     optionalTime(promo.ValidUntil),
+		TicketIDs:      ticketIDs,
+		CreatedAt:      promo.CreatedAt,
+		UpdatedAt:      promo.UpdatedAt,
+	}
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PurchaseHandler struct {
+	purchaseUC *uc.PurchaseUC
+}
+
+func NewPurchaseHandler(purchaseUC *uc.PurchaseUC) *PurchaseHandler {
+	return &PurchaseHandler{
+		purchaseUC: purchaseUC,
+	}
+}
+
+// GetByID godoc
+//
+//	@Summary		Get a purchase by ID
+//	@Description	Retrieves a purchase record from the database by its ID.
+//	@Tags			purchases
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string					false	"Customer the API key acts for, required with an API key"
+//	@Param			purchaseID		path		string					true	"ID of the purchase"
+//	@Success		200				{object}	models.PurchaseResponse	"Details of the requested purchase"
+//	@Failure		404				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Router			/purchases/{purchaseID} [get]
+func (rc *PurchaseHandler) GetByID(c echo.Context) error {
+	id := c.Param("purchaseID")
+
+	purchase, err := rc.purchaseUC.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	// customers only see their own purchases, others are reported as missing
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+	if !claimsFromContext(c).HasRole(staffRoles...) && purchase.UserID != buyer {
+		return HandleEchoError(c, pkg.NewError(errors.New("purchase belongs to another user"), "failed to find purchase", http.StatusNotFound))
+	}
+
+	response := fillPurchaseResponse(purchase)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ListByTicketID godoc
+//
+//	@Summary		List purchases of a ticket
+//	@Description	Retrieves every purchase record of a ticket, oldest first.
+//	@Tags			purchases
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string						true	"ID of the ticket"
+//	@Success		200				{array}		models.PurchaseResponse		"Purchases of the requested ticket"
+//	@Failure		403				{object}	models.FailureResponse		"Caller is not staff"
+//	@Failure		404				{object}	models.FailureResponse		"Error message including details on failure"
+//	@Router			/tickets/{id}/purchases [get]
+func (rc *PurchaseHandler) ListByTicketID(c echo.Context) error {
+	id := c.Param("id")
+
+	purchases, err := rc.purchaseUC.ListByTicketID(c.Request().Context(), id)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := make([]*models.PurchaseResponse, 0, len(purchases))
+	for i := range purchases {
+		response = append(response, fillPurchaseResponse(&purchases[i]))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// CancelPurchase godoc
+//
+//	@Summary		CancelPurchase cancels a purchase
+//	@Description	This endpoint refunds a purchase, fully or partially, and returns the seats to the ticket.
+//	@Tags			purchases
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string							true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string							false	"Customer the API key acts for, required with an API key"
+//	@Param			purchaseID		path		string							true	"ID of the purchase"
+//	@Param			body			body		models.CancelPurchaseRequest	false	"Quantity to cancel, everything when omitted"
+//	@Success		200				{object}	models.PurchaseResponse			"Refunded purchase details"
+//	@Failure		403				{object}	models.FailureResponse			"Purchase belongs to another user"
+//	@Failure		409				{object}	models.FailureResponse			"Purchase already refunded or outside the cancellation window"
+//	@Failure		502				{object}	models.FailureResponse			"Payment provider refused the refund, the seats stay sold"
+//	@Router			/purchases/{purchaseID}/cancel [post]
+func (rc *PurchaseHandler) CancelPurchase(c echo.Context) error {
+	id := c.Param("purchaseID")
+
+	var request models.CancelPurchaseRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	// staff may cancel on behalf of any user
+	if !claimsFromContext(c).HasRole(staffRoles...) {
+		request.UserID = buyer
+	}
+
+	purchase, err := rc.purchaseUC.Cancel(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillPurchaseResponse(purchase)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func fillPurchaseResponse(purchase *models.Purchase) *models.PurchaseResponse {
+	if purchase == nil {
+		return &models.PurchaseResponse{}
+	}
+
+	response := &models.PurchaseResponse{
+		ID:               purchase.ID,
+		TicketID:         purchase.TicketID,
+		TierID:           purchase.TierID,
+		UserID:           purchase.UserID,
+		Quantity:         purchase.Quantity,
+		RefundedQuantity: purchase.RefundedQuantity,
+		Status:           purchase.Status,
+		UnitPrice:        purchase.UnitPrice,
+		Currency:         purchase.Currency,
+		Subtotal:         purchase.Subtotal,
+		Discount:         purchase.Discount,
+		Total:            purchase.Total,
+		PromoCode:        purchase.PromoCode,
+		PaymentID:        purchase.PaymentID,
+		PaymentStatus:    purchase.PaymentStatus,
+		PaymentRefunded:  purchase.PaymentRefunded,
+		CreatedAt:        purchase.CreatedAt,
+		UpdatedAt:        purchase.UpdatedAt,
+		ResoldQuantity:   purchase.ResoldQuantity,
+		ResaleListingID:  purchase.ResaleListingID,
+	}
+	if len(purchase.Credentials) > 0 {
+		response.Credentials = fillCredentialResponses(purchase.Credentials)
+	}
+
+	return response
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/fleimkeipa/tickets-api/models"
@@ -27,9 +28,12 @@ func NewTicketHandler(ticketUC *uc.TicketUC) *TicketHandler {
 //	@Accept			json
 //	@Produce		json
 //	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
-//	@Param			body			{object}	models.CreateRequest	true	"Ticket creation input"
+//	@Param			Idempotency-Key	header		string					false	"Unique key to safely retry the request"
+//	@Param			body			body		models.CreateRequest	true	"Ticket creation input"
 //	@Success		201				{object}	models.TicketResponse			"Created ticket details"
 //	@Failure		400				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Failure		403				{object}	models.FailureResponse	"Caller is not an admin or organizer"
+//	@Failure		422				{object}	models.FailureResponse	"Idempotency key reused with a different request"
 //	@Router			/tickets [post]
 func (rc *TicketHandler) CreateTicket(c echo.Context) error {
 	var request models.CreateRequest
@@ -56,10 +60,19 @@ func (rc *TicketHandler) CreateTicket(c echo.Context) error {
 //	@Accept			json
 //	@Produce		json
 //	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
-//	@Param			body			{object}	models.PurchaseRequest	true	"Ticket purchase input"
-//	@Success		204				"Purchase successful, no content"
+//	@Param			id				path		string					true	"ID of the ticket"
+//	@Param			X-Buyer-ID		header		string					false	"Customer the purchase is for, required with an API key"
+//	@Param			Idempotency-Key	header		string					false	"Unique key to safely retry the request"
+//	@Param			body			body		models.PurchaseRequest	true	"Ticket purchase input"
+//	@Success		201				{object}	models.PurchaseResponse	"Created purchase details"
 //	@Failure		400				{object}	models.FailureResponse	"Error message including details on failure"
-//	@Router			/tickets/{id}/purchase [post]
+//	@Failure		401				{object}	models.FailureResponse	"Missing or invalid access token"
+//	@Failure		402				{object}	models.FailureResponse	"Payment declined, no seats were taken"
+//	@Failure		409				{object}	models.FailureResponse	"Ticket is not on sale or its sales window has not opened"
+//	@Failure		410				{object}	models.FailureResponse	"Ticket sales have closed"
+//	@Failure		422				{object}	models.FailureResponse	"Idempotency key reused with a different request"
+//	@Failure		504				{object}	models.FailureResponse	"Payment provider timed out, no seats were taken"
+//	@Router			/tickets/{id}/purchases [post]
 func (rc *TicketHandler) PurchaseTicket(c echo.Context) error {
 	id := c.Param("id")
 
@@ -67,16 +80,175 @@ func (rc *TicketHandler) PurchaseTicket(c echo.Context) error {
 	if err := c.Bind(&request); err != nil {
 		return HandleEchoError(c, err)
 	}
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+	request.UserID = buyer
 
-	_, err := rc.ticketUC.Purchase(c.Request().Context(), id, &request)
+	purchase, err := rc.ticketUC.Purchase(c.Request().Context(), id, &request)
 	if err != nil {
 		return HandleEchoError(c, err)
 	}
 
-	return c.NoContent(http.StatusOK)
+	response := fillPurchaseResponse(purchase)
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// UpdateTicket godoc
+//
+//	@Summary		UpdateTicket partially updates a ticket
+//	@Description	This endpoint updates the name, description and allocation of a ticket, omitted fields are kept.
+//	@Tags			tickets
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string					true	"ID of the ticket"
+//	@Param			If-Match		header		string					false	"ETag of the ticket the update is based on"
+//	@Param			body			body		models.UpdateRequest	true	"Ticket update input"
+//	@Success		200				{object}	models.TicketResponse	"Updated ticket details"
+//	@Header			200				{string}	ETag					"Version of the updated ticket"
+//	@Failure		400				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Failure		403				{object}	models.FailureResponse	"Caller is not an admin or organizer"
+//	@Failure		412				{object}	models.FailureResponse	"Ticket was modified since the given ETag"
+//	@Router			/tickets/{id} [patch]
+func (rc *TicketHandler) UpdateTicket(c echo.Context) error {
+	id := c.Param("id")
+
+	var request models.UpdateRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	ticket, err := rc.ticketUC.Update(c.Request().Context(), id, &request, expectedVersion)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	setETag(c, ticket.Version)
+	response := fillTicketResponse(ticket)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// PublishTicket godoc
+//
+//	@Summary		PublishTicket puts a ticket on sale
+//	@Description	This endpoint puts a draft or paused ticket on sale, a ticket without seats left becomes sold out.
+//	@Tags			tickets
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string					true	"ID of the ticket"
+//	@Param			If-Match		header		string					false	"ETag of the ticket the change is based on"
+//	@Success		200				{object}	models.TicketResponse	"Updated ticket details"
+//	@Header			200				{string}	ETag					"Version of the updated ticket"
+//	@Failure		403				{object}	models.FailureResponse	"Caller is not an admin or organizer"
+//	@Failure		409				{object}	models.FailureResponse	"Ticket can't move to the requested state"
+//	@Failure		412				{object}	models.FailureResponse	"Ticket was modified since the given ETag"
+//	@Router			/tickets/{id}/publish [post]
+func (rc *TicketHandler) PublishTicket(c echo.Context) error {
+	return rc.transition(c, rc.ticketUC.Publish)
+}
+
+// PauseTicket godoc
+//
+//	@Summary		PauseTicket pauses the sales of a ticket
+//	@Description	This endpoint stops the sales of a ticket until it is published again.
+//	@Tags			tickets
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string					true	"ID of the ticket"
+//	@Param			If-Match		header		string					false	"ETag of the ticket the change is based on"
+//	@Success		200				{object}	models.TicketResponse	"Updated ticket details"
+//	@Header			200				{string}	ETag					"Version of the updated ticket"
+//	@Failure		403				{object}	models.FailureResponse	"Caller is not an admin or organizer"
+//	@Failure		409				{object}	models.FailureResponse	"Ticket can't move to the requested state"
+//	@Failure		412				{object}	models.FailureResponse	"Ticket was modified since the given ETag"
+//	@Router			/tickets/{id}/pause [post]
+func (rc *TicketHandler) PauseTicket(c echo.Context) error {
+	return rc.transition(c, rc.ticketUC.Pause)
+}
+
+// CancelTicket godoc
+//
+//	@Summary		CancelTicket cancels a ticket
+//	@Description	This endpoint permanently stops the sales of a ticket, existing purchases are kept.
+//	@Tags			tickets
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string					true	"ID of the ticket"
+//	@Param			If-Match		header		string					false	"ETag of the ticket the change is based on"
+//	@Success		200				{object}	models.TicketResponse	"Updated ticket details"
+//	@Header			200				{string}	ETag					"Version of the updated ticket"
+//	@Failure		403				{object}	models.FailureResponse	"Caller is not an admin or organizer"
+//	@Failure		409				{object}	models.FailureResponse	"Ticket can't move to the requested state"
+//	@Failure		412				{object}	models.FailureResponse	"Ticket was modified since the given ETag"
+//	@Router			/tickets/{id}/cancel [post]
+func (rc *TicketHandler) CancelTicket(c echo.Context) error {
+	return rc.transition(c, rc.ticketUC.Cancel)
+}
+
+// transition applies a lifecycle change to the ticket of the request.
+func (rc *TicketHandler) transition(c echo.Context, change func(ctx context.Context, ticketID string, expectedVersion *int) (*models.Ticket, error)) error {
+	id := c.Param("id")
+
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	ticket, err := change(c.Request().Context(), id, expectedVersion)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	setETag(c, ticket.Version)
+	response := fillTicketResponse(ticket)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// DeleteTicket godoc
+//
+//	@Summary		DeleteTicket deletes a ticket
+//	@Description	This endpoint soft deletes a ticket, hard=true removes it permanently when it has no purchases.
+//	@Tags			tickets
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string					true	"ID of the ticket"
+//	@Param			hard			query		bool					false	"Permanently delete the ticket"
+//	@Param			If-Match		header		string					false	"ETag of the ticket the delete is based on"
+//	@Success		204				"Ticket deleted, no content"
+//	@Failure		409				{object}	models.FailureResponse	"Ticket with purchases can't be hard deleted"
+//	@Failure		403				{object}	models.FailureResponse	"Caller is not an admin or organizer"
+//	@Failure		412				{object}	models.FailureResponse	"Ticket was modified since the given ETag"
+//	@Router			/tickets/{id} [delete]
+func (rc *TicketHandler) DeleteTicket(c echo.Context) error {
+	id := c.Param("id")
+	hard := c.QueryParam("hard") == "true"
+
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	if err := rc.ticketUC.Delete(c.Request().Context(), id, hard, expectedVersion); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
 }
 
-// GetByID godoc
 // GetByID godoc
 //
 //	@Summary		Get a ticket by ID
@@ -87,6 +259,7 @@ func (rc *TicketHandler) PurchaseTicket(c echo.Context) error {
 //	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
 //	@Param			id				path		string					true	"ID of the ticket"
 //	@Success		200				{object}	models.Ticket			"Details of the requested ticket"
+//	@Header			200				{string}	ETag					"Version of the ticket, send it back in If-Match"
 //	@Failure		400				{object}	models.FailureResponse	"Error message including details on failure"
 //	@Router			/tickets/{id} [get]
 func (rc *TicketHandler) GetByID(c echo.Context) error {
@@ -97,20 +270,74 @@ func (rc *TicketHandler) GetByID(c echo.Context) error {
 		return HandleEchoError(c, err)
 	}
 
+	setETag(c, ticket.Version)
 	response := fillTicketResponse(ticket)
 
 	return c.JSON(http.StatusOK, response)
 }
 
+// List godoc
+//
+//	@Summary		List tickets
+//	@Description	Retrieves a page of tickets, optionally filtered by name and availability.
+//	@Tags			tickets
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			name			query		string						false	"Case insensitive name substring"
+//	@Param			available		query		bool						false	"Only tickets with remaining allocation"
+//	@Param			sort			query		string						false	"Sort field"	Enums(id, name, allocation)
+//	@Param			order			query		string						false	"Sort order"	Enums(asc, desc)
+//	@Param			limit			query		int							false	"Page size, 20 by default and 100 at most"
+//	@Param			cursor			query		string						false	"next_cursor of the previous page"
+//	@Success		200				{object}	models.TicketListResponse	"Page of tickets"
+//	@Failure		400				{object}	models.FailureResponse		"Error message including details on failure"
+//	@Router			/tickets [get]
+func (rc *TicketHandler) List(c echo.Context) error {
+	var request models.TicketListRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	tickets, nextCursor, err := rc.ticketUC.List(c.Request().Context(), &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := models.TicketListResponse{
+		Tickets:    make([]models.TicketResponse, 0, len(tickets)),
+		NextCursor: nextCursor,
+	}
+	for i := range tickets {
+		response.Tickets = append(response.Tickets, *fillTicketResponse(&tickets[i]))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
 func fillTicketResponse(ticket *models.Ticket) *models.TicketResponse {
 	if ticket == nil {
 		return &models.TicketResponse{}
 	}
 
-	return &models.TicketResponse{
+	response := &models.TicketResponse{
 		ID:          ticket.ID,
 		Name:        ticket.Name,
 		Description: ticket.Description,
 		Allocation:  ticket.Allocation,
+		Price:       ticket.Price,
+		Currency:    ticket.Currency,
+		Status:      ticket.Status,
+		MaxPerUser:  ticket.MaxPerUser,
+		SalesStart:  optionalTime(ticket.SalesStart),
+		SalesEnd:    optionalTime(ticket.SalesEnd),
+		EventStart:  optionalTime(ticket.EventStart),
+		SaleStatus:  ticket.SaleStatus,
+		Version:     ticket.Version,
+	}
+	if len(ticket.Tiers) > 0 {
+		response.Tiers = fillTierResponses(ticket.Tiers)
 	}
+
+	return response
 }
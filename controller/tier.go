@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/labstack/echo/v4"
+)
+
+type TierHandler struct {
+	tierUC *uc.TierUC
+}
+
+func NewTierHandler(tierUC *uc.TierUC) *TierHandler {
+	return &TierHandler{
+		tierUC: tierUC,
+	}
+}
+
+// CreateTier godoc
+//
+//	@Summary		CreateTier adds a price tier to a ticket
+//	@Description	This endpoint adds a tier with its own allocation, price and sales window. The ticket's allocation becomes the sum of its tiers.
+//	@Tags			tickets
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string						true	"ID of the ticket"
+//	@Param			body			body		models.CreateTierRequest	true	"Tier creation input"
+//	@Success		201				{object}	models.TicketTierResponse	"Created tier"
+//	@Failure		400				{object}	models.FailureResponse		"Error message including details on failure"
+//	@Failure		403				{object}	models.FailureResponse		"Caller is not an admin or organizer"
+//	@Failure		409				{object}	models.FailureResponse		"First tier of a ticket that is not a draft"
+//	@Router			/tickets/{id}/tiers [post]
+func (rc *TierHandler) CreateTier(c echo.Context) error {
+	id := c.Param("id")
+
+	var request models.CreateTierRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	tier, err := rc.tierUC.Create(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillTierResponse(tier)
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// ListTiers godoc
+//
+//	@Summary		List tiers of a ticket
+//	@Description	Retrieves the tiers of a ticket in their order, with the seats each tier has left.
+//	@Tags			tickets
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string						true	"ID of the ticket"
+//	@Success		200				{array}		models.TicketTierResponse	"Tiers of the ticket"
+//	@Failure		404				{object}	models.FailureResponse		"Error message including details on failure"
+//	@Router			/tickets/{id}/tiers [get]
+func (rc *TierHandler) ListTiers(c echo.Context) error {
+	id := c.Param("id")
+
+	tiers, err := rc.tierUC.ListByTicketID(c.Request().Context(), id)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillTierResponses(tiers)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// UpdateTier godoc
+//
+//	@Summary		UpdateTier partially updates a tier
+//	@Description	This endpoint updates the name, allocation, price, sales window and position of a tier, omitted fields are kept.
+//	@Tags			tickets
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string						true	"ID of the ticket"
+//	@Param			tierID			path		string						true	"ID of the tier"
+//	@Param			body			body		models.UpdateTierRequest	true	"Tier update input"
+//	@Success		200				{object}	models.TicketTierResponse	"Updated tier"
+//	@Failure		400				{object}	models.FailureResponse		"Error message including details on failure"
+//	@Failure		404				{object}	models.FailureResponse		"Tier not found"
+//	@Router			/tickets/{id}/tiers/{tierID} [patch]
+func (rc *TierHandler) UpdateTier(c echo.Context) error {
+	id := c.Param("id")
+	tierID := c.Param("tierID")
+
+	var request models.UpdateTierRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	tier, err := rc.tierUC.Update(c.Request().Context(), id, tierID, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillTierResponse(tier)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// DeleteTier godoc
+//
+//	@Summary		DeleteTier deletes a tier
+//	@Description	This endpoint deletes a tier of a draft ticket, tiers of tickets that were on sale are closed by setting their allocation to 0.
+//	@Tags			tickets
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string					true	"ID of the ticket"
+//	@Param			tierID			path		string					true	"ID of the tier"
+//	@Success		204				"Tier deleted, no content"
+//	@Failure		404				{object}	models.FailureResponse	"Tier not found"
+//	@Failure		409				{object}	models.FailureResponse	"Ticket is not a draft"
+//	@Router			/tickets/{id}/tiers/{tierID} [delete]
+func (rc *TierHandler) DeleteTier(c echo.Context) error {
+	id := c.Param("id")
+	tierID := c.Param("tierID")
+
+	if err := rc.tierUC.Delete(c.Request().Context(), id, tierID); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func fillTierResponse(tier *models.TicketTier) *models.TicketTierResponse {
+	if tier == nil {
+		return &models.TicketTierResponse{}
+	}
+
+	return &models.TicketTierResponse{
+		ID:         tier.ID,
+		Name:       tier.Name,
+		Allocation: tier.Allocation,
+		Price:      tier.Price,
+		SalesStart: optionalTime(tier.SalesStart),
+		SalesEnd:   optionalTime(tier.SalesEnd),
+		SaleStatus: tier.SaleStatus,
+		Position:   tier.Position,
+	}
+}
+
+func fillTierResponses(tiers []models.TicketTier) []models.TicketTierResponse {
+	response := make([]models.TicketTierResponse, 0, len(tiers))
+	for i := range tiers {
+		response = append(response, *fillTierResponse(&tiers[i]))
+	}
+
+	return response
+}
@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/labstack/echo/v4"
+)
+
+type TransferHandler struct {
+	transferUC *uc.TransferUC
+}
+
+func NewTransferHandler(transferUC *uc.TransferUC) *TransferHandler {
+	return &TransferHandler{
+		transferUC: transferUC,
+	}
+}
+
+// CreateTransfer godoc
+//
+//	@Summary		CreateTransfer offers a purchase to another user
+//	@Description	This endpoint starts the transfer of the remaining seats of a purchase to another user, named by user ID or email. The purchase keeps its owner until the recipient accepts. Transfers close a configured time before the event.
+//	@Tags			transfers
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string							true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string							false	"Customer the API key acts for, required with an API key"
+//	@Param			purchaseID		path		string							true	"ID of the purchase"
+//	@Param			body			body		models.CreateTransferRequest	true	"Recipient of the transfer"
+//	@Success		201				{object}	models.TransferResponse			"Pending transfer"
+//	@Failure		400				{object}	models.FailureResponse			"Error message including details on failure"
+//	@Failure		403				{object}	models.FailureResponse			"Purchase belongs to another user"
+//	@Failure		409				{object}	models.FailureResponse			"Purchase already has a pending transfer, was checked in or transfers are closed"
+//	@Router			/purchases/{purchaseID}/transfers [post]
+func (rc *TransferHandler) CreateTransfer(c echo.Context) error {
+	id := c.Param("purchaseID")
+
+	var request models.CreateTransferRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	// staff may transfer on behalf of any user
+	if !claimsFromContext(c).HasRole(staffRoles...) {
+		request.UserID = buyer
+	}
+	request.ActorID = buyer
+
+	transfer, err := rc.transferUC.Create(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillTransferResponse(transfer)
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// ListTransfers godoc
+//
+//	@Summary		List transfers of a purchase
+//	@Description	Retrieves every transfer of a purchase with its audit trail, oldest first.
+//	@Tags			transfers
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string						false	"Customer the API key acts for, required with an API key"
+//	@Param			purchaseID		path		string						true	"ID of the purchase"
+//	@Success		200				{array}		models.TransferResponse		"Transfers of the purchase"
+//	@Failure		403				{object}	models.FailureResponse		"Purchase belongs to another user"
+//	@Failure		404				{object}	models.FailureResponse		"Error message including details on failure"
+//	@Router			/purchases/{purchaseID}/transfers [get]
+func (rc *TransferHandler) ListTransfers(c echo.Context) error {
+	id := c.Param("purchaseID")
+
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	// customers only see transfers of their own purchases
+	var userID string
+	if !claimsFromContext(c).HasRole(staffRoles...) {
+		userID = buyer
+	}
+
+	transfers, err := rc.transferUC.ListByPurchaseID(c.Request().Context(), id, userID)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := make([]*models.TransferResponse, 0, len(transfers))
+	for i := range transfers {
+		response = append(response, fillTransferResponse(&transfers[i]))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// AcceptTransfer godoc
+//
+//	@Summary		AcceptTransfer completes a transfer
+//	@Description	This endpoint lets the recipient accept a transfer. The purchase becomes theirs, the old credentials of its seats are revoked and new ones are issued.
+//	@Tags			transfers
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string					false	"Customer the API key acts for, required with an API key"
+//	@Param			id				path		string					true	"ID of the transfer"
+//	@Success		200				{object}	models.TransferResponse	"Accepted transfer with the purchase and its new credentials"
+//	@Failure		403				{object}	models.FailureResponse	"Transfer is addressed to another user"
+//	@Failure		404				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Failure		409				{object}	models.FailureResponse	"Transfer is not pending anymore or transfers are closed"
+//	@Router			/transfers/{id}/accept [post]
+func (rc *TransferHandler) AcceptTransfer(c echo.Context) error {
+	id := c.Param("id")
+
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	request := models.AcceptTransferRequest{
+		UserID: buyer,
+		Email:  claimsFromContext(c).Email,
+	}
+
+	transfer, err := rc.transferUC.Accept(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillTransferResponse(transfer)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// CancelTransfer godoc
+//
+//	@Summary		CancelTransfer withdraws a pending transfer
+//	@Description	This endpoint withdraws a transfer that wasn't accepted yet, the purchase stays with its owner.
+//	@Tags			transfers
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string					false	"Customer the API key acts for, required with an API key"
+//	@Param			id				path		string					true	"ID of the transfer"
+//	@Success		200				{object}	models.TransferResponse	"Cancelled transfer"
+//	@Failure		403				{object}	models.FailureResponse	"Transfer was sent by another user"
+//	@Failure		404				{object}	models.FailureResponse	"Error message including details on failure"
+//	@Failure		409				{object}	models.FailureResponse	"Transfer is not pending anymore"
+//	@Router			/transfers/{id}/cancel [post]
+func (rc *TransferHandler) CancelTransfer(c echo.Context) error {
+	id := c.Param("id")
+
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	// staff may cancel on behalf of any user
+	request := models.CancelTransferRequest{ActorID: buyer}
+	if !claimsFromContext(c).HasRole(staffRoles...) {
+		request.UserID = buyer
+	}
+
+	transfer, err := rc.transferUC.Cancel(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillTransferResponse(transfer)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func fillTransferResponse(transfer *models.Transfer) *models.TransferResponse {
+	if transfer == nil {
+		return &models.TransferResponse{}
+	}
+
+	response := &models.TransferResponse{
+		ID:         transfer.ID,
+		PurchaseID: transfer.PurchaseID,
+		TicketID:   transfer.TicketID,
+		FromUserID: transfer.FromUserID,
+		ToUserID:   transfer.ToUserID,
+		ToEmail:    transfer.ToEmail,
+		Status:     transfer.Status,
+		AcceptedBy: transfer.AcceptedBy,
+		CreatedAt:  transfer.CreatedAt,
+		UpdatedAt:  transfer.UpdatedAt,
+		Events:     make([]models.TransferEventResponse, 0, len(transfer.Events)),
+	}
+	for _, event := range transfer.Events {
+		response.Events = append(response.Events, models.TransferEventResponse{
+			Action:    event.Action,
+			ActorID:   event.ActorID,
+			Details:   event.Details,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	if transfer.Purchase != nil {
+		response.Purchase = fillPurchaseResponse(transfer.Purchase)
+	}
+
+	return response
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WaitlistHandler struct {
+	waitlistUC *uc.WaitlistUC
+}
+
+func NewWaitlistHandler(waitlistUC *uc.WaitlistUC) *WaitlistHandler {
+	return &WaitlistHandler{
+		waitlistUC: waitlistUC,
+	}
+}
+
+// JoinWaitlist godoc
+//
+//	@Summary		JoinWaitlist queues the user for a sold out ticket
+//	@Description	This endpoint adds the user to the waitlist of a ticket. Seats that come back are offered to waiting users in order.
+//	@Tags			waitlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string							true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string							false	"Customer the API key acts for, required with an API key"
+//	@Param			id				path		string							true	"ID of the ticket"
+//	@Param			body			body		models.WaitlistRequest			true	"Waitlist input"
+//	@Success		201				{object}	models.WaitlistEntryResponse	"Created waitlist entry with its queue position"
+//	@Failure		400				{object}	models.FailureResponse			"Error message including details on failure"
+//	@Failure		409				{object}	models.FailureResponse			"Ticket can be bought right away or the user is already waiting"
+//	@Router			/tickets/{id}/waitlist [post]
+func (rc *WaitlistHandler) JoinWaitlist(c echo.Context) error {
+	id := c.Param("id")
+
+	var request models.WaitlistRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+	request.UserID = buyer
+
+	entry, position, err := rc.waitlistUC.Join(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillWaitlistEntryResponse(entry, position)
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// GetWaitlistEntry godoc
+//
+//	@Summary		GetWaitlistEntry fetches a waitlist entry
+//	@Description	Retrieves a waitlist entry with its queue position while waiting, or its offer once seats are reserved.
+//	@Tags			waitlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string							true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string							false	"Customer the API key acts for, required with an API key"
+//	@Param			id				path		string							true	"ID of the waitlist entry"
+//	@Success		200				{object}	models.WaitlistEntryResponse	"Waitlist entry details"
+//	@Failure		404				{object}	models.FailureResponse			"Error message including details on failure"
+//	@Router			/waitlist/{id} [get]
+func (rc *WaitlistHandler) GetWaitlistEntry(c echo.Context) error {
+	id := c.Param("id")
+
+	entry, position, err := rc.waitlistUC.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	// customers only see their own entries, others are reported as missing
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+	if !claimsFromContext(c).HasRole(staffRoles...) && entry.UserID != buyer {
+		return HandleEchoError(c, pkg.NewError(errors.New("entry belongs to another user"), "failed to find waitlist entry", http.StatusNotFound))
+	}
+
+	response := fillWaitlistEntryResponse(entry, position)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// AcceptOffer godoc
+//
+//	@Summary		AcceptOffer buys the seats offered to a waitlisted user
+//	@Description	This endpoint turns an open waitlist offer into a purchase before the offer expires.
+//	@Tags			waitlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string						true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			X-Buyer-ID		header		string						false	"Customer the API key acts for, required with an API key"
+//	@Param			id				path		string						true	"ID of the waitlist entry"
+//	@Success		201				{object}	models.PurchaseResponse		"Created purchase details"
+//	@Failure		403				{object}	models.FailureResponse		"Entry belongs to another user"
+//	@Failure		409				{object}	models.FailureResponse		"There is no open offer for the entry"
+//	@Router			/waitlist/{id}/accept [post]
+func (rc *WaitlistHandler) AcceptOffer(c echo.Context) error {
+	id := c.Param("id")
+
+	buyer, err := buyerOf(c)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	request := models.AcceptOfferRequest{
+		UserID: buyer,
+	}
+
+	purchase, err := rc.waitlistUC.Accept(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillPurchaseResponse(purchase)
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+func fillWaitlistEntryResponse(entry *models.WaitlistEntry, position int) *models.WaitlistEntryResponse {
+	if entry == nil {
+		return &models.WaitlistEntryResponse{}
+	}
+
+	return &models.WaitlistEntryResponse{
+		ID:             entry.ID,
+		TicketID:       entry.TicketID,
+		TierID:         entry.TierID,
+		UserID:         entry.UserID,
+		Quantity:       entry.Quantity,
+		Status:         entry.Status,
+		Position:       position,
+		OfferExpiresAt: optionalTime(entry.OfferExpiresAt),
+		PurchaseID:     entry.PurchaseID,
+		CreatedAt:      entry.CreatedAt,
+	}
+}
@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookHandler struct {
+	webhookUC *uc.WebhookUC
+}
+
+func NewWebhookHandler(webhookUC *uc.WebhookUC) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUC: webhookUC,
+	}
+}
+
+// CreateWebhook godoc
+//
+//	@Summary		CreateWebhook subscribes an endpoint to events
+//	@Description	This endpoint subscribes a URL to ticket and purchase events. Every delivery is signed with the secret in the X-Webhook-Signature header and retried with exponential backoff until the endpoint answers 2xx.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string								true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			body			body		models.CreateWebhookRequest			true	"Webhook subscription input"
+//	@Success		201				{object}	models.WebhookSubscriptionResponse	"Created subscription"
+//	@Failure		400				{object}	models.FailureResponse				"Error message including details on failure"
+//	@Failure		403				{object}	models.FailureResponse				"Caller is not an admin"
+//	@Router			/webhooks [post]
+func (rc *WebhookHandler) CreateWebhook(c echo.Context) error {
+	var request models.CreateWebhookRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	subscription, err := rc.webhookUC.Create(c.Request().Context(), &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillWebhookSubscriptionResponse(subscription)
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// ListWebhooks godoc
+//
+//	@Summary		List webhook subscriptions
+//	@Description	Retrieves every webhook subscription, newest first. Secrets are never returned.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string								true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Success		200				{array}		models.WebhookSubscriptionResponse	"Webhook subscriptions"
+//	@Failure		403				{object}	models.FailureResponse				"Caller is not an admin"
+//	@Router			/webhooks [get]
+func (rc *WebhookHandler) ListWebhooks(c echo.Context) error {
+	subscriptions, err := rc.webhookUC.List(c.Request().Context())
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := make([]*models.WebhookSubscriptionResponse, 0, len(subscriptions))
+	for i := range subscriptions {
+		response = append(response, fillWebhookSubscriptionResponse(&subscriptions[i]))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetWebhook godoc
+//
+//	@Summary		Get a webhook subscription by ID
+//	@Description	Retrieves a webhook subscription.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string								true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string								true	"ID of the subscription"
+//	@Success		200				{object}	models.WebhookSubscriptionResponse	"Subscription details"
+//	@Failure		404				{object}	models.FailureResponse				"Error message including details on failure"
+//	@Router			/webhooks/{id} [get]
+func (rc *WebhookHandler) GetWebhook(c echo.Context) error {
+	id := c.Param("id")
+
+	subscription, err := rc.webhookUC.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillWebhookSubscriptionResponse(subscription)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// UpdateWebhook godoc
+//
+//	@Summary		UpdateWebhook partially updates a webhook subscription
+//	@Description	This endpoint changes the URL, secret, event types or active flag of a subscription, omitted fields are kept.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string								true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string								true	"ID of the subscription"
+//	@Param			body			body		models.UpdateWebhookRequest			true	"Webhook subscription update input"
+//	@Success		200				{object}	models.WebhookSubscriptionResponse	"Updated subscription"
+//	@Failure		400				{object}	models.FailureResponse				"Error message including details on failure"
+//	@Failure		404				{object}	models.FailureResponse				"Subscription not found"
+//	@Router			/webhooks/{id} [patch]
+func (rc *WebhookHandler) UpdateWebhook(c echo.Context) error {
+	id := c.Param("id")
+
+	var request models.UpdateWebhookRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	subscription, err := rc.webhookUC.Update(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillWebhookSubscriptionResponse(subscription)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// DeleteWebhook godoc
+//
+//	@Summary		DeleteWebhook deletes a webhook subscription
+//	@Description	This endpoint unsubscribes an endpoint, its pending deliveries are dropped and its delivery log is kept.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string					true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string					true	"ID of the subscription"
+//	@Success		204				"Subscription deleted, no content"
+//	@Failure		404				{object}	models.FailureResponse	"Subscription not found"
+//	@Router			/webhooks/{id} [delete]
+func (rc *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	id := c.Param("id")
+
+	if err := rc.webhookUC.Delete(c.Request().Context(), id); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListDeliveries godoc
+//
+//	@Summary		List the deliveries of a webhook subscription
+//	@Description	Retrieves the delivery log of a subscription, newest first, with the attempts and last response of every delivery.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string							true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string							true	"ID of the subscription"
+//	@Param			status			query		string							false	"Only deliveries with this status"	Enums(pending, succeeded, failed)
+//	@Param			limit			query		int								false	"Number of deliveries, 20 by default and 100 at most"
+//	@Success		200				{array}		models.WebhookDeliveryResponse	"Deliveries"
+//	@Failure		400				{object}	models.FailureResponse			"Error message including details on failure"
+//	@Failure		404				{object}	models.FailureResponse			"Subscription not found"
+//	@Router			/webhooks/{id}/deliveries [get]
+func (rc *WebhookHandler) ListDeliveries(c echo.Context) error {
+	id := c.Param("id")
+
+	var request models.WebhookDeliveryListRequest
+	if err := c.Bind(&request); err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	deliveries, err := rc.webhookUC.ListDeliveries(c.Request().Context(), id, &request)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := make([]*models.WebhookDeliveryResponse, 0, len(deliveries))
+	for i := range deliveries {
+		response = append(response, fillWebhookDeliveryResponse(&deliveries[i]))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// RedeliverWebhook godoc
+//
+//	@Summary		RedeliverWebhook sends a delivery again
+//	@Description	This endpoint sends the event of a delivery to the subscription once more, right away. The attempt is logged as a new delivery that is retried like any other if it fails.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string							true	"Insert your access token"	default(Bearer <Add access token here>)
+//	@Param			id				path		string							true	"ID of the subscription"
+//	@Param			deliveryID		path		string							true	"ID of the delivery to send again"
+//	@Success		200				{object}	models.WebhookDeliveryResponse	"New delivery with the outcome of its first attempt"
+//	@Failure		404				{object}	models.FailureResponse			"Subscription or delivery not found"
+//	@Router			/webhooks/{id}/deliveries/{deliveryID}/redeliver [post]
+func (rc *WebhookHandler) RedeliverWebhook(c echo.Context) error {
+	id := c.Param("id")
+	deliveryID := c.Param("deliveryID")
+
+	delivery, err := rc.webhookUC.Redeliver(c.Request().Context(), id, deliveryID)
+	if err != nil {
+		return HandleEchoError(c, err)
+	}
+
+	response := fillWebhookDeliveryResponse(delivery)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func fillWebhookSubscriptionResponse(subscription *models.WebhookSubscription) *models.WebhookSubscriptionResponse {
+	if subscription == nil {
+		return &models.WebhookSubscriptionResponse{}
+	}
+
+	eventTypes := subscription.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	return &models.WebhookSubscriptionResponse{
+		ID:         subscription.ID,
+		URL:        subscription.URL,
+		EventTypes: eventTypes,
+		Active:     subscription.Active,
+		CreatedAt:  subscription.CreatedAt,
+		UpdatedAt:  subscription.UpdatedAt,
+	}
+}
+
+func fillWebhookDeliveryResponse(delivery *models.WebhookDelivery) *models.WebhookDeliveryResponse {
+	if delivery == nil {
+		return &models.WebhookDeliveryResponse{}
+	}
+
+	return &models.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  optionalTime(delivery.NextAttemptAt),
+		LastAttemptAt:  optionalTime(delivery.LastAttemptAt),
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		DeliveredAt:    optionalTime(delivery.DeliveredAt),
+		RedeliveryOf:   delivery.RedeliveryOf,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
@@ -15,9 +15,49 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/tickets": {
+        "/api-keys": {
+            "get": {
+                "description": "Retrieves every issued API key, newest first. Plain key values are never returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "List API keys",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Issued API keys",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIKeyResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "This endpoint creates a new ticket by providing name, description, and allocation.",
+                "description": "This endpoint issues an API key with the given scopes. The key is only returned once.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,9 +65,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tickets"
+                    "api-keys"
                 ],
-                "summary": "CreateTicket creates a new ticket",
+                "summary": "CreateAPIKey issues a new API key",
                 "parameters": [
                     {
                         "type": "string",
@@ -38,20 +78,20 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Ticket creation input",
+                        "description": "API key creation input",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.CreateRequest"
+                            "$ref": "#/definitions/models.CreateAPIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created ticket details",
+                        "description": "Created API key with its plain value",
                         "schema": {
-                            "$ref": "#/definitions/models.Ticket"
+                            "$ref": "#/definitions/models.CreateAPIKeyResponse"
                         }
                     },
                     "400": {
@@ -59,13 +99,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.FailureResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
                     }
                 }
             }
         },
-        "/tickets/{id}": {
-            "get": {
-                "description": "Retrieves a ticket from the database by its ID.",
+        "/api-keys/{id}": {
+            "delete": {
+                "description": "This endpoint permanently disables an API key.",
                 "consumes": [
                     "application/json"
                 ],
@@ -73,9 +119,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tickets"
+                    "api-keys"
                 ],
-                "summary": "Get a ticket by ID",
+                "summary": "RevokeAPIKey revokes an API key",
                 "parameters": [
                     {
                         "type": "string",
@@ -87,7 +133,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "ID of the ticket",
+                        "description": "ID of the API key",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -95,23 +141,29 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Details of the requested ticket",
+                        "description": "Revoked API key",
                         "schema": {
-                            "$ref": "#/definitions/models.Ticket"
+                            "$ref": "#/definitions/models.APIKeyResponse"
                         }
                     },
-                    "400": {
+                    "404": {
                         "description": "Error message including details on failure",
                         "schema": {
                             "$ref": "#/definitions/models.FailureResponse"
                         }
+                    },
+                    "409": {
+                        "description": "API key was already revoked",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
                     }
                 }
             }
         },
-        "/tickets/{id}/purchase": {
+        "/checkins": {
             "post": {
-                "description": "This endpoint purchases a new ticket by providing id and quantity.",
+                "description": "This endpoint verifies a scanned credential and marks it used, so the same code can't admit two people. The result tells the scanner whether to let the holder in: valid, already_used with the first scan's time and gate, revoked or forged.",
                 "consumes": [
                     "application/json"
                 ],
@@ -119,9 +171,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tickets"
+                    "checkins"
                 ],
-                "summary": "PurchaseTicket purchases a new ticket",
+                "summary": "CreateCheckin checks a scanned credential in",
                 "parameters": [
                     {
                         "type": "string",
@@ -132,93 +184,4522 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Ticket purchase input",
+                        "description": "Scanned payload and gate",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.PurchaseRequest"
+                            "$ref": "#/definitions/models.CheckinRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Purchase successful, no content"
+                    "200": {
+                        "description": "Result of the scan",
+                        "schema": {
+                            "$ref": "#/definitions/models.CheckinResponse"
+                        }
                     },
                     "400": {
                         "description": "Error message including details on failure",
                         "schema": {
                             "$ref": "#/definitions/models.FailureResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Caller is not door staff",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.CreateRequest": {
-            "type": "object",
-            "required": [
-                "allocation",
-                "name"
-            ],
-            "properties": {
-                "allocation": {
-                    "type": "integer"
-                },
-                "desc": {
-                    "type": "string",
-                    "maxLength": 500
-                },
-                "name": {
-                    "type": "string",
-                    "maxLength": 100,
-                    "minLength": 5
-                }
-            }
         },
-        "models.FailureResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                },
-                "message": {
-                    "type": "string"
+        "/credentials/public-key": {
+            "get": {
+                "description": "Returns the Ed25519 public key scanners verify credential payloads with, so they can check them offline.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credentials"
+                ],
+                "summary": "Get the credential verification key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Public key, base64 encoded",
+                        "schema": {
+                            "$ref": "#/definitions/models.CredentialKeyResponse"
+                        }
+                    }
                 }
             }
         },
-        "models.PurchaseRequest": {
-            "type": "object",
-            "required": [
-                "quantity",
-                "user_id"
-            ],
-            "properties": {
-                "quantity": {
-                    "type": "integer"
-                },
-                "user_id": {
-                    "type": "string"
+        "/credentials/{id}/qr": {
+            "get": {
+                "description": "Renders the signed payload of a valid credential as a PNG QR code to show at the door.",
+                "produces": [
+                    "image/png"
+                ],
+                "tags": [
+                    "credentials"
+                ],
+                "summary": "Get the QR code of a credential",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the credential",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "QR code of the credential",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Credential was revoked",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
                 }
             }
         },
-        "models.Ticket": {
-            "type": "object",
-            "properties": {
-                "allocation": {
-                    "type": "integer"
-                },
-                "desc": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
+        "/holds/{id}/confirm": {
+            "post": {
+                "description": "This endpoint confirms an active hold and records the purchase of the held seats.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "ConfirmHold turns a hold into a purchase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the hold",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Hold confirmation input",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ConfirmHoldRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created purchase details",
+                        "schema": {
+                            "$ref": "#/definitions/models.PurchaseResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Hold was already confirmed or expired",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
                 }
             }
+        },
+        "/listings": {
+            "get": {
+                "description": "Retrieves a page of active resale listings, optionally filtered by ticket, tier and price, cheapest first by default.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "listings"
+                ],
+                "summary": "Search resale listings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only listings of this ticket",
+                        "name": "ticket_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only listings of this tier",
+                        "name": "tier_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Highest asking price in minor units",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "price",
+                            "id"
+                        ],
+                        "type": "string",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 20 by default and 100 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of listings",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListingListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "This endpoint lists a purchased seat, named by its credential, at an asking price capped at a configured percentage of its face value. Seats can only be resold while the ticket is in its sales window.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "listings"
+                ],
+                "summary": "CreateListing puts a seat up for resale",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Seat and asking price",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateListingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Active listing",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListingResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Price above the cap or invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Seat belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Seat is already listed, can't be resold or the ticket is outside its sales window",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/listings/{id}": {
+            "get": {
+                "description": "Retrieves a resale listing, whatever its status.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "listings"
+                ],
+                "summary": "Get a resale listing by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the listing",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Details of the listing",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListingResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "This endpoint withdraws an active resale listing, the seat stays with the seller.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "listings"
+                ],
+                "summary": "CancelListing takes a listing off sale",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the listing",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cancelled listing",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListingResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Listing belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Listing was already sold or cancelled",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/listings/{id}/buy": {
+            "post": {
+                "description": "This endpoint buys a listed seat at its asking price. The seat moves to a new purchase of the buyer with a new credential, the seller's credential is revoked and the listing is closed, all at once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "listings"
+                ],
+                "summary": "BuyListing buys a resale listing",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the listing",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sold listing with the buyer's purchase",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListingResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment was declined, the listing stays on sale",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Listing is not available anymore or the ticket is outside its sales window",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payments/webhook": {
+            "post": {
+                "description": "This endpoint records captures and refunds reported by the payment provider. It takes no access token, the raw body must be signed in the X-Payment-Signature header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payments"
+                ],
+                "summary": "HandleWebhook receives payment provider webhooks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "HMAC-SHA256 signature of the body",
+                        "name": "X-Payment-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Payment event",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/pkg.PaymentEvent"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Event processed, no content"
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid signature",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/promo-codes": {
+            "get": {
+                "description": "Retrieves every promo code with its redemption count, newest first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promo-codes"
+                ],
+                "summary": "List promo codes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Promo codes",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PromoCodeResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "This endpoint adds a percent or fixed amount promo code with optional caps, validity window and ticket scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promo-codes"
+                ],
+                "summary": "CreatePromoCode adds a promo code",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Promo code creation input",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreatePromoCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created promo code",
+                        "schema": {
+                            "$ref": "#/definitions/models.PromoCodeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A promo code with the same code exists",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/promo-codes/{id}": {
+            "get": {
+                "description": "Retrieves a promo code with its redemption count.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promo-codes"
+                ],
+                "summary": "Get a promo code by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the promo code",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Promo code details",
+                        "schema": {
+                            "$ref": "#/definitions/models.PromoCodeResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "This endpoint deletes a promo code so it can't be redeemed anymore, purchases made with it are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promo-codes"
+                ],
+                "summary": "DeletePromoCode deletes a promo code",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the promo code",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Promo code deleted, no content"
+                    },
+                    "404": {
+                        "description": "Promo code not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "This endpoint updates the value, caps, validity window and ticket scope of a promo code, omitted fields are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promo-codes"
+                ],
+                "summary": "UpdatePromoCode partially updates a promo code",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the promo code",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Promo code update input",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdatePromoCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated promo code",
+                        "schema": {
+                            "$ref": "#/definitions/models.PromoCodeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Promo code not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/purchases/{purchaseID}": {
+            "get": {
+                "description": "Retrieves a purchase record from the database by its ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchases"
+                ],
+                "summary": "Get a purchase by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the purchase",
+                        "name": "purchaseID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Details of the requested purchase",
+                        "schema": {
+                            "$ref": "#/definitions/models.PurchaseResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/purchases/{purchaseID}/cancel": {
+            "post": {
+                "description": "This endpoint refunds a purchase, fully or partially, and returns the seats to the ticket.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchases"
+                ],
+                "summary": "CancelPurchase cancels a purchase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the purchase",
+                        "name": "purchaseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Quantity to cancel, everything when omitted",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.CancelPurchaseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Refunded purchase details",
+                        "schema": {
+                            "$ref": "#/definitions/models.PurchaseResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Purchase belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Purchase already refunded or outside the cancellation window",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Payment provider refused the refund, the seats stay sold",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/purchases/{purchaseID}/transfers": {
+            "get": {
+                "description": "Retrieves every transfer of a purchase with its audit trail, oldest first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "List transfers of a purchase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the purchase",
+                        "name": "purchaseID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transfers of the purchase",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TransferResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Purchase belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "This endpoint starts the transfer of the remaining seats of a purchase to another user, named by user ID or email. The purchase keeps its owner until the recipient accepts. Transfers close a configured time before the event.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "CreateTransfer offers a purchase to another user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the purchase",
+                        "name": "purchaseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Recipient of the transfer",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateTransferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Pending transfer",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransferResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Purchase belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Purchase already has a pending transfer, was checked in or transfers are closed",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets": {
+            "get": {
+                "description": "Retrieves a page of tickets, optionally filtered by name and availability.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "List tickets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case insensitive name substring",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only tickets with remaining allocation",
+                        "name": "available",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "name",
+                            "allocation"
+                        ],
+                        "type": "string",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 20 by default and 100 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of tickets",
+                        "schema": {
+                            "$ref": "#/definitions/models.TicketListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "This endpoint creates a new ticket by providing name, description, and allocation.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "CreateTicket creates a new ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Ticket creation input",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created ticket details",
+                        "schema": {
+                            "$ref": "#/definitions/models.TicketResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin or organizer",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency key reused with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}": {
+            "get": {
+                "description": "Retrieves a ticket from the database by its ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Get a ticket by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Details of the requested ticket",
+                        "schema": {
+                            "$ref": "#/definitions/models.Ticket"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the ticket, send it back in If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "This endpoint soft deletes a ticket, hard=true removes it permanently when it has no purchases.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "DeleteTicket deletes a ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Permanently delete the ticket",
+                        "name": "hard",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the ticket the delete is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Ticket deleted, no content"
+                    },
+                    "403": {
+                        "description": "Caller is not an admin or organizer",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Ticket with purchases can't be hard deleted",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Ticket was modified since the given ETag",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "This endpoint updates the name, description and allocation of a ticket, omitted fields are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "UpdateTicket partially updates a ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the ticket the update is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Ticket update input",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated ticket details",
+                        "schema": {
+                            "$ref": "#/definitions/models.TicketResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated ticket"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin or organizer",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Ticket was modified since the given ETag",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}/cancel": {
+            "post": {
+                "description": "This endpoint permanently stops the sales of a ticket, existing purchases are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "CancelTicket cancels a ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the ticket the change is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated ticket details",
+                        "schema": {
+                            "$ref": "#/definitions/models.TicketResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated ticket"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin or organizer",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Ticket can't move to the requested state",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Ticket was modified since the given ETag",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}/checkins/stats": {
+            "get": {
+                "description": "Retrieves the live attendance of a ticket, overall and per gate, against the seats sold.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checkins"
+                ],
+                "summary": "Get check-in stats of a ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Attendance of the ticket",
+                        "schema": {
+                            "$ref": "#/definitions/models.CheckinStats"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not staff",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}/holds": {
+            "post": {
+                "description": "This endpoint reserves a quantity of a ticket for a user for the given number of minutes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "CreateHold reserves seats of a ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ticket hold input",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.HoldRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created hold details",
+                        "schema": {
+                            "$ref": "#/definitions/models.HoldResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Ticket is not on sale or its sales window has not opened",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Ticket sales have closed",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}/listings": {
+            "get": {
+                "description": "Retrieves a page of active resale listings of a ticket, cheapest first by default.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "listings"
+                ],
+                "summary": "List resale listings of a ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "price",
+                            "id"
+                        ],
+                        "type": "string",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 20 by default and 100 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of listings",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListingListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}/pause": {
+            "post": {
+                "description": "This endpoint stops the sales of a ticket until it is published again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "PauseTicket pauses the sales of a ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the ticket the change is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated ticket details",
+                        "schema": {
+                            "$ref": "#/definitions/models.TicketResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated ticket"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin or organizer",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Ticket can't move to the requested state",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Ticket was modified since the given ETag",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}/publish": {
+            "post": {
+                "description": "This endpoint puts a draft or paused ticket on sale, a ticket without seats left becomes sold out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "PublishTicket puts a ticket on sale",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the ticket the change is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated ticket details",
+                        "schema": {
+                            "$ref": "#/definitions/models.TicketResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated ticket"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin or organizer",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Ticket can't move to the requested state",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Ticket was modified since the given ETag",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}/purchases": {
+            "get": {
+                "description": "Retrieves every purchase record of a ticket, oldest first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchases"
+                ],
+                "summary": "List purchases of a ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Purchases of the requested ticket",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PurchaseResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not staff",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "This endpoint purchases a new ticket by providing id and quantity.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "PurchaseTicket purchases a new ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the purchase is for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Ticket purchase input",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PurchaseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created purchase details",
+                        "schema": {
+                            "$ref": "#/definitions/models.PurchaseResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid access token",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment declined, no seats were taken",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Ticket is not on sale or its sales window has not opened",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Ticket sales have closed",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency key reused with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Payment provider timed out, no seats were taken",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}/scanner/snapshot": {
+            "get": {
+                "description": "Retrieves the valid credentials of a ticket, signed with the credentials key, so a scanner can keep checking people in while offline. The snapshot is base64url encoded JSON, verify its signature with the public key before using it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checkins"
+                ],
+                "summary": "Get the scanner snapshot of a ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Signed snapshot of the ticket's credentials",
+                        "schema": {
+                            "$ref": "#/definitions/models.SignedSnapshot"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not door staff",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}/scanner/sync": {
+            "post": {
+                "description": "This endpoint records a batch of scans made while a scanner was offline, timed by the device. The earliest scan of a credential lets it in, ties going to the lowest gate, so the verdicts don't depend on which device syncs first. Other scans of the same credential are reported as conflicts.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checkins"
+                ],
+                "summary": "SyncScans uploads the scans of an offline scanner",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Device and its offline scans",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScannerSyncRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Verdict of every scan and the conflicts found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScannerSyncResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not door staff",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}/tiers": {
+            "get": {
+                "description": "Retrieves the tiers of a ticket in their order, with the seats each tier has left.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "List tiers of a ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tiers of the ticket",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TicketTierResponse"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "This endpoint adds a tier with its own allocation, price and sales window. The ticket's allocation becomes the sum of its tiers.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "CreateTier adds a price tier to a ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tier creation input",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateTierRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created tier",
+                        "schema": {
+                            "$ref": "#/definitions/models.TicketTierResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin or organizer",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "First tier of a ticket that is not a draft",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}/tiers/{tierID}": {
+            "delete": {
+                "description": "This endpoint deletes a tier of a draft ticket, tiers of tickets that were on sale are closed by setting their allocation to 0.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "DeleteTier deletes a tier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the tier",
+                        "name": "tierID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Tier deleted, no content"
+                    },
+                    "404": {
+                        "description": "Tier not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Ticket is not a draft",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "This endpoint updates the name, allocation, price, sales window and position of a tier, omitted fields are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "UpdateTier partially updates a tier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the tier",
+                        "name": "tierID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tier update input",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateTierRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated tier",
+                        "schema": {
+                            "$ref": "#/definitions/models.TicketTierResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Tier not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}/waitlist": {
+            "post": {
+                "description": "This endpoint adds the user to the waitlist of a ticket. Seats that come back are offered to waiting users in order.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "JoinWaitlist queues the user for a sold out ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Waitlist input",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WaitlistRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created waitlist entry with its queue position",
+                        "schema": {
+                            "$ref": "#/definitions/models.WaitlistEntryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Ticket can be bought right away or the user is already waiting",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/{id}/accept": {
+            "post": {
+                "description": "This endpoint lets the recipient accept a transfer. The purchase becomes theirs, the old credentials of its seats are revoked and new ones are issued.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "AcceptTransfer completes a transfer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the transfer",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Accepted transfer with the purchase and its new credentials",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransferResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Transfer is addressed to another user",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Transfer is not pending anymore or transfers are closed",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers/{id}/cancel": {
+            "post": {
+                "description": "This endpoint withdraws a transfer that wasn't accepted yet, the purchase stays with its owner.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transfers"
+                ],
+                "summary": "CancelTransfer withdraws a pending transfer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the transfer",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cancelled transfer",
+                        "schema": {
+                            "$ref": "#/definitions/models.TransferResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Transfer was sent by another user",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Transfer is not pending anymore",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/waitlist/{id}": {
+            "get": {
+                "description": "Retrieves a waitlist entry with its queue position while waiting, or its offer once seats are reserved.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "GetWaitlistEntry fetches a waitlist entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the waitlist entry",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Waitlist entry details",
+                        "schema": {
+                            "$ref": "#/definitions/models.WaitlistEntryResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/waitlist/{id}/accept": {
+            "post": {
+                "description": "This endpoint turns an open waitlist offer into a purchase before the offer expires.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "AcceptOffer buys the seats offered to a waitlisted user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Customer the API key acts for, required with an API key",
+                        "name": "X-Buyer-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the waitlist entry",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created purchase details",
+                        "schema": {
+                            "$ref": "#/definitions/models.PurchaseResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Entry belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "There is no open offer for the entry",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "Retrieves every webhook subscription, newest first. Secrets are never returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook subscriptions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookSubscriptionResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "This endpoint subscribes a URL to ticket and purchase events. Every delivery is signed with the secret in the X-Webhook-Signature header and retried with exponential backoff until the endpoint answers 2xx.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "CreateWebhook subscribes an endpoint to events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook subscription input",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created subscription",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookSubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "description": "Retrieves a webhook subscription.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get a webhook subscription by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the subscription",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscription details",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookSubscriptionResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "This endpoint unsubscribes an endpoint, its pending deliveries are dropped and its delivery log is kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "DeleteWebhook deletes a webhook subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the subscription",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Subscription deleted, no content"
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "This endpoint changes the URL, secret, event types or active flag of a subscription, omitted fields are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "UpdateWebhook partially updates a webhook subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the subscription",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook subscription update input",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated subscription",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookSubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Retrieves the delivery log of a subscription, newest first, with the attempts and last response of every delivery.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List the deliveries of a webhook subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the subscription",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "succeeded",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Only deliveries with this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of deliveries, 20 by default and 100 at most",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deliveries",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookDeliveryResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Error message including details on failure",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{deliveryID}/redeliver": {
+            "post": {
+                "description": "This endpoint sends the event of a delivery to the subscription once more, right away. The attempt is logged as a new delivery that is retried like any other if it fails.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "RedeliverWebhook sends a delivery again",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the subscription",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the delivery to send again",
+                        "name": "deliveryID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New delivery with the outcome of its first attempt",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookDeliveryResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription or delivery not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.FailureResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.CancelPurchaseRequest": {
+            "type": "object",
+            "properties": {
+                "quantity": {
+                    "description": "Quantity of seats to cancel, the whole remaining quantity when omitted.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CheckinRequest": {
+            "type": "object",
+            "required": [
+                "gate",
+                "payload"
+            ],
+            "properties": {
+                "gate": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "payload": {
+                    "description": "Payload is the scanned content of the credential's QR code.",
+                    "type": "string",
+                    "maxLength": 256
+                }
+            }
+        },
+        "models.CheckinResponse": {
+            "type": "object",
+            "properties": {
+                "credential_id": {
+                    "type": "integer"
+                },
+                "first_gate": {
+                    "type": "string"
+                },
+                "first_scanned_at": {
+                    "description": "FirstScannedAt and FirstGate tell when and where an already used credential got in.",
+                    "type": "string"
+                },
+                "gate": {
+                    "type": "string"
+                },
+                "purchase_id": {
+                    "type": "integer"
+                },
+                "result": {
+                    "$ref": "#/definitions/models.CheckinResult"
+                },
+                "scanned_at": {
+                    "type": "string"
+                },
+                "seat": {
+                    "type": "integer"
+                },
+                "ticket_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CheckinResult": {
+            "type": "string",
+            "enum": [
+                "valid",
+                "already_used",
+                "revoked",
+                "forged"
+            ],
+            "x-enum-varnames": [
+                "CheckinResultValid",
+                "CheckinResultAlreadyUsed",
+                "CheckinResultRevoked",
+                "CheckinResultForged"
+            ]
+        },
+        "models.CheckinStats": {
+            "type": "object",
+            "properties": {
+                "checked_in": {
+                    "type": "integer"
+                },
+                "gates": {
+                    "description": "Gates counts the check-ins per gate.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GateCount"
+                    }
+                },
+                "sold": {
+                    "type": "integer"
+                },
+                "ticket_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ConfirmHoldRequest": {
+            "type": "object"
+        },
+        "models.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt is optional, keys without it never expire.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.CreateListingRequest": {
+            "type": "object",
+            "required": [
+                "credential_id",
+                "price"
+            ],
+            "properties": {
+                "credential_id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "description": "Price is in minor units of the purchase's currency, capped at a percentage of the face value.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CreatePromoCodeRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "discount_type",
+                "value"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code is matched case-insensitively, it is stored upper-cased.",
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 3
+                },
+                "currency": {
+                    "description": "Currency of the amount is required for fixed codes and not allowed for percent codes.",
+                    "type": "string"
+                },
+                "discount_type": {
+                    "enum": [
+                        "percent",
+                        "fixed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DiscountType"
+                        }
+                    ]
+                },
+                "max_per_user": {
+                    "type": "integer"
+                },
+                "max_redemptions": {
+                    "type": "integer"
+                },
+                "ticket_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Value is a percentage between 1 and 100 for percent codes.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CreateRequest": {
+            "type": "object",
+            "required": [
+                "allocation",
+                "name"
+            ],
+            "properties": {
+                "allocation": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "desc": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "event_start": {
+                    "type": "string"
+                },
+                "max_per_user": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 5
+                },
+                "price": {
+                    "description": "Price is in minor units of Currency, a currency is required for priced tickets.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "sales_end": {
+                    "type": "string"
+                },
+                "sales_start": {
+                    "description": "SalesStart and SalesEnd are optional, the sales end must be after the sales start.",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is draft unless the ticket is published right away.",
+                    "enum": [
+                        "draft",
+                        "on_sale"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TicketStatus"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.CreateTierRequest": {
+            "type": "object",
+            "required": [
+                "allocation",
+                "name"
+            ],
+            "properties": {
+                "allocation": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "position": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "price": {
+                    "description": "Price is in minor units of the ticket's currency.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "sales_end": {
+                    "type": "string"
+                },
+                "sales_start": {
+                    "description": "SalesStart and SalesEnd are optional, the sales end must be after the sales start.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateTransferRequest": {
+            "type": "object",
+            "properties": {
+                "to_email": {
+                    "type": "string",
+                    "maxLength": 254
+                },
+                "to_user_id": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "models.CreateWebhookRequest": {
+            "type": "object",
+            "required": [
+                "event_types",
+                "secret",
+                "url"
+            ],
+            "properties": {
+                "event_types": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "Secret is chosen by the subscriber, who needs it to verify the signatures.",
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 16
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CredentialKeyResponse": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string"
+                },
+                "public_key": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CredentialResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "seat": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.CredentialStatus"
+                },
+                "used_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CredentialStatus": {
+            "type": "string",
+            "enum": [
+                "valid",
+                "revoked"
+            ],
+            "x-enum-varnames": [
+                "CredentialStatusValid",
+                "CredentialStatusRevoked"
+            ]
+        },
+        "models.DiscountType": {
+            "type": "string",
+            "enum": [
+                "percent",
+                "fixed"
+            ],
+            "x-enum-varnames": [
+                "DiscountTypePercent",
+                "DiscountTypeFixed"
+            ]
+        },
+        "models.FailureResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GateCount": {
+            "type": "object",
+            "properties": {
+                "checked_in": {
+                    "type": "integer"
+                },
+                "gate": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HoldRequest": {
+            "type": "object",
+            "required": [
+                "minutes",
+                "quantity"
+            ],
+            "properties": {
+                "minutes": {
+                    "type": "integer",
+                    "maximum": 30
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "tier_id": {
+                    "description": "TierID is required for tickets with tiers.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.HoldResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "purchase_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.HoldStatus"
+                },
+                "ticket_id": {
+                    "type": "integer"
+                },
+                "tier_id": {
+                    "type": "integer"
+                },
+                "unit_price": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HoldStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "paying",
+                "confirmed",
+                "expired"
+            ],
+            "x-enum-varnames": [
+                "HoldStatusActive",
+                "HoldStatusPaying",
+                "HoldStatusConfirmed",
+                "HoldStatusExpired"
+            ]
+        },
+        "models.ListingListResponse": {
+            "type": "object",
+            "properties": {
+                "listings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ListingResponse"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ListingResponse": {
+            "type": "object",
+            "properties": {
+                "buyer_id": {
+                    "type": "string"
+                },
+                "buyer_purchase_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "face_value": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "purchase": {
+                    "description": "Purchase is returned to the buyer, with the new credential of the seat.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PurchaseResponse"
+                        }
+                    ]
+                },
+                "seat": {
+                    "type": "integer"
+                },
+                "seller_id": {
+                    "type": "string"
+                },
+                "sold_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.ListingStatus"
+                },
+                "ticket_id": {
+                    "type": "integer"
+                },
+                "tier_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ListingStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "reserved",
+                "sold",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "ListingStatusActive",
+                "ListingStatusReserved",
+                "ListingStatusSold",
+                "ListingStatusCancelled"
+            ]
+        },
+        "models.OfflineScan": {
+            "type": "object",
+            "required": [
+                "gate",
+                "payload",
+                "scanned_at"
+            ],
+            "properties": {
+                "gate": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "payload": {
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "scanned_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PaymentStatus": {
+            "type": "string",
+            "enum": [
+                "authorized",
+                "released",
+                "captured",
+                "partially_refunded",
+                "refunded"
+            ],
+            "x-enum-varnames": [
+                "PaymentStatusAuthorized",
+                "PaymentStatusReleased",
+                "PaymentStatusCaptured",
+                "PaymentStatusPartiallyRefunded",
+                "PaymentStatusRefunded"
+            ]
+        },
+        "models.PromoCodeResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "discount_type": {
+                    "$ref": "#/definitions/models.DiscountType"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_per_user": {
+                    "type": "integer"
+                },
+                "max_redemptions": {
+                    "type": "integer"
+                },
+                "redemptions": {
+                    "type": "integer"
+                },
+                "ticket_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PurchaseRequest": {
+            "type": "object",
+            "required": [
+                "quantity"
+            ],
+            "properties": {
+                "promo_code": {
+                    "description": "PromoCode is redeemed by the purchase when given, it is matched case-insensitively.",
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "tier_id": {
+                    "description": "TierID is required for tickets with tiers.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PurchaseResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "credentials": {
+                    "description": "Credentials admit the seats of the purchase at the door, one per seat.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CredentialResponse"
+                    }
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "payment_refunded": {
+                    "type": "integer"
+                },
+                "payment_status": {
+                    "$ref": "#/definitions/models.PaymentStatus"
+                },
+                "promo_code": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "refunded_quantity": {
+                    "type": "integer"
+                },
+                "resale_listing_id": {
+                    "type": "integer"
+                },
+                "resold_quantity": {
+                    "description": "ResoldQuantity and ResaleListingID tell which seats were resold and which purchases came from a resale listing.",
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.PurchaseStatus"
+                },
+                "subtotal": {
+                    "type": "integer"
+                },
+                "ticket_id": {
+                    "type": "integer"
+                },
+                "tier_id": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "unit_price": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PurchaseStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "failed",
+                "completed",
+                "partially_refunded",
+                "refunded"
+            ],
+            "x-enum-varnames": [
+                "PurchaseStatusPending",
+                "PurchaseStatusFailed",
+                "PurchaseStatusCompleted",
+                "PurchaseStatusPartiallyRefunded",
+                "PurchaseStatusRefunded"
+            ]
+        },
+        "models.RejectedScan": {
+            "type": "object",
+            "properties": {
+                "device_id": {
+                    "type": "string"
+                },
+                "gate": {
+                    "type": "string"
+                },
+                "scanned_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SaleStatus": {
+            "type": "string",
+            "enum": [
+                "not_started",
+                "on_sale",
+                "closed"
+            ],
+            "x-enum-varnames": [
+                "SaleStatusNotStarted",
+                "SaleStatusOnSale",
+                "SaleStatusClosed"
+            ]
+        },
+        "models.ScanConflict": {
+            "type": "object",
+            "properties": {
+                "credential_id": {
+                    "type": "integer"
+                },
+                "gate": {
+                    "type": "string"
+                },
+                "rejected": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RejectedScan"
+                    }
+                },
+                "scanned_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScannerSyncRequest": {
+            "type": "object",
+            "required": [
+                "device_id",
+                "scans"
+            ],
+            "properties": {
+                "device_id": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "scans": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.OfflineScan"
+                    }
+                }
+            }
+        },
+        "models.ScannerSyncResponse": {
+            "type": "object",
+            "properties": {
+                "conflicts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ScanConflict"
+                    }
+                },
+                "results": {
+                    "description": "Results holds the verdict of every uploaded scan, in upload order.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CheckinResponse"
+                    }
+                }
+            }
+        },
+        "models.SignedSnapshot": {
+            "type": "object",
+            "properties": {
+                "signature": {
+                    "type": "string"
+                },
+                "snapshot": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Ticket": {
+            "type": "object",
+            "properties": {
+                "allocation": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "description": "Currency is the ISO-4217 code of the price, empty for free tickets created without one.",
+                    "type": "string"
+                },
+                "desc": {
+                    "type": "string"
+                },
+                "event_start": {
+                    "description": "EventStart is when the event begins, transfers close a configured time before it. A zero time means not announced.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_per_user": {
+                    "description": "MaxPerUser caps the seats a single user may hold across all purchases of the ticket, 0 means no cap.",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "description": "Price is the price of one seat in minor units of Currency, e.g. cents for USD.",
+                    "type": "integer"
+                },
+                "sale_status": {
+                    "description": "SaleStatus is computed from the sales window when the ticket is read, it isn't stored.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SaleStatus"
+                        }
+                    ]
+                },
+                "sales_end": {
+                    "type": "string"
+                },
+                "sales_start": {
+                    "description": "SalesStart and SalesEnd bound the sales window, a zero time leaves that side open.",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status defaults to on_sale for rows written before tickets had a lifecycle.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TicketStatus"
+                        }
+                    ]
+                },
+                "tiers": {
+                    "description": "Tiers are loaded when a single ticket is read, they aren't stored with the ticket.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TicketTier"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TicketListResponse": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "tickets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TicketResponse"
+                    }
+                }
+            }
+        },
+        "models.TicketResponse": {
+            "type": "object",
+            "properties": {
+                "allocation": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "desc": {
+                    "type": "string"
+                },
+                "event_start": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_per_user": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "sale_status": {
+                    "$ref": "#/definitions/models.SaleStatus"
+                },
+                "sales_end": {
+                    "type": "string"
+                },
+                "sales_start": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.TicketStatus"
+                },
+                "tiers": {
+                    "description": "Tiers lists the availability of each tier, tickets without tiers leave it out.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TicketTierResponse"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TicketStatus": {
+            "type": "string",
+            "enum": [
+                "draft",
+                "on_sale",
+                "paused",
+                "sold_out",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "TicketStatusDraft",
+                "TicketStatusOnSale",
+                "TicketStatusPaused",
+                "TicketStatusSoldOut",
+                "TicketStatusCancelled"
+            ]
+        },
+        "models.TicketTier": {
+            "type": "object",
+            "properties": {
+                "allocation": {
+                    "description": "Allocation is the number of seats of the tier that are still available.",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "position": {
+                    "description": "Position orders the tiers of a ticket, lower positions are listed and offered first.",
+                    "type": "integer"
+                },
+                "price": {
+                    "description": "Price is the price of one seat in minor units of the ticket's currency.",
+                    "type": "integer"
+                },
+                "sale_status": {
+                    "description": "SaleStatus is computed from the sales window when the tier is read, it isn't stored.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SaleStatus"
+                        }
+                    ]
+                },
+                "sales_end": {
+                    "type": "string"
+                },
+                "sales_start": {
+                    "description": "SalesStart and SalesEnd bound the sales window of the tier within the ticket's, a zero time leaves that side open.",
+                    "type": "string"
+                },
+                "ticket_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TicketTierResponse": {
+            "type": "object",
+            "properties": {
+                "allocation": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "sale_status": {
+                    "$ref": "#/definitions/models.SaleStatus"
+                },
+                "sales_end": {
+                    "type": "string"
+                },
+                "sales_start": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TransferAction": {
+            "type": "string",
+            "enum": [
+                "initiated",
+                "accepted",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "TransferActionInitiated",
+                "TransferActionAccepted",
+                "TransferActionCancelled"
+            ]
+        },
+        "models.TransferEventResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/models.TransferAction"
+                },
+                "actor_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TransferResponse": {
+            "type": "object",
+            "properties": {
+                "accepted_by": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TransferEventResponse"
+                    }
+                },
+                "from_user_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "purchase": {
+                    "description": "Purchase is returned to the recipient when they accept, with the new credentials of the seats.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PurchaseResponse"
+                        }
+                    ]
+                },
+                "purchase_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.TransferStatus"
+                },
+                "ticket_id": {
+                    "type": "integer"
+                },
+                "to_email": {
+                    "type": "string"
+                },
+                "to_user_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TransferStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "accepted",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "TransferStatusPending",
+                "TransferStatusAccepted",
+                "TransferStatusCancelled"
+            ]
+        },
+        "models.UpdatePromoCodeRequest": {
+            "type": "object",
+            "properties": {
+                "clear_valid_from": {
+                    "description": "ClearValidFrom and ClearValidUntil open that side of the validity window, they can't be combined with a new\ntime for the same side.",
+                    "type": "boolean"
+                },
+                "clear_valid_until": {
+                    "type": "boolean"
+                },
+                "max_per_user": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "max_redemptions": {
+                    "description": "MaxRedemptions and MaxPerUser of 0 remove the cap.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "ticket_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UpdateRequest": {
+            "type": "object",
+            "properties": {
+                "allocation": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "desc": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "event_start": {
+                    "type": "string"
+                },
+                "max_per_user": {
+                    "description": "MaxPerUser of 0 removes the cap.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 5
+                },
+                "price": {
+                    "description": "Price changes apply to later purchases, purchases already made keep the price they were made at.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "sales_end": {
+                    "type": "string"
+                },
+                "sales_start": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UpdateTierRequest": {
+            "type": "object",
+            "properties": {
+                "allocation": {
+                    "description": "Allocation sets the seats of the tier that are still available, 0 closes the tier.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "position": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "price": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "sales_end": {
+                    "type": "string"
+                },
+                "sales_start": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UpdateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Active false pauses the subscription, no deliveries are created for it meanwhile.",
+                    "type": "boolean"
+                },
+                "event_types": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 16
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WaitlistEntryResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "offer_expires_at": {
+                    "type": "string"
+                },
+                "position": {
+                    "description": "Position is the 1-based place in the queue while the entry is waiting.",
+                    "type": "integer"
+                },
+                "purchase_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.WaitlistStatus"
+                },
+                "ticket_id": {
+                    "type": "integer"
+                },
+                "tier_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WaitlistRequest": {
+            "type": "object",
+            "required": [
+                "quantity"
+            ],
+            "properties": {
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.WaitlistStatus": {
+            "type": "string",
+            "enum": [
+                "waiting",
+                "offered",
+                "paying",
+                "fulfilled",
+                "expired"
+            ],
+            "x-enum-varnames": [
+                "WaitlistStatusWaiting",
+                "WaitlistStatusOffered",
+                "WaitlistStatusPaying",
+                "WaitlistStatusFulfilled",
+                "WaitlistStatusExpired"
+            ]
+        },
+        "models.WebhookDeliveryResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_attempt_at": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "redelivery_of": {
+                    "type": "integer"
+                },
+                "response_status": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.WebhookDeliveryStatus"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.WebhookDeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "succeeded",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "WebhookDeliveryStatusPending",
+                "WebhookDeliveryStatusSucceeded",
+                "WebhookDeliveryStatusFailed"
+            ]
+        },
+        "models.WebhookSubscriptionResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "pkg.PaymentEvent": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "refunded": {
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/pkg.PaymentEventType"
+                }
+            }
+        },
+        "pkg.PaymentEventType": {
+            "type": "string",
+            "enum": [
+                "payment.captured",
+                "payment.refunded",
+                "payment.failed"
+            ],
+            "x-enum-varnames": [
+                "PaymentEventCaptured",
+                "PaymentEventRefunded",
+                "PaymentEventFailed"
+            ]
         }
     }
 }`
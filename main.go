@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/fleimkeipa/tickets-api/config"
 	"github.com/fleimkeipa/tickets-api/controller"
 	_ "github.com/fleimkeipa/tickets-api/docs" // which is the generated folder after swag init
+	"github.com/fleimkeipa/tickets-api/models"
 	"github.com/fleimkeipa/tickets-api/pkg"
 	"github.com/fleimkeipa/tickets-api/repositories"
 	"github.com/fleimkeipa/tickets-api/uc"
@@ -52,16 +55,218 @@ func main() {
 
 	validator := pkg.NewValidator()
 
+	txManager := repositories.NewTxManager(dbClient)
+
+	// Create Auth handlers and related components
+	tokenVerifier, err := pkg.NewTokenVerifier(
+		viper.GetString("auth.hmac_secret"),
+		viper.GetString("auth.jwks_file"),
+		viper.GetString("auth.issuer"),
+		viper.GetString("auth.audience"),
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize token verifier: %v", err)
+	}
+	authHandler := controller.NewAuthHandler(tokenVerifier)
+
+	// Create API key handlers and related components
+	apiKeyRepo := repositories.NewAPIKeyRepository(dbClient)
+	apiKeyUC := uc.NewAPIKeyUC(apiKeyRepo, pkg.NewClock(), validator)
+	apiKeyHandler := controller.NewAPIKeyHandler(apiKeyUC)
+
+	ticketReaders := authHandler.Authorize(models.ScopeTicketsRead)
+	ticketManagers := authHandler.Authorize(models.ScopeTicketsWrite, models.RoleAdmin, models.RoleOrganizer)
+	buyers := authHandler.Authorize(models.ScopePurchasesWrite)
+	purchaseViewers := authHandler.RequireRoles(models.RoleAdmin, models.RoleOrganizer, models.RoleSupport)
+	scanners := authHandler.Authorize(models.ScopeCheckinsWrite, models.RoleAdmin, models.RoleOrganizer, models.RoleGateStaff)
+	attendanceViewers := authHandler.Authorize(models.ScopeCheckinsWrite, models.RoleAdmin, models.RoleOrganizer, models.RoleSupport, models.RoleGateStaff)
+
+	// Create Idempotency handlers and related components
+	idempotencyRepo := repositories.NewIdempotencyRepository(dbClient)
+	idempotencyUC := uc.NewIdempotencyUC(idempotencyRepo, pkg.NewClock(), idempotencyLease(), idempotencyTTL())
+	idempotencyHandler := controller.NewIdempotencyHandler(idempotencyUC)
+
 	// Create Ticket handlers and related components
 	ticketRepo := repositories.NewTicketRepository(dbClient)
-	ticketUC := uc.NewTicketUC(ticketRepo, validator)
+	purchaseRepo := repositories.NewPurchaseRepository(dbClient)
+	holdRepo := repositories.NewHoldRepository(dbClient)
+	tierRepo := repositories.NewTierRepository(dbClient)
+	waitlistRepo := repositories.NewWaitlistRepository(dbClient)
+	promoCodeRepo := repositories.NewPromoCodeRepository(dbClient)
+	listingRepo := repositories.NewListingRepository(dbClient)
+
+	// Create Payment handlers and related components, purchases are charged after their seats are reserved
+	paymentProvider := newPaymentProvider()
+	paymentUC := uc.NewPaymentUC(paymentProvider, purchaseRepo, viper.GetDuration("payments.timeout"))
+	paymentHandler := controller.NewPaymentHandler(paymentUC)
+	if fake, ok := paymentProvider.(*pkg.FakePaymentProvider); ok {
+		// the fake gateway has no network to send its webhooks over, they are handled in process
+		fake.OnWebhook(func(payload []byte, signature string) {
+			if err := paymentUC.HandleWebhook(context.Background(), payload, signature); err != nil {
+				sugar.Errorf("failed to handle payment webhook: %v", err)
+			}
+		})
+	}
+
+	// Create Credential handlers and related components, every purchased seat gets a signed credential
+	credentialSigner, err := pkg.NewCredentialSigner(viper.GetString("credentials.signing_key"))
+	if err != nil {
+		log.Fatalf("Failed to initialize credential signer: %v", err)
+	}
+	credentialRepo := repositories.NewCredentialRepository(dbClient)
+	credentialUC := uc.NewCredentialUC(credentialRepo, credentialSigner, pkg.NewClock())
+	credentialHandler := controller.NewCredentialHandler(credentialUC)
+
+	// Create Webhook handlers and related components, deliveries are queued for the events the outbox relays
+	webhookRepo := repositories.NewWebhookRepository(dbClient)
+	webhookSender := pkg.NewWebhookSender(webhookTimeout())
+	webhookUC := uc.NewWebhookUC(webhookRepo, webhookSender, pkg.NewClock(), validator, webhookMaxAttempts(), webhookBackoff())
+	webhookHandler := controller.NewWebhookHandler(webhookUC)
+
+	// Create the event outbox, events are written in the transaction of the change they report and relayed to the
+	// webhook queue and the publisher
+	outboxRepo := repositories.NewOutboxRepository(dbClient)
+	eventPublisher := pkg.NewFanoutEventPublisher(webhookUC, newEventPublisher(sugar))
+	outboxUC := uc.NewOutboxUC(outboxRepo, txManager, eventPublisher, pkg.NewClock(), outboxBackoff())
+
+	// Create the checkout, pending purchases are charged outside the transaction that reserved their seats
+	checkoutUC := uc.NewCheckoutUC(purchaseRepo, ticketRepo, holdRepo, waitlistRepo, listingRepo, promoCodeRepo, txManager, paymentUC, credentialUC, outboxUC, pkg.NewClock(), pendingTimeout())
+
+	// Create Check-in handlers and related components
+	checkinRepo := repositories.NewCheckinRepository(dbClient)
+	checkinUC := uc.NewCheckinUC(checkinRepo, credentialRepo, ticketRepo, txManager, credentialSigner, pkg.NewClock(), validator)
+	checkinHandler := controller.NewCheckinHandler(checkinUC)
+
+	// Create Waitlist handlers and related components, seats returned by the other use cases are offered to it first
+	waitlistUC := uc.NewWaitlistUC(waitlistRepo, ticketRepo, purchaseRepo, tierRepo, holdRepo, txManager, checkoutUC, outboxUC, pkg.NewClock(), validator, offerTTL())
+	waitlistHandler := controller.NewWaitlistHandler(waitlistUC)
+
+	// Create Promo code handlers and related components, codes are redeemed by ticket purchases
+	promoCodeUC := uc.NewPromoCodeUC(promoCodeRepo, purchaseRepo, txManager, pkg.NewClock(), validator)
+	promoCodeHandler := controller.NewPromoCodeHandler(promoCodeUC)
+
+	ticketUC := uc.NewTicketUC(ticketRepo, purchaseRepo, holdRepo, tierRepo, txManager, waitlistUC, promoCodeUC, checkoutUC, outboxUC, pkg.NewClock(), validator)
 	ticketHandler := controller.NewTicketHandler(ticketUC)
 
-	// Define Ticket routes
-	ticketsRoutes := e.Group("/tickets")
-	ticketsRoutes.POST("", ticketHandler.CreateTicket)
-	ticketsRoutes.GET("/:id", ticketHandler.GetByID)
-	ticketsRoutes.POST("/:id/purchases", ticketHandler.PurchaseTicket)
+	// Create Tier handlers and related components
+	tierUC := uc.NewTierUC(tierRepo, ticketRepo, txManager, waitlistUC, pkg.NewClock(), validator)
+	tierHandler := controller.NewTierHandler(tierUC)
+
+	// Create Purchase handlers and related components
+	purchaseUC := uc.NewPurchaseUC(purchaseRepo, ticketRepo, tierRepo, txManager, waitlistUC, paymentUC, credentialUC, outboxUC, pkg.NewClock(), validator, viper.GetDuration("purchases.cancellation_window"))
+	purchaseHandler := controller.NewPurchaseHandler(purchaseUC)
+
+	// Create Transfer handlers and related components
+	transferRepo := repositories.NewTransferRepository(dbClient)
+	transferUC := uc.NewTransferUC(transferRepo, purchaseRepo, ticketRepo, holdRepo, txManager, credentialUC, pkg.NewClock(), validator, viper.GetDuration("transfers.cutoff"))
+	transferHandler := controller.NewTransferHandler(transferUC)
+
+	// Create Listing handlers and related components, resold seats move to new purchases of their buyers
+	listingUC := uc.NewListingUC(listingRepo, purchaseRepo, ticketRepo, tierRepo, holdRepo, txManager, checkoutUC, credentialUC, pkg.NewClock(), validator, resalePriceCap())
+	listingHandler := controller.NewListingHandler(listingUC)
+
+	// Create Hold handlers and related components
+	holdUC := uc.NewHoldUC(holdRepo, ticketRepo, purchaseRepo, tierRepo, txManager, waitlistUC, checkoutUC, outboxUC, pkg.NewClock(), validator)
+	holdHandler := controller.NewHoldHandler(holdUC)
+
+	// Start background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go idempotencyUC.RunSweeper(workerCtx, sweepInterval("idempotency.sweep_interval"), sugar)
+	go holdUC.RunSweeper(workerCtx, sweepInterval("holds.sweep_interval"), sugar)
+	go waitlistUC.RunSweeper(workerCtx, sweepInterval("waitlist.sweep_interval"), sugar)
+	go checkoutUC.RunSweeper(workerCtx, sweepInterval("payments.sweep_interval"), sugar)
+	go webhookUC.RunDispatcher(workerCtx, sweepInterval("webhooks.dispatch_interval"), sugar)
+	go outboxUC.RunRelay(workerCtx, sweepInterval("events.relay_interval"), sugar)
+
+	// Define Ticket routes, API keys are checked before access tokens
+	ticketsRoutes := e.Group("/tickets", apiKeyHandler.APIKeyMiddleware, authHandler.AuthMiddleware)
+	ticketsRoutes.POST("", ticketHandler.CreateTicket, ticketManagers, idempotencyHandler.IdempotencyMiddleware)
+	ticketsRoutes.GET("", ticketHandler.List, ticketReaders)
+	ticketsRoutes.GET("/:id", ticketHandler.GetByID, ticketReaders)
+	ticketsRoutes.PATCH("/:id", ticketHandler.UpdateTicket, ticketManagers)
+	ticketsRoutes.DELETE("/:id", ticketHandler.DeleteTicket, ticketManagers)
+	ticketsRoutes.POST("/:id/publish", ticketHandler.PublishTicket, ticketManagers)
+	ticketsRoutes.POST("/:id/pause", ticketHandler.PauseTicket, ticketManagers)
+	ticketsRoutes.POST("/:id/cancel", ticketHandler.CancelTicket, ticketManagers)
+	ticketsRoutes.POST("/:id/tiers", tierHandler.CreateTier, ticketManagers)
+	ticketsRoutes.GET("/:id/tiers", tierHandler.ListTiers, ticketReaders)
+	ticketsRoutes.PATCH("/:id/tiers/:tierID", tierHandler.UpdateTier, ticketManagers)
+	ticketsRoutes.DELETE("/:id/tiers/:tierID", tierHandler.DeleteTier, ticketManagers)
+	ticketsRoutes.POST("/:id/purchases", ticketHandler.PurchaseTicket, buyers, idempotencyHandler.IdempotencyMiddleware)
+	ticketsRoutes.GET("/:id/purchases", purchaseHandler.ListByTicketID, purchaseViewers)
+	ticketsRoutes.POST("/:id/holds", holdHandler.CreateHold, buyers)
+	ticketsRoutes.POST("/:id/waitlist", waitlistHandler.JoinWaitlist, buyers)
+	ticketsRoutes.GET("/:id/listings", listingHandler.ListTicketListings, ticketReaders)
+	ticketsRoutes.GET("/:id/checkins/stats", checkinHandler.GetCheckinStats, attendanceViewers)
+	ticketsRoutes.GET("/:id/scanner/snapshot", checkinHandler.GetScannerSnapshot, scanners)
+	ticketsRoutes.POST("/:id/scanner/sync", checkinHandler.SyncScans, scanners)
+
+	// Define Purchase routes
+	purchasesRoutes := e.Group("/purchases", apiKeyHandler.APIKeyMiddleware, authHandler.AuthMiddleware)
+	purchasesRoutes.GET("/:purchaseID", purchaseHandler.GetByID)
+	purchasesRoutes.POST("/:purchaseID/cancel", purchaseHandler.CancelPurchase, buyers)
+	purchasesRoutes.POST("/:purchaseID/transfers", transferHandler.CreateTransfer, buyers)
+	purchasesRoutes.GET("/:purchaseID/transfers", transferHandler.ListTransfers)
+
+	// Define Transfer routes
+	transfersRoutes := e.Group("/transfers", apiKeyHandler.APIKeyMiddleware, authHandler.AuthMiddleware)
+	transfersRoutes.POST("/:id/accept", transferHandler.AcceptTransfer, buyers)
+	transfersRoutes.POST("/:id/cancel", transferHandler.CancelTransfer, buyers)
+
+	// Define Hold routes
+	holdsRoutes := e.Group("/holds", apiKeyHandler.APIKeyMiddleware, authHandler.AuthMiddleware)
+	holdsRoutes.POST("/:id/confirm", holdHandler.ConfirmHold, buyers)
+
+	// Define Waitlist routes
+	waitlistRoutes := e.Group("/waitlist", apiKeyHandler.APIKeyMiddleware, authHandler.AuthMiddleware)
+	waitlistRoutes.GET("/:id", waitlistHandler.GetWaitlistEntry)
+	waitlistRoutes.POST("/:id/accept", waitlistHandler.AcceptOffer, buyers)
+
+	// Define Credential routes
+	credentialsRoutes := e.Group("/credentials", apiKeyHandler.APIKeyMiddleware, authHandler.AuthMiddleware)
+	credentialsRoutes.GET("/public-key", credentialHandler.GetPublicKey)
+	credentialsRoutes.GET("/:id/qr", credentialHandler.GetQR)
+
+	// Define Listing routes
+	listingsRoutes := e.Group("/listings", apiKeyHandler.APIKeyMiddleware, authHandler.AuthMiddleware)
+	listingsRoutes.POST("", listingHandler.CreateListing, buyers)
+	listingsRoutes.GET("", listingHandler.SearchListings, ticketReaders)
+	listingsRoutes.GET("/:id", listingHandler.GetListing, ticketReaders)
+	listingsRoutes.POST("/:id/buy", listingHandler.BuyListing, buyers)
+	listingsRoutes.DELETE("/:id", listingHandler.CancelListing, buyers)
+
+	// Define Check-in routes
+	checkinsRoutes := e.Group("/checkins", apiKeyHandler.APIKeyMiddleware, authHandler.AuthMiddleware)
+	checkinsRoutes.POST("", checkinHandler.CreateCheckin, scanners)
+
+	// Define Payment routes, webhooks are authenticated by their signature
+	paymentsRoutes := e.Group("/payments")
+	paymentsRoutes.POST("/webhook", paymentHandler.HandleWebhook)
+
+	// Define Promo code routes, only admins with an access token manage codes
+	promoCodesRoutes := e.Group("/promo-codes", authHandler.AuthMiddleware, authHandler.RequireRoles(models.RoleAdmin))
+	promoCodesRoutes.POST("", promoCodeHandler.CreatePromoCode)
+	promoCodesRoutes.GET("", promoCodeHandler.ListPromoCodes)
+	promoCodesRoutes.GET("/:id", promoCodeHandler.GetPromoCode)
+	promoCodesRoutes.PATCH("/:id", promoCodeHandler.UpdatePromoCode)
+	promoCodesRoutes.DELETE("/:id", promoCodeHandler.DeletePromoCode)
+
+	// Define Webhook routes, only admins with an access token manage subscriptions
+	webhooksRoutes := e.Group("/webhooks", authHandler.AuthMiddleware, authHandler.RequireRoles(models.RoleAdmin))
+	webhooksRoutes.POST("", webhookHandler.CreateWebhook)
+	webhooksRoutes.GET("", webhookHandler.ListWebhooks)
+	webhooksRoutes.GET("/:id", webhookHandler.GetWebhook)
+	webhooksRoutes.PATCH("/:id", webhookHandler.UpdateWebhook)
+	webhooksRoutes.DELETE("/:id", webhookHandler.DeleteWebhook)
+	webhooksRoutes.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+	webhooksRoutes.POST("/:id/deliveries/:deliveryID/redeliver", webhookHandler.RedeliverWebhook)
+
+	// Define API key routes, only admins with an access token manage keys
+	apiKeysRoutes := e.Group("/api-keys", authHandler.AuthMiddleware, authHandler.RequireRoles(models.RoleAdmin))
+	apiKeysRoutes.POST("", apiKeyHandler.CreateAPIKey)
+	apiKeysRoutes.GET("", apiKeyHandler.ListAPIKeys)
+	apiKeysRoutes.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
 
 	// Start the Echo application
 	e.Logger.Fatal(e.Start(fmt.Sprintf(":%d", viper.GetInt("api_service.port"))))
@@ -82,9 +287,10 @@ func configureEcho(e *echo.Echo) {
 // Configures CORS settings
 func configureCORS(e *echo.Echo) {
 	corsConfig := middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{echo.GET, echo.POST},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{echo.GET, echo.POST, echo.PATCH, echo.DELETE},
+		AllowHeaders:  []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, controller.HeaderAPIKey, controller.HeaderIdempotencyKey, controller.HeaderIfMatch},
+		ExposeHeaders: []string{controller.HeaderETag},
 	})
 
 	e.Use(corsConfig)
@@ -116,3 +322,135 @@ func initDB() *pg.DB {
 	log.Println("PostgreSQL client initialized successfully")
 	return psqlDB
 }
+
+// Reads a worker interval from the configuration, defaulting to 30 seconds
+func sweepInterval(key string) time.Duration {
+	interval := viper.GetDuration(key)
+	if interval <= 0 {
+		return 30 * time.Second
+	}
+
+	return interval
+}
+
+// Reads how long a purchase may stay pending before the sweeper settles it, defaulting to 5 minutes
+func pendingTimeout() time.Duration {
+	timeout := viper.GetDuration("payments.pending_timeout")
+	if timeout <= 0 {
+		return 5 * time.Minute
+	}
+
+	return timeout
+}
+
+// Reads how long a request holds its idempotency key before a retry may take it over, defaulting to 5 minutes
+func idempotencyLease() time.Duration {
+	lease := viper.GetDuration("idempotency.lease")
+	if lease <= 0 {
+		return 5 * time.Minute
+	}
+
+	return lease
+}
+
+// Reads how long completed idempotent responses are replayed, defaulting to 24 hours
+func idempotencyTTL() time.Duration {
+	ttl := viper.GetDuration("idempotency.ttl")
+	if ttl <= 0 {
+		return 24 * time.Hour
+	}
+
+	return ttl
+}
+
+// Reads how long waitlist offers stay reserved, defaulting to 15 minutes
+func offerTTL() time.Duration {
+	ttl := viper.GetDuration("waitlist.offer_ttl")
+	if ttl <= 0 {
+		return 15 * time.Minute
+	}
+
+	return ttl
+}
+
+// Reads the resale price cap in percent of the face value, defaulting to face value
+func resalePriceCap() int {
+	percent := viper.GetInt("resale.price_cap_percent")
+	if percent <= 0 {
+		return 100
+	}
+
+	return percent
+}
+
+// Reads how long a webhook endpoint has to answer, defaulting to 10 seconds
+func webhookTimeout() time.Duration {
+	timeout := viper.GetDuration("webhooks.timeout")
+	if timeout <= 0 {
+		return 10 * time.Second
+	}
+
+	return timeout
+}
+
+// Reads how often a webhook delivery is attempted before it fails for good, defaulting to 8 attempts
+func webhookMaxAttempts() int {
+	attempts := viper.GetInt("webhooks.max_attempts")
+	if attempts <= 0 {
+		return 8
+	}
+
+	return attempts
+}
+
+// Reads the wait before the first retry of a webhook delivery, it doubles with every attempt, defaulting to 30 seconds
+func webhookBackoff() time.Duration {
+	backoff := viper.GetDuration("webhooks.backoff")
+	if backoff <= 0 {
+		return 30 * time.Second
+	}
+
+	return backoff
+}
+
+// Reads the wait before an event that failed to publish is tried again, it doubles with every attempt, defaulting to 1 second
+func outboxBackoff() time.Duration {
+	backoff := viper.GetDuration("events.retry_backoff")
+	if backoff <= 0 {
+		return time.Second
+	}
+
+	return backoff
+}
+
+// Creates the event publisher named by events.publisher, only the log publisher is available so far
+func newEventPublisher(logger *zap.SugaredLogger) pkg.EventPublisher {
+	switch publisher := viper.GetString("events.publisher"); publisher {
+	case "", "log":
+		return pkg.NewLogEventPublisher(logger)
+	default:
+		log.Fatalf("Unknown event publisher: %s", publisher)
+		return nil
+	}
+}
+
+// Creates the payment provider named by payments.provider, only the fake gateway is available so far
+func newPaymentProvider() pkg.PaymentProvider {
+	// webhooks signed with an empty secret could be forged by anyone
+	if viper.GetString("payments.webhook_secret") == "" {
+		log.Fatalf("Missing payments.webhook_secret, payment webhooks can't be verified without it")
+	}
+
+	switch provider := viper.GetString("payments.provider"); provider {
+	case "", "fake":
+		return pkg.NewFakePaymentProvider(pkg.FakePaymentConfig{
+			Decline:       viper.GetString("payments.fake.decline"),
+			Timeout:       viper.GetString("payments.fake.timeout"),
+			WebhookDelay:  viper.GetDuration("payments.fake.webhook_delay"),
+			WebhookSecret: viper.GetString("payments.webhook_secret"),
+		})
+	default:
+		log.Fatalf("Unknown payment provider: %s", provider)
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+const (
+	ScopeTicketsRead    = "tickets:read"
+	ScopeTicketsWrite   = "tickets:write"
+	ScopePurchasesWrite = "purchases:write"
+	ScopeCheckinsWrite  = "checkins:write"
+)
+
+// APIKey authenticates server-to-server integrations. Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID         int64     `json:"id" pg:",pk"`
+	Name       string    `json:"name" sql:",notnull"`
+	Prefix     string    `json:"prefix" sql:",notnull"`
+	Hash       string    `json:"-" sql:",notnull,unique"`
+	Scopes     []string  `json:"scopes" sql:",array"`
+	CreatedBy  string    `json:"created_by" sql:",notnull"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	RevokedAt  time.Time `json:"revoked_at"`
+	CreatedAt  time.Time `json:"created_at" sql:"default:now()"`
+}
+
+// HasScope reports whether the key grants the scope.
+func (rc *APIKey) HasScope(scope string) bool {
+	for _, s := range rc.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+type APIKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAPIKeyResponse is the only response that carries the plain key.
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=tickets:read tickets:write purchases:write checkins:write"`
+	// ExpiresAt is optional, keys without it never expire.
+	ExpiresAt *time.Time `json:"expires_at"`
+	// CreatedBy is taken from the access token, never from the request body.
+	CreatedBy string `json:"-" validate:"required"`
+}
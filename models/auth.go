@@ -0,0 +1,31 @@
+package models
+
+const (
+	RoleAdmin     = "admin"
+	RoleOrganizer = "organizer"
+	RoleSupport   = "support"
+	RoleGateStaff = "gate_staff"
+)
+
+// Claims are the verified identity of the caller taken from the access token or API key.
+type Claims struct {
+	UserID string
+	Roles  []string
+	// Email is the address from the token's email claim, API keys and tokens without one leave it empty.
+	Email string
+	// APIKey is set when the caller authenticated with an API key instead of an access token.
+	APIKey *APIKey
+}
+
+// HasRole reports whether the caller has at least one of the given roles.
+func (rc *Claims) HasRole(roles ...string) bool {
+	for _, have := range rc.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,128 @@
+package models
+
+import "time"
+
+// CheckinResult is the verdict of a scan at the door, only valid scans let the holder in.
+type CheckinResult string
+
+const (
+	CheckinResultValid       CheckinResult = "valid"
+	CheckinResultAlreadyUsed CheckinResult = "already_used"
+	CheckinResultRevoked     CheckinResult = "revoked"
+	CheckinResultForged      CheckinResult = "forged"
+)
+
+// Checkin records one scan of a credential, whatever its result. Forged scans have no credential.
+type Checkin struct {
+	ID           int64         `json:"id" pg:",pk"`
+	CredentialID int64         `json:"credential_id"`
+	TicketID     int64         `json:"ticket_id"`
+	Gate         string        `json:"gate" sql:",notnull"`
+	Result       CheckinResult `json:"result" sql:",notnull"`
+	ScannedBy    string        `json:"scanned_by" sql:",notnull"`
+	ScannedAt    time.Time     `json:"scanned_at" sql:",notnull"`
+	CreatedAt    time.Time     `json:"created_at" sql:"default:now()"`
+	// DeviceID is set on scans uploaded by an offline scanner, their ScannedAt is the device's clock.
+	DeviceID string `json:"device_id"`
+	// Credential is the scanned credential as it is after the scan, it isn't stored with the check-in.
+	Credential *Credential `json:"-" sql:"-"`
+}
+
+type CheckinRequest struct {
+	// Payload is the scanned content of the credential's QR code.
+	Payload string `json:"payload" validate:"required,max=256"`
+	Gate    string `json:"gate" validate:"required,max=50"`
+	// ScannedBy is taken from the access token or API key, never from the request body.
+	ScannedBy string `json:"-" validate:"required"`
+}
+
+type CheckinResponse struct {
+	Result       CheckinResult `json:"result"`
+	CredentialID int64         `json:"credential_id,omitempty"`
+	TicketID     int64         `json:"ticket_id,omitempty"`
+	PurchaseID   int64         `json:"purchase_id,omitempty"`
+	Seat         int           `json:"seat,omitempty"`
+	Gate         string        `json:"gate"`
+	ScannedAt    time.Time     `json:"scanned_at"`
+	// FirstScannedAt and FirstGate tell when and where an already used credential got in.
+	FirstScannedAt *time.Time `json:"first_scanned_at,omitempty"`
+	FirstGate      string     `json:"first_gate,omitempty"`
+}
+
+// CheckinStats compares the attendance of a ticket with the seats sold.
+type CheckinStats struct {
+	TicketID  int64 `json:"ticket_id"`
+	Sold      int   `json:"sold"`
+	CheckedIn int   `json:"checked_in"`
+	// Gates counts the check-ins per gate.
+	Gates []GateCount `json:"gates"`
+}
+
+type GateCount struct {
+	Gate      string `json:"gate"`
+	CheckedIn int    `json:"checked_in"`
+}
+
+// ScannerSnapshot is what an offline scanner needs to check credentials of a ticket on its own.
+type ScannerSnapshot struct {
+	TicketID    int64                `json:"ticket_id"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	Credentials []SnapshotCredential `json:"credentials"`
+}
+
+// SnapshotCredential is a valid credential of the ticket, with its first scan when it already got in.
+type SnapshotCredential struct {
+	ID         int64      `json:"id"`
+	PurchaseID int64      `json:"purchase_id"`
+	Seat       int        `json:"seat"`
+	UsedAt     *time.Time `json:"used_at,omitempty"`
+	UsedGate   string     `json:"used_gate,omitempty"`
+}
+
+// SignedSnapshot carries a ScannerSnapshot as base64url encoded JSON with its base64url encoded Ed25519 signature,
+// verifiable with the same public key as the credentials.
+type SignedSnapshot struct {
+	Snapshot  string `json:"snapshot"`
+	Signature string `json:"signature"`
+}
+
+type ScannerSyncRequest struct {
+	DeviceID string        `json:"device_id" validate:"required,max=100"`
+	Scans    []OfflineScan `json:"scans" validate:"required,min=1,max=500,dive"`
+	// ScannedBy is taken from the access token or API key, never from the request body.
+	ScannedBy string `json:"-" validate:"required"`
+}
+
+// OfflineScan is a scan made while the device was offline, ScannedAt is the device's clock.
+type OfflineScan struct {
+	Payload   string    `json:"payload" validate:"required,max=256"`
+	Gate      string    `json:"gate" validate:"required,max=50"`
+	ScannedAt time.Time `json:"scanned_at" validate:"required"`
+}
+
+// ScannerSync is the outcome of an uploaded batch, the check-ins are in upload order.
+type ScannerSync struct {
+	Checkins  []Checkin
+	Conflicts []ScanConflict
+}
+
+// ScanConflict reports a credential that was scanned more than once. Gate and ScannedAt are the scan that let it in,
+// Rejected are the other scans, including an earlier synced scan that an offline one turned out to precede.
+type ScanConflict struct {
+	CredentialID int64          `json:"credential_id"`
+	Gate         string         `json:"gate"`
+	ScannedAt    time.Time      `json:"scanned_at"`
+	Rejected     []RejectedScan `json:"rejected"`
+}
+
+type RejectedScan struct {
+	Gate      string    `json:"gate"`
+	DeviceID  string    `json:"device_id,omitempty"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+type ScannerSyncResponse struct {
+	// Results holds the verdict of every uploaded scan, in upload order.
+	Results   []CheckinResponse `json:"results"`
+	Conflicts []ScanConflict    `json:"conflicts"`
+}
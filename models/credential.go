@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+type CredentialStatus string
+
+const (
+	CredentialStatusValid   CredentialStatus = "valid"
+	CredentialStatusRevoked CredentialStatus = "revoked"
+)
+
+// Credential admits one seat of a purchase. Seats are numbered from 1.
+type Credential struct {
+	ID         int64            `json:"id" pg:",pk"`
+	PurchaseID int64            `json:"purchase_id" sql:",notnull"`
+	TicketID   int64            `json:"ticket_id" sql:",notnull"`
+	UserID     string           `json:"user_id" sql:",notnull"`
+	Seat       int              `json:"seat" sql:",notnull"`
+	Status     CredentialStatus `json:"status" sql:",notnull"`
+	RevokedAt  time.Time        `json:"revoked_at"`
+	CreatedAt  time.Time        `json:"created_at" sql:"default:now()"`
+	// UsedAt and UsedGate record the first scan that let the credential in, a zero time means it wasn't used yet.
+	UsedAt   time.Time `json:"used_at"`
+	UsedGate string    `json:"used_gate"`
+	// Payload is signed from the other fields when the credential is served, see pkg.CredentialSigner.
+	Payload string `json:"payload" sql:"-"`
+}
+
+type CredentialResponse struct {
+	ID        int64            `json:"id"`
+	Seat      int              `json:"seat"`
+	Status    CredentialStatus `json:"status"`
+	Payload   string           `json:"payload"`
+	RevokedAt *time.Time       `json:"revoked_at,omitempty"`
+	UsedAt    *time.Time       `json:"used_at,omitempty"`
+}
+
+// CredentialKeyResponse carries the public key scanners verify credential payloads with.
+type CredentialKeyResponse struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+}
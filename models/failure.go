@@ -0,0 +1,7 @@
+package models
+
+// FailureResponse is the error body documented for the failure responses of the API.
+type FailureResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+type HoldStatus string
+
+const (
+	HoldStatusActive    HoldStatus = "active"
+	HoldStatusPaying    HoldStatus = "paying"
+	HoldStatusConfirmed HoldStatus = "confirmed"
+	HoldStatusExpired   HoldStatus = "expired"
+)
+
+// Hold reserves seats of a ticket for a user until it is confirmed or expires. A hold is paying while the purchase
+// that confirms it is paid, it goes back to active when the payment fails. Direct purchases reserve their seats
+// with a hold too.
+// It keeps the price of the ticket at the time the seats were held, the purchase is made at that price.
+type Hold struct {
+	ID         int64      `json:"id" pg:",pk"`
+	TicketID   int64      `json:"ticket_id" sql:",notnull"`
+	TierID     int64      `json:"tier_id"`
+	UserID     string     `json:"user_id" sql:",notnull"`
+	Quantity   int        `json:"quantity" sql:",notnull"`
+	Status     HoldStatus `json:"status" sql:",notnull"`
+	UnitPrice  int64      `json:"unit_price" sql:",notnull"`
+	Currency   string     `json:"currency" sql:",notnull"`
+	PurchaseID int64      `json:"purchase_id"`
+	ExpiresAt  time.Time  `json:"expires_at" sql:",notnull"`
+	CreatedAt  time.Time  `json:"created_at" sql:"default:now()"`
+	UpdatedAt  time.Time  `json:"updated_at" sql:"default:now()"`
+}
+
+type HoldResponse struct {
+	ID         int64      `json:"id"`
+	TicketID   int64      `json:"ticket_id"`
+	TierID     int64      `json:"tier_id,omitempty"`
+	UserID     string     `json:"user_id"`
+	Quantity   int        `json:"quantity"`
+	Status     HoldStatus `json:"status"`
+	UnitPrice  int64      `json:"unit_price"`
+	Currency   string     `json:"currency,omitempty"`
+	PurchaseID int64      `json:"purchase_id,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type HoldRequest struct {
+	// UserID is taken from the access token, never from the request body.
+	UserID   string `json:"-" validate:"required"`
+	Quantity int    `json:"quantity" validate:"required,gt=0"`
+	Minutes  int    `json:"minutes" validate:"required,gt=0,lte=30"`
+	// TierID is required for tickets with tiers.
+	TierID int64 `json:"tier_id" validate:"omitempty,gt=0"`
+}
+
+type ConfirmHoldRequest struct {
+	// UserID is taken from the access token, never from the request body.
+	UserID string `json:"-" validate:"required"`
+}
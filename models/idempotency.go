@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusProcessing IdempotencyStatus = "processing"
+	IdempotencyStatusCompleted  IdempotencyStatus = "completed"
+)
+
+// IdempotencyKey stores the outcome of a request sent with an Idempotency-Key header,
+// so a retry of the same request can be answered with the original response.
+type IdempotencyKey struct {
+	Key          string            `json:"key" sql:",pk"`
+	Fingerprint  string            `json:"fingerprint" sql:",notnull"`
+	Status       IdempotencyStatus `json:"status" sql:",notnull"`
+	StatusCode   int               `json:"status_code"`
+	ResponseBody []byte            `json:"response_body"`
+	// ExpiresAt ends the lease of a processing key, a retry may take it over once the request holding it is presumed
+	// dead, and the retention of a completed key, after which the key is free to be used again.
+	ExpiresAt time.Time `json:"expires_at" sql:",notnull"`
+	CreatedAt time.Time `json:"created_at" sql:"default:now()"`
+	UpdatedAt time.Time `json:"updated_at" sql:"default:now()"`
+}
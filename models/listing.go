@@ -0,0 +1,106 @@
+package models
+
+import "time"
+
+// ListingStatus is the state of a resale listing, only active listings can be bought. A listing is reserved for its
+// buyer while the purchase is paid.
+type ListingStatus string
+
+const (
+	ListingStatusActive    ListingStatus = "active"
+	ListingStatusReserved  ListingStatus = "reserved"
+	ListingStatusSold      ListingStatus = "sold"
+	ListingStatusCancelled ListingStatus = "cancelled"
+)
+
+// Listing offers a single seat of a purchase for resale. Buying it moves the seat to a new purchase of the buyer,
+// the ticket's allocation doesn't change.
+type Listing struct {
+	ID           int64  `json:"id" pg:",pk"`
+	TicketID     int64  `json:"ticket_id" sql:",notnull"`
+	TierID       int64  `json:"tier_id"`
+	PurchaseID   int64  `json:"purchase_id" sql:",notnull"`
+	CredentialID int64  `json:"credential_id" sql:",notnull"`
+	Seat         int    `json:"seat" sql:",notnull"`
+	SellerID     string `json:"seller_id" sql:",notnull"`
+	// Price is the asking price in minor units of Currency, FaceValue the unit price the seat was first sold at.
+	Price     int64         `json:"price" sql:",notnull"`
+	FaceValue int64         `json:"face_value" sql:",notnull"`
+	Currency  string        `json:"currency" sql:",notnull"`
+	Status    ListingStatus `json:"status" sql:",notnull"`
+	// BuyerID and BuyerPurchaseID are set once the listing is reserved for its buyer.
+	BuyerID         string    `json:"buyer_id"`
+	BuyerPurchaseID int64     `json:"buyer_purchase_id"`
+	SoldAt          time.Time `json:"sold_at"`
+	CreatedAt       time.Time `json:"created_at" sql:"default:now()"`
+	UpdatedAt       time.Time `json:"updated_at" sql:"default:now()"`
+	// Purchase is the buyer's new purchase with its credential, returned when the listing is bought.
+	Purchase *Purchase `json:"-" sql:"-"`
+}
+
+type CreateListingRequest struct {
+	CredentialID int64 `json:"credential_id" validate:"required,gt=0"`
+	// Price is in minor units of the purchase's currency, capped at a percentage of the face value.
+	Price int64 `json:"price" validate:"required,gt=0"`
+	// SellerID is taken from the access token, never from the request body.
+	SellerID string `json:"-" validate:"required"`
+}
+
+type BuyListingRequest struct {
+	// BuyerID is taken from the access token, never from the request body.
+	BuyerID string `json:"-" validate:"required"`
+}
+
+type CancelListingRequest struct {
+	// UserID restricts the cancellation to listings of this user, staff leave it empty.
+	UserID string `json:"-"`
+}
+
+type ListingSearchRequest struct {
+	TicketID int64  `query:"ticket_id" validate:"omitempty,gt=0"`
+	TierID   int64  `query:"tier_id" validate:"omitempty,gt=0"`
+	MaxPrice int64  `query:"max_price" validate:"omitempty,gt=0"`
+	Sort     string `query:"sort" validate:"omitempty,oneof=id price"`
+	Limit    int    `query:"limit" validate:"omitempty,gt=0,lte=100"`
+	Cursor   string `query:"cursor"`
+}
+
+// ListingListOptions are the decoded search parameters handed to the repository, only active listings are searched.
+type ListingListOptions struct {
+	TicketID int64
+	TierID   int64
+	MaxPrice int64
+	Sort     string
+	Limit    int
+	After    *ListingCursor
+}
+
+// ListingCursor marks the last listing of a page, the next page starts right after it.
+type ListingCursor struct {
+	Sort  string `json:"s"`
+	Price int64  `json:"p,omitempty"`
+	ID    int64  `json:"i"`
+}
+
+type ListingResponse struct {
+	ID              int64         `json:"id"`
+	TicketID        int64         `json:"ticket_id"`
+	TierID          int64         `json:"tier_id,omitempty"`
+	Seat            int           `json:"seat"`
+	SellerID        string        `json:"seller_id"`
+	Price           int64         `json:"price"`
+	FaceValue       int64         `json:"face_value"`
+	Currency        string        `json:"currency,omitempty"`
+	Status          ListingStatus `json:"status"`
+	BuyerID         string        `json:"buyer_id,omitempty"`
+	BuyerPurchaseID int64         `json:"buyer_purchase_id,omitempty"`
+	SoldAt          *time.Time    `json:"sold_at,omitempty"`
+	CreatedAt       time.Time     `json:"created_at"`
+	// Purchase is returned to the buyer, with the new credential of the seat.
+	Purchase *PurchaseResponse `json:"purchase,omitempty"`
+}
+
+type ListingListResponse struct {
+	Listings   []ListingResponse `json:"listings"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
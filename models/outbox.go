@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Event types of the domain events that are published through the outbox and sent to webhook subscribers.
+const (
+	EventTicketCreated     = "ticket.created"
+	EventTicketSoldOut     = "ticket.sold_out"
+	EventPurchaseCompleted = "purchase.completed"
+	EventPurchaseRefunded  = "purchase.refunded"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the change it reports. The relay publishes the
+// events of a ticket one after the other in the order they were written, so an event is never published for a change
+// that was rolled back and never overtakes an earlier event of its ticket.
+type OutboxEvent struct {
+	ID        int64  `json:"id" pg:",pk"`
+	TicketID  int64  `json:"ticket_id" sql:",notnull"`
+	EventType string `json:"event_type" sql:",notnull"`
+	// Payload is the JSON of the ticket or purchase the event is about.
+	Payload     string    `json:"payload" sql:",notnull"`
+	OccurredAt  time.Time `json:"occurred_at" sql:",notnull"`
+	PublishedAt time.Time `json:"published_at"`
+	// Attempts counts the failed publications, NextAttemptAt holds the event and the later events of its ticket back
+	// until the next try.
+	Attempts      int       `json:"attempts" sql:",notnull"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastError     string    `json:"last_error"`
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// DiscountType tells how the value of a promo code is applied to a price.
+type DiscountType string
+
+const (
+	DiscountTypePercent DiscountType = "percent"
+	DiscountTypeFixed   DiscountType = "fixed"
+)
+
+// PromoCode discounts purchases made with it. Codes can be capped in total and per user,
+// limited to a validity window and scoped to a set of tickets.
+type PromoCode struct {
+	ID           int64        `json:"id" pg:",pk"`
+	Code         string       `json:"code" sql:",notnull"`
+	DiscountType DiscountType `json:"discount_type" sql:",notnull"`
+	// Value is a percentage for percent codes and an amount in minor units of Currency for fixed codes.
+	Value int64 `json:"value" sql:",notnull"`
+	// Currency is the ISO-4217 code of a fixed amount, fixed codes only apply to prices in it. Percent codes have none.
+	Currency string `json:"currency" sql:",notnull"`
+	// MaxRedemptions caps the redemptions of the code across all users, 0 means no cap.
+	MaxRedemptions int `json:"max_redemptions" sql:",notnull"`
+	// MaxPerUser caps the redemptions of a single user, 0 means no cap.
+	MaxPerUser  int `json:"max_per_user" sql:",notnull"`
+	Redemptions int `json:"redemptions" sql:",notnull"`
+	// ValidFrom and ValidUntil bound the validity window, a zero time leaves that side open.
+	ValidFrom  time.Time `json:"valid_from"`
+	ValidUntil time.Time `json:"valid_until"`
+	// TicketIDs scopes the code to these tickets, an empty list means every ticket.
+	TicketIDs []int64   `json:"ticket_ids" sql:",array"`
+	CreatedAt time.Time `json:"created_at" sql:"default:now()"`
+	UpdatedAt time.Time `json:"updated_at" sql:"default:now()"`
+	DeletedAt time.Time `json:"-" pg:",soft_delete"`
+}
+
+// ValidAt reports whether the time is inside the validity window of the code.
+func (rc *PromoCode) ValidAt(now time.Time) bool {
+	if !rc.ValidFrom.IsZero() && now.Before(rc.ValidFrom) {
+		return false
+	}
+
+	return rc.ValidUntil.IsZero() || now.Before(rc.ValidUntil)
+}
+
+// AppliesTo reports whether the code can be used for the ticket.
+func (rc *PromoCode) AppliesTo(ticketID int64) bool {
+	return len(rc.TicketIDs) == 0 || slices.Contains(rc.TicketIDs, ticketID)
+}
+
+// AppliesToCurrency reports whether the code can discount a price in the currency, fixed amounts only discount
+// prices in their own currency.
+func (rc *PromoCode) AppliesToCurrency(currency string) bool {
+	return rc.DiscountType != DiscountTypeFixed || rc.Currency == currency
+}
+
+// Discount returns the part of the amount, in minor currency units, taken off by the code.
+// Percentages are rounded down and the discount never exceeds the amount.
+func (rc *PromoCode) Discount(amount int64) int64 {
+	var discount int64
+	switch rc.DiscountType {
+	case DiscountTypePercent:
+		discount = amount * rc.Value / 100
+	case DiscountTypeFixed:
+		discount = rc.Value
+	}
+
+	return min(max(discount, 0), amount)
+}
+
+type PromoCodeResponse struct {
+	ID             int64        `json:"id"`
+	Code           string       `json:"code"`
+	DiscountType   DiscountType `json:"discount_type"`
+	Value          int64        `json:"value"`
+	Currency       string       `json:"currency,omitempty"`
+	MaxRedemptions int          `json:"max_redemptions"`
+	MaxPerUser     int          `json:"max_per_user"`
+	Redemptions    int          `json:"redemptions"`
+	ValidFrom      *time.Time   `json:"valid_from,omitempty"`
+	ValidUntil     *time.Time   `json:"valid_until,omitempty"`
+	TicketIDs      []int64      `json:"ticket_ids"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+type CreatePromoCodeRequest struct {
+	// Code is matched case-insensitively, it is stored upper-cased.
+	Code         string       `json:"code" validate:"required,alphanum,min=3,max=32"`
+	DiscountType DiscountType `json:"discount_type" validate:"required,oneof=percent fixed"`
+	// Value is a percentage between 1 and 100 for percent codes.
+	Value int64 `json:"value" validate:"required,gt=0"`
+	// Currency of the amount is required for fixed codes and not allowed for percent codes.
+	Currency       string     `json:"currency" validate:"required_if=DiscountType fixed,excluded_if=DiscountType percent,omitempty,iso4217"`
+	MaxRedemptions int        `json:"max_redemptions" validate:"omitempty,gt=0"`
+	MaxPerUser     int        `json:"max_per_user" validate:"omitempty,gt=0"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	TicketIDs      []int64    `json:"ticket_ids" validate:"dive,gt=0"`
+}
+
+// UpdatePromoCodeRequest carries a partial promo code update, fields left out are not changed.
+// The code and its discount type can't be changed.
+type UpdatePromoCodeRequest struct {
+	Value *int64 `json:"value" validate:"omitempty,gt=0"`
+	// MaxRedemptions and MaxPerUser of 0 remove the cap.
+	MaxRedemptions *int       `json:"max_redemptions" validate:"omitempty,gte=0"`
+	MaxPerUser     *int       `json:"max_per_user" validate:"omitempty,gte=0"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	// ClearValidFrom and ClearValidUntil open that side of the validity window, they can't be combined with a new
+	// time for the same side.
+	ClearValidFrom  bool     `json:"clear_valid_from" validate:"excluded_with=ValidFrom"`
+	ClearValidUntil bool     `json:"clear_valid_until" validate:"excluded_with=ValidUntil"`
+	TicketIDs       *[]int64 `json:"ticket_ids" validate:"omitempty,dive,gt=0"`
+}
@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+type PurchaseStatus string
+
+// A purchase is pending while it is paid, the seats are reserved for it until it completes or fails.
+const (
+	PurchaseStatusPending           PurchaseStatus = "pending"
+	PurchaseStatusFailed            PurchaseStatus = "failed"
+	PurchaseStatusCompleted         PurchaseStatus = "completed"
+	PurchaseStatusPartiallyRefunded PurchaseStatus = "partially_refunded"
+	PurchaseStatusRefunded          PurchaseStatus = "refunded"
+)
+
+// PaymentStatus is the state of the payment of a purchase as last reported by the payment provider.
+type PaymentStatus string
+
+// An authorized payment wasn't captured yet, a released one was never captured and its authorization is gone.
+const (
+	PaymentStatusAuthorized        PaymentStatus = "authorized"
+	PaymentStatusReleased          PaymentStatus = "released"
+	PaymentStatusCaptured          PaymentStatus = "captured"
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentStatusRefunded          PaymentStatus = "refunded"
+)
+
+type Purchase struct {
+	ID               int64          `json:"id" pg:",pk"`
+	TicketID         int64          `json:"ticket_id" sql:",notnull"`
+	TierID           int64          `json:"tier_id"`
+	UserID           string         `json:"user_id" sql:",notnull"`
+	Quantity         int            `json:"quantity" sql:",notnull"`
+	RefundedQuantity int            `json:"refunded_quantity" sql:",notnull"`
+	Status           PurchaseStatus `json:"status" sql:",notnull"`
+	// UnitPrice, Subtotal, Discount and Total are in minor units of Currency. They are fixed when the purchase is made,
+	// later price changes of the ticket and refunds don't rewrite them.
+	UnitPrice int64  `json:"unit_price" sql:",notnull"`
+	Currency  string `json:"currency" sql:",notnull"`
+	Subtotal  int64  `json:"subtotal" sql:",notnull"`
+	Discount  int64  `json:"discount" sql:",notnull"`
+	Total     int64  `json:"total" sql:",notnull"`
+	// PromoCodeID and PromoCode record the promo code redeemed by the purchase, if any.
+	PromoCodeID int64     `json:"promo_code_id"`
+	PromoCode   string    `json:"promo_code"`
+	CreatedAt   time.Time `json:"created_at" sql:"default:now()"`
+	UpdatedAt   time.Time `json:"updated_at" sql:"default:now()"`
+	// PaymentID is the provider's ID of the payment of the purchase, free purchases have none.
+	// PaymentRefunded is the amount the provider has paid back so far.
+	PaymentID       string        `json:"payment_id"`
+	PaymentStatus   PaymentStatus `json:"payment_status"`
+	PaymentRefunded int64         `json:"payment_refunded" sql:",notnull"`
+	// ResoldQuantity counts the seats sold on through a resale listing, they belong to the buyers' purchases since.
+	// ResaleListingID is set on purchases bought from a resale listing.
+	ResoldQuantity  int   `json:"resold_quantity" sql:",notnull"`
+	ResaleListingID int64 `json:"resale_listing_id"`
+	// Credentials are loaded when a single purchase is read, they aren't stored with the purchase.
+	Credentials []Credential `json:"credentials,omitempty" sql:"-"`
+}
+
+// Remaining returns the seats of the purchase that were neither refunded nor resold.
+func (rc *Purchase) Remaining() int {
+	return rc.Quantity - rc.RefundedQuantity - rc.ResoldQuantity
+}
+
+// SetPrice fills the line totals of the purchase for its quantity at the unit price, less the discount of the promo code.
+func (rc *Purchase) SetPrice(unitPrice int64, currency string, promo *PromoCode) {
+	rc.UnitPrice = unitPrice
+	rc.Currency = currency
+	rc.Subtotal = unitPrice * int64(rc.Quantity)
+	rc.Discount = 0
+	if promo != nil {
+		rc.Discount = promo.Discount(rc.Subtotal)
+	}
+	rc.Total = rc.Subtotal - rc.Discount
+}
+
+type PurchaseResponse struct {
+	ID               int64          `json:"id"`
+	TicketID         int64          `json:"ticket_id"`
+	TierID           int64          `json:"tier_id,omitempty"`
+	UserID           string         `json:"user_id"`
+	Quantity         int            `json:"quantity"`
+	RefundedQuantity int            `json:"refunded_quantity"`
+	Status           PurchaseStatus `json:"status"`
+	UnitPrice        int64          `json:"unit_price"`
+	Currency         string         `json:"currency,omitempty"`
+	Subtotal         int64          `json:"subtotal"`
+	Discount         int64          `json:"discount"`
+	Total            int64          `json:"total"`
+	PromoCode        string         `json:"promo_code,omitempty"`
+	PaymentID        string         `json:"payment_id,omitempty"`
+	PaymentStatus    PaymentStatus  `json:"payment_status,omitempty"`
+	PaymentRefunded  int64          `json:"payment_refunded"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	// ResoldQuantity and ResaleListingID tell which seats were resold and which purchases came from a resale listing.
+	ResoldQuantity  int   `json:"resold_quantity"`
+	ResaleListingID int64 `json:"resale_listing_id,omitempty"`
+	// Credentials admit the seats of the purchase at the door, one per seat.
+	Credentials []CredentialResponse `json:"credentials,omitempty"`
+}
+
+type CancelPurchaseRequest struct {
+	// Quantity of seats to cancel, the whole remaining quantity when omitted.
+	Quantity int `json:"quantity" validate:"omitempty,gt=0"`
+	// UserID restricts the cancellation to purchases of this user, staff leave it empty.
+	UserID string `json:"-"`
+}
@@ -1,26 +1,159 @@
 package models
 
+import "time"
+
+// TicketStatus is the lifecycle state of a ticket, only on_sale tickets can be bought.
+type TicketStatus string
+
+const (
+	TicketStatusDraft     TicketStatus = "draft"
+	TicketStatusOnSale    TicketStatus = "on_sale"
+	TicketStatusPaused    TicketStatus = "paused"
+	TicketStatusSoldOut   TicketStatus = "sold_out"
+	TicketStatusCancelled TicketStatus = "cancelled"
+)
+
+// SaleStatus tells whether a ticket can be bought at a given time according to its sales window.
+type SaleStatus string
+
+const (
+	SaleStatusNotStarted SaleStatus = "not_started"
+	SaleStatusOnSale     SaleStatus = "on_sale"
+	SaleStatusClosed     SaleStatus = "closed"
+)
+
 type Ticket struct {
 	ID          int64  `json:"id" pg:",pk"`
 	Name        string `json:"name"`
 	Description string `json:"desc"`
-	Allocation  int    `json:"allocation"`
+	Allocation  int    `json:"allocation" sql:",notnull"`
+	// Price is the price of one seat in minor units of Currency, e.g. cents for USD.
+	Price int64 `json:"price" sql:",notnull"`
+	// Currency is the ISO-4217 code of the price, empty for free tickets created without one.
+	Currency string `json:"currency" sql:",notnull"`
+	// Status defaults to on_sale for rows written before tickets had a lifecycle.
+	Status TicketStatus `json:"status" sql:",notnull,default:'on_sale'"`
+	// MaxPerUser caps the seats a single user may hold across all purchases of the ticket, 0 means no cap.
+	MaxPerUser int `json:"max_per_user" sql:",notnull"`
+	// SalesStart and SalesEnd bound the sales window, a zero time leaves that side open.
+	SalesStart time.Time `json:"sales_start"`
+	SalesEnd   time.Time `json:"sales_end"`
+	Version    int       `json:"version" sql:",notnull"`
+	DeletedAt  time.Time `json:"-" pg:",soft_delete"`
+	// EventStart is when the event begins, transfers close a configured time before it. A zero time means not announced.
+	EventStart time.Time `json:"event_start"`
+	// SaleStatus is computed from the sales window when the ticket is read, it isn't stored.
+	SaleStatus SaleStatus `json:"sale_status" sql:"-"`
+	// Tiers are loaded when a single ticket is read, they aren't stored with the ticket.
+	Tiers []TicketTier `json:"tiers,omitempty" sql:"-"`
+}
+
+// SaleStatusAt returns the sale status of the ticket at the given time.
+func (rc *Ticket) SaleStatusAt(now time.Time) SaleStatus {
+	return saleStatusAt(rc.SalesStart, rc.SalesEnd, now)
+}
+
+// saleStatusAt returns the sale status of a sales window at the given time.
+func saleStatusAt(start, end, now time.Time) SaleStatus {
+	if !start.IsZero() && now.Before(start) {
+		return SaleStatusNotStarted
+	}
+
+	if !end.IsZero() && !now.Before(end) {
+		return SaleStatusClosed
+	}
+
+	return SaleStatusOnSale
 }
 
 type TicketResponse struct {
-	ID          int64  `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"desc"`
-	Allocation  int    `json:"allocation"`
+	ID          int64        `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"desc"`
+	Allocation  int          `json:"allocation"`
+	Price       int64        `json:"price"`
+	Currency    string       `json:"currency,omitempty"`
+	Status      TicketStatus `json:"status"`
+	MaxPerUser  int          `json:"max_per_user"`
+	SalesStart  *time.Time   `json:"sales_start,omitempty"`
+	SalesEnd    *time.Time   `json:"sales_end,omitempty"`
+	EventStart  *time.Time   `json:"event_start,omitempty"`
+	SaleStatus  SaleStatus   `json:"sale_status"`
+	Version     int          `json:"version"`
+	// Tiers lists the availability of each tier, tickets without tiers leave it out.
+	Tiers []TicketTierResponse `json:"tiers,omitempty"`
 }
 
 type CreateRequest struct {
 	Name        string `json:"name" validate:"required,min=5,max=100"`
 	Description string `json:"desc" validate:"max=500"`
 	Allocation  int    `json:"allocation" validate:"required,gt=0"`
+	// Price is in minor units of Currency, a currency is required for priced tickets.
+	Price      int64  `json:"price" validate:"gte=0"`
+	Currency   string `json:"currency" validate:"required_with=Price,omitempty,iso4217"`
+	MaxPerUser int    `json:"max_per_user" validate:"omitempty,gt=0"`
+	// Status is draft unless the ticket is published right away.
+	Status TicketStatus `json:"status" validate:"omitempty,oneof=draft on_sale"`
+	// SalesStart and SalesEnd are optional, the sales end must be after the sales start.
+	SalesStart *time.Time `json:"sales_start"`
+	SalesEnd   *time.Time `json:"sales_end"`
+	EventStart *time.Time `json:"event_start"`
+}
+
+// UpdateRequest carries a partial ticket update, fields left out are not changed.
+type UpdateRequest struct {
+	Name        *string `json:"name" validate:"omitempty,min=5,max=100"`
+	Description *string `json:"desc" validate:"omitempty,max=500"`
+	Allocation  *int    `json:"allocation" validate:"omitempty,gt=0"`
+	// Price changes apply to later purchases, purchases already made keep the price they were made at.
+	Price    *int64  `json:"price" validate:"omitempty,gte=0"`
+	Currency *string `json:"currency" validate:"omitempty,iso4217"`
+	// MaxPerUser of 0 removes the cap.
+	MaxPerUser *int       `json:"max_per_user" validate:"omitempty,gte=0"`
+	SalesStart *time.Time `json:"sales_start"`
+	SalesEnd   *time.Time `json:"sales_end"`
+	EventStart *time.Time `json:"event_start"`
 }
 
 type PurchaseRequest struct {
-	UserID   string `json:"user_id" validate:"required"`
+	// UserID is taken from the access token, never from the request body.
+	UserID   string `json:"-" validate:"required"`
 	Quantity int    `json:"quantity" validate:"required,gt=0"`
+	// TierID is required for tickets with tiers.
+	TierID int64 `json:"tier_id" validate:"omitempty,gt=0"`
+	// PromoCode is redeemed by the purchase when given, it is matched case-insensitively.
+	PromoCode string `json:"promo_code" validate:"omitempty,alphanum,max=32"`
+}
+
+type TicketListRequest struct {
+	Name      string `query:"name" validate:"max=100"`
+	Available bool   `query:"available"`
+	Sort      string `query:"sort" validate:"omitempty,oneof=id name allocation"`
+	Order     string `query:"order" validate:"omitempty,oneof=asc desc"`
+	Limit     int    `query:"limit" validate:"omitempty,gt=0,lte=100"`
+	Cursor    string `query:"cursor"`
+}
+
+type TicketListResponse struct {
+	Tickets    []TicketResponse `json:"tickets"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// TicketListOptions are the decoded list parameters handed to the repository.
+type TicketListOptions struct {
+	Name      string
+	Available bool
+	Sort      string
+	Order     string
+	Limit     int
+	After     *TicketCursor
+}
+
+// TicketCursor marks the last ticket of a page, the next page starts right after it.
+type TicketCursor struct {
+	Sort       string `json:"s"`
+	Order      string `json:"o"`
+	Name       string `json:"n,omitempty"`
+	Allocation int    `json:"a,omitempty"`
+	ID         int64  `json:"i"`
 }
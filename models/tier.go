@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// TicketTier is a priced share of a ticket's seats, e.g. early bird, standard or VIP. Each tier has its own
+// allocation, price and sales window. The allocation of a ticket with tiers is the sum of its tiers' allocations.
+type TicketTier struct {
+	ID       int64  `json:"id" pg:",pk"`
+	TicketID int64  `json:"ticket_id" sql:",notnull"`
+	Name     string `json:"name" sql:",notnull"`
+	// Allocation is the number of seats of the tier that are still available.
+	Allocation int `json:"allocation" sql:",notnull"`
+	// Price is the price of one seat in minor units of the ticket's currency.
+	Price int64 `json:"price" sql:",notnull"`
+	// SalesStart and SalesEnd bound the sales window of the tier within the ticket's, a zero time leaves that side open.
+	SalesStart time.Time `json:"sales_start"`
+	SalesEnd   time.Time `json:"sales_end"`
+	// Position orders the tiers of a ticket, lower positions are listed and offered first.
+	Position  int       `json:"position" sql:",notnull"`
+	CreatedAt time.Time `json:"created_at" sql:"default:now()"`
+	UpdatedAt time.Time `json:"updated_at" sql:"default:now()"`
+	// SaleStatus is computed from the sales window when the tier is read, it isn't stored.
+	SaleStatus SaleStatus `json:"sale_status" sql:"-"`
+}
+
+// SaleStatusAt returns the sale status of the tier at the given time.
+func (rc *TicketTier) SaleStatusAt(now time.Time) SaleStatus {
+	return saleStatusAt(rc.SalesStart, rc.SalesEnd, now)
+}
+
+type TicketTierResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Allocation int        `json:"allocation"`
+	Price      int64      `json:"price"`
+	SalesStart *time.Time `json:"sales_start,omitempty"`
+	SalesEnd   *time.Time `json:"sales_end,omitempty"`
+	SaleStatus SaleStatus `json:"sale_status"`
+	Position   int        `json:"position"`
+}
+
+type CreateTierRequest struct {
+	Name       string `json:"name" validate:"required,max=50"`
+	Allocation int    `json:"allocation" validate:"required,gt=0"`
+	// Price is in minor units of the ticket's currency.
+	Price int64 `json:"price" validate:"gte=0"`
+	// SalesStart and SalesEnd are optional, the sales end must be after the sales start.
+	SalesStart *time.Time `json:"sales_start"`
+	SalesEnd   *time.Time `json:"sales_end"`
+	Position   int        `json:"position" validate:"gte=0"`
+}
+
+// UpdateTierRequest carries a partial tier update, fields left out are not changed.
+type UpdateTierRequest struct {
+	Name *string `json:"name" validate:"omitempty,max=50"`
+	// Allocation sets the seats of the tier that are still available, 0 closes the tier.
+	Allocation *int       `json:"allocation" validate:"omitempty,gte=0"`
+	Price      *int64     `json:"price" validate:"omitempty,gte=0"`
+	SalesStart *time.Time `json:"sales_start"`
+	SalesEnd   *time.Time `json:"sales_end"`
+	Position   *int       `json:"position" validate:"omitempty,gte=0"`
+}
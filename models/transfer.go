@@ -0,0 +1,99 @@
+package models
+
+import "time"
+
+// TransferStatus is the state of a ticket transfer, only pending transfers can be accepted or cancelled.
+type TransferStatus string
+
+const (
+	TransferStatusPending   TransferStatus = "pending"
+	TransferStatusAccepted  TransferStatus = "accepted"
+	TransferStatusCancelled TransferStatus = "cancelled"
+)
+
+// TransferAction is a step recorded in the audit trail of a transfer.
+type TransferAction string
+
+const (
+	TransferActionInitiated TransferAction = "initiated"
+	TransferActionAccepted  TransferAction = "accepted"
+	TransferActionCancelled TransferAction = "cancelled"
+)
+
+// Transfer hands the remaining seats of a purchase to another user. The seats stay sold, only their owner and
+// credentials change.
+type Transfer struct {
+	ID         int64  `json:"id" pg:",pk"`
+	PurchaseID int64  `json:"purchase_id" sql:",notnull"`
+	TicketID   int64  `json:"ticket_id" sql:",notnull"`
+	FromUserID string `json:"from_user_id" sql:",notnull"`
+	// ToUserID or ToEmail names the recipient, a transfer to an email is accepted by the user whose token carries it.
+	ToUserID string         `json:"to_user_id"`
+	ToEmail  string         `json:"to_email"`
+	Status   TransferStatus `json:"status" sql:",notnull"`
+	// AcceptedBy is the user the purchase belongs to once the transfer is accepted.
+	AcceptedBy string    `json:"accepted_by"`
+	CreatedAt  time.Time `json:"created_at" sql:"default:now()"`
+	UpdatedAt  time.Time `json:"updated_at" sql:"default:now()"`
+	// Events are the audit trail of the transfer, they aren't stored with it.
+	Events []TransferEvent `json:"-" sql:"-"`
+	// Purchase is the transferred purchase with its reissued credentials once the transfer is accepted.
+	Purchase *Purchase `json:"-" sql:"-"`
+}
+
+// TransferEvent records who did what to a transfer and when.
+type TransferEvent struct {
+	ID         int64          `json:"id" pg:",pk"`
+	TransferID int64          `json:"transfer_id" sql:",notnull"`
+	PurchaseID int64          `json:"purchase_id" sql:",notnull"`
+	Action     TransferAction `json:"action" sql:",notnull"`
+	ActorID    string         `json:"actor_id" sql:",notnull"`
+	// Details lists what changed, such as the revoked and reissued credentials of an accepted transfer.
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at" sql:"default:now()"`
+}
+
+type CreateTransferRequest struct {
+	ToUserID string `json:"to_user_id" validate:"required_without=ToEmail,excluded_with=ToEmail,max=100"`
+	ToEmail  string `json:"to_email" validate:"required_without=ToUserID,omitempty,email,max=254"`
+	// UserID restricts the transfer to purchases of this user, staff leave it empty.
+	UserID string `json:"-"`
+	// ActorID is the caller taken from the access token, never from the request body.
+	ActorID string `json:"-" validate:"required"`
+}
+
+// AcceptTransferRequest identifies the caller accepting a transfer, it is taken from the access token.
+type AcceptTransferRequest struct {
+	UserID string `json:"-" validate:"required"`
+	Email  string `json:"-"`
+}
+
+type CancelTransferRequest struct {
+	// UserID restricts the cancellation to transfers sent by this user, staff leave it empty.
+	UserID string `json:"-"`
+	// ActorID is the caller taken from the access token, never from the request body.
+	ActorID string `json:"-" validate:"required"`
+}
+
+type TransferResponse struct {
+	ID         int64                   `json:"id"`
+	PurchaseID int64                   `json:"purchase_id"`
+	TicketID   int64                   `json:"ticket_id"`
+	FromUserID string                  `json:"from_user_id"`
+	ToUserID   string                  `json:"to_user_id,omitempty"`
+	ToEmail    string                  `json:"to_email,omitempty"`
+	Status     TransferStatus          `json:"status"`
+	AcceptedBy string                  `json:"accepted_by,omitempty"`
+	CreatedAt  time.Time               `json:"created_at"`
+	UpdatedAt  time.Time               `json:"updated_at"`
+	Events     []TransferEventResponse `json:"events"`
+	// Purchase is returned to the recipient when they accept, with the new credentials of the seats.
+	Purchase *PurchaseResponse `json:"purchase,omitempty"`
+}
+
+type TransferEventResponse struct {
+	Action    TransferAction `json:"action"`
+	ActorID   string         `json:"actor_id"`
+	Details   string         `json:"details,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
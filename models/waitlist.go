@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+type WaitlistStatus string
+
+const (
+	WaitlistStatusWaiting   WaitlistStatus = "waiting"
+	WaitlistStatusOffered   WaitlistStatus = "offered"
+	WaitlistStatusPaying    WaitlistStatus = "paying"
+	WaitlistStatusFulfilled WaitlistStatus = "fulfilled"
+	WaitlistStatusExpired   WaitlistStatus = "expired"
+)
+
+// WaitlistEntry queues a user for seats of a sold out ticket. Restored seats are offered to the oldest waiting entry,
+// the offered seats are reserved for the user until the offer expires. An accepted offer is paying until its purchase
+// is paid, a failed payment opens it again. For tickets with tiers TierID is the tier the offered seats were taken from.
+type WaitlistEntry struct {
+	ID             int64          `json:"id" pg:",pk"`
+	TicketID       int64          `json:"ticket_id" sql:",notnull"`
+	TierID         int64          `json:"tier_id"`
+	UserID         string         `json:"user_id" sql:",notnull"`
+	Quantity       int            `json:"quantity" sql:",notnull"`
+	Status         WaitlistStatus `json:"status" sql:",notnull"`
+	OfferExpiresAt time.Time      `json:"offer_expires_at"`
+	PurchaseID     int64          `json:"purchase_id"`
+	CreatedAt      time.Time      `json:"created_at" sql:"default:now()"`
+	UpdatedAt      time.Time      `json:"updated_at" sql:"default:now()"`
+}
+
+type WaitlistEntryResponse struct {
+	ID       int64          `json:"id"`
+	TicketID int64          `json:"ticket_id"`
+	TierID   int64          `json:"tier_id,omitempty"`
+	UserID   string         `json:"user_id"`
+	Quantity int            `json:"quantity"`
+	Status   WaitlistStatus `json:"status"`
+	// Position is the 1-based place in the queue while the entry is waiting.
+	Position       int        `json:"position,omitempty"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at,omitempty"`
+	PurchaseID     int64      `json:"purchase_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type WaitlistRequest struct {
+	// UserID is taken from the access token, never from the request body.
+	UserID   string `json:"-" validate:"required"`
+	Quantity int    `json:"quantity" validate:"required,gt=0"`
+}
+
+type AcceptOfferRequest struct {
+	// UserID is taken from the access token, never from the request body.
+	UserID string `json:"-" validate:"required"`
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// WebhookDeliveryStatus is the state of a webhook delivery, pending deliveries are retried until they succeed or fail for good.
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookSubscription sends the events of its event types to an endpoint, signed with its secret.
+type WebhookSubscription struct {
+	ID  int64  `json:"id" pg:",pk"`
+	URL string `json:"url" sql:",notnull"`
+	// Secret is the HMAC-SHA256 key deliveries are signed with, it is never returned.
+	Secret     string    `json:"-" sql:",notnull"`
+	EventTypes []string  `json:"event_types" sql:",array"`
+	Active     bool      `json:"active" sql:",notnull"`
+	CreatedAt  time.Time `json:"created_at" sql:"default:now()"`
+	UpdatedAt  time.Time `json:"updated_at" sql:"default:now()"`
+	DeletedAt  time.Time `json:"-" pg:",soft_delete"`
+}
+
+// Subscribes reports whether the subscription wants events of the type.
+func (rc *WebhookSubscription) Subscribes(eventType string) bool {
+	return rc.Active && slices.Contains(rc.EventTypes, eventType)
+}
+
+// WebhookDelivery is one event sent to one subscription, it doubles as the delivery log of the subscription.
+type WebhookDelivery struct {
+	ID             int64  `json:"id" pg:",pk"`
+	SubscriptionID int64  `json:"subscription_id" sql:",notnull"`
+	EventID        string `json:"event_id" sql:",notnull"`
+	EventType      string `json:"event_type" sql:",notnull"`
+	// Payload is the exact body that is signed and sent.
+	Payload string                `json:"payload" sql:",notnull"`
+	Status  WebhookDeliveryStatus `json:"status" sql:",notnull"`
+	// Attempts counts the requests made so far, NextAttemptAt is when a pending delivery is tried again.
+	Attempts      int       `json:"attempts" sql:",notnull"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastAttemptAt time.Time `json:"last_attempt_at"`
+	// ResponseStatus is the HTTP status of the last attempt, 0 when the endpoint couldn't be reached.
+	ResponseStatus int       `json:"response_status"`
+	LastError      string    `json:"last_error"`
+	DeliveredAt    time.Time `json:"delivered_at"`
+	// RedeliveryOf is the delivery this one was manually resent from.
+	RedeliveryOf int64     `json:"redelivery_of"`
+	CreatedAt    time.Time `json:"created_at" sql:"default:now()"`
+}
+
+// WebhookPayload is the body of every delivery, Data holds the ticket or purchase the event is about.
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type WebhookSubscriptionResponse struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             int64                 `json:"id"`
+	SubscriptionID int64                 `json:"subscription_id"`
+	EventID        string                `json:"event_id"`
+	EventType      string                `json:"event_type"`
+	Payload        string                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty"`
+	ResponseStatus int                   `json:"response_status,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	RedeliveryOf   int64                 `json:"redelivery_of,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" validate:"required,url,startswith=http"`
+	// Secret is chosen by the subscriber, who needs it to verify the signatures.
+	Secret     string   `json:"secret" validate:"required,min=16,max=128"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=ticket.created ticket.sold_out purchase.completed purchase.refunded"`
+}
+
+// UpdateWebhookRequest carries a partial subscription update, fields left out are not changed.
+type UpdateWebhookRequest struct {
+	URL        *string   `json:"url" validate:"omitempty,url,startswith=http"`
+	Secret     *string   `json:"secret" validate:"omitempty,min=16,max=128"`
+	EventTypes *[]string `json:"event_types" validate:"omitempty,min=1,dive,oneof=ticket.created ticket.sold_out purchase.completed purchase.refunded"`
+	// Active false pauses the subscription, no deliveries are created for it meanwhile.
+	Active *bool `json:"active"`
+}
+
+type WebhookDeliveryListRequest struct {
+	Status WebhookDeliveryStatus `query:"status" validate:"omitempty,oneof=pending succeeded failed"`
+	Limit  int                   `query:"limit" validate:"omitempty,gt=0,lte=100"`
+}
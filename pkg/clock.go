@@ -0,0 +1,20 @@
+package pkg
+
+import "time"
+
+// Clock abstracts the current time so time dependent flows can be tested without sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+// NewClock returns a Clock backed by the system time.
+func NewClock() Clock {
+	return realClock{}
+}
+
+// Now returns the current system time.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
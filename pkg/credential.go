@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// credentialVersion is the first byte of every credential payload, so the format can change without breaking scanners.
+const credentialVersion byte = 1
+
+// credentialBodySize is the version byte, the credential, purchase and ticket IDs and the seat index.
+const credentialBodySize = 1 + 8 + 8 + 8 + 2
+
+// CredentialClaims are the facts a ticket credential proves to a scanner.
+type CredentialClaims struct {
+	CredentialID int64
+	PurchaseID   int64
+	TicketID     int64
+	Seat         int
+}
+
+// CredentialSigner signs ticket credentials with Ed25519. A payload is the base64url encoding of a fixed size body
+// followed by its signature, short enough for a QR code and verifiable offline with the public key alone.
+type CredentialSigner struct {
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// NewCredentialSigner creates a CredentialSigner from a base64 encoded 32-byte Ed25519 seed, there is no default key.
+func NewCredentialSigner(seed string) (*CredentialSigner, error) {
+	if seed == "" {
+		return nil, errors.New("missing credential signing key")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential signing key: %w", err)
+	}
+	if len(raw) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid credential signing key: want a %d byte seed, got %d bytes", ed25519.SeedSize, len(raw))
+	}
+
+	privateKey := ed25519.NewKeyFromSeed(raw)
+
+	return &CredentialSigner{
+		privateKey: privateKey,
+		publicKey:  privateKey.Public().(ed25519.PublicKey),
+	}, nil
+}
+
+// PublicKey returns the key scanners verify credentials with.
+func (rc *CredentialSigner) PublicKey() ed25519.PublicKey {
+	return rc.publicKey
+}
+
+// Sign returns the payload of a credential. Ed25519 signatures are deterministic, so the same claims always give
+// the same payload and payloads don't need to be stored.
+func (rc *CredentialSigner) Sign(claims CredentialClaims) string {
+	body := make([]byte, credentialBodySize, credentialBodySize+ed25519.SignatureSize)
+	body[0] = credentialVersion
+	binary.BigEndian.PutUint64(body[1:], uint64(claims.CredentialID))
+	binary.BigEndian.PutUint64(body[9:], uint64(claims.PurchaseID))
+	binary.BigEndian.PutUint64(body[17:], uint64(claims.TicketID))
+	binary.BigEndian.PutUint16(body[25:], uint16(claims.Seat))
+
+	return base64.RawURLEncoding.EncodeToString(append(body, ed25519.Sign(rc.privateKey, body)...))
+}
+
+// Verify checks the signature of a credential payload and returns its claims. Payloads that don't decode or
+// aren't signed by the key fail with ErrForgedCredential.
+func (rc *CredentialSigner) Verify(payload string) (*CredentialClaims, error) {
+	return VerifyCredential(rc.publicKey, payload)
+}
+
+// VerifyCredential checks a credential payload against a public key, as an offline scanner does.
+func VerifyCredential(publicKey ed25519.PublicKey, payload string) (*CredentialClaims, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || len(raw) != credentialBodySize+ed25519.SignatureSize || raw[0] != credentialVersion {
+		return nil, ErrForgedCredential
+	}
+
+	body, signature := raw[:credentialBodySize], raw[credentialBodySize:]
+	if !ed25519.Verify(publicKey, body, signature) {
+		return nil, ErrForgedCredential
+	}
+
+	return &CredentialClaims{
+		CredentialID: int64(binary.BigEndian.Uint64(body[1:])),
+		PurchaseID:   int64(binary.BigEndian.Uint64(body[9:])),
+		TicketID:     int64(binary.BigEndian.Uint64(body[17:])),
+		Seat:         int(binary.BigEndian.Uint16(body[25:])),
+	}, nil
+}
+
+// SignMessage returns the base64url encoded signature of a message, such as a scanner snapshot. Messages are JSON,
+// they never start with the credential version byte, so a signed message can't pass for a credential.
+func (rc *CredentialSigner) SignMessage(message []byte) string {
+	return base64.RawURLEncoding.EncodeToString(ed25519.Sign(rc.privateKey, message))
+}
+
+// VerifyMessage checks the signature of a message against a public key, as an offline scanner does with a snapshot.
+func VerifyMessage(publicKey ed25519.PublicKey, message []byte, signature string) error {
+	raw, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || len(raw) != ed25519.SignatureSize || !ed25519.Verify(publicKey, message, raw) {
+		return ErrInvalidSnapshotSignature
+	}
+
+	return nil
+}
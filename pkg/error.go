@@ -1,5 +1,95 @@
 package pkg
 
+import "errors"
+
+// ErrInsufficientAllocation is returned when a ticket does not have enough seats left for the requested quantity.
+var ErrInsufficientAllocation = errors.New("insufficient ticket allocation")
+
+// ErrTicketNotOnSale is returned when a ticket is bought or held outside the on_sale state.
+var ErrTicketNotOnSale = errors.New("ticket is not on sale")
+
+// ErrInvalidTransition is returned when a ticket can't move from its current state to the requested one.
+var ErrInvalidTransition = errors.New("invalid ticket status transition")
+
+// ErrHoldNotActive is returned when a hold was already confirmed, released or has expired.
+var ErrHoldNotActive = errors.New("hold is not active")
+
+// ErrOfferNotActive is returned when a waitlist offer was already accepted or has expired.
+var ErrOfferNotActive = errors.New("waitlist offer is not active")
+
+// ErrTicketAvailable is returned when a user joins the waitlist of a ticket that can serve them right away.
+var ErrTicketAvailable = errors.New("ticket is available")
+
+// ErrAlreadyWaitlisted is returned when a user joins the waitlist of a ticket they are already waiting for.
+var ErrAlreadyWaitlisted = errors.New("already on the waitlist")
+
+// ErrPromoCodeInvalid is returned when a promo code is unknown, outside its validity window, not valid for the ticket
+// or already used as often as the user may.
+var ErrPromoCodeInvalid = errors.New("promo code is not valid")
+
+// ErrPromoCodeExhausted is returned when a promo code has been redeemed as often as it may be.
+var ErrPromoCodeExhausted = errors.New("promo code exhausted")
+
+// ErrVersionConflict is returned when a row was changed since the version the caller based its write on.
+var ErrVersionConflict = errors.New("version conflict")
+
+// ErrAlreadyRefunded is returned when there is nothing left to refund on a purchase.
+var ErrAlreadyRefunded = errors.New("purchase already refunded")
+
+// ErrCancellationWindowClosed is returned when a purchase is too old to be cancelled.
+var ErrCancellationWindowClosed = errors.New("cancellation window closed")
+
+// ErrPurchaseLimitExceeded is returned when a purchase would take a user over the per-user limit of a ticket.
+var ErrPurchaseLimitExceeded = errors.New("purchase limit exceeded")
+
+// ErrSalesNotStarted is returned when a ticket is bought before its sales window opens.
+var ErrSalesNotStarted = errors.New("ticket sales not started")
+
+// ErrSalesClosed is returned when a ticket is bought after its sales window closed.
+var ErrSalesClosed = errors.New("ticket sales closed")
+
+// ErrAPIKeyRevoked is returned when an API key was already revoked.
+var ErrAPIKeyRevoked = errors.New("api key revoked")
+
+// ErrPaymentDeclined is returned when the payment provider refuses to authorize or capture a payment.
+var ErrPaymentDeclined = errors.New("payment declined")
+
+// ErrPaymentTimeout is returned when the payment provider did not answer in time.
+var ErrPaymentTimeout = errors.New("payment provider timed out")
+
+// ErrInvalidWebhookSignature is returned when a payment webhook is not signed by the payment provider.
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// ErrForgedCredential is returned when a ticket credential payload is malformed or not signed by our key.
+var ErrForgedCredential = errors.New("forged credential")
+
+// ErrInvalidSnapshotSignature is returned when a scanner snapshot is not signed by our key.
+var ErrInvalidSnapshotSignature = errors.New("invalid snapshot signature")
+
+// ErrTransferClosed is returned when a transfer is made or accepted after the transfer cutoff of the event.
+var ErrTransferClosed = errors.New("transfers are closed")
+
+// ErrTransferPending is returned when a purchase already has a transfer waiting to be accepted.
+var ErrTransferPending = errors.New("transfer already pending")
+
+// ErrTransferNotPending is returned when a transfer was already accepted or cancelled.
+var ErrTransferNotPending = errors.New("transfer is not pending")
+
+// ErrSeatsCheckedIn is returned when seats somebody already got in with would be given back.
+var ErrSeatsCheckedIn = errors.New("seats already checked in")
+
+// ErrListingUnavailable is returned when a resale listing was sold, cancelled or its seat can't be sold anymore.
+var ErrListingUnavailable = errors.New("listing is not available")
+
+// ErrPriceAboveCap is returned when a resale listing asks more than the price cap allows.
+var ErrPriceAboveCap = errors.New("price above the resale cap")
+
+// ErrPurchaseNotPending is returned when a pending purchase was completed or released in the meantime.
+var ErrPurchaseNotPending = errors.New("purchase is not pending")
+
+// ErrPurchaseNotCompleted is returned when a purchase is changed while it is still paid or after its payment failed.
+var ErrPurchaseNotCompleted = errors.New("purchase is not completed")
+
 // Error struct defines a custom error type with an error, status code, and message.
 type Error struct {
 	err        error
@@ -21,6 +111,11 @@ func (rc *Error) Error() string {
 	return rc.err.Error()
 }
 
+// Unwrap returns the original error so it can be inspected with errors.Is and errors.As.
+func (rc *Error) Unwrap() error {
+	return rc.err
+}
+
 // Message returns the custom error message.
 func (rc *Error) Message() string {
 	return rc.message
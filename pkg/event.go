@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// EventPublisher hands domain events to a message broker or any other consumer. Events may be published more than
+// once, e.g. when the relay stops between publishing an event and recording it, so consumers dedupe them by ID.
+type EventPublisher interface {
+	// Publish delivers the event, an error makes the relay try it again later.
+	Publish(ctx context.Context, event *Event) error
+}
+
+// Event is a domain event as it is published. TicketID is the ordering key, the events of a ticket are published in
+// the order they happened.
+type Event struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	TicketID   int64           `json:"ticket_id"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// LogEventPublisher writes events to the application log, for deployments without a message broker.
+type LogEventPublisher struct {
+	logger *zap.SugaredLogger
+}
+
+func NewLogEventPublisher(logger *zap.SugaredLogger) *LogEventPublisher {
+	return &LogEventPublisher{
+		logger: logger,
+	}
+}
+
+// Publish logs the event, it never fails.
+func (rc *LogEventPublisher) Publish(ctx context.Context, event *Event) error {
+	rc.logger.Infow("domain event published",
+		"id", event.ID,
+		"type", event.Type,
+		"ticket_id", event.TicketID,
+		"occurred_at", event.OccurredAt,
+		"payload", string(event.Payload),
+	)
+
+	return nil
+}
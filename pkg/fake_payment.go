@@ -0,0 +1,238 @@
+package pkg
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Steps of a payment the fake gateway can be told to fail at.
+const (
+	PaymentStepAuthorize = "authorize"
+	PaymentStepCapture   = "capture"
+	PaymentStepRefund    = "refund"
+)
+
+type FakePaymentConfig struct {
+	// Decline is the step the gateway declines, PaymentStepAuthorize or PaymentStepCapture. Empty accepts everything.
+	Decline string
+	// Timeout is the step the gateway never answers, it returns ErrPaymentTimeout once the caller's context ends.
+	Timeout string
+	// WebhookDelay is how long after a capture, refund or declined capture its webhook is sent.
+	// Webhooks are still delivered one at a time, in the order of their events.
+	WebhookDelay time.Duration
+	// WebhookSecret signs the webhooks with HMAC-SHA256, without it no webhook is accepted.
+	WebhookSecret string
+}
+
+// FakePayment is the state of a payment as the fake gateway sees it.
+type FakePayment struct {
+	Amount    int64
+	Currency  string
+	Reference string
+	Captured  bool
+	Released  bool
+	Refunded  int64
+}
+
+// FakePaymentProvider is a deterministic in-process PaymentProvider. Payment and event IDs are sequential and its
+// failures are chosen by FakePaymentConfig, so payment flows can be run offline and in tests.
+type FakePaymentProvider struct {
+	config   FakePaymentConfig
+	mu       sync.Mutex
+	payments map[string]*FakePayment
+	payCount int
+	evtCount int
+	webhooks chan fakeWebhook
+}
+
+type fakeWebhook struct {
+	payload   []byte
+	signature string
+	due       time.Time
+}
+
+// NewFakePaymentProvider creates a FakePaymentProvider. Webhooks are dropped until OnWebhook is called.
+func NewFakePaymentProvider(config FakePaymentConfig) *FakePaymentProvider {
+	return &FakePaymentProvider{
+		config:   config,
+		payments: make(map[string]*FakePayment),
+	}
+}
+
+// OnWebhook starts sending the gateway's signed webhooks to deliver, as a real gateway would POST them.
+// It may only be called once.
+func (rc *FakePaymentProvider) OnWebhook(deliver func(payload []byte, signature string)) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.webhooks = make(chan fakeWebhook, 1024)
+	go func(webhooks <-chan fakeWebhook) {
+		for webhook := range webhooks {
+			time.Sleep(time.Until(webhook.due))
+			deliver(webhook.payload, webhook.signature)
+		}
+	}(rc.webhooks)
+}
+
+// Payment returns a copy of the payment with the given ID.
+func (rc *FakePaymentProvider) Payment(paymentID string) (FakePayment, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	payment, ok := rc.payments[paymentID]
+	if !ok {
+		return FakePayment{}, false
+	}
+
+	return *payment, true
+}
+
+// Authorize creates a payment for the amount unless authorizations are declined.
+func (rc *FakePaymentProvider) Authorize(ctx context.Context, request PaymentRequest) (string, error) {
+	if err := rc.fail(ctx, PaymentStepAuthorize); err != nil {
+		return "", err
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.payCount++
+	paymentID := fmt.Sprintf("fake_pay_%d", rc.payCount)
+	rc.payments[paymentID] = &FakePayment{
+		Amount:    request.Amount,
+		Currency:  request.Currency,
+		Reference: request.Reference,
+	}
+
+	return paymentID, nil
+}
+
+// Capture collects an authorized payment unless captures are declined.
+func (rc *FakePaymentProvider) Capture(ctx context.Context, paymentID string) error {
+	if err := rc.fail(ctx, PaymentStepCapture); err != nil {
+		if errors.Is(err, ErrPaymentDeclined) {
+			rc.mu.Lock()
+			defer rc.mu.Unlock()
+			if payment, ok := rc.payments[paymentID]; ok {
+				rc.send(PaymentEventFailed, paymentID, payment)
+			}
+		}
+		return err
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	payment, ok := rc.payments[paymentID]
+	if !ok || payment.Released {
+		return fmt.Errorf("payment [%s] is not authorized", paymentID)
+	}
+	if payment.Captured {
+		return nil
+	}
+
+	payment.Captured = true
+	rc.send(PaymentEventCaptured, paymentID, payment)
+
+	return nil
+}
+
+// Refund pays back the amount of a captured payment or releases an uncaptured one. Releasing twice is a no-op.
+func (rc *FakePaymentProvider) Refund(ctx context.Context, paymentID string, amount int64) (int64, error) {
+	if err := rc.fail(ctx, PaymentStepRefund); err != nil {
+		return 0, err
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	payment, ok := rc.payments[paymentID]
+	if !ok {
+		return 0, fmt.Errorf("payment [%s] not found", paymentID)
+	}
+
+	if !payment.Captured {
+		payment.Released = true
+		return 0, nil
+	}
+
+	if payment.Refunded+amount > payment.Amount {
+		return 0, fmt.Errorf("refund of %d exceeds the %d left on payment [%s]", amount, payment.Amount-payment.Refunded, paymentID)
+	}
+
+	payment.Refunded += amount
+	rc.send(PaymentEventRefunded, paymentID, payment)
+
+	return payment.Refunded, nil
+}
+
+// VerifyWebhook checks the HMAC-SHA256 signature of a webhook and decodes its event.
+func (rc *FakePaymentProvider) VerifyWebhook(payload []byte, signature string) (*PaymentEvent, error) {
+	// anyone can sign with an empty secret, nothing is trusted without one
+	if rc.config.WebhookSecret == "" {
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(rc.sign(payload))) {
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	event := new(PaymentEvent)
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook: %w", err)
+	}
+
+	return event, nil
+}
+
+// fail returns the configured failure of the step, if any.
+func (rc *FakePaymentProvider) fail(ctx context.Context, step string) error {
+	switch step {
+	case rc.config.Timeout:
+		<-ctx.Done()
+		return fmt.Errorf("%w: %s", ErrPaymentTimeout, step)
+	case rc.config.Decline:
+		return fmt.Errorf("%w: %s", ErrPaymentDeclined, step)
+	}
+
+	return nil
+}
+
+// send signs an event about the payment and delivers it after the webhook delay. The caller must hold rc.mu.
+func (rc *FakePaymentProvider) send(eventType PaymentEventType, paymentID string, payment *FakePayment) {
+	if rc.webhooks == nil {
+		return
+	}
+
+	rc.evtCount++
+	payload, err := json.Marshal(PaymentEvent{
+		ID:        fmt.Sprintf("fake_evt_%d", rc.evtCount),
+		Type:      eventType,
+		PaymentID: paymentID,
+		Amount:    payment.Amount,
+		Refunded:  payment.Refunded,
+	})
+	if err != nil {
+		return
+	}
+
+	rc.webhooks <- fakeWebhook{
+		payload:   payload,
+		signature: rc.sign(payload),
+		due:       time.Now().Add(rc.config.WebhookDelay),
+	}
+}
+
+func (rc *FakePaymentProvider) sign(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(rc.config.WebhookSecret))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
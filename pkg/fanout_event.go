@@ -0,0 +1,27 @@
+package pkg
+
+import "context"
+
+// FanoutEventPublisher hands every event to several publishers, e.g. the webhook queue and the message broker, so
+// they all follow the one outbox.
+type FanoutEventPublisher struct {
+	publishers []EventPublisher
+}
+
+func NewFanoutEventPublisher(publishers ...EventPublisher) *FanoutEventPublisher {
+	return &FanoutEventPublisher{
+		publishers: publishers,
+	}
+}
+
+// Publish hands the event to the publishers in order and stops at the first one that fails. A failed event is handed
+// to every publisher again when it is retried, the ones before the failing one get it twice.
+func (rc *FanoutEventPublisher) Publish(ctx context.Context, event *Event) error {
+	for _, publisher := range rc.publishers {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+)
+
+// InProcessEventPublisher hands events to handlers in the same process and keeps the events it published, so the
+// outbox can be followed in tests and wired to in-process consumers.
+type InProcessEventPublisher struct {
+	mu        sync.Mutex
+	handlers  []func(ctx context.Context, event Event) error
+	published []Event
+}
+
+func NewInProcessEventPublisher() *InProcessEventPublisher {
+	return &InProcessEventPublisher{}
+}
+
+// Subscribe adds a handler that is called with every event, in the order handlers were added.
+func (rc *InProcessEventPublisher) Subscribe(handler func(ctx context.Context, event Event) error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.handlers = append(rc.handlers, handler)
+}
+
+// Publish calls the handlers with the event and stops at the first one that fails. The event counts as published
+// when every handler succeeded, a failed event is handed to all handlers again when it is retried.
+func (rc *InProcessEventPublisher) Publish(ctx context.Context, event *Event) error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for _, handler := range rc.handlers {
+		if err := handler(ctx, *event); err != nil {
+			return err
+		}
+	}
+
+	rc.published = append(rc.published, *event)
+
+	return nil
+}
+
+// Published returns the events published so far, in the order they were published.
+func (rc *InProcessEventPublisher) Published() []Event {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	published := make([]Event, len(rc.published))
+	copy(published, rc.published)
+
+	return published
+}
@@ -0,0 +1,41 @@
+package pkg
+
+import "context"
+
+// PaymentProvider charges and refunds purchases through a payment gateway. Amounts are in minor currency units.
+type PaymentProvider interface {
+	// Authorize reserves the amount on the buyer's payment method and returns the ID of the payment.
+	Authorize(ctx context.Context, request PaymentRequest) (string, error)
+	// Capture collects an authorized payment.
+	Capture(ctx context.Context, paymentID string) error
+	// Refund pays back part or all of a captured payment and returns the amount refunded on it so far, refunding a
+	// payment that was never captured releases its authorization and returns 0.
+	Refund(ctx context.Context, paymentID string, amount int64) (int64, error)
+	// VerifyWebhook checks the signature of a webhook sent by the provider and returns its event.
+	VerifyWebhook(payload []byte, signature string) (*PaymentEvent, error)
+}
+
+type PaymentRequest struct {
+	Amount   int64
+	Currency string
+	// Reference identifies the purchase on the provider's side.
+	Reference string
+}
+
+type PaymentEventType string
+
+const (
+	PaymentEventCaptured PaymentEventType = "payment.captured"
+	PaymentEventRefunded PaymentEventType = "payment.refunded"
+	PaymentEventFailed   PaymentEventType = "payment.failed"
+)
+
+// PaymentEvent is the body of a webhook sent by the payment provider. Refunded is the total refunded on the payment
+// so far, so events that arrive late or twice can be told apart from newer ones.
+type PaymentEvent struct {
+	ID        string           `json:"id"`
+	Type      PaymentEventType `json:"type"`
+	PaymentID string           `json:"payment_id"`
+	Amount    int64            `json:"amount"`
+	Refunded  int64            `json:"refunded"`
+}
@@ -29,6 +29,14 @@ func NewPSQLClient() *pg.DB {
 		log.Fatalf("Failed to create schema: %v", err)
 	}
 
+	if err := migrate(db); err != nil {
+		log.Fatalf("Failed to migrate schema: %v", err)
+	}
+
+	if err := createIndexes(db); err != nil {
+		log.Fatalf("Failed to create indexes: %v", err)
+	}
+
 	return db
 }
 
@@ -36,6 +44,21 @@ func NewPSQLClient() *pg.DB {
 func createTables(db *pg.DB) error {
 	models := []interface{}{
 		(*models.Ticket)(nil),
+		(*models.Purchase)(nil),
+		(*models.IdempotencyKey)(nil),
+		(*models.Hold)(nil),
+		(*models.APIKey)(nil),
+		(*models.WaitlistEntry)(nil),
+		(*models.PromoCode)(nil),
+		(*models.TicketTier)(nil),
+		(*models.Credential)(nil),
+		(*models.Checkin)(nil),
+		(*models.Transfer)(nil),
+		(*models.TransferEvent)(nil),
+		(*models.Listing)(nil),
+		(*models.WebhookSubscription)(nil),
+		(*models.WebhookDelivery)(nil),
+		(*models.OutboxEvent)(nil),
 	}
 
 	for _, model := range models {
@@ -51,6 +74,115 @@ func createTables(db *pg.DB) error {
 	return nil
 }
 
+// migrations add the columns that tables created by earlier versions are missing, CreateTable leaves existing tables
+// alone. They run in order after the tables are created and are no-ops on a fresh schema. New columns go at the end,
+// NOT NULL columns get a default that backfills the existing rows.
+var migrations = []string{
+	// tickets gained soft deletes and versions
+	"ALTER TABLE tickets ADD COLUMN IF NOT EXISTS deleted_at timestamptz",
+	"ALTER TABLE tickets ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 0",
+	// per-user limits and sales windows, existing tickets have neither
+	"ALTER TABLE tickets ADD COLUMN IF NOT EXISTS max_per_user bigint NOT NULL DEFAULT 0",
+	"ALTER TABLE tickets ADD COLUMN IF NOT EXISTS sales_start timestamptz",
+	"ALTER TABLE tickets ADD COLUMN IF NOT EXISTS sales_end timestamptz",
+	// purchases gained partial refunds
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS refunded_quantity bigint NOT NULL DEFAULT 0",
+	// promo codes and prices, existing tickets and purchases are free
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS promo_code_id bigint",
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS promo_code text",
+	"ALTER TABLE tickets ADD COLUMN IF NOT EXISTS price bigint NOT NULL DEFAULT 0",
+	"ALTER TABLE tickets ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT ''",
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS unit_price bigint NOT NULL DEFAULT 0",
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT ''",
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS subtotal bigint NOT NULL DEFAULT 0",
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS discount bigint NOT NULL DEFAULT 0",
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS total bigint NOT NULL DEFAULT 0",
+	"ALTER TABLE holds ADD COLUMN IF NOT EXISTS unit_price bigint NOT NULL DEFAULT 0",
+	"ALTER TABLE holds ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT ''",
+	// tiers, rows without a tier use the ticket's own allocation and price
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS tier_id bigint",
+	"ALTER TABLE holds ADD COLUMN IF NOT EXISTS tier_id bigint",
+	"ALTER TABLE waitlist_entries ADD COLUMN IF NOT EXISTS tier_id bigint",
+	// payments, existing purchases were never charged
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS payment_id text",
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS payment_status text",
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS payment_refunded bigint NOT NULL DEFAULT 0",
+	// check-ins
+	"ALTER TABLE credentials ADD COLUMN IF NOT EXISTS used_at timestamptz",
+	"ALTER TABLE credentials ADD COLUMN IF NOT EXISTS used_gate text",
+	"ALTER TABLE checkins ADD COLUMN IF NOT EXISTS device_id text",
+	// transfers and resale
+	"ALTER TABLE tickets ADD COLUMN IF NOT EXISTS event_start timestamptz",
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS resold_quantity bigint NOT NULL DEFAULT 0",
+	"ALTER TABLE purchases ADD COLUMN IF NOT EXISTS resale_listing_id bigint",
+	// idempotency keys expire, existing keys are kept for another day
+	"ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS expires_at timestamptz NOT NULL DEFAULT now() + interval '1 day'",
+	// ticket lifecycles, existing tickets are on sale
+	"ALTER TABLE tickets ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'on_sale'",
+	// fixed promo codes gained a currency, existing ones only apply to free tickets until they are recreated
+	"ALTER TABLE promo_codes ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT ''",
+}
+
+// migrate brings the tables up to date with the models.
+func migrate(db *pg.DB) error {
+	for _, migration := range migrations {
+		if _, err := db.Exec(migration); err != nil {
+			return fmt.Errorf("failed to migrate schema: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// indexes are created after the tables, they back the keyset pagination and lookups of the repositories.
+var indexes = []string{
+	"CREATE INDEX IF NOT EXISTS tickets_name_id_idx ON tickets (name, id)",
+	"CREATE INDEX IF NOT EXISTS tickets_allocation_id_idx ON tickets (allocation, id)",
+	"CREATE INDEX IF NOT EXISTS purchases_ticket_id_idx ON purchases (ticket_id)",
+	"CREATE INDEX IF NOT EXISTS holds_status_expires_at_idx ON holds (status, expires_at)",
+	"CREATE INDEX IF NOT EXISTS waitlist_entries_ticket_id_status_id_idx ON waitlist_entries (ticket_id, status, id)",
+	"CREATE INDEX IF NOT EXISTS waitlist_entries_status_offer_expires_at_idx ON waitlist_entries (status, offer_expires_at)",
+	// a user can only be queued once per ticket until the entry is settled, entries being paid for are not settled yet.
+	// The index replaces one that left them out.
+	"CREATE UNIQUE INDEX IF NOT EXISTS waitlist_entries_unsettled_user_idx ON waitlist_entries (ticket_id, user_id) WHERE status IN ('waiting', 'offered', 'paying')",
+	"DROP INDEX IF EXISTS waitlist_entries_active_user_idx",
+	// deleted codes free their code for reuse
+	"CREATE UNIQUE INDEX IF NOT EXISTS promo_codes_code_idx ON promo_codes (code) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS purchases_promo_code_id_user_id_idx ON purchases (promo_code_id, user_id)",
+	// payment webhooks find their purchase by the provider's payment ID
+	"CREATE UNIQUE INDEX IF NOT EXISTS purchases_payment_id_idx ON purchases (payment_id) WHERE payment_id IS NOT NULL",
+	"CREATE INDEX IF NOT EXISTS ticket_tiers_ticket_id_position_idx ON ticket_tiers (ticket_id, position, id)",
+	"CREATE INDEX IF NOT EXISTS credentials_purchase_id_seat_idx ON credentials (purchase_id, seat)",
+	"CREATE INDEX IF NOT EXISTS credentials_ticket_id_idx ON credentials (ticket_id)",
+	"CREATE INDEX IF NOT EXISTS checkins_credential_id_idx ON checkins (credential_id)",
+	// a purchase can only be offered to one recipient at a time
+	"CREATE UNIQUE INDEX IF NOT EXISTS transfers_pending_purchase_id_idx ON transfers (purchase_id) WHERE status = 'pending'",
+	"CREATE INDEX IF NOT EXISTS transfer_events_purchase_id_idx ON transfer_events (purchase_id, id)",
+	// a seat can only be listed once at a time
+	"CREATE UNIQUE INDEX IF NOT EXISTS listings_active_credential_id_idx ON listings (credential_id) WHERE status = 'active'",
+	"CREATE INDEX IF NOT EXISTS listings_status_ticket_id_price_idx ON listings (status, ticket_id, price, id)",
+	// the dispatcher picks up due deliveries, the delivery log is read per subscription
+	"CREATE INDEX IF NOT EXISTS webhook_deliveries_status_next_attempt_at_idx ON webhook_deliveries (status, next_attempt_at)",
+	"CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_id_idx ON webhook_deliveries (subscription_id, id)",
+	// the relay checks whether the deliveries of an outbox event were queued already
+	"CREATE INDEX IF NOT EXISTS webhook_deliveries_event_id_idx ON webhook_deliveries (event_id)",
+	// the relay reads the unpublished events in the order they were written
+	"CREATE INDEX IF NOT EXISTS outbox_events_unpublished_idx ON outbox_events (id) WHERE published_at IS NULL",
+	// the sweeper deletes expired idempotency keys
+	"CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at)",
+}
+
+// createIndexes creates the indexes that aren't covered by the table definitions.
+func createIndexes(db *pg.DB) error {
+	for _, index := range indexes {
+		if _, err := db.Exec(index); err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // GetTestInstance starts a PostgreSQL container for testing and returns a connected pg.DB client along with a cleanup function.
 func GetTestInstance(ctx context.Context) (*pg.DB, func()) {
 	const mongoVersion = "17.0"
@@ -95,6 +227,14 @@ func GetTestInstance(ctx context.Context) (*pg.DB, func()) {
 		log.Fatalf("Failed to create test schema: %v", err)
 	}
 
+	if err := migrate(client); err != nil {
+		log.Fatalf("Failed to migrate test schema: %v", err)
+	}
+
+	if err := createIndexes(client); err != nil {
+		log.Fatalf("Failed to create test indexes: %v", err)
+	}
+
 	// Return the client and a cleanup function
 	return client, func() {
 		client.Close()
@@ -102,15 +242,31 @@ func GetTestInstance(ctx context.Context) (*pg.DB, func()) {
 	}
 }
 
-// createTestTables creates temporary test tables for the provided models.
+// createTestTables creates test tables for the provided models.
 func createTestTables(db *pg.DB) error {
 	models := []interface{}{
 		(*models.Ticket)(nil),
+		(*models.Purchase)(nil),
+		(*models.IdempotencyKey)(nil),
+		(*models.Hold)(nil),
+		(*models.APIKey)(nil),
+		(*models.WaitlistEntry)(nil),
+		(*models.PromoCode)(nil),
+		(*models.TicketTier)(nil),
+		(*models.Credential)(nil),
+		(*models.Checkin)(nil),
+		(*models.Transfer)(nil),
+		(*models.TransferEvent)(nil),
+		(*models.Listing)(nil),
+		(*models.WebhookSubscription)(nil),
+		(*models.WebhookDelivery)(nil),
+		(*models.OutboxEvent)(nil),
 	}
 
 	for _, model := range models {
 		opts := orm.CreateTableOptions{
-			Temp:        true, // Creates a temporary table for testing purposes.
+			// Temporary tables are only visible to the connection that created them,
+			// the container is thrown away after each test anyway.
 			IfNotExists: true,
 		}
 		err := db.
@@ -0,0 +1,179 @@
+package pkg
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/fleimkeipa/tickets-api/models"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// TokenVerifier validates HS256 tokens signed with a shared secret and RS256 tokens signed by a key of a local JWKS file.
+type TokenVerifier struct {
+	hmacSecret []byte
+	rsaKeys    map[string]*rsa.PublicKey
+	issuer     string
+	audience   string
+	parser     *jwt.Parser
+}
+
+// NewTokenVerifier creates a TokenVerifier. An empty secret disables HS256 and an empty jwksPath disables RS256,
+// issuer and audience are only checked when set.
+func NewTokenVerifier(hmacSecret, jwksPath, issuer, audience string) (*TokenVerifier, error) {
+	verifier := TokenVerifier{
+		hmacSecret: []byte(hmacSecret),
+		rsaKeys:    make(map[string]*rsa.PublicKey),
+		issuer:     issuer,
+		audience:   audience,
+		parser:     &jwt.Parser{ValidMethods: []string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}},
+	}
+
+	if jwksPath != "" {
+		keys, err := loadJWKS(jwksPath)
+		if err != nil {
+			return nil, err
+		}
+		verifier.rsaKeys = keys
+	}
+
+	if len(verifier.hmacSecret) == 0 && len(verifier.rsaKeys) == 0 {
+		return nil, errors.New("no token verification key configured")
+	}
+
+	return &verifier, nil
+}
+
+// Verify checks the signature and the registered claims of the token and returns the caller's claims.
+func (rc *TokenVerifier) Verify(tokenString string) (*models.Claims, error) {
+	claims := jwt.MapClaims{}
+
+	if _, err := rc.parser.ParseWithClaims(tokenString, claims, rc.key); err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		return nil, errors.New("invalid token: missing exp claim")
+	}
+
+	if rc.issuer != "" && !claims.VerifyIssuer(rc.issuer, true) {
+		return nil, errors.New("invalid token: unexpected issuer")
+	}
+
+	if rc.audience != "" && !claims.VerifyAudience(rc.audience, true) {
+		return nil, errors.New("invalid token: unexpected audience")
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, errors.New("invalid token: missing sub claim")
+	}
+
+	email, _ := claims["email"].(string)
+
+	return &models.Claims{
+		UserID: subject,
+		Roles:  rolesClaim(claims),
+		Email:  email,
+	}, nil
+}
+
+// key picks the verification key matching the token's algorithm.
+func (rc *TokenVerifier) key(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(rc.hmacSecret) == 0 {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		return rc.hmacSecret, nil
+	case *jwt.SigningMethodRSA:
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := rc.rsaKeys[kid]; ok {
+			return key, nil
+		}
+		// a single key set doesn't need the token to name its key
+		if kid == "" && len(rc.rsaKeys) == 1 {
+			for _, key := range rc.rsaKeys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	default:
+		return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+	}
+}
+
+// rolesClaim reads the roles either from a "roles" array or a single "role" string.
+func rolesClaim(claims jwt.MapClaims) []string {
+	roles := make([]string, 0)
+
+	if values, ok := claims["roles"].([]interface{}); ok {
+		for _, v := range values {
+			if role, ok := v.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	if role, ok := claims["role"].(string); ok && role != "" {
+		roles = append(roles, role)
+	}
+
+	return roles
+}
+
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// loadJWKS reads the RSA signing keys of a JWKS file, keyed by their key id.
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks file: %w", err)
+	}
+
+	var set jwks
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks file: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode modulus of key %q: %w", key.Kid, err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode exponent of key %q: %w", key.Kid, err)
+		}
+
+		keys[key.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("jwks file contains no RSA signing keys")
+	}
+
+	return keys, nil
+}
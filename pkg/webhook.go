@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers of outbound webhooks. The signature is the hex HMAC-SHA256 of the timestamp, a dot and the raw body,
+// keyed with the subscription's secret, so a captured request can't be replayed with a new timestamp.
+const (
+	HeaderWebhookSignature = "X-Webhook-Signature"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+)
+
+// WebhookRequest is one signed delivery attempt.
+type WebhookRequest struct {
+	URL        string
+	Secret     string
+	EventType  string
+	DeliveryID int64
+	Payload    []byte
+	SentAt     time.Time
+}
+
+// WebhookSender posts webhooks to subscriber endpoints.
+type WebhookSender struct {
+	client *http.Client
+}
+
+// NewWebhookSender creates a WebhookSender whose requests give up after timeout.
+func NewWebhookSender(timeout time.Duration) *WebhookSender {
+	return &WebhookSender{
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Send posts the signed payload and returns the status code of the response. Any status outside 2xx is an error,
+// the status code is 0 when no response was received.
+func (rc *WebhookSender) Send(ctx context.Context, request *WebhookRequest) (int, error) {
+	timestamp := strconv.FormatInt(request.SentAt.Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, request.URL, bytes.NewReader(request.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookSignature, SignWebhook(request.Secret, timestamp, request.Payload))
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	req.Header.Set(HeaderWebhookEvent, request.EventType)
+	req.Header.Set(HeaderWebhookDelivery, strconv.FormatInt(request.DeliveryID, 10))
+
+	resp, err := rc.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// the body is drained so the connection can be reused, its content isn't looked at
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint answered %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhook returns the signature of a webhook sent at the given unix timestamp.
+func SignWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook reports whether the signature was made with the secret over the timestamp and payload.
+func VerifyWebhook(secret, timestamp string, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(SignWebhook(secret, timestamp, payload)))
+}
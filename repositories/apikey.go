@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+
+	"github.com/go-pg/pg"
+)
+
+type APIKeyRepository struct {
+	db *pg.DB
+}
+
+func NewAPIKeyRepository(db *pg.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new API key into the database.
+func (rc *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	_, err := conn(ctx, rc.db).Model(key).Insert()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// GetByHash retrieves the API key with the given key hash.
+func (rc *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	key := new(models.APIKey)
+
+	err := conn(ctx, rc.db).
+		Model(key).
+		Where("hash = ?", hash).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// List retrieves every API key, newest first.
+func (rc *APIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	keys := make([]models.APIKey, 0)
+
+	err := conn(ctx, rc.db).
+		Model(&keys).
+		Order("id DESC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke marks an API key as revoked. It fails with pkg.ErrAPIKeyRevoked when the key was already revoked.
+func (rc *APIKeyRepository) Revoke(ctx context.Context, id string, now time.Time) (*models.APIKey, error) {
+	key := new(models.APIKey)
+
+	res, err := conn(ctx, rc.db).
+		Model(key).
+		Set("revoked_at = ?", now).
+		Where("id = ?", id).
+		Where("revoked_at IS NULL").
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke api key [%s] id, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		exists, err := conn(ctx, rc.db).Model((*models.APIKey)(nil)).Where("id = ?", id).Exists()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find api key [%s] id, error: %w", id, err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("no api key found for [%s] id", id)
+		}
+		return nil, pkg.ErrAPIKeyRevoked
+	}
+
+	return key, nil
+}
+
+// Touch records the use of an API key. The write is skipped while the last recorded use is newer
+// than staleBefore, so busy keys don't update their row on every request.
+func (rc *APIKeyRepository) Touch(ctx context.Context, id int64, usedAt, staleBefore time.Time) error {
+	_, err := conn(ctx, rc.db).
+		Model((*models.APIKey)(nil)).
+		Set("last_used_at = ?", usedAt).
+		Where("id = ?", id).
+		Where("last_used_at IS NULL OR last_used_at < ?", staleBefore).
+		Update()
+	if err != nil {
+		return fmt.Errorf("failed to touch api key [%d] id, error: %w", id, err)
+	}
+
+	return nil
+}
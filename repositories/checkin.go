@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fleimkeipa/tickets-api/models"
+
+	"github.com/go-pg/pg"
+)
+
+type CheckinRepository struct {
+	db *pg.DB
+}
+
+func NewCheckinRepository(db *pg.DB) *CheckinRepository {
+	return &CheckinRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new check-in into the database.
+func (rc *CheckinRepository) Create(ctx context.Context, checkin *models.Checkin) (*models.Checkin, error) {
+	_, err := conn(ctx, rc.db).Model(checkin).Insert()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create check-in: %w", err)
+	}
+
+	return checkin, nil
+}
+
+// CreateMany inserts the check-ins in one statement and fills in their IDs.
+func (rc *CheckinRepository) CreateMany(ctx context.Context, checkins []models.Checkin) ([]models.Checkin, error) {
+	_, err := conn(ctx, rc.db).Model(&checkins).Insert()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create check-ins: %w", err)
+	}
+
+	return checkins, nil
+}
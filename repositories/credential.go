@@ -0,0 +1,227 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+
+	"github.com/go-pg/pg"
+)
+
+type CredentialRepository struct {
+	db *pg.DB
+}
+
+func NewCredentialRepository(db *pg.DB) *CredentialRepository {
+	return &CredentialRepository{
+		db: db,
+	}
+}
+
+// CreateMany inserts the credentials in one statement and fills in their IDs.
+func (rc *CredentialRepository) CreateMany(ctx context.Context, credentials []models.Credential) ([]models.Credential, error) {
+	_, err := conn(ctx, rc.db).Model(&credentials).Insert()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credentials: %w", err)
+	}
+
+	return credentials, nil
+}
+
+// GetByID retrieves a credential from the database based on the provided credential ID.
+func (rc *CredentialRepository) GetByID(ctx context.Context, id string) (*models.Credential, error) {
+	credential := new(models.Credential)
+
+	err := conn(ctx, rc.db).
+		Model(credential).
+		Where("id = ?", id).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find credential [%s] id, error: %w", id, err)
+	}
+
+	return credential, nil
+}
+
+// ListByPurchaseID retrieves the credentials of a purchase in seat order.
+func (rc *CredentialRepository) ListByPurchaseID(ctx context.Context, purchaseID int64) ([]models.Credential, error) {
+	credentials := make([]models.Credential, 0)
+
+	err := conn(ctx, rc.db).
+		Model(&credentials).
+		Where("purchase_id = ?", purchaseID).
+		Order("seat ASC", "id ASC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials of purchase [%d] id, error: %w", purchaseID, err)
+	}
+
+	return credentials, nil
+}
+
+// ListValidByTicketID retrieves the valid credentials of a ticket, used ones included, oldest first.
+func (rc *CredentialRepository) ListValidByTicketID(ctx context.Context, ticketID string) ([]models.Credential, error) {
+	credentials := make([]models.Credential, 0)
+
+	err := conn(ctx, rc.db).
+		Model(&credentials).
+		Where("ticket_id = ?", ticketID).
+		Where("status = ?", models.CredentialStatusValid).
+		Order("id ASC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials of ticket [%s] id, error: %w", ticketID, err)
+	}
+
+	return credentials, nil
+}
+
+// ListByIDsForUpdate retrieves the credentials with the given IDs and locks their rows until the surrounding
+// transaction ends. Rows are locked in ID order so concurrent callers can't deadlock. Unknown IDs are skipped.
+func (rc *CredentialRepository) ListByIDsForUpdate(ctx context.Context, ids []int64) ([]models.Credential, error) {
+	credentials := make([]models.Credential, 0, len(ids))
+	if len(ids) == 0 {
+		return credentials, nil
+	}
+
+	err := conn(ctx, rc.db).
+		Model(&credentials).
+		Where("id IN (?)", pg.In(ids)).
+		Order("id ASC").
+		For("UPDATE").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials %v, error: %w", ids, err)
+	}
+
+	return credentials, nil
+}
+
+// RevokeByPurchaseID revokes count valid credentials of a purchase and returns them.
+// Unused credentials go first, highest seats first, so people already inside keep a valid credential.
+func (rc *CredentialRepository) RevokeByPurchaseID(ctx context.Context, purchaseID int64, count int, now time.Time) ([]models.Credential, error) {
+	credentials := make([]models.Credential, 0)
+
+	_, err := conn(ctx, rc.db).
+		Model(&credentials).
+		Set("status = ?", models.CredentialStatusRevoked).
+		Set("revoked_at = ?", now).
+		Where("id IN (SELECT id FROM credentials WHERE purchase_id = ? AND status = ? ORDER BY used_at IS NULL DESC, seat DESC LIMIT ?)",
+			purchaseID, models.CredentialStatusValid, count).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke credentials of purchase [%d] id, error: %w", purchaseID, err)
+	}
+
+	return credentials, nil
+}
+
+// RevokeUnusedByPurchaseID revokes up to count valid credentials of a purchase nobody got in with yet, highest seats
+// first, and returns them.
+func (rc *CredentialRepository) RevokeUnusedByPurchaseID(ctx context.Context, purchaseID int64, count int, now time.Time) ([]models.Credential, error) {
+	credentials := make([]models.Credential, 0)
+
+	_, err := conn(ctx, rc.db).
+		Model(&credentials).
+		Set("status = ?", models.CredentialStatusRevoked).
+		Set("revoked_at = ?", now).
+		Where("id IN (SELECT id FROM credentials WHERE purchase_id = ? AND status = ? AND used_at IS NULL ORDER BY seat DESC LIMIT ?)",
+			purchaseID, models.CredentialStatusValid, count).
+		Where("used_at IS NULL").
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke unused credentials of purchase [%d] id, error: %w", purchaseID, err)
+	}
+
+	return credentials, nil
+}
+
+// MarkUsed atomically records the first scan of a valid, unused credential.
+// It returns false when the credential is unknown, revoked or was already used.
+func (rc *CredentialRepository) MarkUsed(ctx context.Context, id int64, gate string, now time.Time) (*models.Credential, bool, error) {
+	credential := new(models.Credential)
+
+	res, err := conn(ctx, rc.db).
+		Model(credential).
+		Set("used_at = ?", now).
+		Set("used_gate = ?", gate).
+		Where("id = ?", id).
+		Where("status = ?", models.CredentialStatusValid).
+		Where("used_at IS NULL").
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to mark credential [%d] id as used, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil, false, nil
+	}
+
+	return credential, true, nil
+}
+
+// RevokeUnused revokes a valid credential nobody got in with yet.
+// It returns false when the credential is unknown, already revoked or was used.
+func (rc *CredentialRepository) RevokeUnused(ctx context.Context, id int64, now time.Time) (bool, error) {
+	res, err := conn(ctx, rc.db).
+		Model((*models.Credential)(nil)).
+		Set("status = ?", models.CredentialStatusRevoked).
+		Set("revoked_at = ?", now).
+		Where("id = ?", id).
+		Where("status = ?", models.CredentialStatusValid).
+		Where("used_at IS NULL").
+		Update()
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke credential [%d] id, error: %w", id, err)
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// SetUsed overwrites the first scan of a credential. Callers lock the row first and decide which scan came first.
+func (rc *CredentialRepository) SetUsed(ctx context.Context, id int64, gate string, usedAt time.Time) error {
+	_, err := conn(ctx, rc.db).
+		Model((*models.Credential)(nil)).
+		Set("used_at = ?", usedAt).
+		Set("used_gate = ?", gate).
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		return fmt.Errorf("failed to set first scan of credential [%d] id, error: %w", id, err)
+	}
+
+	return nil
+}
+
+// CountByTicketID returns how many valid credentials of the ticket there are and how many credentials got in,
+// revoked ones included, per gate.
+func (rc *CredentialRepository) CountByTicketID(ctx context.Context, ticketID string) (int, []models.GateCount, error) {
+	sold, err := conn(ctx, rc.db).
+		Model((*models.Credential)(nil)).
+		Where("ticket_id = ?", ticketID).
+		Where("status = ?", models.CredentialStatusValid).
+		Count()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to count credentials of ticket [%s] id, error: %w", ticketID, err)
+	}
+
+	gates := make([]models.GateCount, 0)
+	err = conn(ctx, rc.db).
+		Model((*models.Credential)(nil)).
+		ColumnExpr("used_gate AS gate, count(*) AS checked_in").
+		Where("ticket_id = ?", ticketID).
+		Where("used_at IS NOT NULL").
+		Group("used_gate").
+		Order("used_gate ASC").
+		Select(&gates)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to count check-ins of ticket [%s] id, error: %w", ticketID, err)
+	}
+
+	return sold, gates, nil
+}
@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+
+	"github.com/go-pg/pg"
+)
+
+type HoldRepository struct {
+	db *pg.DB
+}
+
+func NewHoldRepository(db *pg.DB) *HoldRepository {
+	return &HoldRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new hold into the database.
+func (rc *HoldRepository) Create(ctx context.Context, hold *models.Hold) (*models.Hold, error) {
+	_, err := conn(ctx, rc.db).Model(hold).Insert()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hold: %w", err)
+	}
+
+	return hold, nil
+}
+
+// GetByID retrieves a hold from the database based on the provided hold ID.
+func (rc *HoldRepository) GetByID(ctx context.Context, id string) (*models.Hold, error) {
+	hold := new(models.Hold)
+
+	err := conn(ctx, rc.db).
+		Model(hold).
+		Where("id = ?", id).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find hold [%s] id, error: %w", id, err)
+	}
+
+	return hold, nil
+}
+
+// Pay marks an active, unexpired hold as paid by the given pending purchase.
+// It fails with pkg.ErrHoldNotActive when the hold was confirmed or expired in the meantime.
+func (rc *HoldRepository) Pay(ctx context.Context, id string, purchaseID int64, now time.Time) (*models.Hold, error) {
+	hold := new(models.Hold)
+
+	res, err := conn(ctx, rc.db).
+		Model(hold).
+		Set("status = ?", models.HoldStatusPaying).
+		Set("purchase_id = ?", purchaseID).
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Where("status = ?", models.HoldStatusActive).
+		Where("expires_at > ?", now).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to pay hold [%s] id, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil, pkg.ErrHoldNotActive
+	}
+
+	return hold, nil
+}
+
+// Confirm marks the hold paid by the purchase as confirmed, purchases made without a hold change nothing.
+func (rc *HoldRepository) Confirm(ctx context.Context, purchaseID int64) error {
+	_, err := conn(ctx, rc.db).
+		Model((*models.Hold)(nil)).
+		Set("status = ?", models.HoldStatusConfirmed).
+		Set("updated_at = now()").
+		Where("purchase_id = ?", purchaseID).
+		Where("status = ?", models.HoldStatusPaying).
+		Update()
+	if err != nil {
+		return fmt.Errorf("failed to confirm hold of purchase [%d] id, error: %w", purchaseID, err)
+	}
+
+	return nil
+}
+
+// Reopen makes the hold paid by a failed purchase active again until it expires, purchases made without a hold
+// change nothing.
+func (rc *HoldRepository) Reopen(ctx context.Context, purchaseID int64) error {
+	_, err := conn(ctx, rc.db).
+		Model((*models.Hold)(nil)).
+		Set("status = ?", models.HoldStatusActive).
+		Set("purchase_id = NULL").
+		Set("updated_at = now()").
+		Where("purchase_id = ?", purchaseID).
+		Where("status = ?", models.HoldStatusPaying).
+		Update()
+	if err != nil {
+		return fmt.Errorf("failed to reopen hold of purchase [%d] id, error: %w", purchaseID, err)
+	}
+
+	return nil
+}
+
+// Expire marks every active hold that expired before now as expired and returns them.
+func (rc *HoldRepository) Expire(ctx context.Context, now time.Time) ([]models.Hold, error) {
+	holds := make([]models.Hold, 0)
+
+	_, err := conn(ctx, rc.db).
+		Model(&holds).
+		Set("status = ?", models.HoldStatusExpired).
+		Set("updated_at = now()").
+		Where("status = ?", models.HoldStatusActive).
+		Where("expires_at <= ?", now).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire holds: %w", err)
+	}
+
+	return holds, nil
+}
+
+// ExistsByTicketID reports whether any hold was made for the ticket.
+func (rc *HoldRepository) ExistsByTicketID(ctx context.Context, ticketID string) (bool, error) {
+	exists, err := conn(ctx, rc.db).
+		Model((*models.Hold)(nil)).
+		Where("ticket_id = ?", ticketID).
+		Exists()
+	if err != nil {
+		return false, fmt.Errorf("failed to check holds of ticket [%s] id, error: %w", ticketID, err)
+	}
+
+	return exists, nil
+}
+
+// SumActiveQuantityByUser returns the seats the user currently holds on the ticket.
+func (rc *HoldRepository) SumActiveQuantityByUser(ctx context.Context, ticketID, userID string, now time.Time) (int, error) {
+	var total int
+
+	err := conn(ctx, rc.db).
+		Model((*models.Hold)(nil)).
+		ColumnExpr("COALESCE(SUM(quantity), 0)").
+		Where("ticket_id = ?", ticketID).
+		Where("user_id = ?", userID).
+		Where("status = ?", models.HoldStatusActive).
+		Where("expires_at > ?", now).
+		Select(pg.Scan(&total))
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum holds of user [%s] for ticket [%s] id, error: %w", userID, ticketID, err)
+	}
+
+	return total, nil
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+
+	"github.com/go-pg/pg"
+)
+
+type IdempotencyRepository struct {
+	db *pg.DB
+}
+
+func NewIdempotencyRepository(db *pg.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db: db,
+	}
+}
+
+// Create inserts the key unless it already exists. It reports whether the key was inserted.
+func (rc *IdempotencyRepository) Create(ctx context.Context, key *models.IdempotencyKey) (bool, error) {
+	res, err := conn(ctx, rc.db).
+		Model(key).
+		OnConflict("DO NOTHING").
+		Insert()
+	if err != nil {
+		return false, fmt.Errorf("failed to create idempotency key: %w", err)
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// Reclaim reserves a key that expired before now for a new request, whether its request died while processing or its
+// response was kept long enough. It reports whether the key was reclaimed.
+func (rc *IdempotencyRepository) Reclaim(ctx context.Context, key *models.IdempotencyKey, now time.Time) (bool, error) {
+	res, err := conn(ctx, rc.db).
+		Model(key).
+		Set("fingerprint = ?fingerprint, status = ?status, status_code = NULL, response_body = NULL, expires_at = ?expires_at, updated_at = now()").
+		WherePK().
+		Where("expires_at <= ?", now).
+		Update()
+	if err != nil {
+		return false, fmt.Errorf("failed to reclaim idempotency key: %w", err)
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// Update stores the new state of an existing idempotency key.
+func (rc *IdempotencyRepository) Update(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	res, err := conn(ctx, rc.db).
+		Model(key).
+		Set("status = ?status, status_code = ?status_code, response_body = ?response_body, expires_at = ?expires_at, updated_at = now()").
+		WherePK().
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update idempotency key: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("no idempotency key found for update")
+	}
+
+	return key, nil
+}
+
+// GetByKey retrieves an idempotency key by its value.
+func (rc *IdempotencyRepository) GetByKey(ctx context.Context, key string) (*models.IdempotencyKey, error) {
+	idempotencyKey := new(models.IdempotencyKey)
+
+	err := conn(ctx, rc.db).
+		Model(idempotencyKey).
+		Where("key = ?", key).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find idempotency key [%s], error: %w", key, err)
+	}
+
+	return idempotencyKey, nil
+}
+
+// DeleteExpired removes the keys that expired before now and returns how many were removed.
+func (rc *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	res, err := conn(ctx, rc.db).
+		Model((*models.IdempotencyKey)(nil)).
+		Where("expires_at <= ?", now).
+		Delete()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// Delete removes an idempotency key so the request can be retried from scratch.
+func (rc *IdempotencyRepository) Delete(ctx context.Context, key string) error {
+	_, err := conn(ctx, rc.db).
+		Model((*models.IdempotencyKey)(nil)).
+		Where("key = ?", key).
+		Delete()
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency key [%s], error: %w", key, err)
+	}
+
+	return nil
+}
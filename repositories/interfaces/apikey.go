@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+)
+
+type APIKeyInterfaces interface {
+	Create(ctx context.Context, key *models.APIKey) (*models.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	Revoke(ctx context.Context, keyID string, now time.Time) (*models.APIKey, error)
+	Touch(ctx context.Context, keyID int64, usedAt, staleBefore time.Time) error
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/fleimkeipa/tickets-api/models"
+)
+
+type CheckinInterfaces interface {
+	Create(ctx context.Context, checkin *models.Checkin) (*models.Checkin, error)
+	CreateMany(ctx context.Context, checkins []models.Checkin) ([]models.Checkin, error)
+}
@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+)
+
+type CredentialInterfaces interface {
+	CreateMany(ctx context.Context, credentials []models.Credential) ([]models.Credential, error)
+	GetByID(ctx context.Context, id string) (*models.Credential, error)
+	ListByPurchaseID(ctx context.Context, purchaseID int64) ([]models.Credential, error)
+	ListValidByTicketID(ctx context.Context, ticketID string) ([]models.Credential, error)
+	ListByIDsForUpdate(ctx context.Context, ids []int64) ([]models.Credential, error)
+	MarkUsed(ctx context.Context, id int64, gate string, now time.Time) (*models.Credential, bool, error)
+	SetUsed(ctx context.Context, id int64, gate string, usedAt time.Time) error
+	CountByTicketID(ctx context.Context, ticketID string) (int, []models.GateCount, error)
+	RevokeUnused(ctx context.Context, id int64, now time.Time) (bool, error)
+	RevokeByPurchaseID(ctx context.Context, purchaseID int64, count int, now time.Time) ([]models.Credential, error)
+	RevokeUnusedByPurchaseID(ctx context.Context, purchaseID int64, count int, now time.Time) ([]models.Credential, error)
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+)
+
+type HoldInterfaces interface {
+	Create(ctx context.Context, hold *models.Hold) (*models.Hold, error)
+	GetByID(ctx context.Context, holdID string) (*models.Hold, error)
+	Pay(ctx context.Context, holdID string, purchaseID int64, now time.Time) (*models.Hold, error)
+	Confirm(ctx context.Context, purchaseID int64) error
+	Reopen(ctx context.Context, purchaseID int64) error
+	Expire(ctx context.Context, now time.Time) ([]models.Hold, error)
+	ExistsByTicketID(ctx context.Context, ticketID string) (bool, error)
+	SumActiveQuantityByUser(ctx context.Context, ticketID, userID string, now time.Time) (int, error)
+}
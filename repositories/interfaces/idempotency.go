@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+)
+
+type IdempotencyInterfaces interface {
+	Create(ctx context.Context, key *models.IdempotencyKey) (bool, error)
+	Reclaim(ctx context.Context, key *models.IdempotencyKey, now time.Time) (bool, error)
+	Update(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error)
+	GetByKey(ctx context.Context, key string) (*models.IdempotencyKey, error)
+	Delete(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/fleimkeipa/tickets-api/models"
+)
+
+type ListingInterfaces interface {
+	Create(ctx context.Context, listing *models.Listing) (*models.Listing, error)
+	GetByID(ctx context.Context, id string) (*models.Listing, error)
+	GetByIDForUpdate(ctx context.Context, id string) (*models.Listing, error)
+	ExistsActive(ctx context.Context, credentialID int64) (bool, error)
+	Close(ctx context.Context, listing *models.Listing) (*models.Listing, error)
+	List(ctx context.Context, opts models.ListingListOptions) ([]models.Listing, error)
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+)
+
+type OutboxInterfaces interface {
+	Create(ctx context.Context, event *models.OutboxEvent) (*models.OutboxEvent, error)
+	TryLock(ctx context.Context) (bool, error)
+	ListUnpublished(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id int64, publishedAt time.Time) error
+	RecordFailure(ctx context.Context, event *models.OutboxEvent) error
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/fleimkeipa/tickets-api/models"
+)
+
+type PromoCodeInterfaces interface {
+	Create(ctx context.Context, promo *models.PromoCode) (*models.PromoCode, error)
+	Update(ctx context.Context, promo *models.PromoCode, columns ...string) (*models.PromoCode, error)
+	GetByID(ctx context.Context, id string) (*models.PromoCode, error)
+	GetByCodeForUpdate(ctx context.Context, code string) (*models.PromoCode, error)
+	ExistsByCode(ctx context.Context, code string) (bool, error)
+	List(ctx context.Context) ([]models.PromoCode, error)
+	Delete(ctx context.Context, id string) error
+	Redeem(ctx context.Context, id int64) (*models.PromoCode, error)
+	Unredeem(ctx context.Context, id int64) error
+}
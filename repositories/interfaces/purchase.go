@@ -0,0 +1,27 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+)
+
+type PurchaseInterfaces interface {
+	Create(ctx context.Context, purchase *models.Purchase) (*models.Purchase, error)
+	GetByID(ctx context.Context, purchaseID string) (*models.Purchase, error)
+	GetByIDForUpdate(ctx context.Context, purchaseID string) (*models.Purchase, error)
+	ListByTicketID(ctx context.Context, ticketID string) ([]models.Purchase, error)
+	ListPending(ctx context.Context, before time.Time) ([]models.Purchase, error)
+	ListRefundsDue(ctx context.Context, before time.Time) ([]models.Purchase, error)
+	Settle(ctx context.Context, purchaseID int64, status models.PurchaseStatus) (*models.Purchase, error)
+	Refund(ctx context.Context, purchaseID string, quantity int) (*models.Purchase, error)
+	MarkResold(ctx context.Context, purchaseID int64) (bool, error)
+	SetOwner(ctx context.Context, purchaseID int64, userID string) (*models.Purchase, error)
+	SetPayment(ctx context.Context, purchaseID int64, paymentID string, status models.PaymentStatus) error
+	UpdatePayment(ctx context.Context, paymentID string, status models.PaymentStatus, refunded int64) (bool, error)
+	SetRefunded(ctx context.Context, purchaseID int64, refunded int64) (*models.Purchase, error)
+	ExistsByTicketID(ctx context.Context, ticketID string) (bool, error)
+	SumQuantityByUser(ctx context.Context, ticketID, userID string) (int, error)
+	CountByPromoCodeAndUser(ctx context.Context, promoCodeID int64, userID string) (int, error)
+}
@@ -8,6 +8,15 @@ import (
 
 type TicketInterfaces interface {
 	Create(ctx context.Context, ticket *models.Ticket) (*models.Ticket, error)
-	Update(ctx context.Context, ticket *models.Ticket) (*models.Ticket, error)
+	Update(ctx context.Context, ticket *models.Ticket, columns ...string) (*models.Ticket, error)
 	GetByID(ctx context.Context, ticketID string) (*models.Ticket, error)
+	GetByIDForUpdate(ctx context.Context, ticketID string) (*models.Ticket, error)
+	GetWithDeletedForUpdate(ctx context.Context, ticketID string) (*models.Ticket, error)
+	Lock(ctx context.Context, ticketID string) error
+	Delete(ctx context.Context, ticketID string) error
+	ForceDelete(ctx context.Context, ticketID string) error
+	List(ctx context.Context, opts models.TicketListOptions) ([]models.Ticket, error)
+	DecreaseAllocation(ctx context.Context, ticketID string, quantity int) (*models.Ticket, error)
+	IncreaseAllocation(ctx context.Context, ticketID string, quantity int) (*models.Ticket, error)
+	SyncAllocation(ctx context.Context, ticketID string) (*models.Ticket, error)
 }
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/fleimkeipa/tickets-api/models"
+)
+
+type TierInterfaces interface {
+	Create(ctx context.Context, tier *models.TicketTier) (*models.TicketTier, error)
+	Update(ctx context.Context, tier *models.TicketTier, columns ...string) (*models.TicketTier, error)
+	GetByID(ctx context.Context, ticketID, tierID string) (*models.TicketTier, error)
+	ListByTicketID(ctx context.Context, ticketID string) ([]models.TicketTier, error)
+	ExistsByTicketID(ctx context.Context, ticketID string) (bool, error)
+	Delete(ctx context.Context, ticketID, tierID string) error
+	DeleteByTicketID(ctx context.Context, ticketID string) error
+	DecreaseAllocation(ctx context.Context, tierID int64, quantity int) (*models.TicketTier, error)
+	IncreaseAllocation(ctx context.Context, tierID int64, quantity int) (*models.TicketTier, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/fleimkeipa/tickets-api/models"
+)
+
+type TransferInterfaces interface {
+	Create(ctx context.Context, transfer *models.Transfer) (*models.Transfer, error)
+	GetByIDForUpdate(ctx context.Context, id string) (*models.Transfer, error)
+	ListByPurchaseID(ctx context.Context, purchaseID int64) ([]models.Transfer, error)
+	ExistsPending(ctx context.Context, purchaseID int64) (bool, error)
+	UpdateStatus(ctx context.Context, transfer *models.Transfer) (*models.Transfer, error)
+	CreateEvent(ctx context.Context, event *models.TransferEvent) error
+	ListEventsByPurchaseID(ctx context.Context, purchaseID int64) ([]models.TransferEvent, error)
+}
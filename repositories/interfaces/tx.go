@@ -0,0 +1,7 @@
+package interfaces
+
+import "context"
+
+type TxInterfaces interface {
+	RunInTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
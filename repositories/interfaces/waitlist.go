@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+)
+
+type WaitlistInterfaces interface {
+	Create(ctx context.Context, entry *models.WaitlistEntry) (*models.WaitlistEntry, error)
+	GetByID(ctx context.Context, entryID string) (*models.WaitlistEntry, error)
+	ExistsActive(ctx context.Context, ticketID, userID string) (bool, error)
+	NextWaiting(ctx context.Context, ticketID string) (*models.WaitlistEntry, error)
+	CountAhead(ctx context.Context, entry *models.WaitlistEntry) (int, error)
+	Offer(ctx context.Context, entryID, tierID int64, expiresAt time.Time) (*models.WaitlistEntry, error)
+	Pay(ctx context.Context, entryID string, purchaseID int64, now time.Time) (*models.WaitlistEntry, error)
+	Fulfill(ctx context.Context, purchaseID int64) error
+	Reopen(ctx context.Context, purchaseID int64) error
+	ExpireOffers(ctx context.Context, now time.Time) ([]models.WaitlistEntry, error)
+}
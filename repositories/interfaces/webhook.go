@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+)
+
+type WebhookInterfaces interface {
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) (*models.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription, columns ...string) (*models.WebhookSubscription, error)
+	GetSubscriptionByID(ctx context.Context, id string) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	ListSubscriptionsByEvent(ctx context.Context, eventType string) ([]models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error
+	ExistsDeliveryByEventID(ctx context.Context, eventID string) (bool, error)
+	GetDeliveryByID(ctx context.Context, subscriptionID, id string) (*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID string, status models.WebhookDeliveryStatus, limit int) ([]models.WebhookDelivery, error)
+	ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error)
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fleimkeipa/tickets-api/models"
+
+	"github.com/go-pg/pg"
+)
+
+type ListingRepository struct {
+	db *pg.DB
+}
+
+func NewListingRepository(db *pg.DB) *ListingRepository {
+	return &ListingRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new listing into the database.
+func (rc *ListingRepository) Create(ctx context.Context, listing *models.Listing) (*models.Listing, error) {
+	_, err := conn(ctx, rc.db).Model(listing).Insert()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create listing: %w", err)
+	}
+
+	return listing, nil
+}
+
+// GetByID retrieves a listing from the database based on the provided listing ID.
+func (rc *ListingRepository) GetByID(ctx context.Context, id string) (*models.Listing, error) {
+	listing := new(models.Listing)
+
+	err := conn(ctx, rc.db).
+		Model(listing).
+		Where("id = ?", id).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find listing [%s] id, error: %w", id, err)
+	}
+
+	return listing, nil
+}
+
+// GetByIDForUpdate retrieves a listing and locks its row until the surrounding transaction ends.
+func (rc *ListingRepository) GetByIDForUpdate(ctx context.Context, id string) (*models.Listing, error) {
+	listing := new(models.Listing)
+
+	err := conn(ctx, rc.db).
+		Model(listing).
+		Where("id = ?", id).
+		For("UPDATE").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find listing [%s] id, error: %w", id, err)
+	}
+
+	return listing, nil
+}
+
+// ExistsActive reports whether the credential is listed for resale or reserved for a buyer.
+func (rc *ListingRepository) ExistsActive(ctx context.Context, credentialID int64) (bool, error) {
+	exists, err := conn(ctx, rc.db).
+		Model((*models.Listing)(nil)).
+		Where("credential_id = ?", credentialID).
+		Where("status IN (?, ?)", models.ListingStatusActive, models.ListingStatusReserved).
+		Exists()
+	if err != nil {
+		return false, fmt.Errorf("failed to check listings of credential [%d] id, error: %w", credentialID, err)
+	}
+
+	return exists, nil
+}
+
+// Close writes the status and buyer of a listing that is reserved, sold, cancelled or put back on sale.
+func (rc *ListingRepository) Close(ctx context.Context, listing *models.Listing) (*models.Listing, error) {
+	_, err := conn(ctx, rc.db).
+		Model(listing).
+		Set("status = ?status").
+		Set("buyer_id = ?buyer_id").
+		Set("buyer_purchase_id = ?buyer_purchase_id").
+		Set("sold_at = ?sold_at").
+		Set("updated_at = now()").
+		Where("id = ?id").
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to close listing [%d] id, error: %w", listing.ID, err)
+	}
+
+	return listing, nil
+}
+
+// List retrieves a page of active listings matching the options, ordered by the sort column and then by ID.
+func (rc *ListingRepository) List(ctx context.Context, opts models.ListingListOptions) ([]models.Listing, error) {
+	listings := make([]models.Listing, 0)
+
+	query := conn(ctx, rc.db).
+		Model(&listings).
+		Where("status = ?", models.ListingStatusActive)
+
+	if opts.TicketID != 0 {
+		query = query.Where("ticket_id = ?", opts.TicketID)
+	}
+
+	if opts.TierID != 0 {
+		query = query.Where("tier_id = ?", opts.TierID)
+	}
+
+	if opts.MaxPrice != 0 {
+		query = query.Where("price <= ?", opts.MaxPrice)
+	}
+
+	if after := opts.After; after != nil {
+		if opts.Sort == "price" {
+			query = query.Where("(price, id) > (?, ?)", after.Price, after.ID)
+		} else {
+			query = query.Where("id > ?", after.ID)
+		}
+	}
+
+	if opts.Sort == "price" {
+		query = query.Order("price ASC")
+	}
+
+	err := query.
+		Order("id ASC").
+		Limit(opts.Limit).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list listings: %w", err)
+	}
+
+	return listings, nil
+}
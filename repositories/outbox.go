@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+
+	"github.com/go-pg/pg"
+)
+
+// outboxRelayLock is the advisory lock key that keeps concurrent relays from publishing the same events.
+const outboxRelayLock = 7_301_825_614
+
+type OutboxRepository struct {
+	db *pg.DB
+}
+
+func NewOutboxRepository(db *pg.DB) *OutboxRepository {
+	return &OutboxRepository{
+		db: db,
+	}
+}
+
+// Create inserts an event into the outbox, inside the transaction of the change it reports.
+func (rc *OutboxRepository) Create(ctx context.Context, event *models.OutboxEvent) (*models.OutboxEvent, error) {
+	_, err := conn(ctx, rc.db).Model(event).Insert()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create outbox event: %w", err)
+	}
+
+	return event, nil
+}
+
+// TryLock takes the relay lock for the rest of the transaction, it reports false when another relay holds it.
+func (rc *OutboxRepository) TryLock(ctx context.Context) (bool, error) {
+	var locked bool
+
+	_, err := conn(ctx, rc.db).QueryOne(pg.Scan(&locked), "SELECT pg_try_advisory_xact_lock(?)", outboxRelayLock)
+	if err != nil {
+		return false, fmt.Errorf("failed to take outbox relay lock: %w", err)
+	}
+
+	return locked, nil
+}
+
+// ListUnpublished retrieves up to limit events that weren't published yet, in the order they were written. Tickets
+// whose oldest unpublished event waits for its next attempt after now are left out, so their held back events don't
+// fill the batch and keep the events of other tickets from going out.
+func (rc *OutboxRepository) ListUnpublished(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	events := make([]models.OutboxEvent, 0)
+
+	// only the oldest unpublished event of a ticket is ever attempted, so it is the only one that can be backing off
+	err := conn(ctx, rc.db).
+		Model(&events).
+		Where("published_at IS NULL").
+		Where("ticket_id NOT IN (SELECT ticket_id FROM outbox_events WHERE published_at IS NULL AND next_attempt_at > ?)", now).
+		Order("id ASC").
+		Limit(limit).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unpublished outbox events: %w", err)
+	}
+
+	return events, nil
+}
+
+// MarkPublished records that the event was published.
+func (rc *OutboxRepository) MarkPublished(ctx context.Context, id int64, publishedAt time.Time) error {
+	_, err := conn(ctx, rc.db).
+		Model(new(models.OutboxEvent)).
+		Set("published_at = ?", publishedAt).
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event [%d] id published, error: %w", id, err)
+	}
+
+	return nil
+}
+
+// RecordFailure writes the attempts, next attempt and error of an event that couldn't be published.
+func (rc *OutboxRepository) RecordFailure(ctx context.Context, event *models.OutboxEvent) error {
+	_, err := conn(ctx, rc.db).
+		Model(event).
+		Set("attempts = ?attempts").
+		Set("next_attempt_at = ?next_attempt_at").
+		Set("last_error = ?last_error").
+		WherePK().
+		Update()
+	if err != nil {
+		return fmt.Errorf("failed to record outbox event [%d] id failure, error: %w", event.ID, err)
+	}
+
+	return nil
+}
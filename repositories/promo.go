@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+
+	"github.com/go-pg/pg"
+)
+
+type PromoCodeRepository struct {
+	db *pg.DB
+}
+
+func NewPromoCodeRepository(db *pg.DB) *PromoCodeRepository {
+	return &PromoCodeRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new promo code into the database.
+func (rc *PromoCodeRepository) Create(ctx context.Context, promo *models.PromoCode) (*models.PromoCode, error) {
+	_, err := conn(ctx, rc.db).Model(promo).Insert()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create promo code: %w", err)
+	}
+
+	return promo, nil
+}
+
+// Update writes the given columns of a promo code, the redemption count is never overwritten.
+func (rc *PromoCodeRepository) Update(ctx context.Context, promo *models.PromoCode, columns ...string) (*models.PromoCode, error) {
+	query := conn(ctx, rc.db).Model(promo)
+	for _, column := range columns {
+		query = query.Set(column + " = ?" + column)
+	}
+
+	res, err := query.
+		Set("updated_at = now()").
+		WherePK().
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update promo code: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("no promo code found for update")
+	}
+
+	return promo, nil
+}
+
+// GetByID retrieves a promo code from the database based on the provided promo code ID.
+func (rc *PromoCodeRepository) GetByID(ctx context.Context, id string) (*models.PromoCode, error) {
+	promo := new(models.PromoCode)
+
+	err := conn(ctx, rc.db).
+		Model(promo).
+		Where("id = ?", id).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find promo code [%s] id, error: %w", id, err)
+	}
+
+	return promo, nil
+}
+
+// GetByCodeForUpdate retrieves a promo code by its code and locks its row until the surrounding transaction ends.
+func (rc *PromoCodeRepository) GetByCodeForUpdate(ctx context.Context, code string) (*models.PromoCode, error) {
+	promo := new(models.PromoCode)
+
+	err := conn(ctx, rc.db).
+		Model(promo).
+		Where("code = ?", code).
+		For("UPDATE").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find promo code [%s], error: %w", code, err)
+	}
+
+	return promo, nil
+}
+
+// ExistsByCode reports whether a promo code that isn't deleted uses the code.
+func (rc *PromoCodeRepository) ExistsByCode(ctx context.Context, code string) (bool, error) {
+	exists, err := conn(ctx, rc.db).
+		Model((*models.PromoCode)(nil)).
+		Where("code = ?", code).
+		Exists()
+	if err != nil {
+		return false, fmt.Errorf("failed to check promo code [%s], error: %w", code, err)
+	}
+
+	return exists, nil
+}
+
+// List retrieves every promo code, newest first.
+func (rc *PromoCodeRepository) List(ctx context.Context) ([]models.PromoCode, error) {
+	promos := make([]models.PromoCode, 0)
+
+	err := conn(ctx, rc.db).
+		Model(&promos).
+		Order("id DESC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list promo codes: %w", err)
+	}
+
+	return promos, nil
+}
+
+// Delete soft deletes a promo code, it can't be redeemed anymore while purchases made with it keep their reference.
+func (rc *PromoCodeRepository) Delete(ctx context.Context, id string) error {
+	res, err := conn(ctx, rc.db).
+		Model(new(models.PromoCode)).
+		Where("id = ?", id).
+		Delete()
+	if err != nil {
+		return fmt.Errorf("failed to delete promo code [%s] id, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return errors.New("no promo code found for delete")
+	}
+
+	return nil
+}
+
+// Redeem atomically counts one redemption of a promo code. The update is conditional on the total cap,
+// so concurrent purchases can never redeem a code more often than allowed.
+// It fails with pkg.ErrPromoCodeExhausted when the cap is reached.
+func (rc *PromoCodeRepository) Redeem(ctx context.Context, id int64) (*models.PromoCode, error) {
+	promo := new(models.PromoCode)
+
+	res, err := conn(ctx, rc.db).
+		Model(promo).
+		Set("redemptions = redemptions + 1").
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Where("max_redemptions = 0 OR redemptions < max_redemptions").
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem promo code [%d] id, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil, pkg.ErrPromoCodeExhausted
+	}
+
+	return promo, nil
+}
+
+// Unredeem gives back a redemption of a promo code whose purchase failed.
+func (rc *PromoCodeRepository) Unredeem(ctx context.Context, id int64) error {
+	_, err := conn(ctx, rc.db).
+		Model((*models.PromoCode)(nil)).
+		Set("redemptions = redemptions - 1").
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Where("redemptions > 0").
+		Update()
+	if err != nil {
+		return fmt.Errorf("failed to unredeem promo code [%d] id, error: %w", id, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,305 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+
+	"github.com/go-pg/pg"
+)
+
+type PurchaseRepository struct {
+	db *pg.DB
+}
+
+func NewPurchaseRepository(db *pg.DB) *PurchaseRepository {
+	return &PurchaseRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new purchase record into the database.
+func (rc *PurchaseRepository) Create(ctx context.Context, purchase *models.Purchase) (*models.Purchase, error) {
+	_, err := conn(ctx, rc.db).Model(purchase).Insert()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create purchase: %w", err)
+	}
+
+	return purchase, nil
+}
+
+// GetByID retrieves a purchase from the database based on the provided purchase ID.
+func (rc *PurchaseRepository) GetByID(ctx context.Context, id string) (*models.Purchase, error) {
+	purchase := new(models.Purchase)
+
+	err := conn(ctx, rc.db).
+		Model(purchase).
+		Where("id = ?", id).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find purchase [%s] id, error: %w", id, err)
+	}
+
+	return purchase, nil
+}
+
+// GetByIDForUpdate retrieves a purchase and locks its row until the surrounding transaction ends.
+func (rc *PurchaseRepository) GetByIDForUpdate(ctx context.Context, id string) (*models.Purchase, error) {
+	purchase := new(models.Purchase)
+
+	err := conn(ctx, rc.db).
+		Model(purchase).
+		Where("id = ?", id).
+		For("UPDATE").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find purchase [%s] id, error: %w", id, err)
+	}
+
+	return purchase, nil
+}
+
+// ListByTicketID retrieves all purchases of a ticket, oldest first.
+func (rc *PurchaseRepository) ListByTicketID(ctx context.Context, ticketID string) ([]models.Purchase, error) {
+	purchases := make([]models.Purchase, 0)
+
+	err := conn(ctx, rc.db).
+		Model(&purchases).
+		Where("ticket_id = ?", ticketID).
+		Order("id ASC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list purchases of ticket [%s] id, error: %w", ticketID, err)
+	}
+
+	return purchases, nil
+}
+
+// ListPending retrieves the purchases that are still pending since before the given time, oldest first.
+func (rc *PurchaseRepository) ListPending(ctx context.Context, before time.Time) ([]models.Purchase, error) {
+	purchases := make([]models.Purchase, 0)
+
+	err := conn(ctx, rc.db).
+		Model(&purchases).
+		Where("status = ?", models.PurchaseStatusPending).
+		Where("created_at < ?", before).
+		Order("id ASC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending purchases: %w", err)
+	}
+
+	return purchases, nil
+}
+
+// ListRefundsDue retrieves the purchases whose provider paid back less than their refunded seats are owed, last
+// changed before the given time. The owed amount follows refundDue of the payment use case.
+func (rc *PurchaseRepository) ListRefundsDue(ctx context.Context, before time.Time) ([]models.Purchase, error) {
+	purchases := make([]models.Purchase, 0)
+
+	err := conn(ctx, rc.db).
+		Model(&purchases).
+		Where("payment_id != ''").
+		Where("refunded_quantity > 0").
+		Where("payment_refunded < CASE WHEN refunded_quantity = quantity THEN total ELSE total * refunded_quantity / quantity END").
+		Where("updated_at < ?", before).
+		Order("id ASC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list purchases with refunds due: %w", err)
+	}
+
+	return purchases, nil
+}
+
+// Settle moves a pending purchase to the given status once its payment went through or failed.
+// It fails with pkg.ErrPurchaseNotPending when the purchase was settled in the meantime.
+func (rc *PurchaseRepository) Settle(ctx context.Context, id int64, status models.PurchaseStatus) (*models.Purchase, error) {
+	purchase := new(models.Purchase)
+
+	res, err := conn(ctx, rc.db).
+		Model(purchase).
+		Set("status = ?", status).
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Where("status = ?", models.PurchaseStatusPending).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to settle purchase [%d] id, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil, pkg.ErrPurchaseNotPending
+	}
+
+	return purchase, nil
+}
+
+// Refund atomically adds the quantity to the refunded seats of a completed purchase and updates its status.
+// It fails with pkg.ErrAlreadyRefunded when fewer than quantity seats are left to refund, resold seats aren't refundable.
+func (rc *PurchaseRepository) Refund(ctx context.Context, id string, quantity int) (*models.Purchase, error) {
+	purchase := new(models.Purchase)
+
+	res, err := conn(ctx, rc.db).
+		Model(purchase).
+		Set("refunded_quantity = refunded_quantity + ?", quantity).
+		Set("status = CASE WHEN refunded_quantity + resold_quantity + ? = quantity THEN ? ELSE ? END",
+			quantity, models.PurchaseStatusRefunded, models.PurchaseStatusPartiallyRefunded).
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Where("status IN (?, ?)", models.PurchaseStatusCompleted, models.PurchaseStatusPartiallyRefunded).
+		Where("quantity - refunded_quantity - resold_quantity >= ?", quantity).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refund purchase [%s] id, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil, pkg.ErrAlreadyRefunded
+	}
+
+	return purchase, nil
+}
+
+// SetOwner hands a purchase to another user, its seats and payment stay as they are.
+func (rc *PurchaseRepository) SetOwner(ctx context.Context, id int64, userID string) (*models.Purchase, error) {
+	purchase := new(models.Purchase)
+
+	_, err := conn(ctx, rc.db).
+		Model(purchase).
+		Set("user_id = ?", userID).
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to set owner of purchase [%d] id, error: %w", id, err)
+	}
+
+	return purchase, nil
+}
+
+// MarkResold counts a seat of the purchase as sold on through resale.
+// It returns false when no seat is left that wasn't refunded or resold.
+func (rc *PurchaseRepository) MarkResold(ctx context.Context, id int64) (bool, error) {
+	res, err := conn(ctx, rc.db).
+		Model((*models.Purchase)(nil)).
+		Set("resold_quantity = resold_quantity + 1").
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Where("quantity - refunded_quantity - resold_quantity >= 1").
+		Update()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark seat of purchase [%d] id as resold, error: %w", id, err)
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// SetPayment records the payment of a purchase as it is authorized, captured or released.
+func (rc *PurchaseRepository) SetPayment(ctx context.Context, id int64, paymentID string, status models.PaymentStatus) error {
+	_, err := conn(ctx, rc.db).
+		Model((*models.Purchase)(nil)).
+		Set("payment_id = ?", paymentID).
+		Set("payment_status = ?", status).
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		return fmt.Errorf("failed to set payment of purchase [%d] id, error: %w", id, err)
+	}
+
+	return nil
+}
+
+// UpdatePayment stores the payment status and refunded amount reported for a payment. The refunded amount only grows,
+// so an update older than the stored state is skipped and false is returned, as it is for unknown payments.
+func (rc *PurchaseRepository) UpdatePayment(ctx context.Context, paymentID string, status models.PaymentStatus, refunded int64) (bool, error) {
+	res, err := conn(ctx, rc.db).
+		Model((*models.Purchase)(nil)).
+		Set("payment_status = ?", status).
+		Set("payment_refunded = ?", refunded).
+		Set("updated_at = now()").
+		Where("payment_id = ?", paymentID).
+		Where("payment_refunded <= ?", refunded).
+		Update()
+	if err != nil {
+		return false, fmt.Errorf("failed to update payment [%s], error: %w", paymentID, err)
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// SetRefunded stores the amount the provider reports as refunded on the payment of a purchase so far, with the status
+// that follows from it, and returns the purchase as it is afterwards. The refunded amount only grows, so a refund
+// webhook stored first or a concurrent refund that reported a later total is never undone or counted twice.
+func (rc *PurchaseRepository) SetRefunded(ctx context.Context, id int64, refunded int64) (*models.Purchase, error) {
+	purchase := new(models.Purchase)
+
+	_, err := conn(ctx, rc.db).
+		Model(purchase).
+		Set("payment_refunded = GREATEST(payment_refunded, ?)", refunded).
+		Set("payment_status = CASE WHEN GREATEST(payment_refunded, ?) >= total THEN ? ELSE ? END",
+			refunded, models.PaymentStatusRefunded, models.PaymentStatusPartiallyRefunded).
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to set refund of purchase [%d] id, error: %w", id, err)
+	}
+
+	return purchase, nil
+}
+
+// ExistsByTicketID reports whether any purchase was made for the ticket.
+func (rc *PurchaseRepository) ExistsByTicketID(ctx context.Context, ticketID string) (bool, error) {
+	exists, err := conn(ctx, rc.db).
+		Model((*models.Purchase)(nil)).
+		Where("ticket_id = ?", ticketID).
+		Exists()
+	if err != nil {
+		return false, fmt.Errorf("failed to check purchases of ticket [%s] id, error: %w", ticketID, err)
+	}
+
+	return exists, nil
+}
+
+// SumQuantityByUser returns the seats the user holds across all purchases of the ticket, refunded and resold seats
+// and failed purchases excluded. Pending purchases count, their seats are reserved.
+func (rc *PurchaseRepository) SumQuantityByUser(ctx context.Context, ticketID, userID string) (int, error) {
+	var total int
+
+	err := conn(ctx, rc.db).
+		Model((*models.Purchase)(nil)).
+		ColumnExpr("COALESCE(SUM(quantity - refunded_quantity - resold_quantity), 0)").
+		Where("ticket_id = ?", ticketID).
+		Where("user_id = ?", userID).
+		Where("status != ?", models.PurchaseStatusFailed).
+		Select(pg.Scan(&total))
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum purchases of user [%s] for ticket [%s] id, error: %w", userID, ticketID, err)
+	}
+
+	return total, nil
+}
+
+// CountByPromoCodeAndUser returns how many purchases the user made with the promo code, failed purchases excluded.
+func (rc *PurchaseRepository) CountByPromoCodeAndUser(ctx context.Context, promoCodeID int64, userID string) (int, error) {
+	count, err := conn(ctx, rc.db).
+		Model((*models.Purchase)(nil)).
+		Where("promo_code_id = ?", promoCodeID).
+		Where("user_id = ?", userID).
+		Where("status != ?", models.PurchaseStatusFailed).
+		Count()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count purchases of user [%s] with promo code [%d] id, error: %w", userID, promoCodeID, err)
+	}
+
+	return count, nil
+}
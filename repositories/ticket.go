@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
 
 	"github.com/go-pg/pg"
 )
@@ -22,7 +24,7 @@ func NewTicketRepository(db *pg.DB) *TicketRepository {
 
 // Create inserts a new ticket into the database based on the provided ticket data.
 func (rc *TicketRepository) Create(ctx context.Context, ticket *models.Ticket) (*models.Ticket, error) {
-	_, err := rc.db.Model(ticket).Insert()
+	_, err := conn(ctx, rc.db).Model(ticket).Insert()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create ticket: %w", err)
 	}
@@ -30,25 +32,127 @@ func (rc *TicketRepository) Create(ctx context.Context, ticket *models.Ticket) (
 	return ticket, nil
 }
 
-// Update updates an existing ticket in the database.
-func (rc *TicketRepository) Update(ctx context.Context, ticket *models.Ticket) (*models.Ticket, error) {
-	res, err := rc.db.Model(ticket).WherePK().Update()
+// Update updates an existing ticket in the database if it is still at ticket.Version, and bumps the version.
+// When columns are given only those are written, leaving concurrent changes to the others intact.
+// It fails with pkg.ErrVersionConflict when the ticket was changed in the meantime.
+func (rc *TicketRepository) Update(ctx context.Context, ticket *models.Ticket, columns ...string) (*models.Ticket, error) {
+	query := conn(ctx, rc.db).Model(ticket)
+	if len(columns) == 0 {
+		columns = []string{"name", "description", "allocation"}
+	}
+	for _, column := range columns {
+		query = query.Set(column + " = ?" + column)
+	}
+
+	res, err := query.
+		Set("version = version + 1").
+		WherePK().
+		Where("version = ?version").
+		Returning("*").
+		Update()
 	if err != nil {
 		return nil, fmt.Errorf("failed to update ticket: %w", err)
 	}
 
 	if res.RowsAffected() == 0 {
+		exists, err := conn(ctx, rc.db).Model((*models.Ticket)(nil)).Where("id = ?", ticket.ID).Exists()
+		if err != nil {
+			return nil, fmt.Errorf("failed to update ticket: %w", err)
+		}
+		if exists {
+			return nil, pkg.ErrVersionConflict
+		}
 		return nil, errors.New("no ticket found for update")
 	}
 
 	return ticket, nil
 }
 
+// DecreaseAllocation atomically subtracts the quantity from the allocation of an on_sale ticket and marks it
+// sold_out when no seat is left. The update is conditional on enough seats being left, so concurrent purchases
+// can never oversell. It fails with pkg.ErrTicketNotOnSale or pkg.ErrInsufficientAllocation when the update is refused.
+func (rc *TicketRepository) DecreaseAllocation(ctx context.Context, id string, quantity int) (*models.Ticket, error) {
+	ticket := new(models.Ticket)
+
+	res, err := conn(ctx, rc.db).
+		Model(ticket).
+		Set("allocation = allocation - ?", quantity).
+		Set("status = CASE WHEN allocation = ? THEN ? ELSE status END", quantity, models.TicketStatusSoldOut).
+		Set("version = version + 1").
+		Where("id = ?", id).
+		Where("status = ?", models.TicketStatusOnSale).
+		Where("allocation >= ?", quantity).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrease ticket [%s] allocation, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		onSale, err := conn(ctx, rc.db).Model((*models.Ticket)(nil)).Where("id = ?", id).Where("status = ?", models.TicketStatusOnSale).Exists()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrease ticket [%s] allocation, error: %w", id, err)
+		}
+		if !onSale {
+			return nil, pkg.ErrTicketNotOnSale
+		}
+		return nil, pkg.ErrInsufficientAllocation
+	}
+
+	return ticket, nil
+}
+
+// IncreaseAllocation atomically returns the quantity to the ticket's allocation, a sold_out ticket goes back on sale.
+// Soft deleted tickets are included so expiring holds and refunds still settle.
+func (rc *TicketRepository) IncreaseAllocation(ctx context.Context, id string, quantity int) (*models.Ticket, error) {
+	ticket := new(models.Ticket)
+
+	_, err := conn(ctx, rc.db).QueryOne(ticket, `
+		UPDATE tickets SET allocation = allocation + ?0,
+			status = CASE WHEN status = ?1 AND allocation + ?0 > 0 THEN ?2 ELSE status END,
+			version = version + 1
+		WHERE id = ?3
+		RETURNING *`, quantity, models.TicketStatusSoldOut, models.TicketStatusOnSale, id)
+	if err != nil {
+		if errors.Is(err, pg.ErrNoRows) {
+			return nil, errors.New("no ticket found for update")
+		}
+		return nil, fmt.Errorf("failed to increase ticket [%s] allocation, error: %w", id, err)
+	}
+
+	return ticket, nil
+}
+
+// SyncAllocation sets the allocation of a ticket to the sum of its tiers' allocations. An on_sale ticket without
+// seats left is marked sold_out and a sold_out ticket with seats goes back on sale.
+func (rc *TicketRepository) SyncAllocation(ctx context.Context, id string) (*models.Ticket, error) {
+	ticket := new(models.Ticket)
+
+	_, err := conn(ctx, rc.db).QueryOne(ticket, `
+		UPDATE tickets SET allocation = tiers.total,
+			status = CASE
+				WHEN tickets.status = ?1 AND tiers.total = 0 THEN ?2
+				WHEN tickets.status = ?2 AND tiers.total > 0 THEN ?1
+				ELSE tickets.status END,
+			version = version + 1
+		FROM (SELECT COALESCE(SUM(allocation), 0) AS total FROM ticket_tiers WHERE ticket_id = ?0) AS tiers
+		WHERE tickets.id = ?0
+		RETURNING tickets.*`, id, models.TicketStatusOnSale, models.TicketStatusSoldOut)
+	if err != nil {
+		if errors.Is(err, pg.ErrNoRows) {
+			return nil, errors.New("no ticket found for update")
+		}
+		return nil, fmt.Errorf("failed to sync ticket [%s] allocation, error: %w", id, err)
+	}
+
+	return ticket, nil
+}
+
 // GetByID retrieves a ticket from the database based on the provided ticket ID.
 func (rc *TicketRepository) GetByID(ctx context.Context, id string) (*models.Ticket, error) {
 	ticket := new(models.Ticket)
 
-	err := rc.db.
+	err := conn(ctx, rc.db).
 		Model(ticket).
 		Where("id = ?", id).
 		Select()
@@ -58,3 +162,119 @@ func (rc *TicketRepository) GetByID(ctx context.Context, id string) (*models.Tic
 
 	return ticket, nil
 }
+
+// GetByIDForUpdate retrieves a ticket and locks its row until the surrounding transaction ends.
+func (rc *TicketRepository) GetByIDForUpdate(ctx context.Context, id string) (*models.Ticket, error) {
+	ticket := new(models.Ticket)
+
+	err := conn(ctx, rc.db).
+		Model(ticket).
+		Where("id = ?", id).
+		For("UPDATE").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ticket [%s] id, error: %w", id, err)
+	}
+
+	return ticket, nil
+}
+
+// GetWithDeletedForUpdate is GetByIDForUpdate for soft deleted tickets as well, soft deletes filter the model queries.
+func (rc *TicketRepository) GetWithDeletedForUpdate(ctx context.Context, id string) (*models.Ticket, error) {
+	ticket := new(models.Ticket)
+
+	if _, err := conn(ctx, rc.db).QueryOne(ticket, "SELECT * FROM tickets WHERE id = ? FOR UPDATE", id); err != nil {
+		return nil, fmt.Errorf("failed to find ticket [%s] id, error: %w", id, err)
+	}
+
+	return ticket, nil
+}
+
+// Lock locks the row of a ticket, soft deleted or not, until the surrounding transaction ends.
+func (rc *TicketRepository) Lock(ctx context.Context, id string) error {
+	if _, err := conn(ctx, rc.db).Exec("SELECT 1 FROM tickets WHERE id = ? FOR UPDATE", id); err != nil {
+		return fmt.Errorf("failed to lock ticket [%s] id, error: %w", id, err)
+	}
+
+	return nil
+}
+
+// Delete soft deletes a ticket, it disappears from reads while its purchases stay intact.
+func (rc *TicketRepository) Delete(ctx context.Context, id string) error {
+	res, err := conn(ctx, rc.db).
+		Model(new(models.Ticket)).
+		Where("id = ?", id).
+		Delete()
+	if err != nil {
+		return fmt.Errorf("failed to delete ticket [%s] id, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return errors.New("no ticket found for delete")
+	}
+
+	return nil
+}
+
+// ForceDelete permanently removes a ticket from the database, whether it was soft deleted or not.
+func (rc *TicketRepository) ForceDelete(ctx context.Context, id string) error {
+	res, err := conn(ctx, rc.db).Exec("DELETE FROM tickets WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete ticket [%s] id, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return errors.New("no ticket found for delete")
+	}
+
+	return nil
+}
+
+// List retrieves a page of tickets using keyset pagination, so deep pages cost the same as the first one.
+// It returns up to opts.Limit tickets that come after opts.After in the requested order.
+func (rc *TicketRepository) List(ctx context.Context, opts models.TicketListOptions) ([]models.Ticket, error) {
+	tickets := make([]models.Ticket, 0)
+
+	query := conn(ctx, rc.db).Model(&tickets)
+
+	if opts.Name != "" {
+		query = query.Where("name ILIKE ?", "%"+likeEscaper.Replace(opts.Name)+"%")
+	}
+
+	if opts.Available {
+		query = query.Where("allocation > 0")
+	}
+
+	direction, comparison := "ASC", ">"
+	if opts.Order == "desc" {
+		direction, comparison = "DESC", "<"
+	}
+
+	if after := opts.After; after != nil {
+		switch opts.Sort {
+		case "name":
+			query = query.Where("(name, id) "+comparison+" (?, ?)", after.Name, after.ID)
+		case "allocation":
+			query = query.Where("(allocation, id) "+comparison+" (?, ?)", after.Allocation, after.ID)
+		default:
+			query = query.Where("id "+comparison+" ?", after.ID)
+		}
+	}
+
+	if opts.Sort == "name" || opts.Sort == "allocation" {
+		query = query.Order(opts.Sort + " " + direction)
+	}
+
+	err := query.
+		Order("id " + direction).
+		Limit(opts.Limit).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tickets: %w", err)
+	}
+
+	return tickets, nil
+}
+
+// likeEscaper escapes the LIKE wildcards of user input.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+
+	"github.com/go-pg/pg"
+)
+
+type TierRepository struct {
+	db *pg.DB
+}
+
+func NewTierRepository(db *pg.DB) *TierRepository {
+	return &TierRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new ticket tier into the database.
+func (rc *TierRepository) Create(ctx context.Context, tier *models.TicketTier) (*models.TicketTier, error) {
+	_, err := conn(ctx, rc.db).Model(tier).Insert()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ticket tier: %w", err)
+	}
+
+	return tier, nil
+}
+
+// Update writes the given columns of a ticket tier.
+func (rc *TierRepository) Update(ctx context.Context, tier *models.TicketTier, columns ...string) (*models.TicketTier, error) {
+	query := conn(ctx, rc.db).Model(tier)
+	for _, column := range columns {
+		query = query.Set(column + " = ?" + column)
+	}
+
+	res, err := query.
+		Set("updated_at = now()").
+		WherePK().
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update ticket tier: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("no ticket tier found for update")
+	}
+
+	return tier, nil
+}
+
+// GetByID retrieves a tier of the ticket, tiers of other tickets are not found.
+func (rc *TierRepository) GetByID(ctx context.Context, ticketID, tierID string) (*models.TicketTier, error) {
+	tier := new(models.TicketTier)
+
+	err := conn(ctx, rc.db).
+		Model(tier).
+		Where("id = ?", tierID).
+		Where("ticket_id = ?", ticketID).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tier [%s] id of ticket [%s] id, error: %w", tierID, ticketID, err)
+	}
+
+	return tier, nil
+}
+
+// ListByTicketID retrieves the tiers of a ticket ordered by their position.
+func (rc *TierRepository) ListByTicketID(ctx context.Context, ticketID string) ([]models.TicketTier, error) {
+	tiers := make([]models.TicketTier, 0)
+
+	err := conn(ctx, rc.db).
+		Model(&tiers).
+		Where("ticket_id = ?", ticketID).
+		Order("position ASC", "id ASC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tiers of ticket [%s] id, error: %w", ticketID, err)
+	}
+
+	return tiers, nil
+}
+
+// ExistsByTicketID reports whether the ticket has any tier.
+func (rc *TierRepository) ExistsByTicketID(ctx context.Context, ticketID string) (bool, error) {
+	exists, err := conn(ctx, rc.db).
+		Model((*models.TicketTier)(nil)).
+		Where("ticket_id = ?", ticketID).
+		Exists()
+	if err != nil {
+		return false, fmt.Errorf("failed to check tiers of ticket [%s] id, error: %w", ticketID, err)
+	}
+
+	return exists, nil
+}
+
+// Delete removes a tier of the ticket.
+func (rc *TierRepository) Delete(ctx context.Context, ticketID, tierID string) error {
+	res, err := conn(ctx, rc.db).
+		Model((*models.TicketTier)(nil)).
+		Where("id = ?", tierID).
+		Where("ticket_id = ?", ticketID).
+		Delete()
+	if err != nil {
+		return fmt.Errorf("failed to delete tier [%s] id, error: %w", tierID, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return errors.New("no ticket tier found for delete")
+	}
+
+	return nil
+}
+
+// DeleteByTicketID removes every tier of the ticket.
+func (rc *TierRepository) DeleteByTicketID(ctx context.Context, ticketID string) error {
+	_, err := conn(ctx, rc.db).
+		Model((*models.TicketTier)(nil)).
+		Where("ticket_id = ?", ticketID).
+		Delete()
+	if err != nil {
+		return fmt.Errorf("failed to delete tiers of ticket [%s] id, error: %w", ticketID, err)
+	}
+
+	return nil
+}
+
+// DecreaseAllocation atomically subtracts the quantity from the allocation of a tier. The update is conditional on
+// enough seats being left, it fails with pkg.ErrInsufficientAllocation when the update is refused.
+func (rc *TierRepository) DecreaseAllocation(ctx context.Context, id int64, quantity int) (*models.TicketTier, error) {
+	tier := new(models.TicketTier)
+
+	res, err := conn(ctx, rc.db).
+		Model(tier).
+		Set("allocation = allocation - ?", quantity).
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Where("allocation >= ?", quantity).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrease tier [%d] allocation, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil, pkg.ErrInsufficientAllocation
+	}
+
+	return tier, nil
+}
+
+// IncreaseAllocation atomically returns the quantity to the allocation of a tier.
+func (rc *TierRepository) IncreaseAllocation(ctx context.Context, id int64, quantity int) (*models.TicketTier, error) {
+	tier := new(models.TicketTier)
+
+	res, err := conn(ctx, rc.db).
+		Model(tier).
+		Set("allocation = allocation + ?", quantity).
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to increase tier [%d] allocation, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("no ticket tier found for update")
+	}
+
+	return tier, nil
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fleimkeipa/tickets-api/models"
+
+	"github.com/go-pg/pg"
+)
+
+type TransferRepository struct {
+	db *pg.DB
+}
+
+func NewTransferRepository(db *pg.DB) *TransferRepository {
+	return &TransferRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new transfer into the database.
+func (rc *TransferRepository) Create(ctx context.Context, transfer *models.Transfer) (*models.Transfer, error) {
+	_, err := conn(ctx, rc.db).Model(transfer).Insert()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transfer: %w", err)
+	}
+
+	return transfer, nil
+}
+
+// GetByIDForUpdate retrieves a transfer and locks its row until the surrounding transaction ends.
+func (rc *TransferRepository) GetByIDForUpdate(ctx context.Context, id string) (*models.Transfer, error) {
+	transfer := new(models.Transfer)
+
+	err := conn(ctx, rc.db).
+		Model(transfer).
+		Where("id = ?", id).
+		For("UPDATE").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find transfer [%s] id, error: %w", id, err)
+	}
+
+	return transfer, nil
+}
+
+// ListByPurchaseID retrieves the transfers of a purchase, oldest first.
+func (rc *TransferRepository) ListByPurchaseID(ctx context.Context, purchaseID int64) ([]models.Transfer, error) {
+	transfers := make([]models.Transfer, 0)
+
+	err := conn(ctx, rc.db).
+		Model(&transfers).
+		Where("purchase_id = ?", purchaseID).
+		Order("id ASC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfers of purchase [%d] id, error: %w", purchaseID, err)
+	}
+
+	return transfers, nil
+}
+
+// ExistsPending reports whether the purchase has a transfer waiting to be accepted.
+func (rc *TransferRepository) ExistsPending(ctx context.Context, purchaseID int64) (bool, error) {
+	exists, err := conn(ctx, rc.db).
+		Model((*models.Transfer)(nil)).
+		Where("purchase_id = ?", purchaseID).
+		Where("status = ?", models.TransferStatusPending).
+		Exists()
+	if err != nil {
+		return false, fmt.Errorf("failed to check transfers of purchase [%d] id, error: %w", purchaseID, err)
+	}
+
+	return exists, nil
+}
+
+// UpdateStatus settles a pending transfer with the given status and acceptor.
+func (rc *TransferRepository) UpdateStatus(ctx context.Context, transfer *models.Transfer) (*models.Transfer, error) {
+	_, err := conn(ctx, rc.db).
+		Model(transfer).
+		Set("status = ?status").
+		Set("accepted_by = ?accepted_by").
+		Set("updated_at = now()").
+		Where("id = ?id").
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update transfer [%d] id, error: %w", transfer.ID, err)
+	}
+
+	return transfer, nil
+}
+
+// CreateEvent appends an entry to the audit trail of a transfer.
+func (rc *TransferRepository) CreateEvent(ctx context.Context, event *models.TransferEvent) error {
+	_, err := conn(ctx, rc.db).Model(event).Insert()
+	if err != nil {
+		return fmt.Errorf("failed to create transfer event: %w", err)
+	}
+
+	return nil
+}
+
+// ListEventsByPurchaseID retrieves the audit trail of every transfer of a purchase, oldest first.
+func (rc *TransferRepository) ListEventsByPurchaseID(ctx context.Context, purchaseID int64) ([]models.TransferEvent, error) {
+	events := make([]models.TransferEvent, 0)
+
+	err := conn(ctx, rc.db).
+		Model(&events).
+		Where("purchase_id = ?", purchaseID).
+		Order("id ASC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfer events of purchase [%d] id, error: %w", purchaseID, err)
+	}
+
+	return events, nil
+}
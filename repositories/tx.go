@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+type txKey struct{}
+
+type TxManager struct {
+	db *pg.DB
+}
+
+func NewTxManager(db *pg.DB) *TxManager {
+	return &TxManager{
+		db: db,
+	}
+}
+
+// RunInTx runs fn inside a database transaction which repositories pick up from the context.
+// Nested calls join the transaction that is already running.
+func (rc *TxManager) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*pg.Tx); ok {
+		return fn(ctx)
+	}
+
+	return rc.db.RunInTransaction(func(tx *pg.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction stored in the context, falling back to the plain database handle.
+func conn(ctx context.Context, db *pg.DB) orm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*pg.Tx); ok {
+		return tx
+	}
+
+	return db
+}
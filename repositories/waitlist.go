@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+
+	"github.com/go-pg/pg"
+)
+
+type WaitlistRepository struct {
+	db *pg.DB
+}
+
+func NewWaitlistRepository(db *pg.DB) *WaitlistRepository {
+	return &WaitlistRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new waitlist entry into the database.
+func (rc *WaitlistRepository) Create(ctx context.Context, entry *models.WaitlistEntry) (*models.WaitlistEntry, error) {
+	_, err := conn(ctx, rc.db).Model(entry).Insert()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create waitlist entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// GetByID retrieves a waitlist entry from the database based on the provided entry ID.
+func (rc *WaitlistRepository) GetByID(ctx context.Context, id string) (*models.WaitlistEntry, error) {
+	entry := new(models.WaitlistEntry)
+
+	err := conn(ctx, rc.db).
+		Model(entry).
+		Where("id = ?", id).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find waitlist entry [%s] id, error: %w", id, err)
+	}
+
+	return entry, nil
+}
+
+// ExistsActive reports whether the user is still waiting for, or has an open or paying offer on, the ticket.
+func (rc *WaitlistRepository) ExistsActive(ctx context.Context, ticketID, userID string) (bool, error) {
+	exists, err := conn(ctx, rc.db).
+		Model((*models.WaitlistEntry)(nil)).
+		Where("ticket_id = ?", ticketID).
+		Where("user_id = ?", userID).
+		WhereIn("status IN (?)", []models.WaitlistStatus{models.WaitlistStatusWaiting, models.WaitlistStatusOffered, models.WaitlistStatusPaying}).
+		Exists()
+	if err != nil {
+		return false, fmt.Errorf("failed to check waitlist of ticket [%s] id, error: %w", ticketID, err)
+	}
+
+	return exists, nil
+}
+
+// NextWaiting returns the oldest waiting entry of the ticket, or nil when nobody is waiting.
+func (rc *WaitlistRepository) NextWaiting(ctx context.Context, ticketID string) (*models.WaitlistEntry, error) {
+	entry := new(models.WaitlistEntry)
+
+	err := conn(ctx, rc.db).
+		Model(entry).
+		Where("ticket_id = ?", ticketID).
+		Where("status = ?", models.WaitlistStatusWaiting).
+		Order("id ASC").
+		Limit(1).
+		Select()
+	if err != nil {
+		if errors.Is(err, pg.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find next waitlist entry of ticket [%s] id, error: %w", ticketID, err)
+	}
+
+	return entry, nil
+}
+
+// CountAhead returns how many entries of the same ticket have been waiting longer than the entry.
+func (rc *WaitlistRepository) CountAhead(ctx context.Context, entry *models.WaitlistEntry) (int, error) {
+	count, err := conn(ctx, rc.db).
+		Model((*models.WaitlistEntry)(nil)).
+		Where("ticket_id = ?", entry.TicketID).
+		Where("status = ?", models.WaitlistStatusWaiting).
+		Where("id < ?", entry.ID).
+		Count()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count waitlist entries ahead of [%d] id, error: %w", entry.ID, err)
+	}
+
+	return count, nil
+}
+
+// Offer marks a waiting entry as offered until expiresAt, with the seats taken from the given tier.
+func (rc *WaitlistRepository) Offer(ctx context.Context, id, tierID int64, expiresAt time.Time) (*models.WaitlistEntry, error) {
+	entry := new(models.WaitlistEntry)
+
+	res, err := conn(ctx, rc.db).
+		Model(entry).
+		Set("status = ?", models.WaitlistStatusOffered).
+		Set("tier_id = ?", tierID).
+		Set("offer_expires_at = ?", expiresAt).
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Where("status = ?", models.WaitlistStatusWaiting).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to offer waitlist entry [%d] id, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil, fmt.Errorf("waitlist entry [%d] id is no longer waiting", id)
+	}
+
+	return entry, nil
+}
+
+// Pay marks an offered, unexpired entry as paid by the given pending purchase.
+// It fails with pkg.ErrOfferNotActive when the offer was accepted or expired in the meantime.
+func (rc *WaitlistRepository) Pay(ctx context.Context, id string, purchaseID int64, now time.Time) (*models.WaitlistEntry, error) {
+	entry := new(models.WaitlistEntry)
+
+	res, err := conn(ctx, rc.db).
+		Model(entry).
+		Set("status = ?", models.WaitlistStatusPaying).
+		Set("purchase_id = ?", purchaseID).
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Where("status = ?", models.WaitlistStatusOffered).
+		Where("offer_expires_at > ?", now).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to pay waitlist entry [%s] id, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil, pkg.ErrOfferNotActive
+	}
+
+	return entry, nil
+}
+
+// Fulfill marks the entry paid by the purchase as fulfilled, purchases made without an offer change nothing.
+func (rc *WaitlistRepository) Fulfill(ctx context.Context, purchaseID int64) error {
+	_, err := conn(ctx, rc.db).
+		Model((*models.WaitlistEntry)(nil)).
+		Set("status = ?", models.WaitlistStatusFulfilled).
+		Set("updated_at = now()").
+		Where("purchase_id = ?", purchaseID).
+		Where("status = ?", models.WaitlistStatusPaying).
+		Update()
+	if err != nil {
+		return fmt.Errorf("failed to fulfill waitlist entry of purchase [%d] id, error: %w", purchaseID, err)
+	}
+
+	return nil
+}
+
+// Reopen opens the offer paid by a failed purchase again until it expires, purchases made without an offer
+// change nothing.
+func (rc *WaitlistRepository) Reopen(ctx context.Context, purchaseID int64) error {
+	_, err := conn(ctx, rc.db).
+		Model((*models.WaitlistEntry)(nil)).
+		Set("status = ?", models.WaitlistStatusOffered).
+		Set("purchase_id = NULL").
+		Set("updated_at = now()").
+		Where("purchase_id = ?", purchaseID).
+		Where("status = ?", models.WaitlistStatusPaying).
+		Update()
+	if err != nil {
+		return fmt.Errorf("failed to reopen waitlist entry of purchase [%d] id, error: %w", purchaseID, err)
+	}
+
+	return nil
+}
+
+// ExpireOffers marks every offer that expired before now as expired and returns the entries.
+func (rc *WaitlistRepository) ExpireOffers(ctx context.Context, now time.Time) ([]models.WaitlistEntry, error) {
+	entries := make([]models.WaitlistEntry, 0)
+
+	_, err := conn(ctx, rc.db).
+		Model(&entries).
+		Set("status = ?", models.WaitlistStatusExpired).
+		Set("updated_at = now()").
+		Where("status = ?", models.WaitlistStatusOffered).
+		Where("offer_expires_at <= ?", now).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire waitlist offers: %w", err)
+	}
+
+	return entries, nil
+}
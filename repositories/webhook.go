@@ -0,0 +1,233 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+
+	"github.com/go-pg/pg"
+)
+
+type WebhookRepository struct {
+	db *pg.DB
+}
+
+func NewWebhookRepository(db *pg.DB) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+// CreateSubscription inserts a new webhook subscription into the database.
+func (rc *WebhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	_, err := conn(ctx, rc.db).Model(subscription).Insert()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// UpdateSubscription writes the given columns of a webhook subscription.
+func (rc *WebhookRepository) UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription, columns ...string) (*models.WebhookSubscription, error) {
+	query := conn(ctx, rc.db).Model(subscription)
+	for _, column := range columns {
+		query = query.Set(column + " = ?" + column)
+	}
+
+	res, err := query.
+		Set("updated_at = now()").
+		WherePK().
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil, errors.New("no webhook subscription found for update")
+	}
+
+	return subscription, nil
+}
+
+// GetSubscriptionByID retrieves a webhook subscription from the database based on the provided subscription ID.
+func (rc *WebhookRepository) GetSubscriptionByID(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	subscription := new(models.WebhookSubscription)
+
+	err := conn(ctx, rc.db).
+		Model(subscription).
+		Where("id = ?", id).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook subscription [%s] id, error: %w", id, err)
+	}
+
+	return subscription, nil
+}
+
+// ListSubscriptions retrieves every webhook subscription, newest first.
+func (rc *WebhookRepository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	subscriptions := make([]models.WebhookSubscription, 0)
+
+	err := conn(ctx, rc.db).
+		Model(&subscriptions).
+		Order("id DESC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// ListSubscriptionsByEvent retrieves the active subscriptions to the event type, oldest first.
+func (rc *WebhookRepository) ListSubscriptionsByEvent(ctx context.Context, eventType string) ([]models.WebhookSubscription, error) {
+	subscriptions := make([]models.WebhookSubscription, 0)
+
+	err := conn(ctx, rc.db).
+		Model(&subscriptions).
+		Where("active").
+		Where("? = ANY(event_types)", eventType).
+		Order("id ASC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions of [%s], error: %w", eventType, err)
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteSubscription soft deletes a webhook subscription, its delivery log is kept.
+func (rc *WebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	res, err := conn(ctx, rc.db).
+		Model(new(models.WebhookSubscription)).
+		Where("id = ?", id).
+		Delete()
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription [%s] id, error: %w", id, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return errors.New("no webhook subscription found for delete")
+	}
+
+	return nil
+}
+
+// CreateDeliveries inserts the deliveries of an event in one statement.
+func (rc *WebhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	_, err := conn(ctx, rc.db).Model(&deliveries).Insert()
+	if err != nil {
+		return fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// ExistsDeliveryByEventID reports whether deliveries of the event were created already.
+func (rc *WebhookRepository) ExistsDeliveryByEventID(ctx context.Context, eventID string) (bool, error) {
+	exists, err := conn(ctx, rc.db).
+		Model((*models.WebhookDelivery)(nil)).
+		Where("event_id = ?", eventID).
+		Exists()
+	if err != nil {
+		return false, fmt.Errorf("failed to find webhook deliveries of event [%s], error: %w", eventID, err)
+	}
+
+	return exists, nil
+}
+
+// GetDeliveryByID retrieves a delivery of the subscription.
+func (rc *WebhookRepository) GetDeliveryByID(ctx context.Context, subscriptionID, id string) (*models.WebhookDelivery, error) {
+	delivery := new(models.WebhookDelivery)
+
+	err := conn(ctx, rc.db).
+		Model(delivery).
+		Where("id = ?", id).
+		Where("subscription_id = ?", subscriptionID).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook delivery [%s] id, error: %w", id, err)
+	}
+
+	return delivery, nil
+}
+
+// ListDeliveries retrieves the latest deliveries of the subscription, newest first, optionally only those with the status.
+func (rc *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string, status models.WebhookDeliveryStatus, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := make([]models.WebhookDelivery, 0)
+
+	query := conn(ctx, rc.db).
+		Model(&deliveries).
+		Where("subscription_id = ?", subscriptionID)
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	err := query.
+		Order("id DESC").
+		Limit(limit).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries of subscription [%s] id, error: %w", subscriptionID, err)
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries picks up to limit pending deliveries that are due and pushes their next attempt to leaseUntil,
+// so other dispatchers leave them alone while they are being sent. Rows locked by another dispatcher are skipped.
+func (rc *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := make([]models.WebhookDelivery, 0)
+
+	due := conn(ctx, rc.db).
+		Model((*models.WebhookDelivery)(nil)).
+		Column("id").
+		Where("status = ?", models.WebhookDeliveryStatusPending).
+		Where("next_attempt_at <= ?", now).
+		Order("next_attempt_at ASC", "id ASC").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED")
+
+	_, err := conn(ctx, rc.db).
+		Model(&deliveries).
+		Set("next_attempt_at = ?", leaseUntil).
+		Where("id IN (?)", due).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// UpdateDelivery records the outcome of a delivery attempt.
+func (rc *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	_, err := conn(ctx, rc.db).
+		Model(delivery).
+		Set("status = ?status").
+		Set("attempts = ?attempts").
+		Set("next_attempt_at = ?next_attempt_at").
+		Set("last_attempt_at = ?last_attempt_at").
+		Set("response_status = ?response_status").
+		Set("last_error = ?last_error").
+		Set("delivered_at = ?delivered_at").
+		WherePK().
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook delivery [%d] id, error: %w", delivery.ID, err)
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/controller"
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+)
+
+func TestAPIKey_BuyerID(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	ticket := models.Ticket{ID: 1, Name: "inception", Description: "inception dream", Allocation: 10}
+	if err := addTempData(&ticket); err != nil {
+		t.Fatalf("addTempData error = %v", err)
+	}
+
+	clock := newFakeClock()
+	apiKeyUC := uc.NewAPIKeyUC(repositories.NewAPIKeyRepository(test_db), clock, testTicketValidator)
+	_, plain, err := apiKeyUC.Create(context.TODO(), &models.CreateAPIKeyRequest{
+		Name:      "box office",
+		Scopes:    []string{models.ScopePurchasesWrite},
+		CreatedBy: "admin",
+	})
+	if err != nil {
+		t.Fatalf("APIKeyUC.Create() error = %v", err)
+	}
+
+	verifier, err := pkg.NewTokenVerifier(testHMACSecret, "", "tickets-auth", "tickets-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authHandler := controller.NewAuthHandler(verifier)
+	apiKeyHandler := controller.NewAPIKeyHandler(apiKeyUC)
+	holdHandler := controller.NewHoldHandler(newTestHoldUC(clock))
+	purchaseHandler := controller.NewPurchaseHandler(uc.NewPurchaseUC(
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		newTestPaymentUC(nil),
+		newTestCredentialUC(),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+		0,
+	))
+
+	e := echo.New()
+	buyers := authHandler.Authorize(models.ScopePurchasesWrite)
+	e.POST("/tickets/:id/holds", holdHandler.CreateHold, apiKeyHandler.APIKeyMiddleware, authHandler.AuthMiddleware, buyers)
+	e.POST("/holds/:id/confirm", holdHandler.ConfirmHold, apiKeyHandler.APIKeyMiddleware, authHandler.AuthMiddleware, buyers)
+	e.GET("/purchases/:purchaseID", purchaseHandler.GetByID, apiKeyHandler.APIKeyMiddleware, authHandler.AuthMiddleware)
+	e.POST("/purchases/:purchaseID/cancel", purchaseHandler.CancelPurchase, apiKeyHandler.APIKeyMiddleware, authHandler.AuthMiddleware, buyers)
+
+	userToken := signHS256(t, testHMACSecret, jwt.MapClaims{
+		"sub": "344b6d2d-599a-4b23-b358-8f26512079a9",
+		"iss": "tickets-auth",
+		"aud": "tickets-api",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	type response struct {
+		UserID string `json:"user_id"`
+		Status string `json:"status"`
+	}
+	steps := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		token      string
+		buyerID    string
+		wantStatus int
+		wantUserID string
+	}{
+		{
+			name:       "error - hold with a key but without a buyer",
+			method:     http.MethodPost,
+			path:       "/tickets/1/holds",
+			body:       `{"quantity":2,"minutes":10}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "error - buyer named with an access token",
+			method:     http.MethodPost,
+			path:       "/tickets/1/holds",
+			body:       `{"quantity":2,"minutes":10}`,
+			token:      userToken,
+			buyerID:    "alice",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "success - hold for the key's customer",
+			method:     http.MethodPost,
+			path:       "/tickets/1/holds",
+			body:       `{"quantity":2,"minutes":10}`,
+			buyerID:    "alice",
+			wantStatus: http.StatusCreated,
+			wantUserID: "api-key:1:alice",
+		},
+		{
+			name:       "error - hold confirmed by another customer of the key",
+			method:     http.MethodPost,
+			path:       "/holds/1/confirm",
+			body:       `{}`,
+			buyerID:    "bob",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "success - hold confirmed by its customer",
+			method:     http.MethodPost,
+			path:       "/holds/1/confirm",
+			body:       `{}`,
+			buyerID:    "alice",
+			wantStatus: http.StatusCreated,
+			wantUserID: "api-key:1:alice",
+		},
+		{
+			name:       "success - purchase looked up by its customer",
+			method:     http.MethodGet,
+			path:       "/purchases/1",
+			buyerID:    "alice",
+			wantStatus: http.StatusOK,
+			wantUserID: "api-key:1:alice",
+		},
+		{
+			name:       "error - purchase looked up by another customer of the key",
+			method:     http.MethodGet,
+			path:       "/purchases/1",
+			buyerID:    "bob",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "error - purchase looked up by a user of an access token",
+			method:     http.MethodGet,
+			path:       "/purchases/1",
+			token:      userToken,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "error - purchase cancelled by another customer of the key",
+			method:     http.MethodPost,
+			path:       "/purchases/1/cancel",
+			body:       `{}`,
+			buyerID:    "bob",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "success - purchase cancelled by its customer",
+			method:     http.MethodPost,
+			path:       "/purchases/1/cancel",
+			body:       `{}`,
+			buyerID:    "alice",
+			wantStatus: http.StatusOK,
+			wantUserID: "api-key:1:alice",
+		},
+	}
+	for _, step := range steps {
+		req := httptest.NewRequest(step.method, step.path, strings.NewReader(step.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if step.token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+step.token)
+		} else {
+			req.Header.Set(controller.HeaderAPIKey, plain)
+		}
+		if step.buyerID != "" {
+			req.Header.Set(controller.HeaderBuyerID, step.buyerID)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		if rec.Code != step.wantStatus {
+			t.Fatalf("%s: status = %d, want %d, body %s", step.name, rec.Code, step.wantStatus, rec.Body.String())
+		}
+		if step.wantUserID != "" {
+			var got response
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("%s: decode error = %v", step.name, err)
+			}
+			if got.UserID != step.wantUserID {
+				t.Errorf("%s: user_id = %q, want %q", step.name, got.UserID, step.wantUserID)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+func TestAPIKeyUC_Authenticate(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		revoke  bool
+		key     func(plain string) string
+		wantErr bool
+	}{
+		{
+			name:    "success - valid key",
+			elapsed: time.Hour,
+			key:     func(plain string) string { return plain },
+			wantErr: false,
+		},
+		{
+			name:    "error - unknown key",
+			elapsed: time.Hour,
+			key:     func(plain string) string { return plain + "x" },
+			wantErr: true,
+		},
+		{
+			name:    "error - revoked key",
+			elapsed: time.Hour,
+			revoke:  true,
+			key:     func(plain string) string { return plain },
+			wantErr: true,
+		},
+		{
+			name:    "error - expired key",
+			elapsed: 24 * time.Hour,
+			key:     func(plain string) string { return plain },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			rc := uc.NewAPIKeyUC(repositories.NewAPIKeyRepository(test_db), clock, testTicketValidator)
+
+			expiresAt := clock.Now().Add(24 * time.Hour)
+			created, plain, err := rc.Create(context.TODO(), &models.CreateAPIKeyRequest{
+				Name:      "box office",
+				Scopes:    []string{models.ScopeTicketsRead, models.ScopePurchasesWrite},
+				ExpiresAt: &expiresAt,
+				CreatedBy: "admin",
+			})
+			if err != nil {
+				t.Errorf("APIKeyUC.Create() error = %v", err)
+				return
+			}
+			if tt.revoke {
+				if _, err := rc.Revoke(context.TODO(), "1"); err != nil {
+					t.Errorf("APIKeyUC.Revoke() error = %v", err)
+					return
+				}
+			}
+			clock.Advance(tt.elapsed)
+
+			got, err := rc.Authenticate(context.TODO(), tt.key(plain))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("APIKeyUC.Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != nil {
+				if got.ID != created.ID || !got.HasScope(models.ScopePurchasesWrite) || got.HasScope(models.ScopeTicketsWrite) {
+					t.Errorf("APIKeyUC.Authenticate() = %v, want key %d with its scopes", got, created.ID)
+				}
+				if !got.LastUsedAt.IsZero() {
+					t.Errorf("APIKeyUC.Authenticate() LastUsedAt = %v, want it recorded after the lookup", got.LastUsedAt)
+				}
+
+				keys, err := rc.List(context.TODO())
+				if err != nil {
+					t.Errorf("APIKeyUC.List() error = %v", err)
+					return
+				}
+				if len(keys) != 1 || !keys[0].LastUsedAt.Equal(clock.Now()) {
+					t.Errorf("APIKeyUC.List() = %v, want last use recorded at %v", keys, clock.Now())
+				}
+			}
+			if err := clearTable(); err != nil {
+				t.Errorf("APIKeyUC.Authenticate() clearTable error = %v", err)
+				return
+			}
+		})
+	}
+}
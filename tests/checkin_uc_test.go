@@ -0,0 +1,314 @@
+package tests
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+func newTestCheckinUC(clock pkg.Clock) *uc.CheckinUC {
+	return uc.NewCheckinUC(
+		repositories.NewCheckinRepository(test_db),
+		repositories.NewCredentialRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestCredentialSigner(),
+		clock,
+		testTicketValidator,
+	)
+}
+
+func TestCheckinUC_Check(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("CheckinUC.Check() clearTable error = %v", err)
+		}
+	}()
+
+	if err := addTempData(&models.Ticket{ID: 1, Name: "hamilton", Description: "hamilton musical", Allocation: 10}); err != nil {
+		t.Fatalf("CheckinUC.Check() addTempData error = %v", err)
+	}
+
+	clock := newFakeClock()
+	rc := newTestCheckinUC(clock)
+	ticketUC := newTestTicketUC(clock)
+	purchaseUC := uc.NewPurchaseUC(
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		newTestPaymentUC(nil),
+		newTestCredentialUC(),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+		0,
+	)
+
+	purchase, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 3})
+	if err != nil {
+		t.Fatalf("TicketUC.Purchase() error = %v", err)
+	}
+	first, second, third := purchase.Credentials[0].Payload, purchase.Credentials[1].Payload, purchase.Credentials[2].Payload
+	firstScan := clock.Now()
+
+	// two gates scanning the same code at once let exactly one person in
+	var wg sync.WaitGroup
+	results := make(chan models.CheckinResult, 2)
+	for _, gate := range []string{"north", "north"} {
+		wg.Add(1)
+		go func(gate string) {
+			defer wg.Done()
+			checkin, err := rc.Check(context.TODO(), &models.CheckinRequest{Payload: second, Gate: gate, ScannedBy: "gate-1"})
+			if err != nil {
+				t.Errorf("CheckinUC.Check() concurrent error = %v", err)
+				return
+			}
+			results <- checkin.Result
+		}(gate)
+	}
+	wg.Wait()
+	close(results)
+	admitted := 0
+	for result := range results {
+		if result == models.CheckinResultValid {
+			admitted++
+		}
+	}
+	if admitted != 1 {
+		t.Errorf("CheckinUC.Check() concurrent scans admitted %d, want 1", admitted)
+	}
+
+	steps := []struct {
+		name      string
+		action    func() error
+		payload   string
+		gate      string
+		want      models.CheckinResult
+		wantFirst string
+	}{
+		{
+			name:    "valid - first scan gets in",
+			payload: first,
+			gate:    "north",
+			want:    models.CheckinResultValid,
+		},
+		{
+			name:      "already used - second scan reports the first one",
+			action:    func() error { clock.Advance(10 * time.Minute); return nil },
+			payload:   first,
+			gate:      "south",
+			want:      models.CheckinResultAlreadyUsed,
+			wantFirst: "north",
+		},
+		{
+			name: "revoked - refunded seat can't get in",
+			action: func() error {
+				_, err := purchaseUC.Cancel(context.TODO(), "1", &models.CancelPurchaseRequest{Quantity: 1})
+				return err
+			},
+			payload: third,
+			gate:    "north",
+			want:    models.CheckinResultRevoked,
+		},
+		{
+			name:    "forged - not signed by us",
+			payload: "forged-payload",
+			gate:    "south",
+			want:    models.CheckinResultForged,
+		},
+	}
+	for _, step := range steps {
+		if step.action != nil {
+			if err := step.action(); err != nil {
+				t.Fatalf("%s: action error = %v", step.name, err)
+			}
+		}
+
+		got, err := rc.Check(context.TODO(), &models.CheckinRequest{Payload: step.payload, Gate: step.gate, ScannedBy: "gate-1"})
+		if err != nil {
+			t.Fatalf("%s: CheckinUC.Check() error = %v", step.name, err)
+		}
+		if got.Result != step.want {
+			t.Errorf("%s: result = %s, want %s", step.name, got.Result, step.want)
+		}
+		if step.wantFirst != "" {
+			if got.Credential == nil || got.Credential.UsedGate != step.wantFirst || !got.Credential.UsedAt.Equal(firstScan) {
+				t.Errorf("%s: first scan = %+v, want %s at %v", step.name, got.Credential, step.wantFirst, firstScan)
+			}
+		}
+	}
+
+	// both seats left got in, neither can be given back
+	if _, err := purchaseUC.Cancel(context.TODO(), "1", &models.CancelPurchaseRequest{}); !errors.Is(err, pkg.ErrSeatsCheckedIn) {
+		t.Errorf("PurchaseUC.Cancel() of checked in seats error = %v, want %v", err, pkg.ErrSeatsCheckedIn)
+	}
+
+	stats, err := rc.Stats(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("CheckinUC.Stats() error = %v", err)
+	}
+	want := models.CheckinStats{TicketID: 1, Sold: 2, CheckedIn: 2, Gates: []models.GateCount{{Gate: "north", CheckedIn: 2}}}
+	if stats.TicketID != want.TicketID || stats.Sold != want.Sold || stats.CheckedIn != want.CheckedIn ||
+		len(stats.Gates) != 1 || stats.Gates[0] != want.Gates[0] {
+		t.Errorf("CheckinUC.Stats() = %+v, want %+v", *stats, want)
+	}
+}
+
+func TestCheckinUC_Sync(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("CheckinUC.Sync() clearTable error = %v", err)
+		}
+	}()
+
+	if err := addTempData(&models.Ticket{ID: 1, Name: "hamilton", Description: "hamilton musical", Allocation: 10}); err != nil {
+		t.Fatalf("CheckinUC.Sync() addTempData error = %v", err)
+	}
+
+	clock := newFakeClock()
+	start := clock.Now()
+	rc := newTestCheckinUC(clock)
+	ticketUC := newTestTicketUC(clock)
+	purchaseUC := uc.NewPurchaseUC(
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		newTestPaymentUC(nil),
+		newTestCredentialUC(),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+		0,
+	)
+
+	purchase, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 3})
+	if err != nil {
+		t.Fatalf("TicketUC.Purchase() error = %v", err)
+	}
+	first, second, third := purchase.Credentials[0], purchase.Credentials[1], purchase.Credentials[2]
+
+	// the first seat gets in online at the north gate while the south scanner is offline
+	clock.Advance(10 * time.Minute)
+	if _, err := rc.Check(context.TODO(), &models.CheckinRequest{Payload: first.Payload, Gate: "north", ScannedBy: "gate-1"}); err != nil {
+		t.Fatalf("CheckinUC.Check() error = %v", err)
+	}
+
+	signed, err := rc.Snapshot(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("CheckinUC.Snapshot() error = %v", err)
+	}
+	message, err := base64.RawURLEncoding.DecodeString(signed.Snapshot)
+	if err != nil {
+		t.Fatalf("CheckinUC.Snapshot() decode error = %v", err)
+	}
+	if err := pkg.VerifyMessage(newTestCredentialSigner().PublicKey(), message, signed.Signature); err != nil {
+		t.Fatalf("VerifyMessage() error = %v", err)
+	}
+	var snapshot models.ScannerSnapshot
+	if err := json.Unmarshal(message, &snapshot); err != nil {
+		t.Fatalf("CheckinUC.Snapshot() unmarshal error = %v", err)
+	}
+	if len(snapshot.Credentials) != 3 || snapshot.Credentials[0].UsedGate != "north" || snapshot.Credentials[1].UsedAt != nil {
+		t.Errorf("CheckinUC.Snapshot() = %+v, want 3 credentials with the first used at north", snapshot)
+	}
+	if err := pkg.VerifyMessage(newTestCredentialSigner().PublicKey(), append(message, ' '), signed.Signature); err == nil {
+		t.Errorf("VerifyMessage() of a tampered snapshot succeeded")
+	}
+
+	// the third seat is refunded before the scanner comes back online
+	if _, err := purchaseUC.Cancel(context.TODO(), "1", &models.CancelPurchaseRequest{Quantity: 1}); err != nil {
+		t.Fatalf("PurchaseUC.Cancel() error = %v", err)
+	}
+
+	request := models.ScannerSyncRequest{
+		DeviceID:  "scanner-south",
+		ScannedBy: "gate-2",
+		Scans: []models.OfflineScan{
+			{Payload: first.Payload, Gate: "south", ScannedAt: start.Add(5 * time.Minute)},
+			{Payload: second.Payload, Gate: "south", ScannedAt: start.Add(time.Minute)},
+			{Payload: second.Payload, Gate: "east", ScannedAt: start.Add(time.Minute)},
+			{Payload: "forged-payload", Gate: "south", ScannedAt: start.Add(2 * time.Minute)},
+			{Payload: third.Payload, Gate: "south", ScannedAt: start.Add(3 * time.Minute)},
+		},
+	}
+	wantResults := []models.CheckinResult{
+		models.CheckinResultValid,
+		models.CheckinResultAlreadyUsed,
+		models.CheckinResultValid,
+		models.CheckinResultForged,
+		models.CheckinResultRevoked,
+	}
+
+	got, err := rc.Sync(context.TODO(), "1", &request)
+	if err != nil {
+		t.Fatalf("CheckinUC.Sync() error = %v", err)
+	}
+	for i, checkin := range got.Checkins {
+		if checkin.Result != wantResults[i] {
+			t.Errorf("CheckinUC.Sync() scan %d result = %s, want %s", i, checkin.Result, wantResults[i])
+		}
+	}
+
+	// the offline scan came first, so it wins over the online one
+	wantConflicts := []models.ScanConflict{
+		{
+			CredentialID: first.ID,
+			Gate:         "south",
+			ScannedAt:    start.Add(5 * time.Minute),
+			Rejected:     []models.RejectedScan{{Gate: "north", ScannedAt: start.Add(10 * time.Minute)}},
+		},
+		{
+			CredentialID: second.ID,
+			Gate:         "east",
+			ScannedAt:    start.Add(time.Minute),
+			Rejected:     []models.RejectedScan{{Gate: "south", DeviceID: "scanner-south", ScannedAt: start.Add(time.Minute)}},
+		},
+	}
+	if len(got.Conflicts) != len(wantConflicts) {
+		t.Fatalf("CheckinUC.Sync() conflicts = %+v, want %+v", got.Conflicts, wantConflicts)
+	}
+	for i, conflict := range got.Conflicts {
+		want := wantConflicts[i]
+		if conflict.CredentialID != want.CredentialID || conflict.Gate != want.Gate || !conflict.ScannedAt.Equal(want.ScannedAt) ||
+			len(conflict.Rejected) != 1 || conflict.Rejected[0].Gate != want.Rejected[0].Gate ||
+			conflict.Rejected[0].DeviceID != want.Rejected[0].DeviceID || !conflict.Rejected[0].ScannedAt.Equal(want.Rejected[0].ScannedAt) {
+			t.Errorf("CheckinUC.Sync() conflict %d = %+v, want %+v", i, conflict, want)
+		}
+	}
+
+	// uploading the same batch again gives the same verdicts
+	again, err := rc.Sync(context.TODO(), "1", &request)
+	if err != nil {
+		t.Fatalf("CheckinUC.Sync() again error = %v", err)
+	}
+	for i, checkin := range again.Checkins {
+		if checkin.Result != wantResults[i] {
+			t.Errorf("CheckinUC.Sync() again scan %d result = %s, want %s", i, checkin.Result, wantResults[i])
+		}
+	}
+
+	stats, err := rc.Stats(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("CheckinUC.Stats() error = %v", err)
+	}
+	if stats.CheckedIn != 2 || len(stats.Gates) != 2 || stats.Gates[0].Gate != "east" || stats.Gates[1].Gate != "south" {
+		t.Errorf("CheckinUC.Stats() = %+v, want one check-in at east and one at south", *stats)
+	}
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+const testPendingLease = 5 * time.Minute
+
+func newTestCheckoutUC(clock pkg.Clock, paymentUC *uc.PaymentUC) *uc.CheckoutUC {
+	return uc.NewCheckoutUC(
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewHoldRepository(test_db),
+		repositories.NewWaitlistRepository(test_db),
+		repositories.NewListingRepository(test_db),
+		repositories.NewPromoCodeRepository(test_db),
+		repositories.NewTxManager(test_db),
+		paymentUC,
+		newTestCredentialUC(),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testPendingLease,
+	)
+}
+
+func TestCheckoutUC_Recover(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("CheckoutUC.Recover() clearTable error = %v", err)
+		}
+	}()
+
+	clock := newFakeClock()
+	provider := pkg.NewFakePaymentProvider(pkg.FakePaymentConfig{WebhookSecret: testWebhookSecret})
+	rc := newTestCheckoutUC(clock, newTestPaymentUC(provider))
+
+	ticket := models.Ticket{ID: 1, Name: "heat", Description: "heat", Allocation: 2, Price: 1000, Currency: "EUR"}
+	if err := addTempData(&ticket); err != nil {
+		t.Fatalf("CheckoutUC.Recover() addTempData error = %v", err)
+	}
+
+	captured, err := provider.Authorize(context.TODO(), pkg.PaymentRequest{Amount: 1000, Currency: "EUR"})
+	if err != nil {
+		t.Fatalf("FakePaymentProvider.Authorize() error = %v", err)
+	}
+	if err := provider.Capture(context.TODO(), captured); err != nil {
+		t.Fatalf("FakePaymentProvider.Capture() error = %v", err)
+	}
+	authorized, err := provider.Authorize(context.TODO(), pkg.PaymentRequest{Amount: 1000, Currency: "EUR"})
+	if err != nil {
+		t.Fatalf("FakePaymentProvider.Authorize() error = %v", err)
+	}
+
+	// the instance paying these purchases stopped before it settled them, the last one is still within its lease
+	stale := clock.Now().Add(-2 * testPendingLease)
+	purchases := []models.Purchase{
+		{ID: 1, PaymentID: captured, PaymentStatus: models.PaymentStatusCaptured, CreatedAt: stale},
+		{ID: 2, PaymentID: authorized, PaymentStatus: models.PaymentStatusAuthorized, CreatedAt: stale},
+		{ID: 3, CreatedAt: stale},
+		{ID: 4, CreatedAt: clock.Now().Add(-time.Minute)},
+	}
+	for i := range purchases {
+		purchase := &purchases[i]
+		purchase.TicketID, purchase.UserID, purchase.Quantity, purchase.Status = 1, "alice", 1, models.PurchaseStatusPending
+		purchase.UnitPrice, purchase.Currency, purchase.Subtotal, purchase.Total = 1000, "EUR", 1000, 1000
+		hold := models.Hold{TicketID: 1, UserID: "alice", Quantity: 1, Status: models.HoldStatusPaying, UnitPrice: 1000, Currency: "EUR", PurchaseID: purchase.ID, ExpiresAt: clock.Now().Add(10 * time.Minute)}
+		if err := addTempData(purchase); err != nil {
+			t.Fatalf("CheckoutUC.Recover() addTempData error = %v", err)
+		}
+		if err := addTempData(&hold); err != nil {
+			t.Fatalf("CheckoutUC.Recover() addTempData error = %v", err)
+		}
+	}
+
+	for _, want := range []int{3, 0} {
+		settled, err := rc.Recover(context.TODO())
+		if err != nil {
+			t.Fatalf("CheckoutUC.Recover() error = %v", err)
+		}
+		if settled != want {
+			t.Errorf("CheckoutUC.Recover() = %d, want %d", settled, want)
+		}
+	}
+
+	tests := []struct {
+		purchaseID     int64
+		wantStatus     models.PurchaseStatus
+		wantHoldStatus models.HoldStatus
+	}{
+		{purchaseID: 1, wantStatus: models.PurchaseStatusCompleted, wantHoldStatus: models.HoldStatusConfirmed},
+		{purchaseID: 2, wantStatus: models.PurchaseStatusFailed, wantHoldStatus: models.HoldStatusActive},
+		{purchaseID: 3, wantStatus: models.PurchaseStatusFailed, wantHoldStatus: models.HoldStatusActive},
+		{purchaseID: 4, wantStatus: models.PurchaseStatusPending, wantHoldStatus: models.HoldStatusPaying},
+	}
+	for _, tt := range tests {
+		purchase := new(models.Purchase)
+		if err := test_db.Model(purchase).Where("id = ?", tt.purchaseID).Select(); err != nil {
+			t.Fatalf("CheckoutUC.Recover() select purchase error = %v", err)
+		}
+		if purchase.Status != tt.wantStatus {
+			t.Errorf("CheckoutUC.Recover() purchase %d status = %q, want %q", tt.purchaseID, purchase.Status, tt.wantStatus)
+		}
+
+		hold := new(models.Hold)
+		if err := test_db.Model(hold).Where("id = ?", tt.purchaseID).Select(); err != nil {
+			t.Fatalf("CheckoutUC.Recover() select hold error = %v", err)
+		}
+		if hold.Status != tt.wantHoldStatus {
+			t.Errorf("CheckoutUC.Recover() hold %d status = %q, want %q", tt.purchaseID, hold.Status, tt.wantHoldStatus)
+		}
+	}
+
+	if payment, _ := provider.Payment(authorized); !payment.Released {
+		t.Errorf("CheckoutUC.Recover() gateway payment = %+v, want the authorization released", payment)
+	}
+}
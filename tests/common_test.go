@@ -1,6 +1,9 @@
 package tests
 
 import (
+	"sync"
+	"time"
+
 	"github.com/go-pg/pg"
 	_ "github.com/lib/pq"
 )
@@ -20,10 +23,34 @@ func addTempData(data interface{}) error {
 }
 
 func clearTable() error {
-	_, err := test_db.Exec("TRUNCATE tickets; DELETE FROM tickets")
+	_, err := test_db.Exec("TRUNCATE tickets, purchases, idempotency_keys, holds, api_keys, waitlist_entries, promo_codes, ticket_tiers, credentials, checkins, transfers, transfer_events, listings, webhook_subscriptions, webhook_deliveries, outbox_events RESTART IDENTITY")
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// fakeClock is a pkg.Clock whose time only moves when the test advances it.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (rc *fakeClock) Now() time.Time {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return rc.now
+}
+
+func (rc *fakeClock) Advance(d time.Duration) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.now = rc.now.Add(d)
+}
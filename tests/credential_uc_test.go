@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+// testCredentialSeed is the base64 Ed25519 seed test credentials are signed with.
+var testCredentialSeed = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+
+func newTestCredentialSigner() *pkg.CredentialSigner {
+	signer, err := pkg.NewCredentialSigner(testCredentialSeed)
+	if err != nil {
+		panic(err)
+	}
+
+	return signer
+}
+
+func newTestCredentialUC() *uc.CredentialUC {
+	return uc.NewCredentialUC(repositories.NewCredentialRepository(test_db), newTestCredentialSigner(), newFakeClock())
+}
+
+func TestCredentialSigner_Verify(t *testing.T) {
+	signer := newTestCredentialSigner()
+	claims := pkg.CredentialClaims{CredentialID: 42, PurchaseID: 7, TicketID: 3, Seat: 2}
+	payload := signer.Sign(claims)
+
+	otherSeed := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{8}, ed25519.SeedSize))
+	other, err := pkg.NewCredentialSigner(otherSeed)
+	if err != nil {
+		t.Fatalf("NewCredentialSigner() error = %v", err)
+	}
+
+	// flipping a bit of the seat index must break the signature
+	raw, _ := base64.RawURLEncoding.DecodeString(payload)
+	raw[26] ^= 1
+	tampered := base64.RawURLEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name    string
+		payload string
+		want    *pkg.CredentialClaims
+		wantErr error
+	}{
+		{
+			name:    "success - signed by the key",
+			payload: payload,
+			want:    &claims,
+		},
+		{
+			name:    "error - tampered claims",
+			payload: tampered,
+			wantErr: pkg.ErrForgedCredential,
+		},
+		{
+			name:    "error - signed by another key",
+			payload: other.Sign(claims),
+			wantErr: pkg.ErrForgedCredential,
+		},
+		{
+			name:    "error - not a credential",
+			payload: "hello",
+			wantErr: pkg.ErrForgedCredential,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// scanners only have the public key
+			got, err := pkg.VerifyCredential(signer.PublicKey(), tt.payload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyCredential() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.want != nil && *got != *tt.want {
+				t.Errorf("VerifyCredential() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+
+	if _, err := pkg.NewCredentialSigner("c2hvcnQ="); err == nil {
+		t.Errorf("NewCredentialSigner() with a short seed succeeded")
+	}
+	if _, err := pkg.NewCredentialSigner(""); err == nil {
+		t.Errorf("NewCredentialSigner() without a seed succeeded")
+	}
+}
+
+func TestCredentialUC_Lifecycle(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("CredentialUC.Lifecycle() clearTable error = %v", err)
+		}
+	}()
+
+	if err := addTempData(&models.Ticket{ID: 1, Name: "wicked", Description: "wicked musical", Allocation: 10}); err != nil {
+		t.Fatalf("CredentialUC.Lifecycle() addTempData error = %v", err)
+	}
+
+	clock := newFakeClock()
+	rc := newTestCredentialUC()
+	ticketUC := newTestTicketUC(clock)
+	purchaseUC := uc.NewPurchaseUC(
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		newTestPaymentUC(nil),
+		rc,
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+		0,
+	)
+
+	purchase, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 3})
+	if err != nil {
+		t.Fatalf("TicketUC.Purchase() error = %v", err)
+	}
+	for i, credential := range purchase.Credentials {
+		claims, err := pkg.VerifyCredential(rc.PublicKey(), credential.Payload)
+		if err != nil {
+			t.Fatalf("VerifyCredential() seat %d error = %v", i+1, err)
+		}
+		want := pkg.CredentialClaims{CredentialID: credential.ID, PurchaseID: purchase.ID, TicketID: 1, Seat: i + 1}
+		if *claims != want {
+			t.Errorf("VerifyCredential() seat %d = %+v, want %+v", i+1, *claims, want)
+		}
+	}
+
+	got, err := purchaseUC.Cancel(context.TODO(), "1", &models.CancelPurchaseRequest{Quantity: 1})
+	if err != nil {
+		t.Fatalf("PurchaseUC.Cancel() error = %v", err)
+	}
+	wantStatuses := []models.CredentialStatus{models.CredentialStatusValid, models.CredentialStatusValid, models.CredentialStatusRevoked}
+	if len(got.Credentials) != len(wantStatuses) {
+		t.Fatalf("PurchaseUC.Cancel() credentials = %d, want %d", len(got.Credentials), len(wantStatuses))
+	}
+	for i, credential := range got.Credentials {
+		if credential.Status != wantStatuses[i] {
+			t.Errorf("PurchaseUC.Cancel() seat %d status = %s, want %s", i+1, credential.Status, wantStatuses[i])
+		}
+		// the payload doesn't change, so revocation is checked at the door
+		if credential.Payload != purchase.Credentials[i].Payload {
+			t.Errorf("PurchaseUC.Cancel() seat %d payload changed", i+1)
+		}
+	}
+
+	valid, err := rc.GetByID(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("CredentialUC.GetByID() error = %v", err)
+	}
+	png, err := rc.QR(valid)
+	if err != nil {
+		t.Fatalf("CredentialUC.QR() error = %v", err)
+	}
+	if !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Errorf("CredentialUC.QR() is not a PNG")
+	}
+
+	revoked, err := rc.GetByID(context.TODO(), "3")
+	if err != nil {
+		t.Fatalf("CredentialUC.GetByID() error = %v", err)
+	}
+	var pe *pkg.Error
+	if _, err := rc.QR(revoked); !errors.As(err, &pe) || pe.StatusCode() != http.StatusGone {
+		t.Errorf("CredentialUC.QR() of a revoked credential error = %v, want status %d", err, http.StatusGone)
+	}
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+func newTestHoldUC(clock pkg.Clock) *uc.HoldUC {
+	return uc.NewHoldUC(
+		repositories.NewHoldRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		newTestCheckoutUC(clock, newTestPaymentUC(nil)),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+	)
+}
+
+func TestHoldUC_Confirm(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	type args struct {
+		ctx     context.Context
+		request *models.ConfirmHoldRequest
+	}
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		args    args
+		want    *models.Purchase
+		wantErr bool
+	}{
+		{
+			name:    "success - hold confirmed before expiry",
+			elapsed: 9 * time.Minute,
+			args: args{
+				ctx:     context.TODO(),
+				request: &models.ConfirmHoldRequest{UserID: "344b6d2d-599a-4b23-b358-8f26512079a9"},
+			},
+			want: &models.Purchase{
+				ID:       1,
+				TicketID: 1,
+				UserID:   "344b6d2d-599a-4b23-b358-8f26512079a9",
+				Quantity: 4,
+				Status:   models.PurchaseStatusCompleted,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "error - hold expired",
+			elapsed: 10 * time.Minute,
+			args: args{
+				ctx:     context.TODO(),
+				request: &models.ConfirmHoldRequest{UserID: "344b6d2d-599a-4b23-b358-8f26512079a9"},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "error - hold belongs to another user",
+			elapsed: time.Minute,
+			args: args{
+				ctx:     context.TODO(),
+				request: &models.ConfirmHoldRequest{UserID: "someone-else"},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := models.Ticket{ID: 1, Name: "inception", Description: "inception dream", Allocation: 10}
+			if err := addTempData(&ticket); err != nil {
+				t.Errorf("HoldUC.Confirm() addTempData error = %v", err)
+				return
+			}
+			clock := newFakeClock()
+			rc := newTestHoldUC(clock)
+			_, err := rc.Create(tt.args.ctx, "1", &models.HoldRequest{
+				UserID:   "344b6d2d-599a-4b23-b358-8f26512079a9",
+				Quantity: 4,
+				Minutes:  10,
+			})
+			if err != nil {
+				t.Errorf("HoldUC.Create() error = %v", err)
+				return
+			}
+			clock.Advance(tt.elapsed)
+
+			got, err := rc.Confirm(tt.args.ctx, "1", tt.args.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HoldUC.Confirm() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != nil {
+				if len(got.Credentials) != got.Quantity {
+					t.Errorf("HoldUC.Confirm() credentials = %d, want %d", len(got.Credentials), got.Quantity)
+				}
+				got.CreatedAt, got.UpdatedAt, got.Credentials = time.Time{}, time.Time{}, nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HoldUC.Confirm() = %v, want %v", got, tt.want)
+			}
+			if err := clearTable(); err != nil {
+				t.Errorf("HoldUC.Confirm() clearTable error = %v", err)
+				return
+			}
+		})
+	}
+}
+
+func TestHoldUC_ExpireHolds(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	ticket := models.Ticket{ID: 1, Name: "tenet", Description: "tenet inverted", Allocation: 10}
+	if err := addTempData(&ticket); err != nil {
+		t.Fatalf("HoldUC.ExpireHolds() addTempData error = %v", err)
+	}
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("HoldUC.ExpireHolds() clearTable error = %v", err)
+		}
+	}()
+
+	clock := newFakeClock()
+	rc := newTestHoldUC(clock)
+	for _, minutes := range []int{5, 15} {
+		_, err := rc.Create(context.TODO(), "1", &models.HoldRequest{UserID: "alice", Quantity: 3, Minutes: minutes})
+		if err != nil {
+			t.Fatalf("HoldUC.Create() error = %v", err)
+		}
+	}
+
+	steps := []struct {
+		elapsed        time.Duration
+		wantExpired    int
+		wantAllocation int
+	}{
+		{elapsed: 4 * time.Minute, wantExpired: 0, wantAllocation: 4},
+		{elapsed: time.Minute, wantExpired: 1, wantAllocation: 7},
+		{elapsed: 5 * time.Minute, wantExpired: 0, wantAllocation: 7},
+		{elapsed: 5 * time.Minute, wantExpired: 1, wantAllocation: 10},
+	}
+	for _, step := range steps {
+		clock.Advance(step.elapsed)
+
+		expired, err := rc.ExpireHolds(context.TODO())
+		if err != nil {
+			t.Fatalf("HoldUC.ExpireHolds() error = %v", err)
+		}
+		if expired != step.wantExpired {
+			t.Errorf("HoldUC.ExpireHolds() at %v = %d, want %d", clock.Now(), expired, step.wantExpired)
+		}
+
+		got, err := repositories.NewTicketRepository(test_db).GetByID(context.TODO(), "1")
+		if err != nil {
+			t.Fatalf("TicketRepository.GetByID() error = %v", err)
+		}
+		if got.Allocation != step.wantAllocation {
+			t.Errorf("HoldUC.ExpireHolds() at %v allocation = %d, want %d", clock.Now(), got.Allocation, step.wantAllocation)
+		}
+	}
+}
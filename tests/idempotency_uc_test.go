@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+func TestIdempotencyUC_Begin(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	testIdempotencyRepo := repositories.NewIdempotencyRepository(test_db)
+	clock := newFakeClock()
+	live, expired := clock.Now().Add(time.Hour), clock.Now().Add(-time.Second)
+	type args struct {
+		ctx         context.Context
+		key         string
+		fingerprint string
+	}
+	type tempDatas struct {
+		keys []models.IdempotencyKey
+	}
+	tests := []struct {
+		name       string
+		tempDatas  tempDatas
+		args       args
+		want       *models.IdempotencyKey
+		wantStatus int
+	}{
+		{
+			name: "success - new key is reserved",
+			args: args{
+				ctx:         context.TODO(),
+				key:         "1b9d6bcd",
+				fingerprint: "purchase-1",
+			},
+			want: nil,
+		},
+		{
+			name: "success - completed key is replayed",
+			tempDatas: tempDatas{
+				keys: []models.IdempotencyKey{
+					{
+						Key:          "1b9d6bcd",
+						Fingerprint:  "purchase-1",
+						Status:       models.IdempotencyStatusCompleted,
+						StatusCode:   http.StatusCreated,
+						ResponseBody: []byte(`{"id":1}`),
+						ExpiresAt:    live,
+					},
+				},
+			},
+			args: args{
+				ctx:         context.TODO(),
+				key:         "1b9d6bcd",
+				fingerprint: "purchase-1",
+			},
+			want: &models.IdempotencyKey{
+				Key:          "1b9d6bcd",
+				Fingerprint:  "purchase-1",
+				Status:       models.IdempotencyStatusCompleted,
+				StatusCode:   http.StatusCreated,
+				ResponseBody: []byte(`{"id":1}`),
+				ExpiresAt:    live,
+			},
+		},
+		{
+			name: "error - key reused with a different request",
+			tempDatas: tempDatas{
+				keys: []models.IdempotencyKey{
+					{
+						Key:          "1b9d6bcd",
+						Fingerprint:  "purchase-1",
+						Status:       models.IdempotencyStatusCompleted,
+						StatusCode:   http.StatusCreated,
+						ResponseBody: []byte(`{"id":1}`),
+						ExpiresAt:    live,
+					},
+				},
+			},
+			args: args{
+				ctx:         context.TODO(),
+				key:         "1b9d6bcd",
+				fingerprint: "purchase-2",
+			},
+			want:       nil,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "error - key still in progress",
+			tempDatas: tempDatas{
+				keys: []models.IdempotencyKey{
+					{
+						Key:         "1b9d6bcd",
+						Fingerprint: "purchase-1",
+						Status:      models.IdempotencyStatusProcessing,
+						ExpiresAt:   live,
+					},
+				},
+			},
+			args: args{
+				ctx:         context.TODO(),
+				key:         "1b9d6bcd",
+				fingerprint: "purchase-1",
+			},
+			want:       nil,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "success - key of a request that died is taken over after its lease",
+			tempDatas: tempDatas{
+				keys: []models.IdempotencyKey{
+					{
+						Key:         "1b9d6bcd",
+						Fingerprint: "purchase-1",
+						Status:      models.IdempotencyStatusProcessing,
+						ExpiresAt:   expired,
+					},
+				},
+			},
+			args: args{
+				ctx:         context.TODO(),
+				key:         "1b9d6bcd",
+				fingerprint: "purchase-1",
+			},
+			want: nil,
+		},
+		{
+			name: "success - expired response is not replayed",
+			tempDatas: tempDatas{
+				keys: []models.IdempotencyKey{
+					{
+						Key:          "1b9d6bcd",
+						Fingerprint:  "purchase-1",
+						Status:       models.IdempotencyStatusCompleted,
+						StatusCode:   http.StatusCreated,
+						ResponseBody: []byte(`{"id":1}`),
+						ExpiresAt:    expired,
+					},
+				},
+			},
+			args: args{
+				ctx:         context.TODO(),
+				key:         "1b9d6bcd",
+				fingerprint: "purchase-2",
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, v := range tt.tempDatas.keys {
+				if err := addTempData(&v); err != nil {
+					t.Errorf("IdempotencyUC.Begin() addTempData error = %v", err)
+					return
+				}
+			}
+			rc := uc.NewIdempotencyUC(testIdempotencyRepo, clock, time.Minute, time.Hour)
+			got, err := rc.Begin(tt.args.ctx, tt.args.key, tt.args.fingerprint)
+			var pe *pkg.Error
+			if errors.As(err, &pe) {
+				if pe.StatusCode() != tt.wantStatus {
+					t.Errorf("IdempotencyUC.Begin() status = %v, wantStatus %v", pe.StatusCode(), tt.wantStatus)
+				}
+			} else if err != nil || tt.wantStatus != 0 {
+				t.Errorf("IdempotencyUC.Begin() error = %v, wantStatus %v", err, tt.wantStatus)
+			}
+			if got != nil {
+				got.CreatedAt, got.UpdatedAt, got.ExpiresAt = time.Time{}, time.Time{}, got.ExpiresAt.UTC()
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IdempotencyUC.Begin() = %v, want %v", got, tt.want)
+			}
+			if err := clearTable(); err != nil {
+				t.Errorf("IdempotencyUC.Begin() clearTable error = %v", err)
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,387 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+// testResalePriceCap is the highest asking price of test listings in percent of the face value.
+const testResalePriceCap = 110
+
+func newTestListingUC(clock pkg.Clock) *uc.ListingUC {
+	return uc.NewListingUC(
+		repositories.NewListingRepository(test_db),
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewHoldRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestCheckoutUC(clock, newTestPaymentUC(nil)),
+		newTestCredentialUC(),
+		clock,
+		testTicketValidator,
+		testResalePriceCap,
+	)
+}
+
+func TestListingUC_Buy(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("ListingUC.Buy() clearTable error = %v", err)
+		}
+	}()
+
+	clock := newFakeClock()
+	ticket := models.Ticket{ID: 1, Name: "les miserables", Description: "les miserables musical", Allocation: 10, Price: 1000, Currency: "USD", SalesEnd: clock.Now().Add(24 * time.Hour)}
+	if err := addTempData(&ticket); err != nil {
+		t.Fatalf("ListingUC.Buy() addTempData error = %v", err)
+	}
+
+	rc := newTestListingUC(clock)
+	ticketUC := newTestTicketUC(clock)
+	credentialUC := newTestCredentialUC()
+
+	purchase, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 2})
+	if err != nil {
+		t.Fatalf("TicketUC.Purchase() error = %v", err)
+	}
+	credentials, err := credentialUC.ListByPurchaseID(context.TODO(), purchase.ID)
+	if err != nil || len(credentials) != 2 {
+		t.Fatalf("CredentialUC.ListByPurchaseID() = %d credentials, error = %v", len(credentials), err)
+	}
+	first, second := credentials[0].ID, credentials[1].ID
+
+	create := func(sellerID string, credentialID, price int64) func() error {
+		return func() error {
+			_, err := rc.Create(context.TODO(), &models.CreateListingRequest{CredentialID: credentialID, Price: price, SellerID: sellerID})
+			return err
+		}
+	}
+	buy := func(listingID, buyerID string) func() error {
+		return func() error {
+			_, err := rc.Buy(context.TODO(), listingID, &models.BuyListingRequest{BuyerID: buyerID})
+			return err
+		}
+	}
+
+	steps := []struct {
+		name    string
+		action  func() error
+		wantErr bool
+	}{
+		{
+			name:    "error - price above the cap",
+			action:  create("alice", first, 1101),
+			wantErr: true,
+		},
+		{
+			name:    "error - only the owner can list",
+			action:  create("mallory", first, 1000),
+			wantErr: true,
+		},
+		{
+			name:   "success - listed at the cap",
+			action: create("alice", first, 1100),
+		},
+		{
+			name:    "error - seat listed twice",
+			action:  create("alice", first, 900),
+			wantErr: true,
+		},
+		{
+			name:   "success - second seat listed cheaper",
+			action: create("alice", second, 800),
+		},
+		{
+			name:    "error - seller buys their own listing",
+			action:  buy("1", "alice"),
+			wantErr: true,
+		},
+		{
+			name:   "success - bob buys",
+			action: buy("1", "bob"),
+		},
+		{
+			name:    "error - relisted above the cap of the original face value",
+			action:  create("bob", 3, 1101),
+			wantErr: true,
+		},
+		{
+			name:   "success - relisted at the cap of the original face value",
+			action: create("bob", 3, 1100),
+		},
+		{
+			name:    "error - sold twice",
+			action:  buy("1", "carol"),
+			wantErr: true,
+		},
+		{
+			name: "error - resale closed with the sales window",
+			action: func() error {
+				clock.Advance(25 * time.Hour)
+				return buy("2", "carol")()
+			},
+			wantErr: true,
+		},
+	}
+	for _, step := range steps {
+		if err := step.action(); (err != nil) != step.wantErr {
+			t.Fatalf("%s: error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+	}
+
+	// the seat changed hands, the ticket sold no more seats
+	got, err := ticketUC.GetByID(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("TicketUC.GetByID() error = %v", err)
+	}
+	if got.Allocation != 8 {
+		t.Errorf("TicketUC.GetByID() allocation = %d, want 8", got.Allocation)
+	}
+
+	seller, err := repositories.NewPurchaseRepository(test_db).GetByID(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("PurchaseRepository.GetByID() error = %v", err)
+	}
+	if seller.ResoldQuantity != 1 || seller.Remaining() != 1 {
+		t.Errorf("seller purchase resold = %d, remaining = %d, want 1 and 1", seller.ResoldQuantity, seller.Remaining())
+	}
+
+	listing, err := rc.GetByID(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("ListingUC.GetByID() error = %v", err)
+	}
+	if listing.Status != models.ListingStatusSold || listing.BuyerID != "bob" {
+		t.Errorf("listing status = %s, buyer = %s, want sold to bob", listing.Status, listing.BuyerID)
+	}
+
+	relisted, err := rc.GetByID(context.TODO(), "3")
+	if err != nil {
+		t.Fatalf("ListingUC.GetByID() error = %v", err)
+	}
+	if relisted.FaceValue != 1000 {
+		t.Errorf("relisted face value = %d, want 1000", relisted.FaceValue)
+	}
+
+	buyer, err := repositories.NewPurchaseRepository(test_db).GetByID(context.TODO(), "2")
+	if err != nil {
+		t.Fatalf("PurchaseRepository.GetByID() error = %v", err)
+	}
+	if buyer.UserID != "bob" || buyer.Quantity != 1 || buyer.Total != 1100 || buyer.ResaleListingID != listing.ID {
+		t.Errorf("buyer purchase = %+v, want one seat for bob at 1100 from listing %d", buyer, listing.ID)
+	}
+
+	sold, err := credentialUC.GetByID(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("CredentialUC.GetByID() error = %v", err)
+	}
+	if sold.Status != models.CredentialStatusRevoked {
+		t.Errorf("seller credential status = %s, want revoked", sold.Status)
+	}
+
+	issued, err := credentialUC.ListByPurchaseID(context.TODO(), buyer.ID)
+	if err != nil {
+		t.Fatalf("CredentialUC.ListByPurchaseID() error = %v", err)
+	}
+	if len(issued) != 1 || issued[0].Status != models.CredentialStatusValid || issued[0].UserID != "bob" {
+		t.Errorf("buyer credentials = %+v, want one valid for bob", issued)
+	}
+}
+
+func TestListingUC_Search(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("ListingUC.Search() clearTable error = %v", err)
+		}
+	}()
+
+	clock := newFakeClock()
+	ticket := models.Ticket{ID: 1, Name: "les miserables", Description: "les miserables musical", Allocation: 10, Price: 1000, Currency: "USD"}
+	if err := addTempData(&ticket); err != nil {
+		t.Fatalf("ListingUC.Search() addTempData error = %v", err)
+	}
+
+	rc := newTestListingUC(clock)
+	ticketUC := newTestTicketUC(clock)
+	credentialUC := newTestCredentialUC()
+
+	purchase, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 3})
+	if err != nil {
+		t.Fatalf("TicketUC.Purchase() error = %v", err)
+	}
+	credentials, err := credentialUC.ListByPurchaseID(context.TODO(), purchase.ID)
+	if err != nil {
+		t.Fatalf("CredentialUC.ListByPurchaseID() error = %v", err)
+	}
+	for i, price := range []int64{1000, 700, 900} {
+		request := models.CreateListingRequest{CredentialID: credentials[i].ID, Price: price, SellerID: "alice"}
+		if _, err := rc.Create(context.TODO(), &request); err != nil {
+			t.Fatalf("ListingUC.Create() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		request    models.ListingSearchRequest
+		wantPrices []int64
+	}{
+		{
+			name:       "cheapest first",
+			request:    models.ListingSearchRequest{TicketID: 1},
+			wantPrices: []int64{700, 900, 1000},
+		},
+		{
+			name:       "below a price",
+			request:    models.ListingSearchRequest{TicketID: 1, MaxPrice: 900},
+			wantPrices: []int64{700, 900},
+		},
+		{
+			name:       "oldest first",
+			request:    models.ListingSearchRequest{Sort: "id"},
+			wantPrices: []int64{1000, 700, 900},
+		},
+		{
+			name:       "other ticket",
+			request:    models.ListingSearchRequest{TicketID: 2},
+			wantPrices: []int64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// pages of one listing walk the whole result through the cursor
+			request := tt.request
+			request.Limit = 1
+			prices := []int64{}
+			for {
+				listings, nextCursor, err := rc.Search(context.TODO(), &request)
+				if err != nil {
+					t.Fatalf("ListingUC.Search() error = %v", err)
+				}
+				for _, listing := range listings {
+					prices = append(prices, listing.Price)
+				}
+				if nextCursor == "" {
+					break
+				}
+				request.Cursor = nextCursor
+			}
+
+			if len(prices) != len(tt.wantPrices) {
+				t.Fatalf("ListingUC.Search() prices = %v, want %v", prices, tt.wantPrices)
+			}
+			for i := range prices {
+				if prices[i] != tt.wantPrices[i] {
+					t.Errorf("ListingUC.Search() prices = %v, want %v", prices, tt.wantPrices)
+					break
+				}
+			}
+		})
+	}
+}
+
+// checkInProvider is a fake gateway that lets the seat of a listing be checked in while its buyer is being charged.
+type checkInProvider struct {
+	*pkg.FakePaymentProvider
+	checkIn func()
+}
+
+func (rc *checkInProvider) Authorize(ctx context.Context, request pkg.PaymentRequest) (string, error) {
+	if rc.checkIn != nil {
+		rc.checkIn()
+	}
+
+	return rc.FakePaymentProvider.Authorize(ctx, request)
+}
+
+func TestListingUC_BuyUsedSeat(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("ListingUC.Buy() clearTable error = %v", err)
+		}
+	}()
+
+	clock := newFakeClock()
+	ticket := models.Ticket{ID: 1, Name: "les miserables", Description: "les miserables musical", Allocation: 10, Price: 1000, Currency: "USD", SalesEnd: clock.Now().Add(24 * time.Hour)}
+	if err := addTempData(&ticket); err != nil {
+		t.Fatalf("ListingUC.Buy() addTempData error = %v", err)
+	}
+
+	provider := &checkInProvider{FakePaymentProvider: pkg.NewFakePaymentProvider(pkg.FakePaymentConfig{WebhookSecret: testWebhookSecret})}
+	paymentUC := uc.NewPaymentUC(provider, repositories.NewPurchaseRepository(test_db), testPaymentTimeout)
+	rc := uc.NewListingUC(
+		repositories.NewListingRepository(test_db),
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewHoldRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestCheckoutUC(clock, paymentUC),
+		newTestCredentialUC(),
+		clock,
+		testTicketValidator,
+		testResalePriceCap,
+	)
+	ticketUC := newTestTicketUC(clock)
+	credentialRepo := repositories.NewCredentialRepository(test_db)
+	purchaseRepo := repositories.NewPurchaseRepository(test_db)
+
+	purchase, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 2})
+	if err != nil {
+		t.Fatalf("TicketUC.Purchase() error = %v", err)
+	}
+	for _, credential := range purchase.Credentials {
+		if _, err := rc.Create(context.TODO(), &models.CreateListingRequest{CredentialID: credential.ID, Price: 1000, SellerID: "alice"}); err != nil {
+			t.Fatalf("ListingUC.Create() error = %v", err)
+		}
+	}
+
+	// a seat checked in after it was listed is not sold, and the buyer is not charged for it
+	if _, _, err := credentialRepo.MarkUsed(context.TODO(), purchase.Credentials[0].ID, "north", clock.Now()); err != nil {
+		t.Fatalf("CredentialRepository.MarkUsed() error = %v", err)
+	}
+	if _, err := rc.Buy(context.TODO(), "1", &models.BuyListingRequest{BuyerID: "bob"}); !errors.Is(err, pkg.ErrListingUnavailable) {
+		t.Fatalf("ListingUC.Buy() error = %v, want %v", err, pkg.ErrListingUnavailable)
+	}
+	if _, err := purchaseRepo.GetByID(context.TODO(), "2"); err == nil {
+		t.Errorf("ListingUC.Buy() created a purchase for a seat that was checked in")
+	}
+
+	// a seat checked in while the buyer is charged is refunded and taken off sale
+	provider.checkIn = func() {
+		if _, _, err := credentialRepo.MarkUsed(context.TODO(), purchase.Credentials[1].ID, "north", clock.Now()); err != nil {
+			t.Errorf("CredentialRepository.MarkUsed() error = %v", err)
+		}
+	}
+	if _, err := rc.Buy(context.TODO(), "2", &models.BuyListingRequest{BuyerID: "bob"}); err == nil {
+		t.Fatalf("ListingUC.Buy() error = nil, want the sale to fail")
+	}
+
+	buyer, err := purchaseRepo.GetByID(context.TODO(), "2")
+	if err != nil {
+		t.Fatalf("PurchaseRepository.GetByID() error = %v", err)
+	}
+	if buyer.Status != models.PurchaseStatusFailed {
+		t.Errorf("buyer purchase status = %s, want %s", buyer.Status, models.PurchaseStatusFailed)
+	}
+
+	listing, err := rc.GetByID(context.TODO(), "2")
+	if err != nil {
+		t.Fatalf("ListingUC.GetByID() error = %v", err)
+	}
+	if listing.Status != models.ListingStatusCancelled {
+		t.Errorf("listing status = %s, want %s", listing.Status, models.ListingStatusCancelled)
+	}
+}
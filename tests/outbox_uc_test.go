@@ -0,0 +1,274 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+const testOutboxBackoff = time.Minute
+
+func newTestOutboxUC(clock pkg.Clock, publisher pkg.EventPublisher) *uc.OutboxUC {
+	return uc.NewOutboxUC(
+		repositories.NewOutboxRepository(test_db),
+		repositories.NewTxManager(test_db),
+		publisher,
+		clock,
+		testOutboxBackoff,
+	)
+}
+
+func TestOutboxUC_Relay(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("OutboxUC.Relay() clearTable error = %v", err)
+		}
+	}()
+
+	clock := newFakeClock()
+	ticketUC := newTestTicketUC(clock)
+	decliningTicketUC := uc.NewTicketUC(
+		repositories.NewTicketRepository(test_db),
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewHoldRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		newTestPromoCodeUC(clock),
+		newTestCheckoutUC(clock, newTestPaymentUC(pkg.NewFakePaymentProvider(pkg.FakePaymentConfig{Decline: pkg.PaymentStepCapture}))),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+	)
+
+	// the first handler sees every attempt, the second one fails the first purchase of ticket 1 once
+	var seen []int64
+	failed := false
+	publisher := pkg.NewInProcessEventPublisher()
+	publisher.Subscribe(func(ctx context.Context, event pkg.Event) error {
+		seen = append(seen, event.ID)
+		return nil
+	})
+	publisher.Subscribe(func(ctx context.Context, event pkg.Event) error {
+		if event.TicketID == 1 && event.Type == models.EventPurchaseCompleted && !failed {
+			failed = true
+			return errors.New("broker unavailable")
+		}
+		return nil
+	})
+	rc := newTestOutboxUC(clock, publisher)
+
+	for _, request := range []models.CreateRequest{
+		{Name: "les miserables", Allocation: 2, Status: models.TicketStatusOnSale},
+		{Name: "cats", Allocation: 5, Status: models.TicketStatusOnSale},
+	} {
+		if _, err := ticketUC.Create(context.TODO(), &request); err != nil {
+			t.Fatalf("TicketUC.Create() error = %v", err)
+		}
+	}
+
+	// a purchase whose payment is declined sold the ticket out while its seats were reserved, it reports no purchase
+	// and the hold sweep returns the seats
+	if _, err := decliningTicketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 2}); !errors.Is(err, pkg.ErrPaymentDeclined) {
+		t.Fatalf("TicketUC.Purchase() error = %v, want %v", err, pkg.ErrPaymentDeclined)
+	}
+	if _, err := newTestHoldUC(clock).ExpireHolds(context.TODO()); err != nil {
+		t.Fatalf("HoldUC.ExpireHolds() error = %v", err)
+	}
+
+	if _, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 2}); err != nil {
+		t.Fatalf("TicketUC.Purchase() error = %v", err)
+	}
+	if _, err := ticketUC.Purchase(context.TODO(), "2", &models.PurchaseRequest{UserID: "bob", Quantity: 1}); err != nil {
+		t.Fatalf("TicketUC.Purchase() error = %v", err)
+	}
+
+	// ticket 1 and 2 created are events 1 and 2, ticket 1 sold out by the declined purchase is 3, ticket 1 sold out
+	// again and purchased are 4 and 5, ticket 2 purchased is 6
+	steps := []struct {
+		name          string
+		advance       time.Duration
+		wantPublished int
+		wantIDs       []int64
+	}{
+		{name: "failed event holds back its ticket only", wantPublished: 5, wantIDs: []int64{1, 2, 3, 4, 6}},
+		{name: "not retried before the backoff", advance: testOutboxBackoff - time.Second, wantPublished: 0, wantIDs: []int64{1, 2, 3, 4, 6}},
+		{name: "ticket catches up in order", advance: time.Second, wantPublished: 1, wantIDs: []int64{1, 2, 3, 4, 6, 5}},
+		{name: "nothing left", wantPublished: 0, wantIDs: []int64{1, 2, 3, 4, 6, 5}},
+	}
+	for _, step := range steps {
+		clock.Advance(step.advance)
+
+		published, err := rc.Relay(context.TODO())
+		if err != nil {
+			t.Fatalf("%s: OutboxUC.Relay() error = %v", step.name, err)
+		}
+		if published != step.wantPublished {
+			t.Errorf("%s: OutboxUC.Relay() = %d, want %d", step.name, published, step.wantPublished)
+		}
+
+		ids := make([]int64, 0)
+		for _, event := range publisher.Published() {
+			ids = append(ids, event.ID)
+		}
+		if !slices.Equal(ids, step.wantIDs) {
+			t.Fatalf("%s: published events = %v, want %v", step.name, ids, step.wantIDs)
+		}
+	}
+
+	// the handlers before the failing one got the failed event twice
+	if want := []int64{1, 2, 3, 4, 5, 6, 5}; !slices.Equal(seen, want) {
+		t.Errorf("seen events = %v, want %v", seen, want)
+	}
+
+	wantTypes := []string{models.EventTicketCreated, models.EventTicketCreated, models.EventTicketSoldOut, models.EventTicketSoldOut, models.EventPurchaseCompleted, models.EventPurchaseCompleted}
+	for i, event := range publisher.Published() {
+		if event.Type != wantTypes[i] {
+			t.Errorf("event %d type = %s, want %s", event.ID, event.Type, wantTypes[i])
+		}
+
+		var data map[string]interface{}
+		if err := json.Unmarshal(event.Payload, &data); err != nil {
+			t.Fatalf("event %d payload error = %v", event.ID, err)
+		}
+		if _, ok := data["credentials"]; ok {
+			t.Errorf("event %d carries credentials", event.ID)
+		}
+	}
+}
+
+func TestOutboxUC_RelayBackingOffTicket(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("OutboxUC.Relay() clearTable error = %v", err)
+		}
+	}()
+
+	// ticket 1 has more held back events than a relay run looks at, ticket 2 wrote its event after them
+	clock := newFakeClock()
+	events := make([]models.OutboxEvent, 0)
+	for i := 0; i < 101; i++ {
+		events = append(events, models.OutboxEvent{TicketID: 1, EventType: models.EventTicketCreated, Payload: "{}", OccurredAt: clock.Now()})
+	}
+	events = append(events, models.OutboxEvent{TicketID: 2, EventType: models.EventTicketCreated, Payload: "{}", OccurredAt: clock.Now()})
+	if err := addTempData(&events); err != nil {
+		t.Fatalf("OutboxUC.Relay() addTempData error = %v", err)
+	}
+
+	failed := false
+	publisher := pkg.NewInProcessEventPublisher()
+	publisher.Subscribe(func(ctx context.Context, event pkg.Event) error {
+		if event.TicketID == 1 && !failed {
+			failed = true
+			return errors.New("broker unavailable")
+		}
+		return nil
+	})
+	rc := newTestOutboxUC(clock, publisher)
+
+	steps := []struct {
+		name          string
+		advance       time.Duration
+		wantPublished int
+	}{
+		{name: "first event of ticket 1 fails", wantPublished: 0},
+		{name: "ticket 2 is not starved while ticket 1 backs off", wantPublished: 1},
+		{name: "ticket 1 catches up a batch at a time", advance: testOutboxBackoff, wantPublished: 100},
+		{name: "rest of ticket 1", wantPublished: 1},
+		{name: "nothing left", wantPublished: 0},
+	}
+	for _, step := range steps {
+		clock.Advance(step.advance)
+
+		published, err := rc.Relay(context.TODO())
+		if err != nil {
+			t.Fatalf("%s: OutboxUC.Relay() error = %v", step.name, err)
+		}
+		if published != step.wantPublished {
+			t.Errorf("%s: OutboxUC.Relay() = %d, want %d", step.name, published, step.wantPublished)
+		}
+	}
+
+	ids := make([]int64, 0)
+	for _, event := range publisher.Published() {
+		ids = append(ids, event.ID)
+	}
+	if len(ids) != 102 || ids[0] != 102 || !slices.IsSorted(ids[1:]) {
+		t.Errorf("published events = %v, want 102 first and then the events of ticket 1 in order", ids)
+	}
+}
+
+func TestInProcessEventPublisher_Publish(t *testing.T) {
+	publisher := pkg.NewInProcessEventPublisher()
+
+	var got []string
+	publisher.Subscribe(func(ctx context.Context, event pkg.Event) error {
+		got = append(got, event.Type)
+		if event.Type == models.EventPurchaseRefunded {
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+
+	if err := publisher.Publish(context.TODO(), &pkg.Event{ID: 1, Type: models.EventTicketCreated, TicketID: 1}); err != nil {
+		t.Fatalf("InProcessEventPublisher.Publish() error = %v", err)
+	}
+	if err := publisher.Publish(context.TODO(), &pkg.Event{ID: 2, Type: models.EventPurchaseRefunded, TicketID: 1}); err == nil {
+		t.Fatalf("InProcessEventPublisher.Publish() of a failing event succeeded")
+	}
+
+	if want := []string{models.EventTicketCreated, models.EventPurchaseRefunded}; !slices.Equal(got, want) {
+		t.Errorf("handled events = %v, want %v", got, want)
+	}
+	published := publisher.Published()
+	if len(published) != 1 || published[0].ID != 1 {
+		t.Errorf("InProcessEventPublisher.Published() = %+v, want event 1", published)
+	}
+}
+
+func TestFanoutEventPublisher_Publish(t *testing.T) {
+	var got []string
+	first, second := pkg.NewInProcessEventPublisher(), pkg.NewInProcessEventPublisher()
+	first.Subscribe(func(ctx context.Context, event pkg.Event) error {
+		got = append(got, "first")
+		return nil
+	})
+	second.Subscribe(func(ctx context.Context, event pkg.Event) error {
+		got = append(got, "second")
+		if event.Type == models.EventPurchaseRefunded {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	})
+	publisher := pkg.NewFanoutEventPublisher(first, second)
+
+	if err := publisher.Publish(context.TODO(), &pkg.Event{ID: 1, Type: models.EventTicketCreated, TicketID: 1}); err != nil {
+		t.Fatalf("FanoutEventPublisher.Publish() error = %v", err)
+	}
+	if err := publisher.Publish(context.TODO(), &pkg.Event{ID: 2, Type: models.EventPurchaseRefunded, TicketID: 1}); err == nil {
+		t.Fatalf("FanoutEventPublisher.Publish() of a failing event succeeded")
+	}
+
+	// every publisher gets the events in order, the ones before a failing publisher keep the failed event
+	if want := []string{"first", "second", "first", "second"}; !slices.Equal(got, want) {
+		t.Errorf("publishers called = %v, want %v", got, want)
+	}
+	if published := first.Published(); len(published) != 2 {
+		t.Errorf("first publisher published %d events, want 2", len(published))
+	}
+	if published := second.Published(); len(published) != 1 || published[0].ID != 1 {
+		t.Errorf("second publisher published %+v, want event 1", published)
+	}
+}
@@ -0,0 +1,447 @@
+package tests
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+const (
+	testWebhookSecret  = "webhook-secret"
+	testPaymentTimeout = 100 * time.Millisecond
+)
+
+// newTestPaymentUC charges through the given fake gateway, or through one that accepts everything when it is nil.
+func newTestPaymentUC(provider *pkg.FakePaymentProvider) *uc.PaymentUC {
+	if provider == nil {
+		provider = pkg.NewFakePaymentProvider(pkg.FakePaymentConfig{WebhookSecret: testWebhookSecret})
+	}
+
+	return uc.NewPaymentUC(provider, repositories.NewPurchaseRepository(test_db), testPaymentTimeout)
+}
+
+func TestTicketUC_PurchaseWithPayment(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	tests := []struct {
+		name    string
+		config  pkg.FakePaymentConfig
+		wantErr error
+		// wantStatus is the status the purchase is settled with
+		wantStatus models.PurchaseStatus
+		// wantAllocation is the allocation of the ticket after the holds are swept, seats of failed payments are released
+		wantAllocation int
+	}{
+		{
+			name:           "success - payment captured",
+			config:         pkg.FakePaymentConfig{},
+			wantErr:        nil,
+			wantStatus:     models.PurchaseStatusCompleted,
+			wantAllocation: 8,
+		},
+		{
+			name:           "error - authorization declined",
+			config:         pkg.FakePaymentConfig{Decline: pkg.PaymentStepAuthorize},
+			wantErr:        pkg.ErrPaymentDeclined,
+			wantStatus:     models.PurchaseStatusFailed,
+			wantAllocation: 10,
+		},
+		{
+			name:           "error - capture declined",
+			config:         pkg.FakePaymentConfig{Decline: pkg.PaymentStepCapture},
+			wantErr:        pkg.ErrPaymentDeclined,
+			wantStatus:     models.PurchaseStatusFailed,
+			wantAllocation: 10,
+		},
+		{
+			name:           "error - capture timed out",
+			config:         pkg.FakePaymentConfig{Timeout: pkg.PaymentStepCapture},
+			wantErr:        pkg.ErrPaymentTimeout,
+			wantStatus:     models.PurchaseStatusFailed,
+			wantAllocation: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := models.Ticket{ID: 1, Name: "dune", Description: "dune part two", Allocation: 10, Price: 1000, Currency: "EUR"}
+			if err := addTempData(&ticket); err != nil {
+				t.Errorf("TicketUC.Purchase() addTempData error = %v", err)
+				return
+			}
+
+			clock := newFakeClock()
+			provider := pkg.NewFakePaymentProvider(tt.config)
+			rc := uc.NewTicketUC(
+				repositories.NewTicketRepository(test_db),
+				repositories.NewPurchaseRepository(test_db),
+				repositories.NewHoldRepository(test_db),
+				repositories.NewTierRepository(test_db),
+				repositories.NewTxManager(test_db),
+				newTestWaitlistUC(clock),
+				newTestPromoCodeUC(clock),
+				newTestCheckoutUC(clock, newTestPaymentUC(provider)),
+				newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+				clock,
+				testTicketValidator,
+			)
+
+			got, err := rc.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 2})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("TicketUC.Purchase() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			payment, ok := provider.Payment("fake_pay_1")
+			if tt.wantErr == nil {
+				if got.PaymentID != "fake_pay_1" || got.PaymentStatus != models.PaymentStatusCaptured {
+					t.Errorf("TicketUC.Purchase() payment = %q %q, want fake_pay_1 captured", got.PaymentID, got.PaymentStatus)
+				}
+				if !ok || !payment.Captured || payment.Amount != 2000 {
+					t.Errorf("TicketUC.Purchase() gateway payment = %+v, want 2000 captured", payment)
+				}
+			} else if ok && !payment.Released {
+				t.Errorf("TicketUC.Purchase() gateway payment = %+v, want the authorization released", payment)
+			}
+
+			purchase, err := repositories.NewPurchaseRepository(test_db).GetByID(context.TODO(), "1")
+			if err != nil {
+				t.Errorf("PurchaseRepository.GetByID() error = %v", err)
+				return
+			}
+			if purchase.Status != tt.wantStatus {
+				t.Errorf("TicketUC.Purchase() status = %q, want %q", purchase.Status, tt.wantStatus)
+			}
+
+			// the seats of a failed purchase go back with the next sweep of its hold
+			if _, err := newTestHoldUC(clock).ExpireHolds(context.TODO()); err != nil {
+				t.Errorf("HoldUC.ExpireHolds() error = %v", err)
+				return
+			}
+
+			stored, err := rc.GetByID(context.TODO(), "1")
+			if err != nil {
+				t.Errorf("TicketUC.GetByID() error = %v", err)
+				return
+			}
+			if stored.Allocation != tt.wantAllocation {
+				t.Errorf("TicketUC.Purchase() allocation = %d, want %d", stored.Allocation, tt.wantAllocation)
+			}
+
+			if err := clearTable(); err != nil {
+				t.Errorf("TicketUC.Purchase() clearTable error = %v", err)
+				return
+			}
+		})
+	}
+}
+
+func TestPaymentUC_Webhooks(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("PaymentUC.HandleWebhook() clearTable error = %v", err)
+		}
+	}()
+
+	ticket := models.Ticket{ID: 1, Name: "dune", Description: "dune part two", Allocation: 10, Price: 1000, Currency: "EUR"}
+	if err := addTempData(&ticket); err != nil {
+		t.Fatalf("PaymentUC.HandleWebhook() addTempData error = %v", err)
+	}
+
+	type webhook struct {
+		payload   []byte
+		signature string
+	}
+	webhooks := make(chan webhook, 10)
+	provider := pkg.NewFakePaymentProvider(pkg.FakePaymentConfig{WebhookDelay: 10 * time.Millisecond, WebhookSecret: testWebhookSecret})
+	provider.OnWebhook(func(payload []byte, signature string) {
+		webhooks <- webhook{payload: payload, signature: signature}
+	})
+	receive := func() webhook {
+		select {
+		case w := <-webhooks:
+			return w
+		case <-time.After(time.Second):
+			t.Fatal("no webhook was sent")
+			return webhook{}
+		}
+	}
+
+	clock := newFakeClock()
+	rc := newTestPaymentUC(provider)
+	ticketUC := uc.NewTicketUC(
+		repositories.NewTicketRepository(test_db),
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewHoldRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		newTestPromoCodeUC(clock),
+		newTestCheckoutUC(clock, rc),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+	)
+	purchaseUC := uc.NewPurchaseUC(
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		rc,
+		newTestCredentialUC(),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+		0,
+	)
+
+	if _, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 2}); err != nil {
+		t.Fatalf("TicketUC.Purchase() error = %v", err)
+	}
+	captured := receive()
+
+	if _, err := purchaseUC.Cancel(context.TODO(), "1", &models.CancelPurchaseRequest{Quantity: 1}); err != nil {
+		t.Fatalf("PurchaseUC.Cancel() error = %v", err)
+	}
+	refunded := receive()
+
+	steps := []struct {
+		name       string
+		webhook    webhook
+		wantErr    error
+		wantStatus models.PaymentStatus
+		// wantRefunded is the refunded amount stored on the purchase after the webhook
+		wantRefunded int64
+	}{
+		{
+			name:         "success - refund webhook",
+			webhook:      refunded,
+			wantStatus:   models.PaymentStatusPartiallyRefunded,
+			wantRefunded: 1000,
+		},
+		{
+			name:         "success - late capture webhook does not undo the refund",
+			webhook:      captured,
+			wantStatus:   models.PaymentStatusPartiallyRefunded,
+			wantRefunded: 1000,
+		},
+		{
+			name:         "error - forged signature",
+			webhook:      webhook{payload: captured.payload, signature: "forged"},
+			wantErr:      pkg.ErrInvalidWebhookSignature,
+			wantStatus:   models.PaymentStatusPartiallyRefunded,
+			wantRefunded: 1000,
+		},
+	}
+	for _, step := range steps {
+		err := rc.HandleWebhook(context.TODO(), step.webhook.payload, step.webhook.signature)
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+
+		got, err := purchaseUC.GetByID(context.TODO(), "1")
+		if err != nil {
+			t.Fatalf("PurchaseUC.GetByID() error = %v", err)
+		}
+		if got.PaymentStatus != step.wantStatus || got.PaymentRefunded != step.wantRefunded {
+			t.Errorf("%s: payment = %q %d, want %q %d", step.name, got.PaymentStatus, got.PaymentRefunded, step.wantStatus, step.wantRefunded)
+		}
+	}
+}
+
+// webhookFirstProvider is a fake gateway whose refund webhook is handled before its refund call returns, as with a
+// provider whose webhook outruns its API response.
+type webhookFirstProvider struct {
+	*pkg.FakePaymentProvider
+	handled chan struct{}
+}
+
+func (rc *webhookFirstProvider) Refund(ctx context.Context, paymentID string, amount int64) (int64, error) {
+	refunded, err := rc.FakePaymentProvider.Refund(ctx, paymentID, amount)
+	if err != nil {
+		return 0, err
+	}
+
+	select {
+	case <-rc.handled:
+		return refunded, nil
+	case <-time.After(time.Second):
+		return 0, errors.New("refund webhook was not handled")
+	}
+}
+
+func TestPaymentUC_RefundAfterWebhook(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("PaymentUC.Refund() clearTable error = %v", err)
+		}
+	}()
+
+	ticket := models.Ticket{ID: 1, Name: "dune", Description: "dune part two", Allocation: 10, Price: 1000, Currency: "EUR"}
+	if err := addTempData(&ticket); err != nil {
+		t.Fatalf("PaymentUC.Refund() addTempData error = %v", err)
+	}
+
+	fake := pkg.NewFakePaymentProvider(pkg.FakePaymentConfig{WebhookSecret: testWebhookSecret})
+	provider := &webhookFirstProvider{FakePaymentProvider: fake, handled: make(chan struct{}, 10)}
+	rc := uc.NewPaymentUC(provider, repositories.NewPurchaseRepository(test_db), testPaymentTimeout)
+	fake.OnWebhook(func(payload []byte, signature string) {
+		if err := rc.HandleWebhook(context.TODO(), payload, signature); err != nil {
+			t.Errorf("PaymentUC.HandleWebhook() error = %v", err)
+		}
+		provider.handled <- struct{}{}
+	})
+
+	clock := newFakeClock()
+	ticketUC := uc.NewTicketUC(
+		repositories.NewTicketRepository(test_db),
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewHoldRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		newTestPromoCodeUC(clock),
+		newTestCheckoutUC(clock, rc),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+	)
+	purchaseUC := uc.NewPurchaseUC(
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		rc,
+		newTestCredentialUC(),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+		0,
+	)
+
+	if _, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 2}); err != nil {
+		t.Fatalf("TicketUC.Purchase() error = %v", err)
+	}
+	// the capture webhook
+	select {
+	case <-provider.handled:
+	case <-time.After(time.Second):
+		t.Fatal("capture webhook was not handled")
+	}
+
+	// every refund webhook is stored before the refund is recorded, neither counts it twice
+	steps := []struct {
+		name         string
+		wantStatus   models.PaymentStatus
+		wantRefunded int64
+	}{
+		{name: "first seat", wantStatus: models.PaymentStatusPartiallyRefunded, wantRefunded: 1000},
+		{name: "second seat", wantStatus: models.PaymentStatusRefunded, wantRefunded: 2000},
+	}
+	for _, step := range steps {
+		if _, err := purchaseUC.Cancel(context.TODO(), "1", &models.CancelPurchaseRequest{Quantity: 1}); err != nil {
+			t.Fatalf("%s: PurchaseUC.Cancel() error = %v", step.name, err)
+		}
+
+		got, err := purchaseUC.GetByID(context.TODO(), "1")
+		if err != nil {
+			t.Fatalf("%s: PurchaseUC.GetByID() error = %v", step.name, err)
+		}
+		if got.PaymentStatus != step.wantStatus || got.PaymentRefunded != step.wantRefunded {
+			t.Errorf("%s: payment = %q %d, want %q %d", step.name, got.PaymentStatus, got.PaymentRefunded, step.wantStatus, step.wantRefunded)
+		}
+	}
+
+	// nothing is owed back, the sweeper leaves the purchase alone
+	refunded, err := rc.RetryRefunds(context.TODO(), time.Now().Add(time.Hour))
+	if err != nil || refunded != 0 {
+		t.Errorf("PaymentUC.RetryRefunds() = %d, error = %v, want 0", refunded, err)
+	}
+	if payment, _ := fake.Payment("fake_pay_1"); payment.Refunded != 2000 {
+		t.Errorf("gateway refunded = %d, want 2000", payment.Refunded)
+	}
+}
+
+func TestFakePaymentProvider(t *testing.T) {
+	provider := pkg.NewFakePaymentProvider(pkg.FakePaymentConfig{WebhookSecret: testWebhookSecret})
+	webhooks := make(chan []byte, 10)
+	signatures := make(chan string, 10)
+	provider.OnWebhook(func(payload []byte, signature string) {
+		webhooks <- payload
+		signatures <- signature
+	})
+
+	paymentID, err := provider.Authorize(context.TODO(), pkg.PaymentRequest{Amount: 2000, Currency: "EUR", Reference: "purchase-1"})
+	if err != nil {
+		t.Fatalf("FakePaymentProvider.Authorize() error = %v", err)
+	}
+	if paymentID != "fake_pay_1" {
+		t.Errorf("FakePaymentProvider.Authorize() = %q, want fake_pay_1", paymentID)
+	}
+	if err := provider.Capture(context.TODO(), paymentID); err != nil {
+		t.Fatalf("FakePaymentProvider.Capture() error = %v", err)
+	}
+	if _, err := provider.Refund(context.TODO(), paymentID, 2500); err == nil {
+		t.Errorf("FakePaymentProvider.Refund() of more than was captured succeeded")
+	}
+	refunded, err := provider.Refund(context.TODO(), paymentID, 500)
+	if err != nil {
+		t.Fatalf("FakePaymentProvider.Refund() error = %v", err)
+	}
+	if refunded != 500 {
+		t.Errorf("FakePaymentProvider.Refund() = %d, want 500", refunded)
+	}
+
+	for _, want := range []pkg.PaymentEvent{
+		{ID: "fake_evt_1", Type: pkg.PaymentEventCaptured, PaymentID: paymentID, Amount: 2000},
+		{ID: "fake_evt_2", Type: pkg.PaymentEventRefunded, PaymentID: paymentID, Amount: 2000, Refunded: 500},
+	} {
+		payload, signature := <-webhooks, <-signatures
+		got, err := provider.VerifyWebhook(payload, signature)
+		if err != nil {
+			t.Fatalf("FakePaymentProvider.VerifyWebhook() error = %v", err)
+		}
+		if *got != want {
+			t.Errorf("FakePaymentProvider.VerifyWebhook() = %+v, want %+v", *got, want)
+		}
+		if _, err := provider.VerifyWebhook(payload, "forged"); !errors.Is(err, pkg.ErrInvalidWebhookSignature) {
+			t.Errorf("FakePaymentProvider.VerifyWebhook() forged error = %v, want %v", err, pkg.ErrInvalidWebhookSignature)
+		}
+	}
+
+	// without a secret even a webhook signed with the empty key is rejected
+	unkeyed := pkg.NewFakePaymentProvider(pkg.FakePaymentConfig{})
+	payload := []byte(`{"id":"fake_evt_9","type":"payment.captured","payment_id":"fake_pay_1","amount":2000}`)
+	mac := hmac.New(sha256.New, nil)
+	mac.Write(payload)
+	if _, err := unkeyed.VerifyWebhook(payload, hex.EncodeToString(mac.Sum(nil))); !errors.Is(err, pkg.ErrInvalidWebhookSignature) {
+		t.Errorf("FakePaymentProvider.VerifyWebhook() without secret error = %v, want %v", err, pkg.ErrInvalidWebhookSignature)
+	}
+
+	declining := pkg.NewFakePaymentProvider(pkg.FakePaymentConfig{Decline: pkg.PaymentStepAuthorize})
+	if _, err := declining.Authorize(context.TODO(), pkg.PaymentRequest{Amount: 100}); !errors.Is(err, pkg.ErrPaymentDeclined) {
+		t.Errorf("FakePaymentProvider.Authorize() error = %v, want %v", err, pkg.ErrPaymentDeclined)
+	}
+
+	timingOut := pkg.NewFakePaymentProvider(pkg.FakePaymentConfig{Timeout: pkg.PaymentStepAuthorize})
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := timingOut.Authorize(ctx, pkg.PaymentRequest{Amount: 100}); !errors.Is(err, pkg.ErrPaymentTimeout) {
+		t.Errorf("FakePaymentProvider.Authorize() error = %v, want %v", err, pkg.ErrPaymentTimeout)
+	}
+}
@@ -0,0 +1,345 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+func newTestPromoCodeUC(clock pkg.Clock) *uc.PromoCodeUC {
+	return uc.NewPromoCodeUC(
+		repositories.NewPromoCodeRepository(test_db),
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTxManager(test_db),
+		clock,
+		testTicketValidator,
+	)
+}
+
+func TestTicketUC_PurchaseWithPromoCode(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	clock := newFakeClock()
+	validUntil := clock.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		promo     models.CreatePromoCodeRequest
+		elapsed   time.Duration
+		purchases []models.PurchaseRequest
+		wantErr   error
+		// wantRedemptions is the redemption count of the code after the purchases
+		wantRedemptions int
+	}{
+		{
+			name:            "success - code is matched case-insensitively",
+			promo:           models.CreatePromoCodeRequest{Code: "SUMMER10", DiscountType: models.DiscountTypePercent, Value: 10},
+			purchases:       []models.PurchaseRequest{{UserID: "alice", Quantity: 1, PromoCode: "summer10"}},
+			wantRedemptions: 1,
+		},
+		{
+			name:            "error - unknown code",
+			promo:           models.CreatePromoCodeRequest{Code: "SUMMER10", DiscountType: models.DiscountTypePercent, Value: 10},
+			purchases:       []models.PurchaseRequest{{UserID: "alice", Quantity: 1, PromoCode: "WINTER10"}},
+			wantErr:         pkg.ErrPromoCodeInvalid,
+			wantRedemptions: 0,
+		},
+		{
+			name:            "error - code has expired",
+			promo:           models.CreatePromoCodeRequest{Code: "SUMMER10", DiscountType: models.DiscountTypeFixed, Value: 500, Currency: "USD", ValidUntil: &validUntil},
+			elapsed:         2 * time.Hour,
+			purchases:       []models.PurchaseRequest{{UserID: "alice", Quantity: 1, PromoCode: "SUMMER10"}},
+			wantErr:         pkg.ErrPromoCodeInvalid,
+			wantRedemptions: 0,
+		},
+		{
+			name:            "success - fixed amount in the ticket's currency",
+			promo:           models.CreatePromoCodeRequest{Code: "SUMMER10", DiscountType: models.DiscountTypeFixed, Value: 500, Currency: "USD"},
+			purchases:       []models.PurchaseRequest{{UserID: "alice", Quantity: 1, PromoCode: "SUMMER10"}},
+			wantRedemptions: 1,
+		},
+		{
+			name:            "error - fixed amount in another currency",
+			promo:           models.CreatePromoCodeRequest{Code: "SUMMER10", DiscountType: models.DiscountTypeFixed, Value: 500, Currency: "JPY"},
+			purchases:       []models.PurchaseRequest{{UserID: "alice", Quantity: 1, PromoCode: "SUMMER10"}},
+			wantErr:         pkg.ErrPromoCodeInvalid,
+			wantRedemptions: 0,
+		},
+		{
+			name:            "error - code is scoped to another ticket",
+			promo:           models.CreatePromoCodeRequest{Code: "SUMMER10", DiscountType: models.DiscountTypePercent, Value: 10, TicketIDs: []int64{2}},
+			purchases:       []models.PurchaseRequest{{UserID: "alice", Quantity: 1, PromoCode: "SUMMER10"}},
+			wantErr:         pkg.ErrPromoCodeInvalid,
+			wantRedemptions: 0,
+		},
+		{
+			name:  "error - per user cap reached",
+			promo: models.CreatePromoCodeRequest{Code: "SUMMER10", DiscountType: models.DiscountTypePercent, Value: 10, MaxPerUser: 1},
+			purchases: []models.PurchaseRequest{
+				{UserID: "alice", Quantity: 1, PromoCode: "SUMMER10"},
+				{UserID: "bob", Quantity: 1, PromoCode: "SUMMER10"},
+				{UserID: "alice", Quantity: 1, PromoCode: "SUMMER10"},
+			},
+			wantErr:         pkg.ErrPromoCodeInvalid,
+			wantRedemptions: 2,
+		},
+		{
+			name:  "error - code is exhausted",
+			promo: models.CreatePromoCodeRequest{Code: "SUMMER10", DiscountType: models.DiscountTypePercent, Value: 10, MaxRedemptions: 1},
+			purchases: []models.PurchaseRequest{
+				{UserID: "alice", Quantity: 1, PromoCode: "SUMMER10"},
+				{UserID: "bob", Quantity: 1, PromoCode: "SUMMER10"},
+			},
+			wantErr:         pkg.ErrPromoCodeExhausted,
+			wantRedemptions: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			if err := addTempData(&models.Ticket{ID: 1, Name: "barbie", Description: "barbie pink", Allocation: 10, Price: 1000, Currency: "USD"}); err != nil {
+				t.Errorf("TicketUC.Purchase() addTempData error = %v", err)
+				return
+			}
+
+			promoCodeUC := newTestPromoCodeUC(clock)
+			promo, err := promoCodeUC.Create(context.TODO(), &tt.promo)
+			if err != nil {
+				t.Errorf("PromoCodeUC.Create() error = %v", err)
+				return
+			}
+			clock.Advance(tt.elapsed)
+
+			rc := newTestTicketUC(clock)
+			for i, request := range tt.purchases {
+				purchase, err := rc.Purchase(context.TODO(), "1", &request)
+				if i < len(tt.purchases)-1 || tt.wantErr == nil {
+					if err != nil {
+						t.Errorf("TicketUC.Purchase() error = %v", err)
+						return
+					}
+					if purchase.PromoCodeID != promo.ID || purchase.PromoCode != "SUMMER10" {
+						t.Errorf("TicketUC.Purchase() promo code = %d %q, want %d %q", purchase.PromoCodeID, purchase.PromoCode, promo.ID, "SUMMER10")
+					}
+					continue
+				}
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("TicketUC.Purchase() error = %v, wantErr %v", err, tt.wantErr)
+					return
+				}
+			}
+
+			got, err := promoCodeUC.GetByID(context.TODO(), "1")
+			if err != nil {
+				t.Errorf("PromoCodeUC.GetByID() error = %v", err)
+				return
+			}
+			if got.Redemptions != tt.wantRedemptions {
+				t.Errorf("PromoCodeUC.GetByID() redemptions = %d, want %d", got.Redemptions, tt.wantRedemptions)
+			}
+
+			// a refused code must not take seats either
+			ticket, err := rc.GetByID(context.TODO(), "1")
+			if err != nil {
+				t.Errorf("TicketUC.GetByID() error = %v", err)
+				return
+			}
+			if ticket.Allocation != 10-tt.wantRedemptions {
+				t.Errorf("TicketUC.GetByID() allocation = %d, want %d", ticket.Allocation, 10-tt.wantRedemptions)
+			}
+
+			if err := clearTable(); err != nil {
+				t.Errorf("TicketUC.Purchase() clearTable error = %v", err)
+				return
+			}
+		})
+	}
+}
+
+func TestPromoCodeUC_Update(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("PromoCodeUC.Update() clearTable error = %v", err)
+		}
+	}()
+
+	clock := newFakeClock()
+	validFrom, validUntil := clock.Now().Add(time.Hour), clock.Now().Add(2*time.Hour)
+	rc := newTestPromoCodeUC(clock)
+	request := models.CreatePromoCodeRequest{Code: "SUMMER10", DiscountType: models.DiscountTypePercent, Value: 10, ValidFrom: &validFrom, ValidUntil: &validUntil}
+	if _, err := rc.Create(context.TODO(), &request); err != nil {
+		t.Fatalf("PromoCodeUC.Update() create error = %v", err)
+	}
+
+	// the steps run in order, each one sees the window left by the previous ones
+	steps := []struct {
+		name           string
+		request        models.UpdatePromoCodeRequest
+		wantErr        bool
+		wantValidFrom  time.Time
+		wantValidUntil time.Time
+	}{
+		{
+			name:           "success - clear valid_until",
+			request:        models.UpdatePromoCodeRequest{ClearValidUntil: true},
+			wantValidFrom:  validFrom,
+			wantValidUntil: time.Time{},
+		},
+		{
+			name:           "error - clear and set valid_from together",
+			request:        models.UpdatePromoCodeRequest{ClearValidFrom: true, ValidFrom: &validUntil},
+			wantErr:        true,
+			wantValidFrom:  validFrom,
+			wantValidUntil: time.Time{},
+		},
+		{
+			name:           "success - clear valid_from",
+			request:        models.UpdatePromoCodeRequest{ClearValidFrom: true},
+			wantValidFrom:  time.Time{},
+			wantValidUntil: time.Time{},
+		},
+	}
+	for _, step := range steps {
+		if _, err := rc.Update(context.TODO(), "1", &step.request); (err != nil) != step.wantErr {
+			t.Errorf("%s: PromoCodeUC.Update() error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+
+		got, err := rc.GetByID(context.TODO(), "1")
+		if err != nil {
+			t.Fatalf("%s: PromoCodeUC.GetByID() error = %v", step.name, err)
+		}
+		if !got.ValidFrom.Equal(step.wantValidFrom) || !got.ValidUntil.Equal(step.wantValidUntil) {
+			t.Errorf("%s: PromoCodeUC.Update() window = %v - %v, want %v - %v", step.name, got.ValidFrom, got.ValidUntil, step.wantValidFrom, step.wantValidUntil)
+		}
+	}
+}
+
+func TestPromoCode_Discount(t *testing.T) {
+	tests := []struct {
+		name   string
+		promo  models.PromoCode
+		amount int64
+		want   int64
+	}{
+		{
+			name:   "percent rounds down",
+			promo:  models.PromoCode{DiscountType: models.DiscountTypePercent, Value: 15},
+			amount: 999,
+			want:   149,
+		},
+		{
+			name:   "fixed amount",
+			promo:  models.PromoCode{DiscountType: models.DiscountTypeFixed, Value: 500},
+			amount: 2000,
+			want:   500,
+		},
+		{
+			name:   "fixed amount never exceeds the price",
+			promo:  models.PromoCode{DiscountType: models.DiscountTypeFixed, Value: 500},
+			amount: 300,
+			want:   300,
+		},
+		{
+			name:   "full percentage makes it free",
+			promo:  models.PromoCode{DiscountType: models.DiscountTypePercent, Value: 100},
+			amount: 1250,
+			want:   1250,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.promo.Discount(tt.amount); got != tt.want {
+				t.Errorf("PromoCode.Discount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTicketUC_PurchaseWithPromoCodeConcurrently(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	const (
+		buyers         = 500
+		maxRedemptions = 50
+	)
+
+	ticket := models.Ticket{ID: 1, Name: "barbie", Description: "barbie pink", Allocation: buyers}
+	if err := addTempData(&ticket); err != nil {
+		t.Fatalf("TicketUC.Purchase() addTempData error = %v", err)
+	}
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("TicketUC.Purchase() clearTable error = %v", err)
+		}
+	}()
+
+	clock := newFakeClock()
+	promoCodeUC := newTestPromoCodeUC(clock)
+	request := models.CreatePromoCodeRequest{Code: "SUMMER10", DiscountType: models.DiscountTypePercent, Value: 10, MaxRedemptions: maxRedemptions}
+	if _, err := promoCodeUC.Create(context.TODO(), &request); err != nil {
+		t.Fatalf("PromoCodeUC.Create() error = %v", err)
+	}
+
+	rc := newTestTicketUC(clock)
+
+	// every buyer races for the code, only maxRedemptions of them may get it
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int64
+		exhausted atomic.Int64
+	)
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			request := models.PurchaseRequest{UserID: fmt.Sprintf("buyer-%d", i), Quantity: 1, PromoCode: "SUMMER10"}
+			_, err := rc.Purchase(context.TODO(), "1", &request)
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.Is(err, pkg.ErrPromoCodeExhausted):
+				exhausted.Add(1)
+			default:
+				t.Errorf("TicketUC.Purchase() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if succeeded.Load() != maxRedemptions {
+		t.Errorf("TicketUC.Purchase() succeeded = %d, want %d", succeeded.Load(), maxRedemptions)
+	}
+	if exhausted.Load() != buyers-maxRedemptions {
+		t.Errorf("TicketUC.Purchase() exhausted = %d, want %d", exhausted.Load(), buyers-maxRedemptions)
+	}
+
+	got, err := promoCodeUC.GetByID(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("PromoCodeUC.GetByID() error = %v", err)
+	}
+	if got.Redemptions != maxRedemptions {
+		t.Errorf("PromoCodeUC.GetByID() redemptions = %d, want %d", got.Redemptions, maxRedemptions)
+	}
+
+	// the buyers that were refused the code must not take seats either
+	gotTicket, err := rc.GetByID(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("TicketUC.GetByID() error = %v", err)
+	}
+	if gotTicket.Allocation != buyers-maxRedemptions {
+		t.Errorf("TicketUC.GetByID() allocation = %d, want %d", gotTicket.Allocation, buyers-maxRedemptions)
+	}
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+
+	"github.com/go-pg/pg"
+)
+
+func TestPurchaseRepository_Create(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	type fields struct {
+		db *pg.DB
+	}
+	type args struct {
+		ctx      context.Context
+		purchase *models.Purchase
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *models.Purchase
+		wantErr bool
+	}{
+		{
+			name: "success",
+			fields: fields{
+				db: test_db,
+			},
+			args: args{
+				ctx: context.TODO(),
+				purchase: &models.Purchase{
+					TicketID: 1,
+					UserID:   "344b6d2d-599a-4b23-b358-8f26512079a9",
+					Quantity: 2,
+					Status:   models.PurchaseStatusCompleted,
+				},
+			},
+			want: &models.Purchase{
+				ID:       1,
+				TicketID: 1,
+				UserID:   "344b6d2d-599a-4b23-b358-8f26512079a9",
+				Quantity: 2,
+				Status:   models.PurchaseStatusCompleted,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := repositories.NewPurchaseRepository(tt.fields.db)
+			got, err := rc.Create(tt.args.ctx, tt.args.purchase)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PurchaseRepository.Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != nil {
+				if got.CreatedAt.IsZero() || got.UpdatedAt.IsZero() {
+					t.Errorf("PurchaseRepository.Create() timestamps were not set, got %v", got)
+				}
+				got.CreatedAt, got.UpdatedAt = time.Time{}, time.Time{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PurchaseRepository.Create() = %v, want %v", got, tt.want)
+			}
+			if err := clearTable(); err != nil {
+				t.Errorf("PurchaseRepository.Create() clearTable error = %v", err)
+				return
+			}
+		})
+	}
+}
+
+func TestPurchaseRepository_ListByTicketID(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	type fields struct {
+		db *pg.DB
+	}
+	type args struct {
+		ctx      context.Context
+		ticketID string
+	}
+	type tempDatas struct {
+		purchases []models.Purchase
+	}
+	tests := []struct {
+		name      string
+		tempDatas tempDatas
+		fields    fields
+		args      args
+		want      []models.Purchase
+		wantErr   bool
+	}{
+		{
+			name: "success",
+			fields: fields{
+				db: test_db,
+			},
+			tempDatas: tempDatas{
+				purchases: []models.Purchase{
+					{ID: 1, TicketID: 1, UserID: "alice", Quantity: 1, Status: models.PurchaseStatusCompleted},
+					{ID: 2, TicketID: 2, UserID: "bob", Quantity: 3, Status: models.PurchaseStatusCompleted},
+					{ID: 3, TicketID: 1, UserID: "carol", Quantity: 2, Status: models.PurchaseStatusCompleted},
+				},
+			},
+			args: args{
+				ctx:      context.TODO(),
+				ticketID: "1",
+			},
+			want: []models.Purchase{
+				{ID: 1, TicketID: 1, UserID: "alice", Quantity: 1, Status: models.PurchaseStatusCompleted},
+				{ID: 3, TicketID: 1, UserID: "carol", Quantity: 2, Status: models.PurchaseStatusCompleted},
+			},
+			wantErr: false,
+		},
+		{
+			name: "success - ticket without purchases",
+			fields: fields{
+				db: test_db,
+			},
+			tempDatas: tempDatas{
+				purchases: []models.Purchase{
+					{ID: 1, TicketID: 1, UserID: "alice", Quantity: 1, Status: models.PurchaseStatusCompleted},
+				},
+			},
+			args: args{
+				ctx:      context.TODO(),
+				ticketID: "2",
+			},
+			want:    []models.Purchase{},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, v := range tt.tempDatas.purchases {
+				if err := addTempData(&v); err != nil {
+					t.Errorf("PurchaseRepository.ListByTicketID() addTempData error = %v", err)
+					return
+				}
+			}
+			rc := repositories.NewPurchaseRepository(tt.fields.db)
+			got, err := rc.ListByTicketID(tt.args.ctx, tt.args.ticketID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PurchaseRepository.ListByTicketID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			for i := range got {
+				got[i].CreatedAt, got[i].UpdatedAt = time.Time{}, time.Time{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PurchaseRepository.ListByTicketID() = %v, want %v", got, tt.want)
+			}
+			if err := clearTable(); err != nil {
+				t.Errorf("PurchaseRepository.ListByTicketID() clearTable error = %v", err)
+				return
+			}
+		})
+	}
+}
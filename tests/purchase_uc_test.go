@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+func TestPurchaseUC_Cancel(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	clock := newFakeClock()
+	const cancellationWindow = 24 * time.Hour
+
+	type args struct {
+		ctx     context.Context
+		request *models.CancelPurchaseRequest
+	}
+	tests := []struct {
+		name           string
+		purchase       models.Purchase
+		args           args
+		want           *models.Purchase
+		wantAllocation int
+		wantErr        bool
+	}{
+		{
+			name: "success - full cancellation",
+			purchase: models.Purchase{
+				ID: 1, TicketID: 1, UserID: "alice", Quantity: 4,
+				Status: models.PurchaseStatusCompleted, CreatedAt: clock.Now().Add(-time.Hour),
+			},
+			args: args{
+				ctx:     context.TODO(),
+				request: &models.CancelPurchaseRequest{},
+			},
+			want: &models.Purchase{
+				ID: 1, TicketID: 1, UserID: "alice", Quantity: 4, RefundedQuantity: 4,
+				Status: models.PurchaseStatusRefunded,
+			},
+			wantAllocation: 10,
+			wantErr:        false,
+		},
+		{
+			name: "success - partial cancellation",
+			purchase: models.Purchase{
+				ID: 1, TicketID: 1, UserID: "alice", Quantity: 4,
+				Status: models.PurchaseStatusCompleted, CreatedAt: clock.Now().Add(-time.Hour),
+			},
+			args: args{
+				ctx:     context.TODO(),
+				request: &models.CancelPurchaseRequest{Quantity: 1},
+			},
+			want: &models.Purchase{
+				ID: 1, TicketID: 1, UserID: "alice", Quantity: 4, RefundedQuantity: 1,
+				Status: models.PurchaseStatusPartiallyRefunded,
+			},
+			wantAllocation: 7,
+			wantErr:        false,
+		},
+		{
+			name: "error - double refund",
+			purchase: models.Purchase{
+				ID: 1, TicketID: 1, UserID: "alice", Quantity: 4, RefundedQuantity: 4,
+				Status: models.PurchaseStatusRefunded, CreatedAt: clock.Now().Add(-time.Hour),
+			},
+			args: args{
+				ctx:     context.TODO(),
+				request: &models.CancelPurchaseRequest{},
+			},
+			want:           nil,
+			wantAllocation: 6,
+			wantErr:        true,
+		},
+		{
+			name: "error - quantity exceeds remaining seats",
+			purchase: models.Purchase{
+				ID: 1, TicketID: 1, UserID: "alice", Quantity: 4, RefundedQuantity: 3,
+				Status: models.PurchaseStatusPartiallyRefunded, CreatedAt: clock.Now().Add(-time.Hour),
+			},
+			args: args{
+				ctx:     context.TODO(),
+				request: &models.CancelPurchaseRequest{Quantity: 2},
+			},
+			want:           nil,
+			wantAllocation: 6,
+			wantErr:        true,
+		},
+		{
+			name: "error - outside the cancellation window",
+			purchase: models.Purchase{
+				ID: 1, TicketID: 1, UserID: "alice", Quantity: 4,
+				Status: models.PurchaseStatusCompleted, CreatedAt: clock.Now().Add(-cancellationWindow - time.Minute),
+			},
+			args: args{
+				ctx:     context.TODO(),
+				request: &models.CancelPurchaseRequest{},
+			},
+			want:           nil,
+			wantAllocation: 6,
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := models.Ticket{ID: 1, Name: "oppenheimer", Description: "oppenheimer 70mm", Allocation: 6}
+			if err := addTempData(&ticket); err != nil {
+				t.Errorf("PurchaseUC.Cancel() addTempData error = %v", err)
+				return
+			}
+			if err := addTempData(&tt.purchase); err != nil {
+				t.Errorf("PurchaseUC.Cancel() addTempData error = %v", err)
+				return
+			}
+			ticketRepo := repositories.NewTicketRepository(test_db)
+			rc := uc.NewPurchaseUC(
+				repositories.NewPurchaseRepository(test_db),
+				ticketRepo,
+				repositories.NewTierRepository(test_db),
+				repositories.NewTxManager(test_db),
+				newTestWaitlistUC(clock),
+				newTestPaymentUC(nil),
+				newTestCredentialUC(),
+				newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+				clock,
+				testTicketValidator,
+				cancellationWindow,
+			)
+			got, err := rc.Cancel(tt.args.ctx, "1", tt.args.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PurchaseUC.Cancel() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != nil {
+				// the seeded purchases have no credentials, revoking them is covered by TestCredentialUC_Lifecycle
+				got.CreatedAt, got.UpdatedAt, got.Credentials = time.Time{}, time.Time{}, nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PurchaseUC.Cancel() = %v, want %v", got, tt.want)
+			}
+			gotTicket, err := ticketRepo.GetByID(context.TODO(), "1")
+			if err != nil {
+				t.Errorf("TicketRepository.GetByID() error = %v", err)
+				return
+			}
+			if gotTicket.Allocation != tt.wantAllocation {
+				t.Errorf("PurchaseUC.Cancel() allocation = %v, want %v", gotTicket.Allocation, tt.wantAllocation)
+			}
+			if err := clearTable(); err != nil {
+				t.Errorf("PurchaseUC.Cancel() clearTable error = %v", err)
+				return
+			}
+		})
+	}
+}
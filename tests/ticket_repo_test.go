@@ -48,6 +48,7 @@ func TestTicketRepository_Create(t *testing.T) {
 				Name:        "batman",
 				Description: "batman returns",
 				Allocation:  100,
+				Status:      models.TicketStatusOnSale,
 			},
 			wantErr: false,
 		},
@@ -119,9 +120,38 @@ func TestTicketRepository_Update(t *testing.T) {
 				Name:        "joker",
 				Description: "joker up",
 				Allocation:  99,
+				Status:      models.TicketStatusOnSale,
+				Version:     1,
 			},
 			wantErr: false,
 		},
+		{
+			name: "error - updating a stale version",
+			fields: fields{
+				db: test_db,
+			},
+			tempData: tempData{
+				ticket: &models.Ticket{
+					ID:          1,
+					Name:        "joker",
+					Description: "joker down",
+					Allocation:  100,
+					Version:     2,
+				},
+			},
+			args: args{
+				ctx: context.TODO(),
+				ticket: &models.Ticket{
+					ID:          1,
+					Name:        "joker",
+					Description: "joker up",
+					Allocation:  99,
+					Version:     1,
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name: "error - updating a non-existent ticket",
 			fields: fields{
@@ -222,6 +252,7 @@ func TestTicketRepository_GetByID(t *testing.T) {
 				Name:        "devil",
 				Description: "devil may cry",
 				Allocation:  100,
+				Status:      models.TicketStatusOnSale,
 			},
 			wantErr: false,
 		},
@@ -255,6 +286,7 @@ func TestTicketRepository_GetByID(t *testing.T) {
 				Name:        "wanted",
 				Description: "wanted follows you",
 				Allocation:  23,
+				Status:      models.TicketStatusOnSale,
 			},
 			wantErr: false,
 		},
@@ -305,3 +337,224 @@ func TestTicketRepository_GetByID(t *testing.T) {
 		})
 	}
 }
+
+func TestTicketRepository_DecreaseAllocation(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	type fields struct {
+		db *pg.DB
+	}
+	type args struct {
+		ctx      context.Context
+		id       string
+		quantity int
+	}
+	type tempData struct {
+		ticket *models.Ticket
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		tempData tempData
+		args     args
+		want     *models.Ticket
+		wantErr  bool
+	}{
+		{
+			name: "success",
+			fields: fields{
+				db: test_db,
+			},
+			tempData: tempData{
+				ticket: &models.Ticket{
+					ID:          1,
+					Name:        "matrix",
+					Description: "matrix reloaded",
+					Allocation:  10,
+				},
+			},
+			args: args{
+				ctx:      context.TODO(),
+				id:       "1",
+				quantity: 10,
+			},
+			want: &models.Ticket{
+				ID:          1,
+				Name:        "matrix",
+				Description: "matrix reloaded",
+				Allocation:  0,
+				Status:      models.TicketStatusSoldOut,
+				Version:     1,
+			},
+			wantErr: false,
+		},
+		{
+			name: "error - quantity exceeds allocation",
+			fields: fields{
+				db: test_db,
+			},
+			tempData: tempData{
+				ticket: &models.Ticket{
+					ID:          1,
+					Name:        "matrix",
+					Description: "matrix reloaded",
+					Allocation:  10,
+				},
+			},
+			args: args{
+				ctx:      context.TODO(),
+				id:       "1",
+				quantity: 11,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "error - decreasing a non-existent ticket",
+			fields: fields{
+				db: test_db,
+			},
+			tempData: tempData{
+				ticket: &models.Ticket{
+					ID:          1,
+					Name:        "matrix",
+					Description: "matrix reloaded",
+					Allocation:  10,
+				},
+			},
+			args: args{
+				ctx:      context.TODO(),
+				id:       "2",
+				quantity: 1,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := addTempData(tt.tempData.ticket); err != nil {
+				t.Errorf("TicketRepository.DecreaseAllocation() addTempData error = %v", err)
+				return
+			}
+			rc := repositories.NewTicketRepository(tt.fields.db)
+			got, err := rc.DecreaseAllocation(tt.args.ctx, tt.args.id, tt.args.quantity)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TicketRepository.DecreaseAllocation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TicketRepository.DecreaseAllocation() = %v, want %v", got, tt.want)
+			}
+			if err := clearTable(); err != nil {
+				t.Errorf("TicketRepository.DecreaseAllocation() clearTable error = %v", err)
+				return
+			}
+		})
+	}
+}
+
+func TestTicketRepository_List(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	tempTickets := []models.Ticket{
+		{ID: 1, Name: "dune part one", Description: "arrakis", Allocation: 5},
+		{ID: 2, Name: "alien", Description: "nostromo", Allocation: 0},
+		{ID: 3, Name: "dune part two", Description: "arrakis again", Allocation: 12},
+		{ID: 4, Name: "blade runner", Description: "tears in rain", Allocation: 5},
+		{ID: 5, Name: "100% dune", Description: "wildcard", Allocation: 1},
+	}
+	for _, v := range tempTickets {
+		if err := addTempData(&v); err != nil {
+			t.Fatalf("TicketRepository.List() addTempData error = %v", err)
+		}
+	}
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("TicketRepository.List() clearTable error = %v", err)
+		}
+	}()
+
+	type args struct {
+		ctx  context.Context
+		opts models.TicketListOptions
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantIDs []int64
+		wantErr bool
+	}{
+		{
+			name: "success - default order",
+			args: args{
+				ctx:  context.TODO(),
+				opts: models.TicketListOptions{Limit: 10},
+			},
+			wantIDs: []int64{1, 2, 3, 4, 5},
+		},
+		{
+			name: "success - name search is case insensitive",
+			args: args{
+				ctx:  context.TODO(),
+				opts: models.TicketListOptions{Name: "DUNE", Limit: 10},
+			},
+			wantIDs: []int64{1, 3, 5},
+		},
+		{
+			name: "success - wildcards in the search are literal",
+			args: args{
+				ctx:  context.TODO(),
+				opts: models.TicketListOptions{Name: "0%", Limit: 10},
+			},
+			wantIDs: []int64{5},
+		},
+		{
+			name: "success - only available tickets",
+			args: args{
+				ctx:  context.TODO(),
+				opts: models.TicketListOptions{Available: true, Limit: 10},
+			},
+			wantIDs: []int64{1, 3, 4, 5},
+		},
+		{
+			name: "success - sorted by allocation descending after a cursor",
+			args: args{
+				ctx: context.TODO(),
+				opts: models.TicketListOptions{
+					Sort:  "allocation",
+					Order: "desc",
+					Limit: 2,
+					After: &models.TicketCursor{Allocation: 5, ID: 4},
+				},
+			},
+			wantIDs: []int64{1, 5},
+		},
+		{
+			name: "success - sorted by name",
+			args: args{
+				ctx:  context.TODO(),
+				opts: models.TicketListOptions{Sort: "name", Order: "asc", Limit: 3},
+			},
+			wantIDs: []int64{5, 2, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := repositories.NewTicketRepository(test_db)
+			got, err := rc.List(tt.args.ctx, tt.args.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TicketRepository.List() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			gotIDs := make([]int64, 0, len(got))
+			for _, v := range got {
+				gotIDs = append(gotIDs, v.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("TicketRepository.List() ids = %v, want %v", gotIDs, tt.wantIDs)
+			}
+		})
+	}
+}
@@ -2,8 +2,12 @@ package tests
 
 import (
 	"context"
+	"errors"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/fleimkeipa/tickets-api/models"
 	"github.com/fleimkeipa/tickets-api/pkg"
@@ -18,14 +22,38 @@ func init() {
 	testTicketValidator = pkg.NewValidator()
 }
 
+func newTestTicketUC(clock pkg.Clock) *uc.TicketUC {
+	return uc.NewTicketUC(
+		repositories.NewTicketRepository(test_db),
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewHoldRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		newTestPromoCodeUC(clock),
+		newTestCheckoutUC(clock, newTestPaymentUC(nil)),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+	)
+}
+
 func TestTicketUC_Create(t *testing.T) {
 	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
 	defer terminateDB()
 
 	testTicketRepo := repositories.NewTicketRepository(test_db)
+	testPurchaseRepo := repositories.NewPurchaseRepository(test_db)
+	testHoldRepo := repositories.NewHoldRepository(test_db)
+	testTxManager := repositories.NewTxManager(test_db)
+	salesStart := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	salesEnd := salesStart.Add(-time.Hour)
 	type fields struct {
-		ticketRepo interfaces.TicketInterfaces
-		validator  *pkg.CustomValidator
+		ticketRepo   interfaces.TicketInterfaces
+		purchaseRepo interfaces.PurchaseInterfaces
+		holdRepo     interfaces.HoldInterfaces
+		txManager    interfaces.TxInterfaces
+		validator    *pkg.CustomValidator
 	}
 	type args struct {
 		ctx     context.Context
@@ -41,8 +69,38 @@ func TestTicketUC_Create(t *testing.T) {
 		{
 			name: "success",
 			fields: fields{
-				ticketRepo: testTicketRepo,
-				validator:  testTicketValidator,
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
+			},
+			args: args{
+				ctx: context.TODO(),
+				request: &models.CreateRequest{
+					Name:        "spiderman",
+					Description: "spiderman homecoming",
+					Allocation:  23,
+				},
+			},
+			want: &models.Ticket{
+				ID:          1,
+				Name:        "spiderman",
+				Description: "spiderman homecoming",
+				Allocation:  23,
+				Status:      models.TicketStatusDraft,
+				SaleStatus:  models.SaleStatusOnSale,
+			},
+			wantErr: false,
+		},
+		{
+			name: "success - priced ticket",
+			fields: fields{
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
 			},
 			args: args{
 				ctx: context.TODO(),
@@ -50,6 +108,8 @@ func TestTicketUC_Create(t *testing.T) {
 					Name:        "spiderman",
 					Description: "spiderman homecoming",
 					Allocation:  23,
+					Price:       1250,
+					Currency:    "EUR",
 				},
 			},
 			want: &models.Ticket{
@@ -57,14 +117,64 @@ func TestTicketUC_Create(t *testing.T) {
 				Name:        "spiderman",
 				Description: "spiderman homecoming",
 				Allocation:  23,
+				Price:       1250,
+				Currency:    "EUR",
+				Status:      models.TicketStatusDraft,
+				SaleStatus:  models.SaleStatusOnSale,
 			},
 			wantErr: false,
 		},
+		{
+			name: "error - price without currency",
+			fields: fields{
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
+			},
+			args: args{
+				ctx: context.TODO(),
+				request: &models.CreateRequest{
+					Name:        "spiderman",
+					Description: "spiderman homecoming",
+					Allocation:  23,
+					Price:       1250,
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "error - unknown currency",
+			fields: fields{
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
+			},
+			args: args{
+				ctx: context.TODO(),
+				request: &models.CreateRequest{
+					Name:        "spiderman",
+					Description: "spiderman homecoming",
+					Allocation:  23,
+					Price:       1250,
+					Currency:    "XYZ",
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name: "error - invalid allocation value",
 			fields: fields{
-				ticketRepo: testTicketRepo,
-				validator:  testTicketValidator,
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
 			},
 			args: args{
 				ctx: context.TODO(),
@@ -80,8 +190,11 @@ func TestTicketUC_Create(t *testing.T) {
 		{
 			name: "error - missing required fields",
 			fields: fields{
-				ticketRepo: testTicketRepo,
-				validator:  testTicketValidator,
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
 			},
 			args: args{
 				ctx: context.TODO(),
@@ -93,10 +206,32 @@ func TestTicketUC_Create(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "error - sales end before sales start",
+			fields: fields{
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
+			},
+			args: args{
+				ctx: context.TODO(),
+				request: &models.CreateRequest{
+					Name:        "spiderman",
+					Description: "spiderman homecoming",
+					Allocation:  23,
+					SalesStart:  &salesStart,
+					SalesEnd:    &salesEnd,
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			rc := uc.NewTicketUC(tt.fields.ticketRepo, tt.fields.validator)
+			rc := uc.NewTicketUC(tt.fields.ticketRepo, tt.fields.purchaseRepo, tt.fields.holdRepo, repositories.NewTierRepository(test_db), tt.fields.txManager, newTestWaitlistUC(newFakeClock()), newTestPromoCodeUC(newFakeClock()), newTestCheckoutUC(newFakeClock(), newTestPaymentUC(nil)), newTestOutboxUC(newFakeClock(), pkg.NewInProcessEventPublisher()), newFakeClock(), tt.fields.validator)
 			got, err := rc.Create(tt.args.ctx, tt.args.request)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("TicketUC.Create() error = %v, wantErr %v", err, tt.wantErr)
@@ -118,9 +253,15 @@ func TestTicketUC_Purchase(t *testing.T) {
 	defer terminateDB()
 
 	testTicketRepo := repositories.NewTicketRepository(test_db)
+	testPurchaseRepo := repositories.NewPurchaseRepository(test_db)
+	testHoldRepo := repositories.NewHoldRepository(test_db)
+	testTxManager := repositories.NewTxManager(test_db)
 	type fields struct {
-		ticketRepo interfaces.TicketInterfaces
-		validator  *pkg.CustomValidator
+		ticketRepo   interfaces.TicketInterfaces
+		purchaseRepo interfaces.PurchaseInterfaces
+		holdRepo     interfaces.HoldInterfaces
+		txManager    interfaces.TxInterfaces
+		validator    *pkg.CustomValidator
 	}
 	type args struct {
 		ctx    context.Context
@@ -135,14 +276,17 @@ func TestTicketUC_Purchase(t *testing.T) {
 		tempDatas tempDatas
 		fields    fields
 		args      args
-		want      *models.Ticket
+		want      *models.Purchase
 		wantErr   bool
 	}{
 		{
 			name: "success - corret quantity",
 			fields: fields{
-				ticketRepo: testTicketRepo,
-				validator:  testTicketValidator,
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
 			},
 			tempDatas: tempDatas{
 				ticket: []models.Ticket{
@@ -168,19 +312,23 @@ func TestTicketUC_Purchase(t *testing.T) {
 					Quantity: 70,
 				},
 			},
-			want: &models.Ticket{
-				ID:          1,
-				Name:        "vangogh",
-				Description: "vangogh ear",
-				Allocation:  3,
+			want: &models.Purchase{
+				ID:       1,
+				TicketID: 1,
+				UserID:   "344b6d2d-599a-4b23-b358-8f26512079a9",
+				Quantity: 70,
+				Status:   models.PurchaseStatusCompleted,
 			},
 			wantErr: false,
 		},
 		{
 			name: "error - quantity exceeds allocation",
 			fields: fields{
-				ticketRepo: testTicketRepo,
-				validator:  testTicketValidator,
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
 			},
 			tempDatas: tempDatas{
 				ticket: []models.Ticket{
@@ -212,8 +360,11 @@ func TestTicketUC_Purchase(t *testing.T) {
 		{
 			name: "error - buying on zero allocation ticket",
 			fields: fields{
-				ticketRepo: testTicketRepo,
-				validator:  testTicketValidator,
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
 			},
 			tempDatas: tempDatas{
 				ticket: []models.Ticket{
@@ -239,8 +390,11 @@ func TestTicketUC_Purchase(t *testing.T) {
 		{
 			name: "error - updating a non-existent ticket",
 			fields: fields{
-				ticketRepo: testTicketRepo,
-				validator:  testTicketValidator,
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
 			},
 			tempDatas: tempDatas{
 				ticket: []models.Ticket{
@@ -266,8 +420,11 @@ func TestTicketUC_Purchase(t *testing.T) {
 		{
 			name: "error - failed validation on user id",
 			fields: fields{
-				ticketRepo: testTicketRepo,
-				validator:  testTicketValidator,
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
 			},
 			tempDatas: tempDatas{
 				ticket: []models.Ticket{
@@ -293,8 +450,11 @@ func TestTicketUC_Purchase(t *testing.T) {
 		{
 			name: "error - failed validation on negative quantity",
 			fields: fields{
-				ticketRepo: testTicketRepo,
-				validator:  testTicketValidator,
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
 			},
 			tempDatas: tempDatas{
 				ticket: []models.Ticket{
@@ -320,8 +480,11 @@ func TestTicketUC_Purchase(t *testing.T) {
 		{
 			name: "error - failed validation on negative quantity",
 			fields: fields{
-				ticketRepo: testTicketRepo,
-				validator:  testTicketValidator,
+				ticketRepo:   testTicketRepo,
+				purchaseRepo: testPurchaseRepo,
+				holdRepo:     testHoldRepo,
+				txManager:    testTxManager,
+				validator:    testTicketValidator,
 			},
 			tempDatas: tempDatas{
 				ticket: []models.Ticket{
@@ -353,12 +516,19 @@ func TestTicketUC_Purchase(t *testing.T) {
 					return
 				}
 			}
-			rc := uc.NewTicketUC(tt.fields.ticketRepo, tt.fields.validator)
+			rc := uc.NewTicketUC(tt.fields.ticketRepo, tt.fields.purchaseRepo, tt.fields.holdRepo, repositories.NewTierRepository(test_db), tt.fields.txManager, newTestWaitlistUC(newFakeClock()), newTestPromoCodeUC(newFakeClock()), newTestCheckoutUC(newFakeClock(), newTestPaymentUC(nil)), newTestOutboxUC(newFakeClock(), pkg.NewInProcessEventPublisher()), newFakeClock(), tt.fields.validator)
 			got, err := rc.Purchase(tt.args.ctx, tt.args.id, tt.args.ticket)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("TicketUC.Purchase() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if got != nil {
+				if len(got.Credentials) != got.Quantity {
+					t.Errorf("TicketUC.Purchase() credentials = %d, want %d", len(got.Credentials), got.Quantity)
+				}
+				// timestamps are set by the database
+				got.CreatedAt, got.UpdatedAt, got.Credentials = time.Time{}, time.Time{}, nil
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("TicketUC.Purchase() = %v, want %v", got, tt.want)
 			}
@@ -369,3 +539,640 @@ func TestTicketUC_Purchase(t *testing.T) {
 		})
 	}
 }
+
+func TestTicketUC_PurchaseConcurrently(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	const (
+		allocation = 100
+		buyers     = 500
+	)
+
+	ticket := models.Ticket{
+		ID:          1,
+		Name:        "interstellar",
+		Description: "interstellar imax",
+		Allocation:  allocation,
+	}
+	if err := addTempData(&ticket); err != nil {
+		t.Fatalf("TicketUC.Purchase() addTempData error = %v", err)
+	}
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("TicketUC.Purchase() clearTable error = %v", err)
+		}
+	}()
+
+	rc := newTestTicketUC(newFakeClock())
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int64
+		failed    atomic.Int64
+	)
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request := models.PurchaseRequest{
+				UserID:   "344b6d2d-599a-4b23-b358-8f26512079a9",
+				Quantity: 1,
+			}
+			if _, err := rc.Purchase(context.TODO(), "1", &request); err != nil {
+				failed.Add(1)
+				return
+			}
+			succeeded.Add(1)
+		}()
+	}
+	wg.Wait()
+
+	if succeeded.Load() != allocation {
+		t.Errorf("TicketUC.Purchase() succeeded = %d, want %d", succeeded.Load(), allocation)
+	}
+	if failed.Load() != buyers-allocation {
+		t.Errorf("TicketUC.Purchase() failed = %d, want %d", failed.Load(), buyers-allocation)
+	}
+
+	got, err := rc.GetByID(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("TicketUC.GetByID() error = %v", err)
+	}
+	if got.Allocation != 0 {
+		t.Errorf("TicketUC.Purchase() final allocation = %d, want 0", got.Allocation)
+	}
+
+	purchases, err := repositories.NewPurchaseRepository(test_db).ListByTicketID(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("PurchaseRepository.ListByTicketID() error = %v", err)
+	}
+	if len(purchases) != allocation {
+		t.Errorf("TicketUC.Purchase() purchase records = %d, want %d", len(purchases), allocation)
+	}
+}
+
+func TestTicketUC_PurchaseLimit(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	ticket := models.Ticket{ID: 1, Name: "oppenheimer", Description: "oppenheimer 70mm", Allocation: 100, MaxPerUser: 4}
+	if err := addTempData(&ticket); err != nil {
+		t.Fatalf("TicketUC.Purchase() addTempData error = %v", err)
+	}
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("TicketUC.Purchase() clearTable error = %v", err)
+		}
+	}()
+
+	rc := newTestTicketUC(newFakeClock())
+
+	// the steps run in order, each one sees the purchases of the previous ones
+	steps := []struct {
+		name     string
+		userID   string
+		quantity int
+		wantErr  bool
+	}{
+		{name: "success - below the limit", userID: "alice", quantity: 3, wantErr: false},
+		{name: "error - over the limit across purchases", userID: "alice", quantity: 2, wantErr: true},
+		{name: "success - up to the limit", userID: "alice", quantity: 1, wantErr: false},
+		{name: "error - limit reached", userID: "alice", quantity: 1, wantErr: true},
+		{name: "success - other users have their own limit", userID: "bob", quantity: 4, wantErr: false},
+	}
+	for _, step := range steps {
+		_, err := rc.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: step.userID, Quantity: step.quantity})
+		if (err != nil) != step.wantErr {
+			t.Errorf("%s: TicketUC.Purchase() error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		if err != nil && !errors.Is(err, pkg.ErrPurchaseLimitExceeded) {
+			t.Errorf("%s: TicketUC.Purchase() error = %v, want %v", step.name, err, pkg.ErrPurchaseLimitExceeded)
+		}
+	}
+
+	// concurrent purchases of one user must not slip past the limit together
+	const buyers = 20
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int64
+	)
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := rc.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "carol", Quantity: 1}); err == nil {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded.Load() != int64(ticket.MaxPerUser) {
+		t.Errorf("TicketUC.Purchase() concurrent succeeded = %d, want %d", succeeded.Load(), ticket.MaxPerUser)
+	}
+
+	got, err := rc.GetByID(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("TicketUC.GetByID() error = %v", err)
+	}
+	if got.Allocation != 88 {
+		t.Errorf("TicketUC.Purchase() final allocation = %d, want 88", got.Allocation)
+	}
+}
+
+func TestTicketUC_PurchaseSalesWindow(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	tests := []struct {
+		name       string
+		elapsed    time.Duration
+		wantStatus models.SaleStatus
+		wantErr    error
+	}{
+		{
+			name:       "error - not on sale yet",
+			elapsed:    30 * time.Minute,
+			wantStatus: models.SaleStatusNotStarted,
+			wantErr:    pkg.ErrSalesNotStarted,
+		},
+		{
+			name:       "success - sales window open",
+			elapsed:    time.Hour,
+			wantStatus: models.SaleStatusOnSale,
+			wantErr:    nil,
+		},
+		{
+			name:       "error - sales closed",
+			elapsed:    2 * time.Hour,
+			wantStatus: models.SaleStatusClosed,
+			wantErr:    pkg.ErrSalesClosed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			ticket := models.Ticket{
+				ID:          1,
+				Name:        "dune part two",
+				Description: "dune imax",
+				Allocation:  10,
+				SalesStart:  clock.Now().Add(time.Hour),
+				SalesEnd:    clock.Now().Add(2 * time.Hour),
+			}
+			if err := addTempData(&ticket); err != nil {
+				t.Errorf("TicketUC.Purchase() addTempData error = %v", err)
+				return
+			}
+			rc := newTestTicketUC(clock)
+			clock.Advance(tt.elapsed)
+
+			got, err := rc.GetByID(context.TODO(), "1")
+			if err != nil {
+				t.Errorf("TicketUC.GetByID() error = %v", err)
+				return
+			}
+			if got.SaleStatus != tt.wantStatus {
+				t.Errorf("TicketUC.GetByID() SaleStatus = %v, want %v", got.SaleStatus, tt.wantStatus)
+			}
+
+			_, err = rc.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 1})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("TicketUC.Purchase() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := clearTable(); err != nil {
+				t.Errorf("TicketUC.Purchase() clearTable error = %v", err)
+				return
+			}
+		})
+	}
+}
+
+func TestTicketUC_Lifecycle(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("TicketUC.Lifecycle() clearTable error = %v", err)
+		}
+	}()
+
+	clock := newFakeClock()
+	rc := newTestTicketUC(clock)
+	purchaseUC := uc.NewPurchaseUC(
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		newTestPaymentUC(nil),
+		newTestCredentialUC(),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+		0,
+	)
+
+	ticket, err := rc.Create(context.TODO(), &models.CreateRequest{Name: "barbie", Description: "barbie pink", Allocation: 2})
+	if err != nil {
+		t.Fatalf("TicketUC.Create() error = %v", err)
+	}
+
+	purchase := func() error {
+		_, err := rc.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 2})
+		return err
+	}
+	refund := func() error {
+		_, err := purchaseUC.Cancel(context.TODO(), "1", &models.CancelPurchaseRequest{Quantity: 1})
+		return err
+	}
+	publish := func() error {
+		_, err := rc.Publish(context.TODO(), "1", nil)
+		return err
+	}
+	pause := func() error {
+		_, err := rc.Pause(context.TODO(), "1", nil)
+		return err
+	}
+	cancel := func() error {
+		_, err := rc.Cancel(context.TODO(), "1", nil)
+		return err
+	}
+
+	// the steps run in order, each one starts from the state the previous one left
+	steps := []struct {
+		name       string
+		action     func() error
+		wantErr    error
+		wantStatus models.TicketStatus
+	}{
+		{name: "draft can't be bought", action: purchase, wantErr: pkg.ErrTicketNotOnSale, wantStatus: models.TicketStatusDraft},
+		{name: "publish", action: publish, wantStatus: models.TicketStatusOnSale},
+		{name: "pause", action: pause, wantStatus: models.TicketStatusPaused},
+		{name: "paused can't be bought", action: purchase, wantErr: pkg.ErrTicketNotOnSale, wantStatus: models.TicketStatusPaused},
+		{name: "publish again", action: publish, wantStatus: models.TicketStatusOnSale},
+		{name: "last seats sell out", action: purchase, wantStatus: models.TicketStatusSoldOut},
+		{name: "refund restores the sale", action: refund, wantStatus: models.TicketStatusOnSale},
+		{name: "cancel", action: cancel, wantStatus: models.TicketStatusCancelled},
+		{name: "cancelled is final", action: publish, wantErr: pkg.ErrInvalidTransition, wantStatus: models.TicketStatusCancelled},
+	}
+	for _, step := range steps {
+		if err := step.action(); !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		ticket, err = rc.GetByID(context.TODO(), "1")
+		if err != nil {
+			t.Fatalf("%s: TicketUC.GetByID() error = %v", step.name, err)
+		}
+		if ticket.Status != step.wantStatus {
+			t.Errorf("%s: status = %v, want %v", step.name, ticket.Status, step.wantStatus)
+		}
+	}
+}
+
+func TestTicketUC_List(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	for i := 1; i <= 7; i++ {
+		ticket := models.Ticket{ID: int64(i), Name: "concert night", Description: "encore", Allocation: i % 3}
+		if err := addTempData(&ticket); err != nil {
+			t.Fatalf("TicketUC.List() addTempData error = %v", err)
+		}
+	}
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("TicketUC.List() clearTable error = %v", err)
+		}
+	}()
+
+	rc := newTestTicketUC(newFakeClock())
+
+	// walk every page of the available tickets sorted by allocation
+	request := models.TicketListRequest{Available: true, Sort: "allocation", Limit: 2}
+	gotIDs := make([]int64, 0)
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatalf("TicketUC.List() did not stop paginating")
+		}
+		tickets, nextCursor, err := rc.List(context.TODO(), &request)
+		if err != nil {
+			t.Fatalf("TicketUC.List() error = %v", err)
+		}
+		for _, v := range tickets {
+			gotIDs = append(gotIDs, v.ID)
+		}
+		if nextCursor == "" {
+			break
+		}
+		request.Cursor = nextCursor
+	}
+
+	wantIDs := []int64{1, 4, 7, 2, 5}
+	if !reflect.DeepEqual(gotIDs, wantIDs) {
+		t.Errorf("TicketUC.List() ids = %v, want %v", gotIDs, wantIDs)
+	}
+
+	// a cursor can't be reused with another ordering
+	request.Sort = "name"
+	if _, _, err := rc.List(context.TODO(), &request); err == nil {
+		t.Errorf("TicketUC.List() error = nil, want cursor mismatch error")
+	}
+}
+
+func TestTicketUC_Update(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	name, description, allocation, invalidAllocation := "joker folie a deux", "", 40, 0
+	currentVersion, staleVersion := 3, 2
+	type args struct {
+		ctx             context.Context
+		id              string
+		request         *models.UpdateRequest
+		expectedVersion *int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *models.Ticket
+		wantErr bool
+	}{
+		{
+			name: "success - only the given fields change",
+			args: args{
+				ctx:     context.TODO(),
+				id:      "1",
+				request: &models.UpdateRequest{Name: &name, Allocation: &allocation},
+			},
+			want: &models.Ticket{
+				ID:          1,
+				Name:        "joker folie a deux",
+				Description: "joker down",
+				Allocation:  40,
+				Version:     4,
+				Status:      models.TicketStatusOnSale,
+				SaleStatus:  models.SaleStatusOnSale,
+			},
+			wantErr: false,
+		},
+		{
+			name: "success - description can be cleared",
+			args: args{
+				ctx:     context.TODO(),
+				id:      "1",
+				request: &models.UpdateRequest{Description: &description},
+			},
+			want: &models.Ticket{
+				ID:          1,
+				Name:        "joker",
+				Description: "",
+				Allocation:  100,
+				Version:     4,
+				Status:      models.TicketStatusOnSale,
+				SaleStatus:  models.SaleStatusOnSale,
+			},
+			wantErr: false,
+		},
+		{
+			name: "success - matching version",
+			args: args{
+				ctx:             context.TODO(),
+				id:              "1",
+				request:         &models.UpdateRequest{Allocation: &allocation},
+				expectedVersion: &currentVersion,
+			},
+			want: &models.Ticket{
+				ID:          1,
+				Name:        "joker",
+				Description: "joker down",
+				Allocation:  40,
+				Version:     4,
+				Status:      models.TicketStatusOnSale,
+				SaleStatus:  models.SaleStatusOnSale,
+			},
+			wantErr: false,
+		},
+		{
+			name: "error - stale version",
+			args: args{
+				ctx:             context.TODO(),
+				id:              "1",
+				request:         &models.UpdateRequest{Allocation: &allocation},
+				expectedVersion: &staleVersion,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "error - invalid allocation value",
+			args: args{
+				ctx:     context.TODO(),
+				id:      "1",
+				request: &models.UpdateRequest{Allocation: &invalidAllocation},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "error - updating a non-existent ticket",
+			args: args{
+				ctx:     context.TODO(),
+				id:      "2",
+				request: &models.UpdateRequest{Name: &name},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := models.Ticket{ID: 1, Name: "joker", Description: "joker down", Allocation: 100, Version: 3}
+			if err := addTempData(&ticket); err != nil {
+				t.Errorf("TicketUC.Update() addTempData error = %v", err)
+				return
+			}
+			rc := newTestTicketUC(newFakeClock())
+			if _, err := rc.Update(tt.args.ctx, tt.args.id, tt.args.request, tt.args.expectedVersion); (err != nil) != tt.wantErr {
+				t.Errorf("TicketUC.Update() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.want != nil {
+				got, err := rc.GetByID(context.TODO(), tt.args.id)
+				if err != nil {
+					t.Errorf("TicketUC.GetByID() error = %v", err)
+					return
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("TicketUC.Update() = %v, want %v", got, tt.want)
+				}
+			}
+			if err := clearTable(); err != nil {
+				t.Errorf("TicketUC.Update() clearTable error = %v", err)
+				return
+			}
+		})
+	}
+}
+
+func TestTicketUC_Delete(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+
+	type args struct {
+		ctx  context.Context
+		id   string
+		hard bool
+	}
+	tests := []struct {
+		name      string
+		purchases []models.Purchase
+		// softDeleted soft deletes the ticket before it is deleted
+		softDeleted bool
+		args        args
+		wantErr     bool
+	}{
+		{
+			name: "success - soft delete keeps purchases",
+			purchases: []models.Purchase{
+				{TicketID: 1, UserID: "alice", Quantity: 1, Status: models.PurchaseStatusCompleted},
+			},
+			args:    args{ctx: context.TODO(), id: "1", hard: false},
+			wantErr: false,
+		},
+		{
+			name:    "success - hard delete of an unsold ticket",
+			args:    args{ctx: context.TODO(), id: "1", hard: true},
+			wantErr: false,
+		},
+		{
+			name:        "success - hard delete of a soft deleted ticket",
+			softDeleted: true,
+			args:        args{ctx: context.TODO(), id: "1", hard: true},
+			wantErr:     false,
+		},
+		{
+			name: "error - hard delete of a sold ticket",
+			purchases: []models.Purchase{
+				{TicketID: 1, UserID: "alice", Quantity: 1, Status: models.PurchaseStatusCompleted},
+			},
+			args:    args{ctx: context.TODO(), id: "1", hard: true},
+			wantErr: true,
+		},
+		{
+			name:    "error - deleting a non-existent ticket",
+			args:    args{ctx: context.TODO(), id: "2", hard: false},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := models.Ticket{ID: 1, Name: "se7en", Description: "what's in the box", Allocation: 7}
+			if err := addTempData(&ticket); err != nil {
+				t.Errorf("TicketUC.Delete() addTempData error = %v", err)
+				return
+			}
+			for _, v := range tt.purchases {
+				if err := addTempData(&v); err != nil {
+					t.Errorf("TicketUC.Delete() addTempData error = %v", err)
+					return
+				}
+			}
+			rc := newTestTicketUC(newFakeClock())
+			if tt.softDeleted {
+				if err := rc.Delete(tt.args.ctx, tt.args.id, false, nil); err != nil {
+					t.Errorf("TicketUC.Delete() soft error = %v", err)
+					return
+				}
+			}
+			err := rc.Delete(tt.args.ctx, tt.args.id, tt.args.hard, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TicketUC.Delete() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				if _, err := rc.GetByID(context.TODO(), tt.args.id); err == nil {
+					t.Errorf("TicketUC.GetByID() found deleted ticket %v", tt.args.id)
+				}
+				purchases, err := repositories.NewPurchaseRepository(test_db).ListByTicketID(context.TODO(), tt.args.id)
+				if err != nil {
+					t.Errorf("PurchaseRepository.ListByTicketID() error = %v", err)
+					return
+				}
+				if len(purchases) != len(tt.purchases) {
+					t.Errorf("TicketUC.Delete() kept %d purchases, want %d", len(purchases), len(tt.purchases))
+				}
+			}
+			if !tt.wantErr && tt.args.hard {
+				kept, err := test_db.Model((*models.Ticket)(nil)).Deleted().Where("id = ?", tt.args.id).Count()
+				if err != nil {
+					t.Errorf("TicketUC.Delete() count error = %v", err)
+					return
+				}
+				if kept != 0 {
+					t.Errorf("TicketUC.Delete() hard delete kept the soft deleted ticket %v", tt.args.id)
+				}
+			}
+			if err := clearTable(); err != nil {
+				t.Errorf("TicketUC.Delete() clearTable error = %v", err)
+				return
+			}
+		})
+	}
+}
+
+func TestTicketUC_PurchasePricing(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("TicketUC.Purchase() clearTable error = %v", err)
+		}
+	}()
+
+	if err := addTempData(&models.Ticket{ID: 1, Name: "barbie", Description: "barbie pink", Allocation: 10, Price: 2500, Currency: "USD"}); err != nil {
+		t.Fatalf("TicketUC.Purchase() addTempData error = %v", err)
+	}
+
+	clock := newFakeClock()
+	if _, err := newTestPromoCodeUC(clock).Create(context.TODO(), &models.CreatePromoCodeRequest{Code: "SUMMER10", DiscountType: models.DiscountTypePercent, Value: 10}); err != nil {
+		t.Fatalf("PromoCodeUC.Create() error = %v", err)
+	}
+
+	rc := newTestTicketUC(clock)
+	got, err := rc.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 3, PromoCode: "SUMMER10"})
+	if err != nil {
+		t.Fatalf("TicketUC.Purchase() error = %v", err)
+	}
+	if got.UnitPrice != 2500 || got.Currency != "USD" || got.Subtotal != 7500 || got.Discount != 750 || got.Total != 6750 {
+		t.Errorf("TicketUC.Purchase() = %v, want 3 x 2500 USD less 750", got)
+	}
+
+	// a later price change doesn't rewrite the purchase
+	price := int64(3000)
+	if _, err := rc.Update(context.TODO(), "1", &models.UpdateRequest{Price: &price}, nil); err != nil {
+		t.Fatalf("TicketUC.Update() error = %v", err)
+	}
+
+	purchaseUC := uc.NewPurchaseUC(
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		newTestPaymentUC(nil),
+		newTestCredentialUC(),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+		0,
+	)
+	stored, err := purchaseUC.GetByID(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("PurchaseUC.GetByID() error = %v", err)
+	}
+	if stored.UnitPrice != 2500 || stored.Total != 6750 {
+		t.Errorf("PurchaseUC.GetByID() = %v, want the totals of the purchase time", stored)
+	}
+}
@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+func newTestTierUC(clock pkg.Clock) *uc.TierUC {
+	return uc.NewTierUC(
+		repositories.NewTierRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		clock,
+		testTicketValidator,
+	)
+}
+
+func TestTierUC_Purchase(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("TierUC.Purchase() clearTable error = %v", err)
+		}
+	}()
+
+	ticket := models.Ticket{ID: 1, Name: "oppenheimer", Description: "oppenheimer imax", Currency: "EUR", Status: models.TicketStatusDraft}
+	if err := addTempData(&ticket); err != nil {
+		t.Fatalf("TierUC.Purchase() addTempData error = %v", err)
+	}
+
+	clock := newFakeClock()
+	rc := newTestTierUC(clock)
+	ticketUC := newTestTicketUC(clock)
+	purchaseUC := uc.NewPurchaseUC(
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		newTestPaymentUC(nil),
+		newTestCredentialUC(),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+		0,
+	)
+
+	earlyBird, err := rc.Create(context.TODO(), "1", &models.CreateTierRequest{Name: "early bird", Allocation: 2, Price: 1500, Position: 0})
+	if err != nil {
+		t.Fatalf("TierUC.Create() error = %v", err)
+	}
+	standard, err := rc.Create(context.TODO(), "1", &models.CreateTierRequest{Name: "standard", Allocation: 3, Price: 2500, Position: 1})
+	if err != nil {
+		t.Fatalf("TierUC.Create() error = %v", err)
+	}
+	if _, err := ticketUC.Publish(context.TODO(), "1", nil); err != nil {
+		t.Fatalf("TicketUC.Publish() error = %v", err)
+	}
+
+	purchase := func(tierID int64, quantity int, wantPrice int64) func() error {
+		return func() error {
+			got, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: quantity, TierID: tierID})
+			if err == nil && (got.TierID != tierID || got.UnitPrice != wantPrice) {
+				t.Errorf("TicketUC.Purchase() tier = %d price = %d, want %d %d", got.TierID, got.UnitPrice, tierID, wantPrice)
+			}
+			return err
+		}
+	}
+
+	steps := []struct {
+		name    string
+		action  func() error
+		wantErr bool
+		// remaining seats of the ticket and of each tier after the step
+		wantAllocation int
+		wantEarlyBird  int
+		wantStandard   int
+	}{
+		{
+			name:           "error - tiered ticket needs a tier",
+			action:         purchase(0, 1, 0),
+			wantErr:        true,
+			wantAllocation: 5, wantEarlyBird: 2, wantStandard: 3,
+		},
+		{
+			name:           "success - early bird at its own price",
+			action:         purchase(earlyBird.ID, 2, 1500),
+			wantAllocation: 3, wantEarlyBird: 0, wantStandard: 3,
+		},
+		{
+			name:           "error - early bird is sold out",
+			action:         purchase(earlyBird.ID, 1, 1500),
+			wantErr:        true,
+			wantAllocation: 3, wantEarlyBird: 0, wantStandard: 3,
+		},
+		{
+			name:           "success - standard still on sale",
+			action:         purchase(standard.ID, 1, 2500),
+			wantAllocation: 2, wantEarlyBird: 0, wantStandard: 2,
+		},
+		{
+			name: "error - ticket allocation is derived from the tiers",
+			action: func() error {
+				allocation := 10
+				_, err := ticketUC.Update(context.TODO(), "1", &models.UpdateRequest{Allocation: &allocation}, nil)
+				return err
+			},
+			wantErr:        true,
+			wantAllocation: 2, wantEarlyBird: 0, wantStandard: 2,
+		},
+		{
+			name: "success - refund returns seats to their tier",
+			action: func() error {
+				_, err := purchaseUC.Cancel(context.TODO(), "1", &models.CancelPurchaseRequest{Quantity: 1})
+				return err
+			},
+			wantAllocation: 3, wantEarlyBird: 1, wantStandard: 2,
+		},
+		{
+			name: "success - restocking a tier raises the ticket",
+			action: func() error {
+				allocation := 5
+				_, err := rc.Update(context.TODO(), "1", "2", &models.UpdateTierRequest{Allocation: &allocation})
+				return err
+			},
+			wantAllocation: 6, wantEarlyBird: 1, wantStandard: 5,
+		},
+	}
+	for _, step := range steps {
+		err := step.action()
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+
+		got, err := ticketUC.GetByID(context.TODO(), "1")
+		if err != nil {
+			t.Fatalf("TicketUC.GetByID() error = %v", err)
+		}
+		if got.Allocation != step.wantAllocation {
+			t.Errorf("%s: allocation = %d, want %d", step.name, got.Allocation, step.wantAllocation)
+		}
+		if len(got.Tiers) != 2 || got.Tiers[0].Allocation != step.wantEarlyBird || got.Tiers[1].Allocation != step.wantStandard {
+			t.Errorf("%s: tiers = %+v, want %d and %d seats", step.name, got.Tiers, step.wantEarlyBird, step.wantStandard)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const testHMACSecret = "test-secret"
+
+func TestTokenVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := pkg.NewTokenVerifier(testHMACSecret, writeJWKS(t, "key-1", &rsaKey.PublicKey), "tickets-auth", "tickets-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":   "344b6d2d-599a-4b23-b358-8f26512079a9",
+			"roles": []string{models.RoleOrganizer},
+			"iss":   "tickets-auth",
+			"aud":   "tickets-api",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	tests := []struct {
+		name    string
+		token   string
+		want    *models.Claims
+		wantErr bool
+	}{
+		{
+			name:  "success - HS256",
+			token: signHS256(t, testHMACSecret, validClaims()),
+			want: &models.Claims{
+				UserID: "344b6d2d-599a-4b23-b358-8f26512079a9",
+				Roles:  []string{models.RoleOrganizer},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "success - RS256 from jwks",
+			token: signRS256(t, rsaKey, "key-1", validClaims()),
+			want: &models.Claims{
+				UserID: "344b6d2d-599a-4b23-b358-8f26512079a9",
+				Roles:  []string{models.RoleOrganizer},
+			},
+			wantErr: false,
+		},
+		{
+			name: "success - single role claim",
+			token: signHS256(t, testHMACSecret, jwt.MapClaims{
+				"sub":  "alice",
+				"role": models.RoleAdmin,
+				"iss":  "tickets-auth",
+				"aud":  "tickets-api",
+				"exp":  time.Now().Add(time.Hour).Unix(),
+			}),
+			want: &models.Claims{
+				UserID: "alice",
+				Roles:  []string{models.RoleAdmin},
+			},
+			wantErr: false,
+		},
+		{
+			name: "success - email claim",
+			token: signHS256(t, testHMACSecret, jwt.MapClaims{
+				"sub":   "bob",
+				"email": "bob@example.com",
+				"iss":   "tickets-auth",
+				"aud":   "tickets-api",
+				"exp":   time.Now().Add(time.Hour).Unix(),
+			}),
+			want: &models.Claims{
+				UserID: "bob",
+				Roles:  []string{},
+				Email:  "bob@example.com",
+			},
+			wantErr: false,
+		},
+		{
+			name: "error - expired",
+			token: func() string {
+				claims := validClaims()
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return signHS256(t, testHMACSecret, claims)
+			}(),
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "error - wrong secret",
+			token:   signHS256(t, "another-secret", validClaims()),
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "error - unknown key id",
+			token:   signRS256(t, rsaKey, "key-2", validClaims()),
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "error - wrong audience",
+			token: func() string {
+				claims := validClaims()
+				claims["aud"] = "another-api"
+				return signHS256(t, testHMACSecret, claims)
+			}(),
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "error - missing subject",
+			token: func() string {
+				claims := validClaims()
+				delete(claims, "sub")
+				return signHS256(t, testHMACSecret, claims)
+			}(),
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "error - missing expiry",
+			token: func() string {
+				claims := validClaims()
+				delete(claims, "exp")
+				return signHS256(t, testHMACSecret, claims)
+			}(),
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "error - unsigned token",
+			token: func() string {
+				token, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			}(),
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TokenVerifier.Verify() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TokenVerifier.Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func signHS256(t *testing.T, secret string, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+// writeJWKS writes a JWKS file holding the public key and returns its path.
+func writeJWKS(t *testing.T, kid string, key *rsa.PublicKey) string {
+	set := map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			},
+		},
+	}
+
+	b, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
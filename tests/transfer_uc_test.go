@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+// testTransferCutoff is how long before the event test transfers close.
+const testTransferCutoff = 2 * time.Hour
+
+func newTestTransferUC(clock pkg.Clock) *uc.TransferUC {
+	return uc.NewTransferUC(
+		repositories.NewTransferRepository(test_db),
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewHoldRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestCredentialUC(),
+		clock,
+		testTicketValidator,
+		testTransferCutoff,
+	)
+}
+
+func TestTransferUC_Accept(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("TransferUC.Accept() clearTable error = %v", err)
+		}
+	}()
+
+	clock := newFakeClock()
+	ticket := models.Ticket{ID: 1, Name: "les miserables", Description: "les miserables musical", Allocation: 10, EventStart: clock.Now().Add(48 * time.Hour)}
+	if err := addTempData(&ticket); err != nil {
+		t.Fatalf("TransferUC.Accept() addTempData error = %v", err)
+	}
+
+	rc := newTestTransferUC(clock)
+	ticketUC := newTestTicketUC(clock)
+	credentialUC := newTestCredentialUC()
+
+	purchase, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 2})
+	if err != nil {
+		t.Fatalf("TicketUC.Purchase() error = %v", err)
+	}
+
+	create := func(userID string, request models.CreateTransferRequest) func() error {
+		return func() error {
+			request.UserID, request.ActorID = userID, userID
+			_, err := rc.Create(context.TODO(), "1", &request)
+			return err
+		}
+	}
+	accept := func(transferID, userID, email string) func() error {
+		return func() error {
+			_, err := rc.Accept(context.TODO(), transferID, &models.AcceptTransferRequest{UserID: userID, Email: email})
+			return err
+		}
+	}
+	cancel := func(transferID, userID string) func() error {
+		return func() error {
+			_, err := rc.Cancel(context.TODO(), transferID, &models.CancelTransferRequest{UserID: userID, ActorID: userID})
+			return err
+		}
+	}
+
+	steps := []struct {
+		name    string
+		action  func() error
+		wantErr bool
+		// owner of the purchase after the step
+		wantOwner string
+	}{
+		{
+			name:      "error - recipient already owns the purchase",
+			action:    create("alice", models.CreateTransferRequest{ToUserID: "alice"}),
+			wantErr:   true,
+			wantOwner: "alice",
+		},
+		{
+			name:      "error - only the owner can transfer",
+			action:    create("mallory", models.CreateTransferRequest{ToUserID: "mallory"}),
+			wantErr:   true,
+			wantOwner: "alice",
+		},
+		{
+			name:      "success - offered to an email",
+			action:    create("alice", models.CreateTransferRequest{ToEmail: "Bob@Example.com"}),
+			wantOwner: "alice",
+		},
+		{
+			name:      "error - one pending transfer at a time",
+			action:    create("alice", models.CreateTransferRequest{ToUserID: "carol"}),
+			wantErr:   true,
+			wantOwner: "alice",
+		},
+		{
+			name:      "error - addressed to someone else",
+			action:    accept("1", "carol", "carol@example.com"),
+			wantErr:   true,
+			wantOwner: "alice",
+		},
+		{
+			name:      "success - recipient accepts",
+			action:    accept("1", "bob", "bob@example.com"),
+			wantOwner: "bob",
+		},
+		{
+			name:      "error - accepted twice",
+			action:    accept("1", "bob", "bob@example.com"),
+			wantErr:   true,
+			wantOwner: "bob",
+		},
+		{
+			name:      "success - new owner offers it on",
+			action:    create("bob", models.CreateTransferRequest{ToUserID: "carol"}),
+			wantOwner: "bob",
+		},
+		{
+			name:      "error - only the sender can cancel",
+			action:    cancel("2", "alice"),
+			wantErr:   true,
+			wantOwner: "bob",
+		},
+		{
+			name:      "success - sender cancels",
+			action:    cancel("2", "bob"),
+			wantOwner: "bob",
+		},
+		{
+			name: "error - transfers closed before the event",
+			action: func() error {
+				clock.Advance(47 * time.Hour)
+				return create("bob", models.CreateTransferRequest{ToUserID: "carol"})()
+			},
+			wantErr:   true,
+			wantOwner: "bob",
+		},
+	}
+	for _, step := range steps {
+		err := step.action()
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+
+		got, err := repositories.NewPurchaseRepository(test_db).GetByID(context.TODO(), "1")
+		if err != nil {
+			t.Fatalf("PurchaseRepository.GetByID() error = %v", err)
+		}
+		if got.UserID != step.wantOwner {
+			t.Errorf("%s: owner = %s, want %s", step.name, got.UserID, step.wantOwner)
+		}
+	}
+
+	// the seats stay sold, only their credentials change
+	got, err := ticketUC.GetByID(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("TicketUC.GetByID() error = %v", err)
+	}
+	if got.Allocation != 8 {
+		t.Errorf("TicketUC.GetByID() allocation = %d, want 8", got.Allocation)
+	}
+
+	credentials, err := credentialUC.ListByPurchaseID(context.TODO(), purchase.ID)
+	if err != nil {
+		t.Fatalf("CredentialUC.ListByPurchaseID() error = %v", err)
+	}
+	valid := 0
+	for _, credential := range credentials {
+		switch {
+		case credential.UserID == "alice" && credential.Status != models.CredentialStatusRevoked:
+			t.Errorf("credential %d of the old owner is %s, want revoked", credential.ID, credential.Status)
+		case credential.UserID == "bob" && credential.Status == models.CredentialStatusValid:
+			valid++
+		}
+	}
+	if len(credentials) != 4 || valid != 2 {
+		t.Errorf("credentials = %d with %d valid for bob, want 4 with 2", len(credentials), valid)
+	}
+
+	transfers, err := rc.ListByPurchaseID(context.TODO(), "1", "bob")
+	if err != nil {
+		t.Fatalf("TransferUC.ListByPurchaseID() error = %v", err)
+	}
+	wantActions := [][]models.TransferAction{
+		{models.TransferActionInitiated, models.TransferActionAccepted},
+		{models.TransferActionInitiated, models.TransferActionCancelled},
+	}
+	if len(transfers) != len(wantActions) {
+		t.Fatalf("TransferUC.ListByPurchaseID() = %d transfers, want %d", len(transfers), len(wantActions))
+	}
+	for i, transfer := range transfers {
+		if len(transfer.Events) != len(wantActions[i]) {
+			t.Errorf("transfer %d events = %+v, want %v", transfer.ID, transfer.Events, wantActions[i])
+			continue
+		}
+		for j, event := range transfer.Events {
+			if event.Action != wantActions[i][j] {
+				t.Errorf("transfer %d event %d = %s, want %s", transfer.ID, j, event.Action, wantActions[i][j])
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+const testOfferTTL = 15 * time.Minute
+
+func newTestWaitlistUC(clock pkg.Clock) *uc.WaitlistUC {
+	return uc.NewWaitlistUC(
+		repositories.NewWaitlistRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewHoldRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestCheckoutUC(clock, newTestPaymentUC(nil)),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+		testOfferTTL,
+	)
+}
+
+func TestWaitlistUC_Offers(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("WaitlistUC.Offers() clearTable error = %v", err)
+		}
+	}()
+
+	if err := addTempData(&models.Ticket{ID: 1, Name: "barbie", Description: "barbie pink", Allocation: 2}); err != nil {
+		t.Fatalf("WaitlistUC.Offers() addTempData error = %v", err)
+	}
+
+	clock := newFakeClock()
+	rc := newTestWaitlistUC(clock)
+	ticketUC := newTestTicketUC(clock)
+	purchaseUC := uc.NewPurchaseUC(
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		rc,
+		newTestPaymentUC(nil),
+		newTestCredentialUC(),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+		0,
+	)
+
+	join := func(userID string, wantPosition int) func() error {
+		return func() error {
+			_, position, err := rc.Join(context.TODO(), "1", &models.WaitlistRequest{UserID: userID, Quantity: 1})
+			if err == nil && position != wantPosition {
+				t.Errorf("WaitlistUC.Join() position = %d, want %d", position, wantPosition)
+			}
+			return err
+		}
+	}
+	accept := func(entryID, userID string) func() error {
+		return func() error {
+			_, err := rc.Accept(context.TODO(), entryID, &models.AcceptOfferRequest{UserID: userID})
+			return err
+		}
+	}
+	purchase := func() error {
+		_, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 2})
+		return err
+	}
+	refund := func() error {
+		_, err := purchaseUC.Cancel(context.TODO(), "1", &models.CancelPurchaseRequest{Quantity: 1})
+		return err
+	}
+	expire := func() error {
+		clock.Advance(testOfferTTL + time.Second)
+		_, err := rc.ExpireOffers(context.TODO())
+		return err
+	}
+
+	// the steps run in order, each one starts from the state the previous one left
+	steps := []struct {
+		name           string
+		action         func() error
+		wantErr        error
+		wantStatuses   []models.WaitlistStatus
+		wantAllocation int
+	}{
+		{name: "available ticket can't be joined", action: join("bob", 1), wantErr: pkg.ErrTicketAvailable, wantAllocation: 2},
+		{name: "sell out", action: purchase, wantAllocation: 0},
+		{name: "bob joins", action: join("bob", 1), wantStatuses: []models.WaitlistStatus{models.WaitlistStatusWaiting}},
+		{name: "carol joins", action: join("carol", 2), wantStatuses: []models.WaitlistStatus{models.WaitlistStatusWaiting, models.WaitlistStatusWaiting}},
+		{name: "bob can't join twice", action: join("bob", 1), wantErr: pkg.ErrAlreadyWaitlisted, wantStatuses: []models.WaitlistStatus{models.WaitlistStatusWaiting, models.WaitlistStatusWaiting}},
+		{name: "refunded seat is offered to bob", action: refund, wantStatuses: []models.WaitlistStatus{models.WaitlistStatusOffered, models.WaitlistStatusWaiting}},
+		{name: "expired offer passes to carol", action: expire, wantStatuses: []models.WaitlistStatus{models.WaitlistStatusExpired, models.WaitlistStatusOffered}},
+		{name: "bob's offer is gone", action: accept("1", "bob"), wantErr: pkg.ErrOfferNotActive, wantStatuses: []models.WaitlistStatus{models.WaitlistStatusExpired, models.WaitlistStatusOffered}},
+		{name: "carol accepts", action: accept("2", "carol"), wantStatuses: []models.WaitlistStatus{models.WaitlistStatusExpired, models.WaitlistStatusFulfilled}},
+	}
+	for _, step := range steps {
+		if err := step.action(); !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		for i, wantStatus := range step.wantStatuses {
+			entry, _, err := rc.GetByID(context.TODO(), strconv.Itoa(i+1))
+			if err != nil {
+				t.Fatalf("%s: WaitlistUC.GetByID() error = %v", step.name, err)
+			}
+			if entry.Status != wantStatus {
+				t.Errorf("%s: entry %d status = %v, want %v", step.name, i+1, entry.Status, wantStatus)
+			}
+		}
+		ticket, err := ticketUC.GetByID(context.TODO(), "1")
+		if err != nil {
+			t.Fatalf("%s: TicketUC.GetByID() error = %v", step.name, err)
+		}
+		if ticket.Allocation != step.wantAllocation {
+			t.Errorf("%s: allocation = %d, want %d", step.name, ticket.Allocation, step.wantAllocation)
+		}
+	}
+
+	purchases, err := purchaseUC.ListByTicketID(context.TODO(), "1")
+	if err != nil {
+		t.Fatalf("PurchaseUC.ListByTicketID() error = %v", err)
+	}
+	if len(purchases) != 2 || purchases[1].UserID != "carol" || purchases[1].Quantity != 1 {
+		t.Errorf("PurchaseUC.ListByTicketID() = %v, want alice's purchase and carol's accepted offer", purchases)
+	}
+}
+
+func TestWaitlistUC_Limits(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("WaitlistUC.Limits() clearTable error = %v", err)
+		}
+	}()
+
+	if err := addTempData(&models.Ticket{ID: 1, Name: "barbie", Description: "barbie pink", Allocation: 2, Status: models.TicketStatusOnSale, MaxPerUser: 2}); err != nil {
+		t.Fatalf("WaitlistUC.Limits() addTempData error = %v", err)
+	}
+
+	clock := newFakeClock()
+	rc := newTestWaitlistUC(clock)
+	ticketUC := newTestTicketUC(clock)
+	purchaseUC := uc.NewPurchaseUC(
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		rc,
+		newTestPaymentUC(nil),
+		newTestCredentialUC(),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+		0,
+	)
+
+	// the steps run in order, each one starts from the state the previous one left
+	steps := []struct {
+		name    string
+		action  func() error
+		wantErr error
+	}{
+		{name: "alice buys her limit", action: func() error {
+			_, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 2})
+			return err
+		}},
+		{name: "alice can't queue past her limit", action: func() error {
+			_, _, err := rc.Join(context.TODO(), "1", &models.WaitlistRequest{UserID: "alice", Quantity: 1})
+			return err
+		}, wantErr: pkg.ErrPurchaseLimitExceeded},
+		{name: "bob joins", action: func() error {
+			_, _, err := rc.Join(context.TODO(), "1", &models.WaitlistRequest{UserID: "bob", Quantity: 1})
+			return err
+		}},
+		{name: "refunded seat is offered to bob", action: func() error {
+			_, err := purchaseUC.Cancel(context.TODO(), "1", &models.CancelPurchaseRequest{Quantity: 1})
+			return err
+		}},
+		{name: "paused ticket", action: func() error {
+			_, err := ticketUC.Pause(context.TODO(), "1", nil)
+			return err
+		}},
+		{name: "offer of a paused ticket can't be accepted", action: func() error {
+			_, err := rc.Accept(context.TODO(), "1", &models.AcceptOfferRequest{UserID: "bob"})
+			return err
+		}, wantErr: pkg.ErrTicketNotOnSale},
+		{name: "back on sale", action: func() error {
+			_, err := ticketUC.Publish(context.TODO(), "1", nil)
+			return err
+		}},
+		{name: "bob accepts", action: func() error {
+			_, err := rc.Accept(context.TODO(), "1", &models.AcceptOfferRequest{UserID: "bob"})
+			return err
+		}},
+	}
+	for _, step := range steps {
+		if err := step.action(); !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories"
+	"github.com/fleimkeipa/tickets-api/uc"
+)
+
+const (
+	testWebhookMaxAttempts = 3
+	testWebhookBackoff     = time.Minute
+	testSubscriptionSecret = "subscription-secret"
+)
+
+func newTestWebhookUC(clock pkg.Clock) *uc.WebhookUC {
+	return uc.NewWebhookUC(
+		repositories.NewWebhookRepository(test_db),
+		pkg.NewWebhookSender(time.Second),
+		clock,
+		testTicketValidator,
+		testWebhookMaxAttempts,
+		testWebhookBackoff,
+	)
+}
+
+// webhookReceiver is a subscriber endpoint that records the webhooks it gets and answers with status.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	received []receivedWebhook
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func (rc *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.received = append(rc.received, receivedWebhook{header: r.Header, body: body})
+	w.WriteHeader(rc.status)
+}
+
+func (rc *webhookReceiver) answer(status int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.status = status
+}
+
+// take returns the webhooks received since the last call.
+func (rc *webhookReceiver) take() []receivedWebhook {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	received := rc.received
+	rc.received = nil
+
+	return received
+}
+
+func TestWebhookUC_Deliver(t *testing.T) {
+	test_db, terminateDB = pkg.GetTestInstance(context.TODO())
+	defer terminateDB()
+	defer func() {
+		if err := clearTable(); err != nil {
+			t.Errorf("WebhookUC.Deliver() clearTable error = %v", err)
+		}
+	}()
+
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	clock := newFakeClock()
+	rc := newTestWebhookUC(clock)
+	relay := newTestOutboxUC(clock, rc)
+	ticketUC := newTestTicketUC(clock)
+	purchaseUC := uc.NewPurchaseUC(
+		repositories.NewPurchaseRepository(test_db),
+		repositories.NewTicketRepository(test_db),
+		repositories.NewTierRepository(test_db),
+		repositories.NewTxManager(test_db),
+		newTestWaitlistUC(clock),
+		newTestPaymentUC(nil),
+		newTestCredentialUC(),
+		newTestOutboxUC(clock, pkg.NewInProcessEventPublisher()),
+		clock,
+		testTicketValidator,
+		0,
+	)
+
+	sales, err := rc.Create(context.TODO(), &models.CreateWebhookRequest{
+		URL:        server.URL + "/sales",
+		Secret:     testSubscriptionSecret,
+		EventTypes: []string{models.EventTicketCreated, models.EventTicketSoldOut, models.EventPurchaseCompleted},
+	})
+	if err != nil {
+		t.Fatalf("WebhookUC.Create() error = %v", err)
+	}
+	refunds, err := rc.Create(context.TODO(), &models.CreateWebhookRequest{
+		URL:        server.URL + "/refunds",
+		Secret:     testSubscriptionSecret,
+		EventTypes: []string{models.EventPurchaseRefunded},
+	})
+	if err != nil {
+		t.Fatalf("WebhookUC.Create() error = %v", err)
+	}
+
+	if _, err := ticketUC.Create(context.TODO(), &models.CreateRequest{Name: "les miserables", Allocation: 2, Status: models.TicketStatusOnSale}); err != nil {
+		t.Fatalf("TicketUC.Create() error = %v", err)
+	}
+	if _, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "alice", Quantity: 2}); err != nil {
+		t.Fatalf("TicketUC.Purchase() error = %v", err)
+	}
+
+	// a failed purchase reports nothing
+	if _, err := ticketUC.Purchase(context.TODO(), "1", &models.PurchaseRequest{UserID: "bob", Quantity: 1}); err == nil {
+		t.Fatalf("TicketUC.Purchase() of a sold out ticket succeeded")
+	}
+
+	// deliveries are queued when the outbox relays the events
+	if sent, err := rc.DeliverDue(context.TODO()); err != nil || sent != 0 {
+		t.Fatalf("WebhookUC.DeliverDue() before the relay = %d, error = %v, want 0", sent, err)
+	}
+	if published, err := relay.Relay(context.TODO()); err != nil || published != 3 {
+		t.Fatalf("OutboxUC.Relay() = %d, error = %v, want 3", published, err)
+	}
+
+	// an event the relay publishes again is not queued twice
+	if err := rc.Publish(context.TODO(), &pkg.Event{ID: 1, Type: models.EventTicketCreated, TicketID: 1, Payload: json.RawMessage(`{}`)}); err != nil {
+		t.Fatalf("WebhookUC.Publish() error = %v", err)
+	}
+
+	sent, err := rc.DeliverDue(context.TODO())
+	if err != nil {
+		t.Fatalf("WebhookUC.DeliverDue() error = %v", err)
+	}
+	if sent != 3 {
+		t.Fatalf("WebhookUC.DeliverDue() = %d, want 3", sent)
+	}
+
+	wantEvents := map[string]bool{
+		models.EventTicketCreated:     true,
+		models.EventTicketSoldOut:     true,
+		models.EventPurchaseCompleted: true,
+	}
+	for _, webhook := range receiver.take() {
+		timestamp := webhook.header.Get(pkg.HeaderWebhookTimestamp)
+		if !pkg.VerifyWebhook(testSubscriptionSecret, timestamp, webhook.body, webhook.header.Get(pkg.HeaderWebhookSignature)) {
+			t.Errorf("webhook %s has an invalid signature", webhook.body)
+		}
+
+		var payload struct {
+			ID   string                 `json:"id"`
+			Type string                 `json:"type"`
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(webhook.body, &payload); err != nil {
+			t.Fatalf("webhook payload error = %v", err)
+		}
+		if payload.Type != webhook.header.Get(pkg.HeaderWebhookEvent) || !wantEvents[payload.Type] {
+			t.Errorf("webhook type = %s, header %s, want one of %v", payload.Type, webhook.header.Get(pkg.HeaderWebhookEvent), wantEvents)
+		}
+		delete(wantEvents, payload.Type)
+
+		if _, ok := payload.Data["credentials"]; ok {
+			t.Errorf("webhook %s carries credentials", payload.Type)
+		}
+	}
+	if len(wantEvents) != 0 {
+		t.Errorf("webhooks not received: %v", wantEvents)
+	}
+
+	// the refund endpoint is down, its delivery backs off until it runs out of attempts
+	receiver.answer(http.StatusServiceUnavailable)
+	if _, err := purchaseUC.Cancel(context.TODO(), "1", &models.CancelPurchaseRequest{Quantity: 1}); err != nil {
+		t.Fatalf("PurchaseUC.Cancel() error = %v", err)
+	}
+	if published, err := relay.Relay(context.TODO()); err != nil || published != 1 {
+		t.Fatalf("OutboxUC.Relay() = %d, error = %v, want 1", published, err)
+	}
+
+	refundsID := strconv.FormatInt(refunds.ID, 10)
+	steps := []struct {
+		name         string
+		advance      time.Duration
+		wantSent     int
+		wantStatus   models.WebhookDeliveryStatus
+		wantAttempts int
+	}{
+		{name: "first attempt fails", wantSent: 1, wantStatus: models.WebhookDeliveryStatusPending, wantAttempts: 1},
+		{name: "not due before the backoff", advance: testWebhookBackoff - time.Second, wantSent: 0, wantStatus: models.WebhookDeliveryStatusPending, wantAttempts: 1},
+		{name: "second attempt after the backoff", advance: time.Second, wantSent: 1, wantStatus: models.WebhookDeliveryStatusPending, wantAttempts: 2},
+		{name: "backoff doubles", advance: testWebhookBackoff, wantSent: 0, wantStatus: models.WebhookDeliveryStatusPending, wantAttempts: 2},
+		{name: "last attempt fails for good", advance: testWebhookBackoff, wantSent: 1, wantStatus: models.WebhookDeliveryStatusFailed, wantAttempts: 3},
+	}
+	for _, step := range steps {
+		clock.Advance(step.advance)
+
+		sent, err := rc.DeliverDue(context.TODO())
+		if err != nil {
+			t.Fatalf("%s: WebhookUC.DeliverDue() error = %v", step.name, err)
+		}
+		if sent != step.wantSent {
+			t.Errorf("%s: WebhookUC.DeliverDue() = %d, want %d", step.name, sent, step.wantSent)
+		}
+
+		deliveries, err := rc.ListDeliveries(context.TODO(), refundsID, &models.WebhookDeliveryListRequest{})
+		if err != nil {
+			t.Fatalf("%s: WebhookUC.ListDeliveries() error = %v", step.name, err)
+		}
+		if len(deliveries) != 1 || deliveries[0].Status != step.wantStatus || deliveries[0].Attempts != step.wantAttempts {
+			t.Fatalf("%s: deliveries = %+v, want one %s after %d attempts", step.name, deliveries, step.wantStatus, step.wantAttempts)
+		}
+		if deliveries[0].ResponseStatus != http.StatusServiceUnavailable {
+			t.Errorf("%s: response status = %d, want %d", step.name, deliveries[0].ResponseStatus, http.StatusServiceUnavailable)
+		}
+	}
+	failed, err := rc.ListDeliveries(context.TODO(), refundsID, &models.WebhookDeliveryListRequest{Status: models.WebhookDeliveryStatusFailed})
+	if err != nil || len(failed) != 1 {
+		t.Fatalf("WebhookUC.ListDeliveries() = %d failed deliveries, error = %v", len(failed), err)
+	}
+	receiver.take()
+
+	// once the endpoint is back the failed delivery can be sent again by hand
+	receiver.answer(http.StatusNoContent)
+	redelivery, err := rc.Redeliver(context.TODO(), refundsID, strconv.FormatInt(failed[0].ID, 10))
+	if err != nil {
+		t.Fatalf("WebhookUC.Redeliver() error = %v", err)
+	}
+	if redelivery.Status != models.WebhookDeliveryStatusSucceeded || redelivery.RedeliveryOf != failed[0].ID || redelivery.Attempts != 1 {
+		t.Errorf("WebhookUC.Redeliver() = %+v, want a succeeded redelivery of %d", redelivery, failed[0].ID)
+	}
+
+	received := receiver.take()
+	if len(received) != 1 {
+		t.Fatalf("received %d webhooks, want 1", len(received))
+	}
+	var payload models.WebhookPayload
+	if err := json.Unmarshal(received[0].body, &payload); err != nil {
+		t.Fatalf("webhook payload error = %v", err)
+	}
+	if payload.Type != models.EventPurchaseRefunded || payload.ID != failed[0].EventID {
+		t.Errorf("redelivered payload = %+v, want the purchase.refunded event %s", payload, failed[0].EventID)
+	}
+
+	// each subscription only got its own event types, the log keeps the failed delivery next to its redelivery
+	wantDeliveries := map[*models.WebhookSubscription]int{sales: 3, refunds: 2}
+	for subscription, want := range wantDeliveries {
+		deliveries, err := rc.ListDeliveries(context.TODO(), strconv.FormatInt(subscription.ID, 10), &models.WebhookDeliveryListRequest{})
+		if err != nil {
+			t.Fatalf("WebhookUC.ListDeliveries() error = %v", err)
+		}
+		if len(deliveries) != want {
+			t.Errorf("subscription %d has %d deliveries, want %d", subscription.ID, len(deliveries), want)
+		}
+		for _, delivery := range deliveries {
+			if !subscription.Subscribes(delivery.EventType) {
+				t.Errorf("subscription %d got a %s delivery", subscription.ID, delivery.EventType)
+			}
+		}
+	}
+}
+
+func TestWebhookSender_Send(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	sentAt := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	payload := []byte(`{"id":"evt_1","type":"ticket.created"}`)
+
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "success - 2xx", status: http.StatusAccepted, wantStatus: http.StatusAccepted},
+		{name: "error - 4xx", status: http.StatusGone, wantStatus: http.StatusGone, wantErr: true},
+		{name: "error - 5xx", status: http.StatusBadGateway, wantStatus: http.StatusBadGateway, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver.answer(tt.status)
+
+			status, err := pkg.NewWebhookSender(time.Second).Send(context.TODO(), &pkg.WebhookRequest{
+				URL:        server.URL,
+				Secret:     testSubscriptionSecret,
+				EventType:  models.EventTicketCreated,
+				DeliveryID: 7,
+				Payload:    payload,
+				SentAt:     sentAt,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WebhookSender.Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("WebhookSender.Send() status = %d, want %d", status, tt.wantStatus)
+			}
+
+			received := receiver.take()
+			if len(received) != 1 {
+				t.Fatalf("received %d webhooks, want 1", len(received))
+			}
+			header := received[0].header
+			if header.Get(pkg.HeaderWebhookTimestamp) != "1727784000" || header.Get(pkg.HeaderWebhookDelivery) != "7" {
+				t.Errorf("webhook headers = %v", header)
+			}
+			if !pkg.VerifyWebhook(testSubscriptionSecret, header.Get(pkg.HeaderWebhookTimestamp), received[0].body, header.Get(pkg.HeaderWebhookSignature)) {
+				t.Errorf("webhook signature %s does not verify", header.Get(pkg.HeaderWebhookSignature))
+			}
+			if pkg.VerifyWebhook("another-secret-value", header.Get(pkg.HeaderWebhookTimestamp), received[0].body, header.Get(pkg.HeaderWebhookSignature)) {
+				t.Errorf("webhook signature verifies with another secret")
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package uc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+)
+
+const (
+	// apiKeyPrefix marks the keys of this API so leaked keys are easy to spot.
+	apiKeyPrefix = "tk_"
+	// apiKeyDisplayLength is how much of the key is kept in plain text to tell keys apart.
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+	// apiKeyTouchInterval is the resolution of the last used time of a key.
+	apiKeyTouchInterval = time.Minute
+)
+
+type APIKeyUC struct {
+	apiKeyRepo interfaces.APIKeyInterfaces
+	clock      pkg.Clock
+	validator  *pkg.CustomValidator
+}
+
+func NewAPIKeyUC(apiKeyRepo interfaces.APIKeyInterfaces, clock pkg.Clock, validator *pkg.CustomValidator) *APIKeyUC {
+	return &APIKeyUC{
+		apiKeyRepo: apiKeyRepo,
+		clock:      clock,
+		validator:  validator,
+	}
+}
+
+// Create issues a new API key and returns it with its plain value, which is not stored and can't be shown again.
+func (rc *APIKeyUC) Create(ctx context.Context, request *models.CreateAPIKeyRequest) (*models.APIKey, string, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, "", pkg.NewError(err, "failed to validate api key request", http.StatusBadRequest)
+	}
+
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(rc.clock.Now()) {
+		return nil, "", pkg.NewError(errors.New("expiry is in the past"), "expires_at must be in the future", http.StatusBadRequest)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", pkg.NewError(err, "failed to generate api key", http.StatusInternalServerError)
+	}
+	plain := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key := models.APIKey{
+		Name:      request.Name,
+		Prefix:    plain[:apiKeyDisplayLength],
+		Hash:      hashAPIKey(plain),
+		Scopes:    request.Scopes,
+		CreatedBy: request.CreatedBy,
+	}
+	if request.ExpiresAt != nil {
+		key.ExpiresAt = request.ExpiresAt.UTC()
+	}
+
+	created, err := rc.apiKeyRepo.Create(ctx, &key)
+	if err != nil {
+		return nil, "", pkg.NewError(err, "failed to create api key", http.StatusInternalServerError)
+	}
+
+	return created, plain, nil
+}
+
+// List retrieves every issued API key, revoked and expired ones included.
+func (rc *APIKeyUC) List(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := rc.apiKeyRepo.List(ctx)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to list api keys", http.StatusInternalServerError)
+	}
+
+	return keys, nil
+}
+
+// Revoke permanently disables an API key.
+func (rc *APIKeyUC) Revoke(ctx context.Context, id string) (*models.APIKey, error) {
+	key, err := rc.apiKeyRepo.Revoke(ctx, id, rc.clock.Now())
+	if err != nil {
+		if errors.Is(err, pkg.ErrAPIKeyRevoked) {
+			return nil, pkg.NewError(err, "api key was already revoked", http.StatusConflict)
+		}
+		return nil, pkg.NewError(err, "failed to find api key", http.StatusNotFound)
+	}
+
+	return key, nil
+}
+
+// Authenticate resolves a plain API key to its record. Unknown, revoked and expired keys are rejected.
+func (rc *APIKeyUC) Authenticate(ctx context.Context, plain string) (*models.APIKey, error) {
+	key, err := rc.apiKeyRepo.GetByHash(ctx, hashAPIKey(plain))
+	if err != nil {
+		return nil, pkg.NewError(err, "invalid api key", http.StatusUnauthorized)
+	}
+
+	now := rc.clock.Now()
+	if !key.RevokedAt.IsZero() {
+		return nil, pkg.NewError(pkg.ErrAPIKeyRevoked, "api key was revoked", http.StatusUnauthorized)
+	}
+
+	if !key.ExpiresAt.IsZero() && !key.ExpiresAt.After(now) {
+		return nil, pkg.NewError(errors.New("api key expired"), "api key has expired", http.StatusUnauthorized)
+	}
+
+	if err := rc.apiKeyRepo.Touch(ctx, key.ID, now, now.Add(-apiKeyTouchInterval)); err != nil {
+		return nil, pkg.NewError(err, "failed to record api key use", http.StatusInternalServerError)
+	}
+
+	return key, nil
+}
+
+// hashAPIKey returns the hex encoded SHA-256 hash the key is stored under.
+// The keys are random, so a plain hash is enough and keeps lookups a single indexed query.
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
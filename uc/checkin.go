@@ -0,0 +1,322 @@
+package uc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+)
+
+type CheckinUC struct {
+	checkinRepo    interfaces.CheckinInterfaces
+	credentialRepo interfaces.CredentialInterfaces
+	ticketRepo     interfaces.TicketInterfaces
+	txManager      interfaces.TxInterfaces
+	signer         *pkg.CredentialSigner
+	clock          pkg.Clock
+	validator      *pkg.CustomValidator
+}
+
+func NewCheckinUC(checkinRepo interfaces.CheckinInterfaces, credentialRepo interfaces.CredentialInterfaces, ticketRepo interfaces.TicketInterfaces, txManager interfaces.TxInterfaces, signer *pkg.CredentialSigner, clock pkg.Clock, validator *pkg.CustomValidator) *CheckinUC {
+	return &CheckinUC{
+		checkinRepo:    checkinRepo,
+		credentialRepo: credentialRepo,
+		ticketRepo:     ticketRepo,
+		txManager:      txManager,
+		signer:         signer,
+		clock:          clock,
+		validator:      validator,
+	}
+}
+
+// Check verifies a scanned credential and lets it in at most once: the first valid scan marks it used atomically,
+// later scans are reported as already used with the time and gate of the first one. Every scan is recorded.
+func (rc *CheckinUC) Check(ctx context.Context, request *models.CheckinRequest) (*models.Checkin, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate check-in request", http.StatusBadRequest)
+	}
+
+	checkin := models.Checkin{
+		Gate:      request.Gate,
+		ScannedBy: request.ScannedBy,
+		ScannedAt: rc.clock.Now(),
+	}
+
+	var created *models.Checkin
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		if err := rc.verify(ctx, request.Payload, &checkin); err != nil {
+			return err
+		}
+
+		var err error
+		created, err = rc.checkinRepo.Create(ctx, &checkin)
+		if err != nil {
+			return pkg.NewError(err, "failed to record check-in", http.StatusInternalServerError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, txError(err, "failed to check in")
+	}
+
+	return created, nil
+}
+
+// verify fills in the result and credential of a scan, marking the credential used when it gets in.
+func (rc *CheckinUC) verify(ctx context.Context, payload string, checkin *models.Checkin) error {
+	claims, err := rc.signer.Verify(payload)
+	if err != nil {
+		checkin.Result = models.CheckinResultForged
+		return nil
+	}
+
+	credential, used, err := rc.credentialRepo.MarkUsed(ctx, claims.CredentialID, checkin.Gate, checkin.ScannedAt)
+	if err != nil {
+		return pkg.NewError(err, "failed to check in credential", http.StatusInternalServerError)
+	}
+
+	if !used {
+		credential, err = rc.credentialRepo.GetByID(ctx, strconv.FormatInt(claims.CredentialID, 10))
+		if err != nil {
+			// a credential we signed but don't know anymore can't get anyone in
+			checkin.Result = models.CheckinResultForged
+			return nil
+		}
+	}
+
+	checkin.CredentialID = credential.ID
+	checkin.TicketID = credential.TicketID
+	checkin.Credential = credential
+
+	switch {
+	case used:
+		checkin.Result = models.CheckinResultValid
+	case credential.Status == models.CredentialStatusRevoked:
+		checkin.Result = models.CheckinResultRevoked
+	default:
+		checkin.Result = models.CheckinResultAlreadyUsed
+	}
+
+	return nil
+}
+
+// Stats returns the live attendance of a ticket against its sold seats.
+func (rc *CheckinUC) Stats(ctx context.Context, ticketID string) (*models.CheckinStats, error) {
+	ticket, err := rc.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+	}
+
+	sold, gates, err := rc.credentialRepo.CountByTicketID(ctx, ticketID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to count check-ins", http.StatusInternalServerError)
+	}
+
+	stats := models.CheckinStats{
+		TicketID: ticket.ID,
+		Sold:     sold,
+		Gates:    gates,
+	}
+	for _, gate := range gates {
+		stats.CheckedIn += gate.CheckedIn
+	}
+
+	return &stats, nil
+}
+
+// Snapshot returns the signed list of valid credentials of a ticket, so a scanner can keep checking people in while
+// it is offline. Credentials that already got in carry their first scan.
+func (rc *CheckinUC) Snapshot(ctx context.Context, ticketID string) (*models.SignedSnapshot, error) {
+	ticket, err := rc.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+	}
+
+	credentials, err := rc.credentialRepo.ListValidByTicketID(ctx, ticketID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to list credentials", http.StatusInternalServerError)
+	}
+
+	snapshot := models.ScannerSnapshot{
+		TicketID:    ticket.ID,
+		GeneratedAt: rc.clock.Now(),
+		Credentials: make([]models.SnapshotCredential, 0, len(credentials)),
+	}
+	for _, credential := range credentials {
+		item := models.SnapshotCredential{
+			ID:         credential.ID,
+			PurchaseID: credential.PurchaseID,
+			Seat:       credential.Seat,
+		}
+		if !credential.UsedAt.IsZero() {
+			usedAt := credential.UsedAt
+			item.UsedAt = &usedAt
+			item.UsedGate = credential.UsedGate
+		}
+		snapshot.Credentials = append(snapshot.Credentials, item)
+	}
+
+	message, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to encode snapshot", http.StatusInternalServerError)
+	}
+
+	return &models.SignedSnapshot{
+		Snapshot:  base64.RawURLEncoding.EncodeToString(message),
+		Signature: rc.signer.SignMessage(message),
+	}, nil
+}
+
+// Sync records a batch of scans a scanner made while it was offline. Scans are replayed in device time order, so
+// the same scans always give the same verdicts whatever order they arrive in: the earliest scan of a credential lets
+// it in, even over a later scan synced before, ties going to the lowest gate. Every other scan of the credential is
+// rejected as already used and reported as a conflict.
+func (rc *CheckinUC) Sync(ctx context.Context, ticketID string, request *models.ScannerSyncRequest) (*models.ScannerSync, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate scanner sync request", http.StatusBadRequest)
+	}
+
+	ticket, err := rc.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+	}
+
+	checkins := make([]models.Checkin, len(request.Scans))
+	ids := make([]int64, 0, len(request.Scans))
+	for i, scan := range request.Scans {
+		checkins[i] = models.Checkin{
+			TicketID:  ticket.ID,
+			Gate:      scan.Gate,
+			Result:    models.CheckinResultForged,
+			ScannedBy: request.ScannedBy,
+			// the database keeps microseconds, a batch uploaded again must compare equal to what was stored
+			ScannedAt: scan.ScannedAt.UTC().Truncate(time.Microsecond),
+			DeviceID:  request.DeviceID,
+		}
+
+		// credentials of another ticket can't get in here either
+		claims, err := rc.signer.Verify(scan.Payload)
+		if err != nil || claims.TicketID != ticket.ID {
+			continue
+		}
+		checkins[i].CredentialID = claims.CredentialID
+		ids = append(ids, claims.CredentialID)
+	}
+
+	// the batch comes from a single device, equal scans keep their upload order
+	order := make([]int, len(checkins))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		x, y := checkins[order[a]], checkins[order[b]]
+		return scannedBefore(x.ScannedAt, x.Gate, y.ScannedAt, y.Gate)
+	})
+
+	var conflicts []models.ScanConflict
+	err = rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		credentials, err := rc.credentialRepo.ListByIDsForUpdate(ctx, ids)
+		if err != nil {
+			return pkg.NewError(err, "failed to lock credentials", http.StatusInternalServerError)
+		}
+
+		byID := make(map[int64]*models.Credential, len(credentials))
+		for i := range credentials {
+			byID[credentials[i].ID] = &credentials[i]
+		}
+
+		rejected := make(map[int64][]models.RejectedScan)
+		changed := make(map[int64]bool)
+		for _, i := range order {
+			checkin := &checkins[i]
+
+			credential, ok := byID[checkin.CredentialID]
+			if !ok {
+				// a credential we signed but don't know anymore can't get anyone in
+				checkin.CredentialID = 0
+				continue
+			}
+
+			switch {
+			case credential.Status == models.CredentialStatusRevoked:
+				checkin.Result = models.CheckinResultRevoked
+			case credential.UsedAt.IsZero() || scannedBefore(checkin.ScannedAt, checkin.Gate, credential.UsedAt, credential.UsedGate):
+				if !credential.UsedAt.IsZero() {
+					rejected[credential.ID] = append(rejected[credential.ID], models.RejectedScan{Gate: credential.UsedGate, ScannedAt: credential.UsedAt})
+				}
+				credential.UsedAt = checkin.ScannedAt
+				credential.UsedGate = checkin.Gate
+				changed[credential.ID] = true
+				checkin.Result = models.CheckinResultValid
+			case checkin.ScannedAt.Equal(credential.UsedAt) && checkin.Gate == credential.UsedGate:
+				// the scan that got in, uploaded again
+				checkin.Result = models.CheckinResultValid
+			default:
+				rejected[credential.ID] = append(rejected[credential.ID], models.RejectedScan{Gate: checkin.Gate, DeviceID: checkin.DeviceID, ScannedAt: checkin.ScannedAt})
+				checkin.Result = models.CheckinResultAlreadyUsed
+			}
+
+			scanned := *credential
+			checkin.Credential = &scanned
+		}
+
+		for i := range credentials {
+			credential := &credentials[i]
+			if !changed[credential.ID] {
+				continue
+			}
+			if err := rc.credentialRepo.SetUsed(ctx, credential.ID, credential.UsedGate, credential.UsedAt); err != nil {
+				return pkg.NewError(err, "failed to check in credential", http.StatusInternalServerError)
+			}
+		}
+
+		if _, err := rc.checkinRepo.CreateMany(ctx, checkins); err != nil {
+			return pkg.NewError(err, "failed to record check-ins", http.StatusInternalServerError)
+		}
+
+		// credentials are in ID order, so are the conflicts
+		conflicts = make([]models.ScanConflict, 0, len(rejected))
+		for _, credential := range credentials {
+			scans, ok := rejected[credential.ID]
+			if !ok {
+				continue
+			}
+			sort.SliceStable(scans, func(a, b int) bool {
+				return scannedBefore(scans[a].ScannedAt, scans[a].Gate, scans[b].ScannedAt, scans[b].Gate)
+			})
+			conflicts = append(conflicts, models.ScanConflict{
+				CredentialID: credential.ID,
+				Gate:         credential.UsedGate,
+				ScannedAt:    credential.UsedAt,
+				Rejected:     scans,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, txError(err, "failed to sync scans")
+	}
+
+	return &models.ScannerSync{
+		Checkins:  checkins,
+		Conflicts: conflicts,
+	}, nil
+}
+
+// scannedBefore reports whether the first scan precedes the second, equal times are ordered by gate.
+func scannedBefore(at time.Time, gate string, otherAt time.Time, otherGate string) bool {
+	if !at.Equal(otherAt) {
+		return at.Before(otherAt)
+	}
+
+	return gate < otherGate
+}
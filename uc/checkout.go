@@ -0,0 +1,289 @@
+package uc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+
+	"go.uber.org/zap"
+)
+
+type CheckoutUC struct {
+	purchaseRepo interfaces.PurchaseInterfaces
+	ticketRepo   interfaces.TicketInterfaces
+	holdRepo     interfaces.HoldInterfaces
+	waitlistRepo interfaces.WaitlistInterfaces
+	listingRepo  interfaces.ListingInterfaces
+	promoRepo    interfaces.PromoCodeInterfaces
+	txManager    interfaces.TxInterfaces
+	paymentUC    *PaymentUC
+	credentialUC *CredentialUC
+	outboxUC     *OutboxUC
+	clock        pkg.Clock
+	lease        time.Duration
+}
+
+// NewCheckoutUC creates a CheckoutUC. Purchases still pending after lease are settled by the sweeper, the lease must
+// be longer than a payment may take, at least twice the timeout of the payment provider.
+func NewCheckoutUC(purchaseRepo interfaces.PurchaseInterfaces, ticketRepo interfaces.TicketInterfaces, holdRepo interfaces.HoldInterfaces, waitlistRepo interfaces.WaitlistInterfaces, listingRepo interfaces.ListingInterfaces, promoRepo interfaces.PromoCodeInterfaces, txManager interfaces.TxInterfaces, paymentUC *PaymentUC, credentialUC *CredentialUC, outboxUC *OutboxUC, clock pkg.Clock, lease time.Duration) *CheckoutUC {
+	return &CheckoutUC{
+		purchaseRepo: purchaseRepo,
+		ticketRepo:   ticketRepo,
+		holdRepo:     holdRepo,
+		waitlistRepo: waitlistRepo,
+		listingRepo:  listingRepo,
+		promoRepo:    promoRepo,
+		txManager:    txManager,
+		paymentUC:    paymentUC,
+		credentialUC: credentialUC,
+		outboxUC:     outboxUC,
+		clock:        clock,
+		lease:        lease,
+	}
+}
+
+// Settle charges a pending purchase and completes it, or releases what it reserved when the payment fails. Callers
+// reserve the seats and create the pending purchase in a transaction of their own and settle it once that committed,
+// so no row lock is held while the payment provider is called.
+func (rc *CheckoutUC) Settle(ctx context.Context, purchase *models.Purchase) (*models.Purchase, error) {
+	if err := rc.paymentUC.Charge(ctx, purchase); err != nil {
+		return nil, rc.fail(ctx, purchase, err)
+	}
+
+	return rc.finish(ctx, purchase)
+}
+
+// Recover settles the purchases still pending after the lease, e.g. because the instance charging them stopped:
+// captured ones are completed, the others are released and an authorized payment is given back. Refunds of
+// cancellations that failed are paid back as well. It reports how many purchases were settled or refunded.
+func (rc *CheckoutUC) Recover(ctx context.Context) (int, error) {
+	before := rc.clock.Now().Add(-rc.lease)
+
+	purchases, err := rc.purchaseRepo.ListPending(ctx, before)
+	if err != nil {
+		return 0, pkg.NewError(err, "failed to list pending purchases", http.StatusInternalServerError)
+	}
+
+	settled := 0
+	var errs []error
+	for i := range purchases {
+		if err := rc.recover(ctx, &purchases[i]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		settled++
+	}
+
+	refunded, err := rc.paymentUC.RetryRefunds(ctx, before)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return settled + refunded, errors.Join(errs...)
+}
+
+// RunSweeper recovers pending purchases and failed refunds every interval until the context is cancelled.
+func (rc *CheckoutUC) RunSweeper(ctx context.Context, interval time.Duration, logger *zap.SugaredLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			settled, err := rc.Recover(ctx)
+			if err != nil {
+				logger.Errorf("failed to sweep pending purchases: %v", err)
+			}
+			if settled > 0 {
+				logger.Infof("settled %d pending purchases and refunds", settled)
+			}
+		}
+	}
+}
+
+func (rc *CheckoutUC) recover(ctx context.Context, purchase *models.Purchase) error {
+	switch purchase.PaymentStatus {
+	case models.PaymentStatusCaptured:
+		_, err := rc.finish(ctx, purchase)
+		return err
+	case models.PaymentStatusAuthorized:
+		if err := rc.paymentUC.Refund(ctx, purchase, purchase.Total); err != nil {
+			return err
+		}
+	}
+
+	return rc.release(ctx, purchase)
+}
+
+// finish completes a paid purchase. A purchase that can't be completed is refunded and released, unless the sweeper
+// settled it in the meantime. When the refund fails the purchase stays pending and the sweeper completes it later.
+func (rc *CheckoutUC) finish(ctx context.Context, purchase *models.Purchase) (*models.Purchase, error) {
+	completed, err := rc.complete(ctx, purchase)
+	if err == nil {
+		return completed, nil
+	}
+	if errors.Is(err, pkg.ErrPurchaseNotPending) {
+		return nil, err
+	}
+
+	if refundErr := rc.paymentUC.Refund(ctx, purchase, purchase.Total-purchase.PaymentRefunded); refundErr != nil {
+		return nil, pkg.NewError(errors.Join(err, refundErr), "purchase failed and its payment could not be refunded", http.StatusInternalServerError)
+	}
+
+	return nil, rc.fail(ctx, purchase, err)
+}
+
+// fail releases a purchase whose payment failed and returns the error it failed with.
+func (rc *CheckoutUC) fail(ctx context.Context, purchase *models.Purchase, err error) error {
+	if releaseErr := rc.release(ctx, purchase); releaseErr != nil && !errors.Is(releaseErr, pkg.ErrPurchaseNotPending) {
+		return errors.Join(err, releaseErr)
+	}
+
+	return err
+}
+
+// complete marks a paid purchase as completed together with the hold, offer or listing it was reserved with,
+// issues its credentials and reports it.
+func (rc *CheckoutUC) complete(ctx context.Context, purchase *models.Purchase) (*models.Purchase, error) {
+	var completed *models.Purchase
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		var err error
+		completed, err = rc.purchaseRepo.Settle(ctx, purchase.ID, models.PurchaseStatusCompleted)
+		if err != nil {
+			return settleError(err)
+		}
+
+		if err := rc.holdRepo.Confirm(ctx, purchase.ID); err != nil {
+			return pkg.NewError(err, "failed to confirm hold", http.StatusInternalServerError)
+		}
+
+		if err := rc.waitlistRepo.Fulfill(ctx, purchase.ID); err != nil {
+			return pkg.NewError(err, "failed to accept offer", http.StatusInternalServerError)
+		}
+
+		if completed.ResaleListingID != 0 {
+			if err := rc.sellListing(ctx, completed); err != nil {
+				return err
+			}
+		}
+
+		if err := rc.credentialUC.Issue(ctx, completed); err != nil {
+			return err
+		}
+
+		// the ticket row lock keeps the events of the purchase in order with the other events of the ticket
+		if err := rc.ticketRepo.Lock(ctx, strconv.FormatInt(completed.TicketID, 10)); err != nil {
+			return pkg.NewError(err, "failed to lock ticket", http.StatusInternalServerError)
+		}
+
+		return rc.outboxUC.RecordPurchase(ctx, models.EventPurchaseCompleted, completed)
+	})
+	if err != nil {
+		return nil, txError(err, "failed to complete purchase")
+	}
+
+	return completed, nil
+}
+
+// sellListing closes the listing a purchase bought: the seat moves from the seller's purchase to the buyer's and the
+// seller's credential is revoked. It fails when the seat was refunded or checked in while the purchase was paid.
+func (rc *CheckoutUC) sellListing(ctx context.Context, purchase *models.Purchase) error {
+	listing, err := rc.listingRepo.GetByIDForUpdate(ctx, strconv.FormatInt(purchase.ResaleListingID, 10))
+	if err != nil {
+		return pkg.NewError(err, "failed to find listing", http.StatusNotFound)
+	}
+
+	resold, err := rc.purchaseRepo.MarkResold(ctx, listing.PurchaseID)
+	if err != nil {
+		return pkg.NewError(err, "failed to update purchase", http.StatusInternalServerError)
+	}
+	if !resold {
+		return pkg.NewError(pkg.ErrListingUnavailable, "seat was refunded since it was listed", http.StatusConflict)
+	}
+
+	if err := rc.credentialUC.RevokeUnused(ctx, listing.CredentialID); err != nil {
+		return err
+	}
+
+	listing.Status = models.ListingStatusSold
+	listing.SoldAt = rc.clock.Now()
+	if _, err := rc.listingRepo.Close(ctx, listing); err != nil {
+		return pkg.NewError(err, "failed to close listing", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// release marks a purchase whose payment failed as failed and gives back what it reserved: a hold or offer is open
+// again until it expires, a listing goes back on sale unless its seat can't change hands anymore and the promo code
+// redemption is returned. Direct purchases
+// reserve their seats with a hold that has already expired, the hold sweeper returns them to the allocation and
+// offers them to the waitlist.
+func (rc *CheckoutUC) release(ctx context.Context, purchase *models.Purchase) error {
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		if _, err := rc.purchaseRepo.Settle(ctx, purchase.ID, models.PurchaseStatusFailed); err != nil {
+			return settleError(err)
+		}
+
+		if err := rc.holdRepo.Reopen(ctx, purchase.ID); err != nil {
+			return pkg.NewError(err, "failed to reopen hold", http.StatusInternalServerError)
+		}
+
+		if err := rc.waitlistRepo.Reopen(ctx, purchase.ID); err != nil {
+			return pkg.NewError(err, "failed to reopen offer", http.StatusInternalServerError)
+		}
+
+		if purchase.ResaleListingID != 0 {
+			listing, err := rc.listingRepo.GetByIDForUpdate(ctx, strconv.FormatInt(purchase.ResaleListingID, 10))
+			if err != nil {
+				return pkg.NewError(err, "failed to find listing", http.StatusNotFound)
+			}
+
+			credential, err := rc.credentialUC.GetByID(ctx, strconv.FormatInt(listing.CredentialID, 10))
+			if err != nil {
+				return err
+			}
+
+			// a seat refunded, resold or checked in meanwhile would only be charged and refunded again
+			listing.Status = models.ListingStatusActive
+			if credential.Status != models.CredentialStatusValid || !credential.UsedAt.IsZero() {
+				listing.Status = models.ListingStatusCancelled
+			}
+			listing.BuyerID = ""
+			listing.BuyerPurchaseID = 0
+			if _, err := rc.listingRepo.Close(ctx, listing); err != nil {
+				return pkg.NewError(err, "failed to reopen listing", http.StatusInternalServerError)
+			}
+		}
+
+		if purchase.PromoCodeID != 0 {
+			if err := rc.promoRepo.Unredeem(ctx, purchase.PromoCodeID); err != nil {
+				return pkg.NewError(err, "failed to return promo code redemption", http.StatusInternalServerError)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return txError(err, "failed to release purchase")
+	}
+
+	return nil
+}
+
+// settleError maps a failed status change of a pending purchase to the status the buyer should see.
+func settleError(err error) error {
+	if errors.Is(err, pkg.ErrPurchaseNotPending) {
+		return pkg.NewError(err, "purchase was settled in the meantime", http.StatusConflict)
+	}
+
+	return pkg.NewError(err, "failed to settle purchase", http.StatusInternalServerError)
+}
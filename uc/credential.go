@@ -0,0 +1,199 @@
+package uc
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// qrSize is the edge length in pixels of credential QR codes.
+const qrSize = 256
+
+type CredentialUC struct {
+	credentialRepo interfaces.CredentialInterfaces
+	signer         *pkg.CredentialSigner
+	clock          pkg.Clock
+}
+
+func NewCredentialUC(credentialRepo interfaces.CredentialInterfaces, signer *pkg.CredentialSigner, clock pkg.Clock) *CredentialUC {
+	return &CredentialUC{
+		credentialRepo: credentialRepo,
+		signer:         signer,
+		clock:          clock,
+	}
+}
+
+// Issue creates one signed credential per seat of a new purchase and puts them on it.
+// Callers run it in the transaction that creates the purchase.
+func (rc *CredentialUC) Issue(ctx context.Context, purchase *models.Purchase) error {
+	credentials := make([]models.Credential, 0, purchase.Quantity)
+	for seat := 1; seat <= purchase.Quantity; seat++ {
+		credentials = append(credentials, models.Credential{
+			PurchaseID: purchase.ID,
+			TicketID:   purchase.TicketID,
+			UserID:     purchase.UserID,
+			Seat:       seat,
+			Status:     models.CredentialStatusValid,
+		})
+	}
+
+	credentials, err := rc.credentialRepo.CreateMany(ctx, credentials)
+	if err != nil {
+		return pkg.NewError(err, "failed to issue credentials", http.StatusInternalServerError)
+	}
+
+	purchase.Credentials = rc.sign(credentials)
+
+	return nil
+}
+
+// Reissue replaces the valid credentials of a purchase that changed hands: they are revoked and the same seats get
+// new credentials for the new owner, put on the purchase. It returns the revoked and the new credentials.
+// Callers run it in the transaction that changes the owner.
+func (rc *CredentialUC) Reissue(ctx context.Context, purchase *models.Purchase) ([]models.Credential, []models.Credential, error) {
+	revoked, err := rc.credentialRepo.RevokeByPurchaseID(ctx, purchase.ID, purchase.Quantity, rc.clock.Now())
+	if err != nil {
+		return nil, nil, pkg.NewError(err, "failed to revoke credentials", http.StatusInternalServerError)
+	}
+
+	issued := make([]models.Credential, 0, len(revoked))
+	for _, credential := range revoked {
+		issued = append(issued, models.Credential{
+			PurchaseID: purchase.ID,
+			TicketID:   purchase.TicketID,
+			UserID:     purchase.UserID,
+			Seat:       credential.Seat,
+			Status:     models.CredentialStatusValid,
+		})
+	}
+	if len(issued) == 0 {
+		purchase.Credentials = issued
+		return revoked, issued, nil
+	}
+
+	sort.Slice(issued, func(i, j int) bool { return issued[i].Seat < issued[j].Seat })
+
+	issued, err = rc.credentialRepo.CreateMany(ctx, issued)
+	if err != nil {
+		return nil, nil, pkg.NewError(err, "failed to issue credentials", http.StatusInternalServerError)
+	}
+
+	purchase.Credentials = rc.sign(issued)
+
+	return revoked, issued, nil
+}
+
+// GetByID returns a credential with its signed payload.
+func (rc *CredentialUC) GetByID(ctx context.Context, id string) (*models.Credential, error) {
+	credential, err := rc.credentialRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find credential", http.StatusNotFound)
+	}
+	credential.Payload = rc.signer.Sign(claimsOf(credential))
+
+	return credential, nil
+}
+
+// ListByPurchaseID returns the credentials of a purchase in seat order with their signed payloads.
+func (rc *CredentialUC) ListByPurchaseID(ctx context.Context, purchaseID int64) ([]models.Credential, error) {
+	credentials, err := rc.credentialRepo.ListByPurchaseID(ctx, purchaseID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to list credentials", http.StatusInternalServerError)
+	}
+
+	return rc.sign(credentials), nil
+}
+
+// CountCheckedIn returns how many seats of a purchase somebody got in with, their credentials are valid and used.
+func (rc *CredentialUC) CountCheckedIn(ctx context.Context, purchaseID int64) (int, error) {
+	credentials, err := rc.credentialRepo.ListByPurchaseID(ctx, purchaseID)
+	if err != nil {
+		return 0, pkg.NewError(err, "failed to list credentials", http.StatusInternalServerError)
+	}
+
+	checkedIn := 0
+	for _, credential := range credentials {
+		if credential.Status == models.CredentialStatusValid && !credential.UsedAt.IsZero() {
+			checkedIn++
+		}
+	}
+
+	return checkedIn, nil
+}
+
+// Revoke invalidates the credentials of count seats a purchase gave back, only credentials nobody got in with are
+// revoked. It fails when the seats the purchase keeps are fewer than the ones already checked in, e.g. because a
+// seat was scanned while it was given back.
+func (rc *CredentialUC) Revoke(ctx context.Context, purchase *models.Purchase, count int) error {
+	if _, err := rc.credentialRepo.RevokeUnusedByPurchaseID(ctx, purchase.ID, count, rc.clock.Now()); err != nil {
+		return pkg.NewError(err, "failed to revoke credentials", http.StatusInternalServerError)
+	}
+
+	checkedIn, err := rc.CountCheckedIn(ctx, purchase.ID)
+	if err != nil {
+		return err
+	}
+	if checkedIn > purchase.Remaining() {
+		return pkg.NewError(pkg.ErrSeatsCheckedIn, "seats of the purchase were already checked in", http.StatusConflict)
+	}
+
+	return nil
+}
+
+// RevokeUnused invalidates the credential of a single seat that changes hands.
+// It fails when the credential was revoked or someone got in with it meanwhile.
+func (rc *CredentialUC) RevokeUnused(ctx context.Context, id int64) error {
+	revoked, err := rc.credentialRepo.RevokeUnused(ctx, id, rc.clock.Now())
+	if err != nil {
+		return pkg.NewError(err, "failed to revoke credential", http.StatusInternalServerError)
+	}
+	if !revoked {
+		return pkg.NewError(errors.New("credential was revoked or used"), "seat can't change hands anymore", http.StatusConflict)
+	}
+
+	return nil
+}
+
+// QR renders the payload of a valid credential as a PNG QR code.
+func (rc *CredentialUC) QR(credential *models.Credential) ([]byte, error) {
+	if credential.Status != models.CredentialStatusValid {
+		return nil, pkg.NewError(errors.New("credential is not valid"), "credential was revoked", http.StatusGone)
+	}
+
+	png, err := qrcode.Encode(credential.Payload, qrcode.Medium, qrSize)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to render QR code", http.StatusInternalServerError)
+	}
+
+	return png, nil
+}
+
+// PublicKey returns the Ed25519 key scanners verify credential payloads with.
+func (rc *CredentialUC) PublicKey() ed25519.PublicKey {
+	return rc.signer.PublicKey()
+}
+
+func (rc *CredentialUC) sign(credentials []models.Credential) []models.Credential {
+	for i := range credentials {
+		credentials[i].Payload = rc.signer.Sign(claimsOf(&credentials[i]))
+	}
+
+	return credentials
+}
+
+func claimsOf(credential *models.Credential) pkg.CredentialClaims {
+	return pkg.CredentialClaims{
+		CredentialID: credential.ID,
+		PurchaseID:   credential.PurchaseID,
+		TicketID:     credential.TicketID,
+		Seat:         credential.Seat,
+	}
+}
@@ -0,0 +1,19 @@
+package uc
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/fleimkeipa/tickets-api/pkg"
+)
+
+// txError keeps a *pkg.Error returned from inside a transaction as is,
+// anything else (e.g. a failed commit) is reported as an internal error.
+func txError(err error, message string) error {
+	var pe *pkg.Error
+	if errors.As(err, &pe) {
+		return pe
+	}
+
+	return pkg.NewError(err, message, http.StatusInternalServerError)
+}
@@ -0,0 +1,221 @@
+package uc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+
+	"go.uber.org/zap"
+)
+
+type HoldUC struct {
+	holdRepo     interfaces.HoldInterfaces
+	ticketRepo   interfaces.TicketInterfaces
+	purchaseRepo interfaces.PurchaseInterfaces
+	tierRepo     interfaces.TierInterfaces
+	txManager    interfaces.TxInterfaces
+	waitlistUC   *WaitlistUC
+	checkoutUC   *CheckoutUC
+	outboxUC     *OutboxUC
+	clock        pkg.Clock
+	validator    *pkg.CustomValidator
+}
+
+func NewHoldUC(holdRepo interfaces.HoldInterfaces, ticketRepo interfaces.TicketInterfaces, purchaseRepo interfaces.PurchaseInterfaces, tierRepo interfaces.TierInterfaces, txManager interfaces.TxInterfaces, waitlistUC *WaitlistUC, checkoutUC *CheckoutUC, outboxUC *OutboxUC, clock pkg.Clock, validator *pkg.CustomValidator) *HoldUC {
+	return &HoldUC{
+		holdRepo:     holdRepo,
+		ticketRepo:   ticketRepo,
+		purchaseRepo: purchaseRepo,
+		tierRepo:     tierRepo,
+		txManager:    txManager,
+		waitlistUC:   waitlistUC,
+		checkoutUC:   checkoutUC,
+		outboxUC:     outboxUC,
+		clock:        clock,
+		validator:    validator,
+	}
+}
+
+// Create reserves the requested quantity of a ticket for the user until the hold expires.
+func (rc *HoldUC) Create(ctx context.Context, ticketID string, request *models.HoldRequest) (*models.Hold, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate hold request", http.StatusBadRequest)
+	}
+
+	// ticket exist control
+	existTicket, err := rc.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+	}
+
+	if err := checkTicketStatus(existTicket); err != nil {
+		return nil, err
+	}
+
+	if err := checkSalesWindow(existTicket.SaleStatusAt(rc.clock.Now())); err != nil {
+		return nil, err
+	}
+
+	if existTicket.Allocation == 0 {
+		return nil, pkg.NewError(errors.New("ticket is sold out"), "there is no available ticket now", http.StatusBadRequest)
+	}
+
+	tier, err := resolveTier(ctx, rc.tierRepo, ticketID, request.TierID, rc.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var hold *models.Hold
+	err = rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		// the limit is read under the ticket's row lock, a limit set since the ticket was read applies as well
+		if err := checkPurchaseLimit(ctx, rc.ticketRepo, rc.purchaseRepo, rc.holdRepo, ticketID, request.UserID, request.Quantity, rc.clock.Now()); err != nil {
+			return err
+		}
+
+		t, err := takeSeats(ctx, rc.ticketRepo, rc.tierRepo, ticketID, tier, request.Quantity)
+		if err != nil {
+			return err
+		}
+
+		hold, err = rc.holdRepo.Create(ctx, &models.Hold{
+			TicketID:  t.ID,
+			TierID:    request.TierID,
+			UserID:    request.UserID,
+			Quantity:  request.Quantity,
+			Status:    models.HoldStatusActive,
+			UnitPrice: unitPrice(t, tier),
+			Currency:  t.Currency,
+			ExpiresAt: rc.clock.Now().Add(time.Duration(request.Minutes) * time.Minute),
+		})
+		if err != nil {
+			return pkg.NewError(err, "failed to create hold", http.StatusInternalServerError)
+		}
+
+		return rc.outboxUC.RecordSoldOut(ctx, t)
+	})
+	if err != nil {
+		return nil, txError(err, "failed to hold ticket")
+	}
+
+	return hold, nil
+}
+
+// Confirm turns an active hold into a purchase once it is paid. The seats were already taken from the allocation when
+// the hold was created, the hold is paying while the purchase is paid and a failed payment leaves it active.
+func (rc *HoldUC) Confirm(ctx context.Context, holdID string, request *models.ConfirmHoldRequest) (*models.Purchase, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate confirm request", http.StatusBadRequest)
+	}
+
+	existHold, err := rc.holdRepo.GetByID(ctx, holdID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find hold", http.StatusNotFound)
+	}
+
+	if existHold.UserID != request.UserID {
+		return nil, pkg.NewError(errors.New("hold belongs to another user"), "hold belongs to another user", http.StatusForbidden)
+	}
+
+	if existHold.Status != models.HoldStatusActive || !rc.clock.Now().Before(existHold.ExpiresAt) {
+		return nil, pkg.NewError(pkg.ErrHoldNotActive, "hold is no longer active", http.StatusConflict)
+	}
+
+	var purchase *models.Purchase
+	err = rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		newPurchase := models.Purchase{
+			TicketID: existHold.TicketID,
+			TierID:   existHold.TierID,
+			UserID:   existHold.UserID,
+			Quantity: existHold.Quantity,
+			Status:   models.PurchaseStatusPending,
+		}
+		newPurchase.SetPrice(existHold.UnitPrice, existHold.Currency, nil)
+
+		purchase, err = rc.purchaseRepo.Create(ctx, &newPurchase)
+		if err != nil {
+			return pkg.NewError(err, "failed to create purchase", http.StatusInternalServerError)
+		}
+
+		// the hold may have been swept or confirmed since it was read
+		if _, err := rc.holdRepo.Pay(ctx, holdID, purchase.ID, rc.clock.Now()); err != nil {
+			if errors.Is(err, pkg.ErrHoldNotActive) {
+				return pkg.NewError(err, "hold is no longer active", http.StatusConflict)
+			}
+			return pkg.NewError(err, "failed to confirm hold", http.StatusInternalServerError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, txError(err, "failed to confirm hold")
+	}
+
+	return rc.checkoutUC.Settle(ctx, purchase)
+}
+
+// ExpireHolds releases every hold that has expired and returns its seats to the ticket's allocation.
+// It reports how many holds were released.
+func (rc *HoldUC) ExpireHolds(ctx context.Context) (int, error) {
+	var expired int
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		holds, err := rc.holdRepo.Expire(ctx, rc.clock.Now())
+		if err != nil {
+			return err
+		}
+
+		ticketIDs := make([]string, 0)
+		for _, hold := range holds {
+			ticketID := strconv.FormatInt(hold.TicketID, 10)
+			if err := returnSeats(ctx, rc.ticketRepo, rc.tierRepo, ticketID, hold.TierID, hold.Quantity); err != nil {
+				return err
+			}
+			if !slices.Contains(ticketIDs, ticketID) {
+				ticketIDs = append(ticketIDs, ticketID)
+			}
+		}
+
+		for _, ticketID := range ticketIDs {
+			if err := rc.waitlistUC.OfferSeats(ctx, ticketID); err != nil {
+				return err
+			}
+		}
+
+		expired = len(holds)
+
+		return nil
+	})
+	if err != nil {
+		return 0, pkg.NewError(err, "failed to expire holds", http.StatusInternalServerError)
+	}
+
+	return expired, nil
+}
+
+// RunSweeper expires holds every interval until the context is cancelled.
+func (rc *HoldUC) RunSweeper(ctx context.Context, interval time.Duration, logger *zap.SugaredLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := rc.ExpireHolds(ctx)
+			if err != nil {
+				logger.Errorf("failed to sweep expired holds: %v", err)
+				continue
+			}
+			if expired > 0 {
+				logger.Infof("released %d expired holds", expired)
+			}
+		}
+	}
+}
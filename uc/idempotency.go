@@ -0,0 +1,142 @@
+package uc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+
+	"go.uber.org/zap"
+)
+
+// maxIdempotencyKeyLength limits the size of client supplied keys.
+const maxIdempotencyKeyLength = 255
+
+type IdempotencyUC struct {
+	idempotencyRepo interfaces.IdempotencyInterfaces
+	clock           pkg.Clock
+	lease           time.Duration
+	ttl             time.Duration
+}
+
+// NewIdempotencyUC creates an IdempotencyUC. A request holds its key for lease, a retry after that takes the key over
+// because the request is presumed dead, and completed responses are replayed for ttl.
+func NewIdempotencyUC(idempotencyRepo interfaces.IdempotencyInterfaces, clock pkg.Clock, lease, ttl time.Duration) *IdempotencyUC {
+	return &IdempotencyUC{
+		idempotencyRepo: idempotencyRepo,
+		clock:           clock,
+		lease:           lease,
+		ttl:             ttl,
+	}
+}
+
+// Begin reserves the key for a request with the given fingerprint.
+// It returns nil when the request should be processed, or the completed record when it should be replayed.
+// An expired key is reserved again as if it was new.
+func (rc *IdempotencyUC) Begin(ctx context.Context, key, fingerprint string) (*models.IdempotencyKey, error) {
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, pkg.NewError(errors.New("idempotency key too long"), "idempotency key must be at most 255 characters", http.StatusBadRequest)
+	}
+
+	now := rc.clock.Now()
+	reserved := &models.IdempotencyKey{
+		Key:         key,
+		Fingerprint: fingerprint,
+		Status:      models.IdempotencyStatusProcessing,
+		ExpiresAt:   now.Add(rc.lease),
+	}
+
+	inserted, err := rc.idempotencyRepo.Create(ctx, reserved)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to store idempotency key", http.StatusInternalServerError)
+	}
+
+	if inserted {
+		return nil, nil
+	}
+
+	reclaimed, err := rc.idempotencyRepo.Reclaim(ctx, reserved, now)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to store idempotency key", http.StatusInternalServerError)
+	}
+
+	if reclaimed {
+		return nil, nil
+	}
+
+	existKey, err := rc.idempotencyRepo.GetByKey(ctx, key)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find idempotency key", http.StatusInternalServerError)
+	}
+
+	if existKey.Fingerprint != fingerprint {
+		return nil, pkg.NewError(errors.New("idempotency key fingerprint mismatch"), "idempotency key was already used with a different request", http.StatusUnprocessableEntity)
+	}
+
+	if existKey.Status != models.IdempotencyStatusCompleted {
+		return nil, pkg.NewError(errors.New("idempotency key in progress"), "a request with this idempotency key is still in progress", http.StatusConflict)
+	}
+
+	return existKey, nil
+}
+
+// Complete stores the response of the request so later retries receive the same result.
+func (rc *IdempotencyUC) Complete(ctx context.Context, key string, statusCode int, body []byte) error {
+	_, err := rc.idempotencyRepo.Update(ctx, &models.IdempotencyKey{
+		Key:          key,
+		Status:       models.IdempotencyStatusCompleted,
+		StatusCode:   statusCode,
+		ResponseBody: body,
+		ExpiresAt:    rc.clock.Now().Add(rc.ttl),
+	})
+	if err != nil {
+		return pkg.NewError(err, "failed to store idempotent response", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// Release forgets the key, allowing the request to be retried after a server side failure.
+func (rc *IdempotencyUC) Release(ctx context.Context, key string) error {
+	if err := rc.idempotencyRepo.Delete(ctx, key); err != nil {
+		return pkg.NewError(err, "failed to release idempotency key", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// Expire deletes the keys that expired and returns how many were deleted.
+func (rc *IdempotencyUC) Expire(ctx context.Context) (int, error) {
+	deleted, err := rc.idempotencyRepo.DeleteExpired(ctx, rc.clock.Now())
+	if err != nil {
+		return 0, pkg.NewError(err, "failed to delete expired idempotency keys", http.StatusInternalServerError)
+	}
+
+	return deleted, nil
+}
+
+// RunSweeper deletes expired keys every interval until the context is cancelled.
+func (rc *IdempotencyUC) RunSweeper(ctx context.Context, interval time.Duration, logger *zap.SugaredLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := rc.Expire(ctx)
+			if err != nil {
+				logger.Errorf("failed to sweep expired idempotency keys: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				logger.Infof("deleted %d expired idempotency keys", deleted)
+			}
+		}
+	}
+}
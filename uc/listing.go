@@ -0,0 +1,382 @@
+package uc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+)
+
+type ListingUC struct {
+	listingRepo  interfaces.ListingInterfaces
+	purchaseRepo interfaces.PurchaseInterfaces
+	ticketRepo   interfaces.TicketInterfaces
+	tierRepo     interfaces.TierInterfaces
+	holdRepo     interfaces.HoldInterfaces
+	txManager    interfaces.TxInterfaces
+	checkoutUC   *CheckoutUC
+	credentialUC *CredentialUC
+	clock        pkg.Clock
+	validator    *pkg.CustomValidator
+	priceCap     int64
+}
+
+// NewListingUC creates a ListingUC. priceCap is the highest asking price of a listing in percent of the seat's face value.
+func NewListingUC(listingRepo interfaces.ListingInterfaces, purchaseRepo interfaces.PurchaseInterfaces, ticketRepo interfaces.TicketInterfaces, tierRepo interfaces.TierInterfaces, holdRepo interfaces.HoldInterfaces, txManager interfaces.TxInterfaces, checkoutUC *CheckoutUC, credentialUC *CredentialUC, clock pkg.Clock, validator *pkg.CustomValidator, priceCap int) *ListingUC {
+	return &ListingUC{
+		listingRepo:  listingRepo,
+		purchaseRepo: purchaseRepo,
+		ticketRepo:   ticketRepo,
+		tierRepo:     tierRepo,
+		holdRepo:     holdRepo,
+		txManager:    txManager,
+		checkoutUC:   checkoutUC,
+		credentialUC: credentialUC,
+		clock:        clock,
+		validator:    validator,
+		priceCap:     int64(priceCap),
+	}
+}
+
+// Create lists the seat of a credential for resale. Only the owner of the purchase can list its seats, one listing
+// per seat at a time, and only while the ticket is in its sales window.
+func (rc *ListingUC) Create(ctx context.Context, request *models.CreateListingRequest) (*models.Listing, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate listing request", http.StatusBadRequest)
+	}
+
+	var listing *models.Listing
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		credential, err := rc.credentialUC.GetByID(ctx, strconv.FormatInt(request.CredentialID, 10))
+		if err != nil {
+			return err
+		}
+
+		// the row lock keeps refunds and transfers of the purchase out until the listing is written
+		purchase, err := rc.purchaseRepo.GetByIDForUpdate(ctx, strconv.FormatInt(credential.PurchaseID, 10))
+		if err != nil {
+			return pkg.NewError(err, "failed to find purchase", http.StatusNotFound)
+		}
+
+		if purchase.UserID != request.SellerID {
+			return pkg.NewError(errors.New("purchase belongs to another user"), "seat does not belong to the user", http.StatusForbidden)
+		}
+
+		if credential.Status != models.CredentialStatusValid || !credential.UsedAt.IsZero() {
+			return pkg.NewError(pkg.ErrListingUnavailable, "seat was refunded, resold or checked in", http.StatusConflict)
+		}
+
+		if err := rc.checkResaleOpen(ctx, purchase.TicketID, purchase.TierID); err != nil {
+			return err
+		}
+
+		faceValue, err := rc.faceValue(ctx, purchase)
+		if err != nil {
+			return err
+		}
+
+		if maxPrice := faceValue * rc.priceCap / 100; request.Price > maxPrice {
+			message := fmt.Sprintf("price may be at most %d, %d%% of the face value", maxPrice, rc.priceCap)
+			return pkg.NewError(pkg.ErrPriceAboveCap, message, http.StatusBadRequest)
+		}
+
+		listed, err := rc.listingRepo.ExistsActive(ctx, credential.ID)
+		if err != nil {
+			return pkg.NewError(err, "failed to check listings", http.StatusInternalServerError)
+		}
+		if listed {
+			return pkg.NewError(pkg.ErrListingUnavailable, "seat is already listed", http.StatusConflict)
+		}
+
+		listing, err = rc.listingRepo.Create(ctx, &models.Listing{
+			TicketID:     purchase.TicketID,
+			TierID:       purchase.TierID,
+			PurchaseID:   purchase.ID,
+			CredentialID: credential.ID,
+			Seat:         credential.Seat,
+			SellerID:     purchase.UserID,
+			Price:        request.Price,
+			FaceValue:    faceValue,
+			Currency:     purchase.Currency,
+			Status:       models.ListingStatusActive,
+		})
+		if err != nil {
+			return pkg.NewError(err, "failed to create listing", http.StatusInternalServerError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, txError(err, "failed to create listing")
+	}
+
+	return listing, nil
+}
+
+// faceValue returns the unit price the seat of a purchase was first sold at. A seat bought on resale keeps the face
+// value of the listing it was bought from, so relisting it can't raise the cap with every sale.
+func (rc *ListingUC) faceValue(ctx context.Context, purchase *models.Purchase) (int64, error) {
+	if purchase.ResaleListingID == 0 {
+		return purchase.UnitPrice, nil
+	}
+
+	listing, err := rc.listingRepo.GetByID(ctx, strconv.FormatInt(purchase.ResaleListingID, 10))
+	if err != nil {
+		return 0, pkg.NewError(err, "failed to find listing", http.StatusNotFound)
+	}
+
+	return listing.FaceValue, nil
+}
+
+// Buy reserves a listing for the buyer with a pending purchase at the asking price and settles it once it is paid:
+// the seat moves to the buyer's purchase, the seller's credential is revoked and the buyer gets a new one, and the
+// listing is closed. A failed payment puts the listing back on sale. The ticket's allocation doesn't change.
+// Listings whose seat was refunded, transferred or checked in since can't be bought.
+func (rc *ListingUC) Buy(ctx context.Context, listingID string, request *models.BuyListingRequest) (*models.Listing, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate buy request", http.StatusBadRequest)
+	}
+
+	var purchase *models.Purchase
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		listing, err := rc.listingRepo.GetByIDForUpdate(ctx, listingID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find listing", http.StatusNotFound)
+		}
+
+		if listing.Status != models.ListingStatusActive {
+			return pkg.NewError(pkg.ErrListingUnavailable, "listing was already "+string(listing.Status), http.StatusConflict)
+		}
+
+		if listing.SellerID == request.BuyerID {
+			return pkg.NewError(errors.New("buyer is the seller"), "cannot buy your own listing", http.StatusBadRequest)
+		}
+
+		if err := rc.checkResaleOpen(ctx, listing.TicketID, listing.TierID); err != nil {
+			return err
+		}
+
+		sellerPurchase, err := rc.purchaseRepo.GetByIDForUpdate(ctx, strconv.FormatInt(listing.PurchaseID, 10))
+		if err != nil {
+			return pkg.NewError(err, "failed to find purchase", http.StatusNotFound)
+		}
+		if sellerPurchase.UserID != listing.SellerID {
+			return pkg.NewError(pkg.ErrListingUnavailable, "seat was transferred since it was listed", http.StatusConflict)
+		}
+		if sellerPurchase.Remaining() == 0 {
+			return pkg.NewError(pkg.ErrListingUnavailable, "seat was refunded since it was listed", http.StatusConflict)
+		}
+
+		// a partial cancel or a check-in since the seat was listed leaves nothing to sell, don't charge the buyer for it
+		credential, err := rc.credentialUC.GetByID(ctx, strconv.FormatInt(listing.CredentialID, 10))
+		if err != nil {
+			return err
+		}
+		if credential.Status != models.CredentialStatusValid || !credential.UsedAt.IsZero() {
+			return pkg.NewError(pkg.ErrListingUnavailable, "seat was refunded or checked in since it was listed", http.StatusConflict)
+		}
+
+		// the seat counts against the buyer's limit as if they bought it from us
+		ticketID := strconv.FormatInt(listing.TicketID, 10)
+		if err := checkPurchaseLimit(ctx, rc.ticketRepo, rc.purchaseRepo, rc.holdRepo, ticketID, request.BuyerID, 1, rc.clock.Now()); err != nil {
+			return err
+		}
+
+		newPurchase := models.Purchase{
+			TicketID:        listing.TicketID,
+			TierID:          listing.TierID,
+			UserID:          request.BuyerID,
+			Quantity:        1,
+			Status:          models.PurchaseStatusPending,
+			ResaleListingID: listing.ID,
+		}
+		newPurchase.SetPrice(listing.Price, listing.Currency, nil)
+
+		purchase, err = rc.purchaseRepo.Create(ctx, &newPurchase)
+		if err != nil {
+			return pkg.NewError(err, "failed to create purchase", http.StatusInternalServerError)
+		}
+
+		listing.Status = models.ListingStatusReserved
+		listing.BuyerID = request.BuyerID
+		listing.BuyerPurchaseID = purchase.ID
+		if _, err := rc.listingRepo.Close(ctx, listing); err != nil {
+			return pkg.NewError(err, "failed to reserve listing", http.StatusInternalServerError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, txError(err, "failed to buy listing")
+	}
+
+	purchase, err = rc.checkoutUC.Settle(ctx, purchase)
+	if err != nil {
+		return nil, err
+	}
+
+	listing, err := rc.GetByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	listing.Purchase = purchase
+
+	return listing, nil
+}
+
+// Cancel takes an active listing off sale, the seat stays with the seller.
+func (rc *ListingUC) Cancel(ctx context.Context, listingID string, request *models.CancelListingRequest) (*models.Listing, error) {
+	var listing *models.Listing
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		var err error
+		listing, err = rc.listingRepo.GetByIDForUpdate(ctx, listingID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find listing", http.StatusNotFound)
+		}
+
+		if request.UserID != "" && listing.SellerID != request.UserID {
+			return pkg.NewError(errors.New("listing belongs to another user"), "listing does not belong to the user", http.StatusForbidden)
+		}
+
+		if listing.Status != models.ListingStatusActive {
+			return pkg.NewError(pkg.ErrListingUnavailable, "listing was already "+string(listing.Status), http.StatusConflict)
+		}
+
+		listing.Status = models.ListingStatusCancelled
+		listing, err = rc.listingRepo.Close(ctx, listing)
+		if err != nil {
+			return pkg.NewError(err, "failed to cancel listing", http.StatusInternalServerError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, txError(err, "failed to cancel listing")
+	}
+
+	return listing, nil
+}
+
+// GetByID retrieves a listing by the provided listing ID.
+func (rc *ListingUC) GetByID(ctx context.Context, listingID string) (*models.Listing, error) {
+	listing, err := rc.listingRepo.GetByID(ctx, listingID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find listing", http.StatusNotFound)
+	}
+
+	return listing, nil
+}
+
+// Search retrieves a page of active listings and the cursor of the next page, which is empty on the last page.
+func (rc *ListingUC) Search(ctx context.Context, request *models.ListingSearchRequest) ([]models.Listing, string, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, "", pkg.NewError(err, "failed to validate search request", http.StatusBadRequest)
+	}
+
+	opts := models.ListingListOptions{
+		TicketID: request.TicketID,
+		TierID:   request.TierID,
+		MaxPrice: request.MaxPrice,
+		Sort:     request.Sort,
+		Limit:    request.Limit,
+	}
+	if opts.Sort == "" {
+		opts.Sort = "price"
+	}
+	if opts.Limit == 0 {
+		opts.Limit = defaultListLimit
+	}
+
+	if request.Cursor != "" {
+		cursor, err := decodeListingCursor(request.Cursor)
+		if err != nil {
+			return nil, "", pkg.NewError(err, "invalid cursor", http.StatusBadRequest)
+		}
+
+		// a cursor only makes sense for the ordering it was created with
+		if cursor.Sort != opts.Sort {
+			return nil, "", pkg.NewError(errors.New("cursor ordering mismatch"), "cursor does not match the requested sort order", http.StatusBadRequest)
+		}
+
+		opts.After = cursor
+	}
+
+	// one extra listing tells whether there is a next page
+	opts.Limit++
+	listings, err := rc.listingRepo.List(ctx, opts)
+	if err != nil {
+		return nil, "", pkg.NewError(err, "failed to search listings", http.StatusInternalServerError)
+	}
+
+	if len(listings) < opts.Limit {
+		return listings, "", nil
+	}
+
+	listings = listings[:len(listings)-1]
+	last := listings[len(listings)-1]
+	nextCursor := encodeListingCursor(&models.ListingCursor{
+		Sort:  opts.Sort,
+		Price: last.Price,
+		ID:    last.ID,
+	})
+
+	return listings, nextCursor, nil
+}
+
+// checkResaleOpen fails when seats of the ticket and tier can't change hands: resale follows the sales window of the
+// ticket and of the tier, and sold out tickets can still be resold.
+func (rc *ListingUC) checkResaleOpen(ctx context.Context, ticketID, tierID int64) error {
+	ticket, err := rc.ticketRepo.GetByID(ctx, strconv.FormatInt(ticketID, 10))
+	if err != nil {
+		return pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+	}
+
+	if ticket.Status != models.TicketStatusOnSale && ticket.Status != models.TicketStatusSoldOut {
+		return pkg.NewError(pkg.ErrTicketNotOnSale, "ticket is not on sale", http.StatusConflict)
+	}
+
+	now := rc.clock.Now()
+	if err := checkSalesWindow(ticket.SaleStatusAt(now)); err != nil {
+		return err
+	}
+
+	if tierID == 0 {
+		return nil
+	}
+
+	tier, err := rc.tierRepo.GetByID(ctx, strconv.FormatInt(ticketID, 10), strconv.FormatInt(tierID, 10))
+	if err != nil {
+		return pkg.NewError(err, "failed to find tier", http.StatusNotFound)
+	}
+
+	return checkSalesWindow(tier.SaleStatusAt(now))
+}
+
+// encodeListingCursor turns the position of the last listing of a page into an opaque cursor.
+func encodeListingCursor(cursor *models.ListingCursor) string {
+	b, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeListingCursor parses a cursor created by encodeListingCursor.
+func decodeListingCursor(s string) (*models.ListingCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := new(models.ListingCursor)
+	if err := json.Unmarshal(b, cursor); err != nil {
+		return nil, err
+	}
+
+	return cursor, nil
+}
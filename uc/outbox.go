@@ -0,0 +1,177 @@
+package uc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// outboxBatchSize is how many unpublished events a relay run looks at most.
+	outboxBatchSize = 100
+	// outboxMaxBackoff caps the wait before an event that failed to publish is tried again.
+	outboxMaxBackoff = 10 * time.Minute
+)
+
+type OutboxUC struct {
+	outboxRepo interfaces.OutboxInterfaces
+	txManager  interfaces.TxInterfaces
+	publisher  pkg.EventPublisher
+	clock      pkg.Clock
+	backoff    time.Duration
+}
+
+// NewOutboxUC creates an OutboxUC. Events that fail to publish are tried again after backoff, doubling with every
+// failed attempt.
+func NewOutboxUC(outboxRepo interfaces.OutboxInterfaces, txManager interfaces.TxInterfaces, publisher pkg.EventPublisher, clock pkg.Clock, backoff time.Duration) *OutboxUC {
+	return &OutboxUC{
+		outboxRepo: outboxRepo,
+		txManager:  txManager,
+		publisher:  publisher,
+		clock:      clock,
+		backoff:    backoff,
+	}
+}
+
+// Record writes an event about the ticket to the outbox. It runs in the caller's transaction, so the event is only
+// published when the change it reports is committed. It must come after the ticket row was written, the row lock
+// then keeps concurrent changes of the ticket from committing their events out of order.
+func (rc *OutboxUC) Record(ctx context.Context, eventType string, ticketID int64, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return pkg.NewError(err, "failed to record event", http.StatusInternalServerError)
+	}
+
+	_, err = rc.outboxRepo.Create(ctx, &models.OutboxEvent{
+		TicketID:   ticketID,
+		EventType:  eventType,
+		Payload:    string(payload),
+		OccurredAt: rc.clock.Now(),
+	})
+	if err != nil {
+		return pkg.NewError(err, "failed to record event", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// RecordPurchase records an event about the purchase, its credentials are left out of the payload.
+func (rc *OutboxUC) RecordPurchase(ctx context.Context, eventType string, purchase *models.Purchase) error {
+	data := *purchase
+	data.Credentials = nil
+
+	return rc.Record(ctx, eventType, purchase.TicketID, &data)
+}
+
+// RecordSoldOut records a ticket.sold_out event when the ticket has no seat left after a change of its allocation.
+func (rc *OutboxUC) RecordSoldOut(ctx context.Context, ticket *models.Ticket) error {
+	if ticket.Status != models.TicketStatusSoldOut {
+		return nil
+	}
+
+	return rc.Record(ctx, models.EventTicketSoldOut, ticket.ID, ticket)
+}
+
+// Relay publishes the unpublished events in the order they were written and returns how many were published.
+// An event that fails to publish holds back the later events of its ticket until it went out, events of other
+// tickets are not held up. Only one relay runs at a time, a run that finds another one busy publishes nothing.
+// An event is marked published after the publisher accepted it, so it is published again when marking it fails.
+func (rc *OutboxUC) Relay(ctx context.Context) (int, error) {
+	published := 0
+
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		locked, err := rc.outboxRepo.TryLock(ctx)
+		if err != nil || !locked {
+			return err
+		}
+
+		now := rc.clock.Now()
+		events, err := rc.outboxRepo.ListUnpublished(ctx, now, outboxBatchSize)
+		if err != nil {
+			return err
+		}
+
+		blocked := make(map[int64]bool)
+		for i := range events {
+			event := &events[i]
+			if blocked[event.TicketID] {
+				continue
+			}
+
+			if event.NextAttemptAt.After(now) {
+				blocked[event.TicketID] = true
+				continue
+			}
+
+			err := rc.publisher.Publish(ctx, &pkg.Event{
+				ID:         event.ID,
+				Type:       event.EventType,
+				TicketID:   event.TicketID,
+				Payload:    json.RawMessage(event.Payload),
+				OccurredAt: event.OccurredAt.UTC(),
+			})
+			if err != nil {
+				blocked[event.TicketID] = true
+
+				event.Attempts++
+				event.NextAttemptAt = now.Add(rc.retryDelay(event.Attempts))
+				event.LastError = err.Error()
+				if err := rc.outboxRepo.RecordFailure(ctx, event); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := rc.outboxRepo.MarkPublished(ctx, event.ID, now); err != nil {
+				return err
+			}
+			published++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return published, nil
+}
+
+// RunRelay publishes outbox events every interval until the context is cancelled.
+func (rc *OutboxUC) RunRelay(ctx context.Context, interval time.Duration, logger *zap.SugaredLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			published, err := rc.Relay(ctx)
+			if err != nil {
+				logger.Errorf("failed to relay outbox events: %v", err)
+				continue
+			}
+			if published > 0 {
+				logger.Infof("published %d outbox events", published)
+			}
+		}
+	}
+}
+
+// retryDelay is the wait after the given number of failed attempts, doubling from the backoff up to outboxMaxBackoff.
+func (rc *OutboxUC) retryDelay(attempts int) time.Duration {
+	delay := rc.backoff
+	for i := 1; i < attempts && delay < outboxMaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, outboxMaxBackoff)
+}
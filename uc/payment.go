@@ -0,0 +1,213 @@
+package uc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+)
+
+type PaymentUC struct {
+	provider     pkg.PaymentProvider
+	purchaseRepo interfaces.PurchaseInterfaces
+	timeout      time.Duration
+}
+
+func NewPaymentUC(provider pkg.PaymentProvider, purchaseRepo interfaces.PurchaseInterfaces, timeout time.Duration) *PaymentUC {
+	return &PaymentUC{
+		provider:     provider,
+		purchaseRepo: purchaseRepo,
+		timeout:      timeout,
+	}
+}
+
+// Charge authorizes and captures the total of a pending purchase and records the payment on it as it goes, so a
+// purchase left pending by a crash shows how far its payment got. Callers run it after the transaction that reserved
+// the seats committed, no row lock is held while the provider is called. When the capture fails the authorization
+// is released.
+func (rc *PaymentUC) Charge(ctx context.Context, purchase *models.Purchase) error {
+	if purchase.Total == 0 {
+		return nil
+	}
+
+	authorizeCtx, cancel := rc.withTimeout(ctx)
+	defer cancel()
+
+	paymentID, err := rc.provider.Authorize(authorizeCtx, pkg.PaymentRequest{
+		Amount:    purchase.Total,
+		Currency:  purchase.Currency,
+		Reference: "purchase-" + strconv.FormatInt(purchase.ID, 10),
+	})
+	if err != nil {
+		return paymentError(err, "payment was not authorized")
+	}
+
+	if err := rc.setPayment(ctx, purchase, paymentID, models.PaymentStatusAuthorized); err != nil {
+		// without a record the authorization can't be released later, it is released right away
+		if _, releaseErr := rc.refund(ctx, paymentID, purchase.Total); releaseErr != nil {
+			err = errors.Join(err, releaseErr)
+		}
+		return err
+	}
+
+	captureCtx, cancel := rc.withTimeout(ctx)
+	defer cancel()
+
+	if err := rc.provider.Capture(captureCtx, paymentID); err != nil {
+		// a capture that timed out may still go through, releasing covers both outcomes
+		if _, releaseErr := rc.refund(ctx, paymentID, purchase.Total); releaseErr != nil {
+			return paymentError(errors.Join(err, releaseErr), "payment was not captured")
+		}
+		if recordErr := rc.setPayment(ctx, purchase, paymentID, models.PaymentStatusReleased); recordErr != nil {
+			err = errors.Join(err, recordErr)
+		}
+		return paymentError(err, "payment was not captured")
+	}
+
+	return rc.setPayment(ctx, purchase, paymentID, models.PaymentStatusCaptured)
+}
+
+// Refund pays back the amount of a purchase's payment and records the total the provider refunded so far on the
+// purchase, an authorization that was never captured is released instead. Cancellations run it after their
+// transaction committed, a refund that fails is paid back later by RetryRefunds.
+func (rc *PaymentUC) Refund(ctx context.Context, purchase *models.Purchase, amount int64) error {
+	if purchase.PaymentID == "" || amount <= 0 {
+		return nil
+	}
+
+	total, err := rc.refund(ctx, purchase.PaymentID, amount)
+	if err != nil {
+		return paymentError(err, "payment was not refunded")
+	}
+
+	if purchase.PaymentStatus == models.PaymentStatusAuthorized {
+		return rc.setPayment(ctx, purchase, purchase.PaymentID, models.PaymentStatusReleased)
+	}
+
+	// the provider's total is stored rather than the amount added to it, its refund webhook may have been stored first
+	refunded, err := rc.purchaseRepo.SetRefunded(ctx, purchase.ID, total)
+	if err != nil {
+		return pkg.NewError(err, "failed to record refund", http.StatusInternalServerError)
+	}
+	purchase.PaymentStatus = refunded.PaymentStatus
+	purchase.PaymentRefunded = refunded.PaymentRefunded
+
+	return nil
+}
+
+// RetryRefunds pays back what the refunded seats of purchases are owed but their provider didn't pay back yet, e.g.
+// because the refund of a cancellation failed. Only purchases last changed before the given time are looked at, so
+// a cancellation that is still refunding isn't paid back twice. It reports how many purchases were refunded.
+func (rc *PaymentUC) RetryRefunds(ctx context.Context, before time.Time) (int, error) {
+	purchases, err := rc.purchaseRepo.ListRefundsDue(ctx, before)
+	if err != nil {
+		return 0, pkg.NewError(err, "failed to list refunds due", http.StatusInternalServerError)
+	}
+
+	refunded := 0
+	var errs []error
+	for i := range purchases {
+		purchase := &purchases[i]
+		if err := rc.Refund(ctx, purchase, refundDue(purchase, purchase.RefundedQuantity)-purchase.PaymentRefunded); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		refunded++
+	}
+
+	return refunded, errors.Join(errs...)
+}
+
+// HandleWebhook verifies a webhook of the payment provider and stores the payment state it reports. Events about
+// payments without a purchase, e.g. of purchases that were rolled back, and events older than the stored state are ignored.
+func (rc *PaymentUC) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	event, err := rc.provider.VerifyWebhook(payload, signature)
+	if err != nil {
+		if errors.Is(err, pkg.ErrInvalidWebhookSignature) {
+			return pkg.NewError(err, "invalid webhook signature", http.StatusUnauthorized)
+		}
+		return pkg.NewError(err, "failed to read webhook", http.StatusBadRequest)
+	}
+
+	if event.Type != pkg.PaymentEventCaptured && event.Type != pkg.PaymentEventRefunded {
+		return nil
+	}
+
+	status := paymentStatus(event.Amount, event.Refunded)
+	if _, err := rc.purchaseRepo.UpdatePayment(ctx, event.PaymentID, status, event.Refunded); err != nil {
+		return pkg.NewError(err, "failed to update payment", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// setPayment records the payment of a purchase at the given status.
+func (rc *PaymentUC) setPayment(ctx context.Context, purchase *models.Purchase, paymentID string, status models.PaymentStatus) error {
+	if err := rc.purchaseRepo.SetPayment(ctx, purchase.ID, paymentID, status); err != nil {
+		return pkg.NewError(err, "failed to record payment", http.StatusInternalServerError)
+	}
+	purchase.PaymentID = paymentID
+	purchase.PaymentStatus = status
+
+	return nil
+}
+
+func (rc *PaymentUC) refund(ctx context.Context, paymentID string, amount int64) (int64, error) {
+	ctx, cancel := rc.withTimeout(ctx)
+	defer cancel()
+
+	return rc.provider.Refund(ctx, paymentID, amount)
+}
+
+func (rc *PaymentUC) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if rc.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, rc.timeout)
+}
+
+// refundAmount is the part of the purchase total paid back for cancelling quantity seats, purchase already counts them
+// as refunded. It is the difference of what is due before and after the cancellation, so concurrent cancellations
+// never pay back more than the total.
+func refundAmount(purchase *models.Purchase, quantity int) int64 {
+	return refundDue(purchase, purchase.RefundedQuantity) - refundDue(purchase, purchase.RefundedQuantity-quantity)
+}
+
+// refundDue is the part of the purchase total owed back for refunded seats. The last seat gets whatever is left,
+// so rounding never keeps money back.
+func refundDue(purchase *models.Purchase, refunded int) int64 {
+	if refunded == purchase.Quantity {
+		return purchase.Total
+	}
+
+	return purchase.Total * int64(refunded) / int64(purchase.Quantity)
+}
+
+func paymentStatus(amount, refunded int64) models.PaymentStatus {
+	switch {
+	case refunded == 0:
+		return models.PaymentStatusCaptured
+	case refunded < amount:
+		return models.PaymentStatusPartiallyRefunded
+	default:
+		return models.PaymentStatusRefunded
+	}
+}
+
+// paymentError maps a failed call to the payment provider to the status the buyer should see.
+func paymentError(err error, message string) error {
+	switch {
+	case errors.Is(err, pkg.ErrPaymentDeclined):
+		return pkg.NewError(err, message, http.StatusPaymentRequired)
+	case errors.Is(err, pkg.ErrPaymentTimeout), errors.Is(err, context.DeadlineExceeded):
+		return pkg.NewError(err, message, http.StatusGatewayTimeout)
+	default:
+		return pkg.NewError(err, message, http.StatusBadGateway)
+	}
+}
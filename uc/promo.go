@@ -0,0 +1,236 @@
+package uc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+)
+
+type PromoCodeUC struct {
+	promoRepo    interfaces.PromoCodeInterfaces
+	purchaseRepo interfaces.PurchaseInterfaces
+	txManager    interfaces.TxInterfaces
+	clock        pkg.Clock
+	validator    *pkg.CustomValidator
+}
+
+func NewPromoCodeUC(promoRepo interfaces.PromoCodeInterfaces, purchaseRepo interfaces.PurchaseInterfaces, txManager interfaces.TxInterfaces, clock pkg.Clock, validator *pkg.CustomValidator) *PromoCodeUC {
+	return &PromoCodeUC{
+		promoRepo:    promoRepo,
+		purchaseRepo: purchaseRepo,
+		txManager:    txManager,
+		clock:        clock,
+		validator:    validator,
+	}
+}
+
+// Create adds a new promo code, codes are unique among the codes that aren't deleted.
+func (rc *PromoCodeUC) Create(ctx context.Context, request *models.CreatePromoCodeRequest) (*models.PromoCode, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate promo code request", http.StatusBadRequest)
+	}
+
+	promo := models.PromoCode{
+		Code:           normalizePromoCode(request.Code),
+		DiscountType:   request.DiscountType,
+		Value:          request.Value,
+		Currency:       request.Currency,
+		MaxRedemptions: request.MaxRedemptions,
+		MaxPerUser:     request.MaxPerUser,
+		TicketIDs:      request.TicketIDs,
+	}
+	if request.ValidFrom != nil {
+		promo.ValidFrom = request.ValidFrom.UTC()
+	}
+	if request.ValidUntil != nil {
+		promo.ValidUntil = request.ValidUntil.UTC()
+	}
+
+	if err := validatePromoCode(&promo); err != nil {
+		return nil, err
+	}
+
+	exists, err := rc.promoRepo.ExistsByCode(ctx, promo.Code)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to check promo code", http.StatusInternalServerError)
+	}
+	if exists {
+		return nil, pkg.NewError(errors.New("promo code already exists"), "a promo code with this code already exists", http.StatusConflict)
+	}
+
+	created, err := rc.promoRepo.Create(ctx, &promo)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to create promo code", http.StatusInternalServerError)
+	}
+
+	return created, nil
+}
+
+// Update changes the given fields of a promo code, redemptions made so far are kept.
+func (rc *PromoCodeUC) Update(ctx context.Context, promoID string, request *models.UpdatePromoCodeRequest) (*models.PromoCode, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate promo code request", http.StatusBadRequest)
+	}
+
+	existPromo, err := rc.promoRepo.GetByID(ctx, promoID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find promo code", http.StatusNotFound)
+	}
+
+	columns := make([]string, 0)
+	if request.Value != nil {
+		existPromo.Value = *request.Value
+		columns = append(columns, "value")
+	}
+	if request.MaxRedemptions != nil {
+		existPromo.MaxRedemptions = *request.MaxRedemptions
+		columns = append(columns, "max_redemptions")
+	}
+	if request.MaxPerUser != nil {
+		existPromo.MaxPerUser = *request.MaxPerUser
+		columns = append(columns, "max_per_user")
+	}
+	if request.ValidFrom != nil {
+		existPromo.ValidFrom = request.ValidFrom.UTC()
+		columns = append(columns, "valid_from")
+	}
+	if request.ValidUntil != nil {
+		existPromo.ValidUntil = request.ValidUntil.UTC()
+		columns = append(columns, "valid_until")
+	}
+	if request.ClearValidFrom {
+		existPromo.ValidFrom = time.Time{}
+		columns = append(columns, "valid_from")
+	}
+	if request.ClearValidUntil {
+		existPromo.ValidUntil = time.Time{}
+		columns = append(columns, "valid_until")
+	}
+	if request.TicketIDs != nil {
+		existPromo.TicketIDs = *request.TicketIDs
+		columns = append(columns, "ticket_ids")
+	}
+
+	if err := validatePromoCode(existPromo); err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		return existPromo, nil
+	}
+
+	promo, err := rc.promoRepo.Update(ctx, existPromo, columns...)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to update promo code", http.StatusInternalServerError)
+	}
+
+	return promo, nil
+}
+
+// GetByID retrieves a promo code by the provided promo code ID.
+func (rc *PromoCodeUC) GetByID(ctx context.Context, promoID string) (*models.PromoCode, error) {
+	promo, err := rc.promoRepo.GetByID(ctx, promoID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find promo code", http.StatusNotFound)
+	}
+
+	return promo, nil
+}
+
+// List retrieves every promo code that isn't deleted.
+func (rc *PromoCodeUC) List(ctx context.Context) ([]models.PromoCode, error) {
+	promos, err := rc.promoRepo.List(ctx)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to list promo codes", http.StatusInternalServerError)
+	}
+
+	return promos, nil
+}
+
+// Delete removes a promo code, it can't be redeemed anymore.
+func (rc *PromoCodeUC) Delete(ctx context.Context, promoID string) error {
+	if err := rc.promoRepo.Delete(ctx, promoID); err != nil {
+		return pkg.NewError(err, "failed to delete promo code", http.StatusNotFound)
+	}
+
+	return nil
+}
+
+// Redeem validates the code for a purchase of the ticket priced in the currency by the user and counts one
+// redemption. It joins the caller's transaction, so the redemption is rolled back with the purchase it was made for.
+func (rc *PromoCodeUC) Redeem(ctx context.Context, code string, ticketID int64, currency, userID string) (*models.PromoCode, error) {
+	var promo *models.PromoCode
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		// the row lock keeps the per-user count stable until the redemption is written
+		existPromo, err := rc.promoRepo.GetByCodeForUpdate(ctx, normalizePromoCode(code))
+		if err != nil {
+			return pkg.NewError(pkg.ErrPromoCodeInvalid, "promo code is not valid", http.StatusBadRequest)
+		}
+
+		if !existPromo.ValidAt(rc.clock.Now()) {
+			return pkg.NewError(pkg.ErrPromoCodeInvalid, "promo code is not valid at this time", http.StatusBadRequest)
+		}
+
+		if !existPromo.AppliesTo(ticketID) {
+			return pkg.NewError(pkg.ErrPromoCodeInvalid, "promo code is not valid for this ticket", http.StatusBadRequest)
+		}
+
+		if !existPromo.AppliesToCurrency(currency) {
+			return pkg.NewError(pkg.ErrPromoCodeInvalid, "promo code is not valid for the currency of this ticket", http.StatusBadRequest)
+		}
+
+		if existPromo.MaxPerUser > 0 {
+			used, err := rc.purchaseRepo.CountByPromoCodeAndUser(ctx, existPromo.ID, userID)
+			if err != nil {
+				return pkg.NewError(err, "failed to check promo code usage", http.StatusInternalServerError)
+			}
+			if used >= existPromo.MaxPerUser {
+				return pkg.NewError(pkg.ErrPromoCodeInvalid, "promo code was already used as often as allowed", http.StatusBadRequest)
+			}
+		}
+
+		promo, err = rc.promoRepo.Redeem(ctx, existPromo.ID)
+		if err != nil {
+			if errors.Is(err, pkg.ErrPromoCodeExhausted) {
+				return pkg.NewError(err, "promo code has been fully redeemed", http.StatusConflict)
+			}
+			return pkg.NewError(err, "failed to redeem promo code", http.StatusInternalServerError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, txError(err, "failed to redeem promo code")
+	}
+
+	return promo, nil
+}
+
+// normalizePromoCode upper-cases a code so codes match case-insensitively.
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// validatePromoCode fails for percentages over 100, fixed amounts without a currency and validity windows that end
+// before they start.
+func validatePromoCode(promo *models.PromoCode) error {
+	if promo.DiscountType == models.DiscountTypePercent && promo.Value > 100 {
+		return pkg.NewError(errors.New("percentage over 100"), "value of a percent code must be between 1 and 100", http.StatusBadRequest)
+	}
+
+	if promo.DiscountType == models.DiscountTypeFixed && promo.Currency == "" {
+		return pkg.NewError(errors.New("fixed amount without currency"), "currency of a fixed code is required", http.StatusBadRequest)
+	}
+
+	if !promo.ValidFrom.IsZero() && !promo.ValidUntil.IsZero() && !promo.ValidUntil.After(promo.ValidFrom) {
+		return pkg.NewError(errors.New("valid until before valid from"), "valid_until must be after valid_from", http.StatusBadRequest)
+	}
+
+	return nil
+}
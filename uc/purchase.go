@@ -0,0 +1,173 @@
+package uc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+)
+
+type PurchaseUC struct {
+	purchaseRepo       interfaces.PurchaseInterfaces
+	ticketRepo         interfaces.TicketInterfaces
+	tierRepo           interfaces.TierInterfaces
+	txManager          interfaces.TxInterfaces
+	waitlistUC         *WaitlistUC
+	paymentUC          *PaymentUC
+	credentialUC       *CredentialUC
+	outboxUC           *OutboxUC
+	clock              pkg.Clock
+	validator          *pkg.CustomValidator
+	cancellationWindow time.Duration
+}
+
+// NewPurchaseUC creates a PurchaseUC. Purchases older than cancellationWindow can't be cancelled,
+// a non-positive window allows cancellations at any time.
+func NewPurchaseUC(purchaseRepo interfaces.PurchaseInterfaces, ticketRepo interfaces.TicketInterfaces, tierRepo interfaces.TierInterfaces, txManager interfaces.TxInterfaces, waitlistUC *WaitlistUC, paymentUC *PaymentUC, credentialUC *CredentialUC, outboxUC *OutboxUC, clock pkg.Clock, validator *pkg.CustomValidator, cancellationWindow time.Duration) *PurchaseUC {
+	return &PurchaseUC{
+		purchaseRepo:       purchaseRepo,
+		ticketRepo:         ticketRepo,
+		tierRepo:           tierRepo,
+		txManager:          txManager,
+		waitlistUC:         waitlistUC,
+		paymentUC:          paymentUC,
+		credentialUC:       credentialUC,
+		outboxUC:           outboxUC,
+		clock:              clock,
+		validator:          validator,
+		cancellationWindow: cancellationWindow,
+	}
+}
+
+// GetByID retrieves a purchase by the provided purchase ID together with its credentials.
+func (rc *PurchaseUC) GetByID(ctx context.Context, purchaseID string) (*models.Purchase, error) {
+	p, err := rc.purchaseRepo.GetByID(ctx, purchaseID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find purchase", http.StatusNotFound)
+	}
+
+	p.Credentials, err = rc.credentialUC.ListByPurchaseID(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// ListByTicketID retrieves all purchases made for the provided ticket ID.
+func (rc *PurchaseUC) ListByTicketID(ctx context.Context, ticketID string) ([]models.Purchase, error) {
+	// ticket exist control
+	if _, err := rc.ticketRepo.GetByID(ctx, ticketID); err != nil {
+		return nil, pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+	}
+
+	purchases, err := rc.purchaseRepo.ListByTicketID(ctx, ticketID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to list purchases", http.StatusInternalServerError)
+	}
+
+	return purchases, nil
+}
+
+// Cancel refunds the requested quantity of a purchase, or every seat left that wasn't checked in when no quantity is
+// given, and returns the seats to the ticket's allocation. Seats somebody got in with can't be cancelled. The payment
+// is refunded once the cancellation committed, a refund that fails is paid back later by the checkout sweeper and the
+// returned purchase shows what was refunded so far.
+func (rc *PurchaseUC) Cancel(ctx context.Context, purchaseID string, request *models.CancelPurchaseRequest) (*models.Purchase, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate cancel request", http.StatusBadRequest)
+	}
+
+	existPurchase, err := rc.GetByID(ctx, purchaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.UserID != "" && existPurchase.UserID != request.UserID {
+		return nil, pkg.NewError(errors.New("purchase belongs to another user"), "purchase does not belong to the user", http.StatusForbidden)
+	}
+
+	if existPurchase.Status == models.PurchaseStatusPending || existPurchase.Status == models.PurchaseStatusFailed {
+		return nil, pkg.NewError(pkg.ErrPurchaseNotCompleted, "purchase was not completed", http.StatusConflict)
+	}
+
+	remaining := existPurchase.Remaining()
+	if remaining == 0 {
+		return nil, pkg.NewError(pkg.ErrAlreadyRefunded, "purchase was already refunded", http.StatusConflict)
+	}
+
+	if rc.cancellationWindow > 0 && rc.clock.Now().After(existPurchase.CreatedAt.Add(rc.cancellationWindow)) {
+		return nil, pkg.NewError(pkg.ErrCancellationWindowClosed, "cancellation window has closed for this purchase", http.StatusConflict)
+	}
+
+	// seats somebody got in with can't be given back
+	checkedIn, err := rc.credentialUC.CountCheckedIn(ctx, existPurchase.ID)
+	if err != nil {
+		return nil, err
+	}
+	cancellable := max(remaining-checkedIn, 0)
+	if cancellable == 0 {
+		return nil, pkg.NewError(pkg.ErrSeatsCheckedIn, "seats of the purchase were already checked in", http.StatusConflict)
+	}
+
+	quantity := request.Quantity
+	if quantity == 0 {
+		quantity = cancellable
+	}
+
+	if quantity > remaining {
+		return nil, pkg.NewError(errors.New("cancel quantity exceeds remaining seats"), "cannot cancel more than the remaining quantity", http.StatusBadRequest)
+	}
+
+	if quantity > cancellable {
+		message := fmt.Sprintf("only %d seats of the purchase can be cancelled, the others were checked in", cancellable)
+		return nil, pkg.NewError(pkg.ErrSeatsCheckedIn, message, http.StatusConflict)
+	}
+
+	var purchase *models.Purchase
+	err = rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		// a concurrent cancellation may have refunded the seats since the purchase was read
+		purchase, err = rc.purchaseRepo.Refund(ctx, purchaseID, quantity)
+		if err != nil {
+			if errors.Is(err, pkg.ErrAlreadyRefunded) {
+				return pkg.NewError(err, "purchase was already refunded", http.StatusConflict)
+			}
+			return pkg.NewError(err, "failed to refund purchase", http.StatusInternalServerError)
+		}
+
+		ticketID := strconv.FormatInt(purchase.TicketID, 10)
+		if err := returnSeats(ctx, rc.ticketRepo, rc.tierRepo, ticketID, purchase.TierID, quantity); err != nil {
+			return pkg.NewError(err, "failed to update ticket", http.StatusInternalServerError)
+		}
+
+		// the given back seats can't get in anymore, even with a screenshot of their QR code
+		if err := rc.credentialUC.Revoke(ctx, purchase, quantity); err != nil {
+			return err
+		}
+
+		if err := rc.waitlistUC.OfferSeats(ctx, ticketID); err != nil {
+			return pkg.NewError(err, "failed to offer seats to the waitlist", http.StatusInternalServerError)
+		}
+
+		return rc.outboxUC.RecordPurchase(ctx, models.EventPurchaseRefunded, purchase)
+	})
+	if err != nil {
+		return nil, txError(err, "failed to cancel purchase")
+	}
+
+	// the cancellation stands when the refund fails, the sweeper finds the amount still owed and pays it back
+	_ = rc.paymentUC.Refund(ctx, purchase, refundAmount(purchase, quantity))
+
+	purchase.Credentials, err = rc.credentialUC.ListByPurchaseID(ctx, purchase.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return purchase, nil
+}
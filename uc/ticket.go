@@ -2,7 +2,13 @@ package uc
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/fleimkeipa/tickets-api/models"
 	"github.com/fleimkeipa/tickets-api/pkg"
@@ -10,14 +16,32 @@ import (
 )
 
 type TicketUC struct {
-	ticketRepo interfaces.TicketInterfaces
-	validator  *pkg.CustomValidator
+	ticketRepo   interfaces.TicketInterfaces
+	purchaseRepo interfaces.PurchaseInterfaces
+	holdRepo     interfaces.HoldInterfaces
+	tierRepo     interfaces.TierInterfaces
+	txManager    interfaces.TxInterfaces
+	waitlistUC   *WaitlistUC
+	promoCodeUC  *PromoCodeUC
+	checkoutUC   *CheckoutUC
+	outboxUC     *OutboxUC
+	clock        pkg.Clock
+	validator    *pkg.CustomValidator
 }
 
-func NewTicketUC(ticketRepo interfaces.TicketInterfaces, validator *pkg.CustomValidator) *TicketUC {
+func NewTicketUC(ticketRepo interfaces.TicketInterfaces, purchaseRepo interfaces.PurchaseInterfaces, holdRepo interfaces.HoldInterfaces, tierRepo interfaces.TierInterfaces, txManager interfaces.TxInterfaces, waitlistUC *WaitlistUC, promoCodeUC *PromoCodeUC, checkoutUC *CheckoutUC, outboxUC *OutboxUC, clock pkg.Clock, validator *pkg.CustomValidator) *TicketUC {
 	return &TicketUC{
-		ticketRepo: ticketRepo,
-		validator:  validator,
+		ticketRepo:   ticketRepo,
+		purchaseRepo: purchaseRepo,
+		holdRepo:     holdRepo,
+		tierRepo:     tierRepo,
+		txManager:    txManager,
+		waitlistUC:   waitlistUC,
+		promoCodeUC:  promoCodeUC,
+		checkoutUC:   checkoutUC,
+		outboxUC:     outboxUC,
+		clock:        clock,
+		validator:    validator,
 	}
 }
 
@@ -31,18 +55,219 @@ func (rc *TicketUC) Create(ctx context.Context, request *models.CreateRequest) (
 		Name:        request.Name,
 		Description: request.Description,
 		Allocation:  request.Allocation,
+		Price:       request.Price,
+		Currency:    request.Currency,
+		Status:      request.Status,
+		MaxPerUser:  request.MaxPerUser,
+	}
+	if ticket.Status == "" {
+		ticket.Status = models.TicketStatusDraft
+	}
+	if request.SalesStart != nil {
+		ticket.SalesStart = request.SalesStart.UTC()
+	}
+	if request.SalesEnd != nil {
+		ticket.SalesEnd = request.SalesEnd.UTC()
+	}
+	if request.EventStart != nil {
+		ticket.EventStart = request.EventStart.UTC()
 	}
 
-	t, err := rc.ticketRepo.Create(ctx, &ticket)
+	if err := validateSalesWindow(&ticket); err != nil {
+		return nil, err
+	}
+
+	var t *models.Ticket
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		var err error
+		t, err = rc.ticketRepo.Create(ctx, &ticket)
+		if err != nil {
+			return pkg.NewError(err, "failed to create ticket", http.StatusInternalServerError)
+		}
+		t.SaleStatus = t.SaleStatusAt(rc.clock.Now())
+
+		return rc.outboxUC.Record(ctx, models.EventTicketCreated, t.ID, t)
+	})
 	if err != nil {
-		return nil, pkg.NewError(err, "failed to create ticket", http.StatusInternalServerError)
+		return nil, txError(err, "failed to create ticket")
 	}
 
 	return t, nil
 }
 
-// Purchase handles the purchasing of a ticket by the provided ticket ID.
-func (rc *TicketUC) Purchase(ctx context.Context, ticketID string, request *models.PurchaseRequest) (*models.Ticket, error) {
+// Update applies a partial update to the ticket by the provided ticket ID.
+// When expectedVersion is given the update is refused unless the ticket is still at that version.
+func (rc *TicketUC) Update(ctx context.Context, ticketID string, request *models.UpdateRequest, expectedVersion *int) (*models.Ticket, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate update request", http.StatusBadRequest)
+	}
+
+	var ticket *models.Ticket
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		// ticket exist control, the row lock keeps the version stable until the update is written
+		existTicket, err := rc.ticketRepo.GetByIDForUpdate(ctx, ticketID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+		}
+
+		if expectedVersion != nil && *expectedVersion != existTicket.Version {
+			return pkg.NewError(pkg.ErrVersionConflict, "ticket was modified by another request", http.StatusPreconditionFailed)
+		}
+
+		columns := make([]string, 0)
+		if request.Name != nil {
+			existTicket.Name = *request.Name
+			columns = append(columns, "name")
+		}
+		if request.Description != nil {
+			existTicket.Description = *request.Description
+			columns = append(columns, "description")
+		}
+		if request.Allocation != nil {
+			tiered, err := rc.tierRepo.ExistsByTicketID(ctx, ticketID)
+			if err != nil {
+				return pkg.NewError(err, "failed to check ticket tiers", http.StatusInternalServerError)
+			}
+			if tiered {
+				return pkg.NewError(errors.New("ticket has tiers"), "allocation of a ticket with tiers is the sum of its tiers, update the tiers instead", http.StatusConflict)
+			}
+
+			existTicket.Allocation = *request.Allocation
+			columns = append(columns, "allocation")
+
+			// a restock puts a sold out ticket back on sale
+			if existTicket.Status == models.TicketStatusSoldOut {
+				existTicket.Status = models.TicketStatusOnSale
+				columns = append(columns, "status")
+			}
+		}
+		if request.Price != nil {
+			existTicket.Price = *request.Price
+			columns = append(columns, "price")
+		}
+		if request.Currency != nil {
+			existTicket.Currency = *request.Currency
+			columns = append(columns, "currency")
+		}
+		if request.MaxPerUser != nil {
+			existTicket.MaxPerUser = *request.MaxPerUser
+			columns = append(columns, "max_per_user")
+		}
+		if request.SalesStart != nil {
+			existTicket.SalesStart = request.SalesStart.UTC()
+			columns = append(columns, "sales_start")
+		}
+		if request.SalesEnd != nil {
+			existTicket.SalesEnd = request.SalesEnd.UTC()
+			columns = append(columns, "sales_end")
+		}
+		if request.EventStart != nil {
+			existTicket.EventStart = request.EventStart.UTC()
+			columns = append(columns, "event_start")
+		}
+
+		if err := validateSalesWindow(existTicket); err != nil {
+			return err
+		}
+
+		if existTicket.Price > 0 && existTicket.Currency == "" {
+			return pkg.NewError(errors.New("price without currency"), "currency is required for priced tickets", http.StatusBadRequest)
+		}
+
+		if len(columns) == 0 {
+			ticket = existTicket
+			return nil
+		}
+
+		ticket, err = rc.ticketRepo.Update(ctx, existTicket, columns...)
+		if err != nil {
+			if errors.Is(err, pkg.ErrVersionConflict) {
+				return pkg.NewError(err, "ticket was modified by another request", http.StatusPreconditionFailed)
+			}
+			return pkg.NewError(err, "failed to update ticket", http.StatusInternalServerError)
+		}
+
+		// restocked seats go to the waitlist first
+		if request.Allocation != nil {
+			ticket, err = rc.offerToWaitlist(ctx, ticketID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, txError(err, "failed to update ticket")
+	}
+	ticket.SaleStatus = ticket.SaleStatusAt(rc.clock.Now())
+
+	return ticket, nil
+}
+
+// Delete soft deletes the ticket by the provided ticket ID, its purchases are kept.
+// A hard delete permanently removes the ticket and is refused once it was sold or held.
+// When expectedVersion is given the delete is refused unless the ticket is still at that version.
+func (rc *TicketUC) Delete(ctx context.Context, ticketID string, hard bool, expectedVersion *int) error {
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		// the row lock keeps new purchases and updates out until the ticket is gone, a hard delete also removes
+		// tickets that were soft deleted before
+		getTicket := rc.ticketRepo.GetByIDForUpdate
+		if hard {
+			getTicket = rc.ticketRepo.GetWithDeletedForUpdate
+		}
+		existTicket, err := getTicket(ctx, ticketID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+		}
+
+		if expectedVersion != nil && *expectedVersion != existTicket.Version {
+			return pkg.NewError(pkg.ErrVersionConflict, "ticket was modified by another request", http.StatusPreconditionFailed)
+		}
+
+		if !hard {
+			if err := rc.ticketRepo.Delete(ctx, ticketID); err != nil {
+				return pkg.NewError(err, "failed to delete ticket", http.StatusInternalServerError)
+			}
+
+			return nil
+		}
+
+		purchased, err := rc.purchaseRepo.ExistsByTicketID(ctx, ticketID)
+		if err != nil {
+			return pkg.NewError(err, "failed to check ticket purchases", http.StatusInternalServerError)
+		}
+
+		held, err := rc.holdRepo.ExistsByTicketID(ctx, ticketID)
+		if err != nil {
+			return pkg.NewError(err, "failed to check ticket holds", http.StatusInternalServerError)
+		}
+
+		if purchased || held {
+			return pkg.NewError(errors.New("ticket has purchases"), "ticket with purchases can only be soft deleted", http.StatusConflict)
+		}
+
+		if err := rc.tierRepo.DeleteByTicketID(ctx, ticketID); err != nil {
+			return pkg.NewError(err, "failed to delete ticket tiers", http.StatusInternalServerError)
+		}
+
+		if err := rc.ticketRepo.ForceDelete(ctx, ticketID); err != nil {
+			return pkg.NewError(err, "failed to delete ticket", http.StatusInternalServerError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return txError(err, "failed to delete ticket")
+	}
+
+	return nil
+}
+
+// Purchase handles the purchasing of a ticket by the provided ticket ID. The seats are reserved for a pending purchase
+// with a hold in one transaction, the purchase is completed once it is paid. A failed payment leaves the hold expired,
+// the hold sweeper returns its seats.
+func (rc *TicketUC) Purchase(ctx context.Context, ticketID string, request *models.PurchaseRequest) (*models.Purchase, error) {
 	if err := rc.validator.Validate(request); err != nil {
 		return nil, pkg.NewError(err, "failed to validate purchase request", http.StatusBadRequest)
 	}
@@ -53,22 +278,240 @@ func (rc *TicketUC) Purchase(ctx context.Context, ticketID string, request *mode
 		return nil, pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
 	}
 
+	if err := checkTicketStatus(existTicket); err != nil {
+		return nil, err
+	}
+
+	if err := checkSalesWindow(existTicket.SaleStatusAt(rc.clock.Now())); err != nil {
+		return nil, err
+	}
+
 	if existTicket.Allocation == 0 {
-		return nil, pkg.NewError(err, "there is no available ticket now", http.StatusBadRequest)
+		return nil, pkg.NewError(errors.New("ticket is sold out"), "there is no available ticket now", http.StatusBadRequest)
+	}
+
+	tier, err := resolveTier(ctx, rc.tierRepo, ticketID, request.TierID, rc.clock.Now())
+	if err != nil {
+		return nil, err
 	}
 
-	if existTicket.Allocation < request.Quantity {
-		return nil, pkg.NewError(err, "cannot afford this quantity", http.StatusBadRequest)
+	available := existTicket.Allocation
+	if tier != nil {
+		available = tier.Allocation
+	}
+	if available < request.Quantity {
+		return nil, pkg.NewError(pkg.ErrInsufficientAllocation, "cannot afford this quantity", http.StatusBadRequest)
 	}
 
-	existTicket.Allocation -= request.Quantity
+	var purchase *models.Purchase
+	err = rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		// the limit is read under the ticket's row lock, a limit set since the ticket was read applies as well
+		if err := checkPurchaseLimit(ctx, rc.ticketRepo, rc.purchaseRepo, rc.holdRepo, ticketID, request.UserID, request.Quantity, rc.clock.Now()); err != nil {
+			return err
+		}
+
+		t, err := takeSeats(ctx, rc.ticketRepo, rc.tierRepo, ticketID, tier, request.Quantity)
+		if err != nil {
+			return err
+		}
+
+		newPurchase := models.Purchase{
+			TicketID: t.ID,
+			TierID:   request.TierID,
+			UserID:   request.UserID,
+			Quantity: request.Quantity,
+			Status:   models.PurchaseStatusPending,
+		}
+
+		// the redemption is given back with the seats if the purchase fails
+		var promo *models.PromoCode
+		if request.PromoCode != "" {
+			promo, err = rc.promoCodeUC.Redeem(ctx, request.PromoCode, t.ID, t.Currency, request.UserID)
+			if err != nil {
+				return err
+			}
+			newPurchase.PromoCodeID = promo.ID
+			newPurchase.PromoCode = promo.Code
+		}
+		newPurchase.SetPrice(unitPrice(t, tier), t.Currency, promo)
 
-	t, err := rc.ticketRepo.Update(ctx, existTicket)
+		purchase, err = rc.purchaseRepo.Create(ctx, &newPurchase)
+		if err != nil {
+			return pkg.NewError(err, "failed to create purchase", http.StatusInternalServerError)
+		}
+
+		// the hold has expired from the start, once the payment failed the sweeper may return its seats
+		if _, err := rc.holdRepo.Create(ctx, &models.Hold{
+			TicketID:   t.ID,
+			TierID:     request.TierID,
+			UserID:     request.UserID,
+			Quantity:   request.Quantity,
+			Status:     models.HoldStatusPaying,
+			UnitPrice:  purchase.UnitPrice,
+			Currency:   purchase.Currency,
+			PurchaseID: purchase.ID,
+			ExpiresAt:  rc.clock.Now(),
+		}); err != nil {
+			return pkg.NewError(err, "failed to create hold", http.StatusInternalServerError)
+		}
+
+		return rc.outboxUC.RecordSoldOut(ctx, t)
+	})
 	if err != nil {
-		return nil, pkg.NewError(err, "failed to update ticket", http.StatusInternalServerError)
+		return nil, txError(err, "failed to purchase ticket")
 	}
 
-	return t, nil
+	return rc.checkoutUC.Settle(ctx, purchase)
+}
+
+// ticketTransitions lists the states a ticket may move to from each state. The moves between on_sale and sold_out
+// also happen on their own when the allocation runs out or seats are returned.
+var ticketTransitions = map[models.TicketStatus][]models.TicketStatus{
+	models.TicketStatusDraft:     {models.TicketStatusOnSale, models.TicketStatusCancelled},
+	models.TicketStatusOnSale:    {models.TicketStatusPaused, models.TicketStatusSoldOut, models.TicketStatusCancelled},
+	models.TicketStatusPaused:    {models.TicketStatusOnSale, models.TicketStatusCancelled},
+	models.TicketStatusSoldOut:   {models.TicketStatusOnSale, models.TicketStatusPaused, models.TicketStatusCancelled},
+	models.TicketStatusCancelled: {},
+}
+
+// Publish puts a draft or paused ticket on sale, or marks it sold out when no seat is left.
+func (rc *TicketUC) Publish(ctx context.Context, ticketID string, expectedVersion *int) (*models.Ticket, error) {
+	return rc.transition(ctx, ticketID, models.TicketStatusOnSale, expectedVersion)
+}
+
+// Pause stops the sales of a ticket until it is published again.
+func (rc *TicketUC) Pause(ctx context.Context, ticketID string, expectedVersion *int) (*models.Ticket, error) {
+	return rc.transition(ctx, ticketID, models.TicketStatusPaused, expectedVersion)
+}
+
+// Cancel permanently stops the sales of a ticket, existing purchases are kept.
+func (rc *TicketUC) Cancel(ctx context.Context, ticketID string, expectedVersion *int) (*models.Ticket, error) {
+	return rc.transition(ctx, ticketID, models.TicketStatusCancelled, expectedVersion)
+}
+
+// transition moves the ticket to the target state when ticketTransitions allows it.
+func (rc *TicketUC) transition(ctx context.Context, ticketID string, target models.TicketStatus, expectedVersion *int) (*models.Ticket, error) {
+	var ticket *models.Ticket
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		existTicket, err := rc.ticketRepo.GetByIDForUpdate(ctx, ticketID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+		}
+
+		if expectedVersion != nil && *expectedVersion != existTicket.Version {
+			return pkg.NewError(pkg.ErrVersionConflict, "ticket was modified by another request", http.StatusPreconditionFailed)
+		}
+
+		if !slices.Contains(ticketTransitions[existTicket.Status], target) {
+			message := fmt.Sprintf("ticket can't move from %s to %s", existTicket.Status, target)
+			return pkg.NewError(pkg.ErrInvalidTransition, message, http.StatusConflict)
+		}
+
+		existTicket.Status = target
+		if target == models.TicketStatusOnSale && existTicket.Allocation == 0 {
+			existTicket.Status = models.TicketStatusSoldOut
+		}
+
+		ticket, err = rc.ticketRepo.Update(ctx, existTicket, "status")
+		if err != nil {
+			return pkg.NewError(err, "failed to update ticket", http.StatusInternalServerError)
+		}
+
+		// seats freed while the ticket was off sale go to the waitlist first
+		if ticket.Status == models.TicketStatusOnSale {
+			ticket, err = rc.offerToWaitlist(ctx, ticketID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, txError(err, "failed to update ticket status")
+	}
+	ticket.SaleStatus = ticket.SaleStatusAt(rc.clock.Now())
+
+	return ticket, nil
+}
+
+// offerToWaitlist offers the ticket's available seats to its waitlist and returns the ticket as it is afterwards.
+func (rc *TicketUC) offerToWaitlist(ctx context.Context, ticketID string) (*models.Ticket, error) {
+	if err := rc.waitlistUC.OfferSeats(ctx, ticketID); err != nil {
+		return nil, pkg.NewError(err, "failed to offer seats to the waitlist", http.StatusInternalServerError)
+	}
+
+	ticket, err := rc.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+	}
+
+	return ticket, nil
+}
+
+// checkTicketStatus fails for tickets that can't be bought in their current state.
+func checkTicketStatus(ticket *models.Ticket) error {
+	switch ticket.Status {
+	case models.TicketStatusOnSale:
+		return nil
+	case models.TicketStatusSoldOut:
+		return pkg.NewError(errors.New("ticket is sold out"), "there is no available ticket now", http.StatusBadRequest)
+	default:
+		return pkg.NewError(pkg.ErrTicketNotOnSale, "ticket is not on sale", http.StatusConflict)
+	}
+}
+
+// validateSalesWindow fails when the ticket's sales end isn't after its sales start.
+func validateSalesWindow(ticket *models.Ticket) error {
+	if ticket.SalesStart.IsZero() || ticket.SalesEnd.IsZero() || ticket.SalesEnd.After(ticket.SalesStart) {
+		return nil
+	}
+
+	return pkg.NewError(errors.New("sales end before sales start"), "sales_end must be after sales_start", http.StatusBadRequest)
+}
+
+// checkSalesWindow fails with a distinct error for tickets and tiers that are not on sale yet and those whose sales closed.
+func checkSalesWindow(status models.SaleStatus) error {
+	switch status {
+	case models.SaleStatusNotStarted:
+		return pkg.NewError(pkg.ErrSalesNotStarted, "ticket is not on sale yet", http.StatusConflict)
+	case models.SaleStatusClosed:
+		return pkg.NewError(pkg.ErrSalesClosed, "ticket sales have closed", http.StatusGone)
+	default:
+		return nil
+	}
+}
+
+// checkPurchaseLimit fails when quantity more seats would take the user over the per-user limit of the ticket.
+// Seats the user bought or still holds count against the limit. It must run inside the purchase transaction,
+// the ticket row lock serializes the user's concurrent purchases until the new seats are written.
+func checkPurchaseLimit(ctx context.Context, ticketRepo interfaces.TicketInterfaces, purchaseRepo interfaces.PurchaseInterfaces, holdRepo interfaces.HoldInterfaces, ticketID, userID string, quantity int, now time.Time) error {
+	ticket, err := ticketRepo.GetByIDForUpdate(ctx, ticketID)
+	if err != nil {
+		return pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+	}
+
+	if ticket.MaxPerUser == 0 {
+		return nil
+	}
+
+	purchased, err := purchaseRepo.SumQuantityByUser(ctx, ticketID, userID)
+	if err != nil {
+		return pkg.NewError(err, "failed to check purchase limit", http.StatusInternalServerError)
+	}
+
+	held, err := holdRepo.SumActiveQuantityByUser(ctx, ticketID, userID, now)
+	if err != nil {
+		return pkg.NewError(err, "failed to check purchase limit", http.StatusInternalServerError)
+	}
+
+	remaining := max(ticket.MaxPerUser-purchased-held, 0)
+	if quantity > remaining {
+		message := fmt.Sprintf("purchase limit of %d per user reached, you may buy %d more", ticket.MaxPerUser, remaining)
+		return pkg.NewError(pkg.ErrPurchaseLimitExceeded, message, http.StatusBadRequest)
+	}
+
+	return nil
 }
 
 // GetByID retrieves a ticket by the provided ticket ID.
@@ -77,6 +520,107 @@ func (rc *TicketUC) GetByID(ctx context.Context, ticketID string) (*models.Ticke
 	if err != nil {
 		return nil, pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
 	}
+	t.SaleStatus = t.SaleStatusAt(rc.clock.Now())
+
+	tiers, err := rc.tierRepo.ListByTicketID(ctx, ticketID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to list ticket tiers", http.StatusInternalServerError)
+	}
+	for i := range tiers {
+		tiers[i].SaleStatus = tiers[i].SaleStatusAt(rc.clock.Now())
+	}
+	t.Tiers = tiers
 
 	return t, nil
 }
+
+// defaultListLimit is the page size used when the request doesn't specify one.
+const defaultListLimit = 20
+
+// List retrieves a page of tickets and the cursor of the next page, which is empty on the last page.
+func (rc *TicketUC) List(ctx context.Context, request *models.TicketListRequest) ([]models.Ticket, string, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, "", pkg.NewError(err, "failed to validate list request", http.StatusBadRequest)
+	}
+
+	opts := models.TicketListOptions{
+		Name:      request.Name,
+		Available: request.Available,
+		Sort:      request.Sort,
+		Order:     request.Order,
+		Limit:     request.Limit,
+	}
+	if opts.Sort == "" {
+		opts.Sort = "id"
+	}
+	if opts.Order == "" {
+		opts.Order = "asc"
+	}
+	if opts.Limit == 0 {
+		opts.Limit = defaultListLimit
+	}
+
+	if request.Cursor != "" {
+		cursor, err := decodeTicketCursor(request.Cursor)
+		if err != nil {
+			return nil, "", pkg.NewError(err, "invalid cursor", http.StatusBadRequest)
+		}
+
+		// a cursor only makes sense for the ordering it was created with
+		if cursor.Sort != opts.Sort || cursor.Order != opts.Order {
+			return nil, "", pkg.NewError(errors.New("cursor ordering mismatch"), "cursor does not match the requested sort order", http.StatusBadRequest)
+		}
+
+		opts.After = cursor
+	}
+
+	// one extra ticket tells whether there is a next page
+	opts.Limit++
+	tickets, err := rc.ticketRepo.List(ctx, opts)
+	if err != nil {
+		return nil, "", pkg.NewError(err, "failed to list tickets", http.StatusInternalServerError)
+	}
+
+	now := rc.clock.Now()
+	for i := range tickets {
+		tickets[i].SaleStatus = tickets[i].SaleStatusAt(now)
+	}
+
+	if len(tickets) < opts.Limit {
+		return tickets, "", nil
+	}
+
+	tickets = tickets[:len(tickets)-1]
+	last := tickets[len(tickets)-1]
+	nextCursor := encodeTicketCursor(&models.TicketCursor{
+		Sort:       opts.Sort,
+		Order:      opts.Order,
+		Name:       last.Name,
+		Allocation: last.Allocation,
+		ID:         last.ID,
+	})
+
+	return tickets, nextCursor, nil
+}
+
+// encodeTicketCursor turns the cursor into an opaque string for clients.
+func encodeTicketCursor(cursor *models.TicketCursor) string {
+	b, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeTicketCursor parses a cursor created by encodeTicketCursor.
+func decodeTicketCursor(s string) (*models.TicketCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := new(models.TicketCursor)
+	if err := json.Unmarshal(b, cursor); err != nil {
+		return nil, err
+	}
+
+	return cursor, nil
+}
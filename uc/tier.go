@@ -0,0 +1,315 @@
+package uc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+)
+
+type TierUC struct {
+	tierRepo   interfaces.TierInterfaces
+	ticketRepo interfaces.TicketInterfaces
+	txManager  interfaces.TxInterfaces
+	waitlistUC *WaitlistUC
+	clock      pkg.Clock
+	validator  *pkg.CustomValidator
+}
+
+func NewTierUC(tierRepo interfaces.TierInterfaces, ticketRepo interfaces.TicketInterfaces, txManager interfaces.TxInterfaces, waitlistUC *WaitlistUC, clock pkg.Clock, validator *pkg.CustomValidator) *TierUC {
+	return &TierUC{
+		tierRepo:   tierRepo,
+		ticketRepo: ticketRepo,
+		txManager:  txManager,
+		waitlistUC: waitlistUC,
+		clock:      clock,
+		validator:  validator,
+	}
+}
+
+// Create adds a tier to a ticket and adds its seats to the ticket's allocation. A ticket without tiers can only get
+// its first tier while it is a draft, from then on its allocation is the sum of its tiers.
+func (rc *TierUC) Create(ctx context.Context, ticketID string, request *models.CreateTierRequest) (*models.TicketTier, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate tier request", http.StatusBadRequest)
+	}
+
+	tier := models.TicketTier{
+		Name:       request.Name,
+		Allocation: request.Allocation,
+		Price:      request.Price,
+		Position:   request.Position,
+	}
+	if request.SalesStart != nil {
+		tier.SalesStart = request.SalesStart.UTC()
+	}
+	if request.SalesEnd != nil {
+		tier.SalesEnd = request.SalesEnd.UTC()
+	}
+
+	var created *models.TicketTier
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		// the row lock keeps purchases out until the ticket's allocation matches its tiers again
+		existTicket, err := rc.ticketRepo.GetByIDForUpdate(ctx, ticketID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+		}
+
+		if err := validateTier(existTicket, &tier); err != nil {
+			return err
+		}
+
+		tiered, err := rc.tierRepo.ExistsByTicketID(ctx, ticketID)
+		if err != nil {
+			return pkg.NewError(err, "failed to check ticket tiers", http.StatusInternalServerError)
+		}
+		// seats sold before the ticket had tiers belong to no tier
+		if !tiered && existTicket.Status != models.TicketStatusDraft {
+			return pkg.NewError(errors.New("ticket is not a draft"), "tiers can only be added to draft tickets or tickets that already have tiers", http.StatusConflict)
+		}
+
+		tier.TicketID = existTicket.ID
+		created, err = rc.tierRepo.Create(ctx, &tier)
+		if err != nil {
+			return pkg.NewError(err, "failed to create tier", http.StatusInternalServerError)
+		}
+
+		return rc.syncAllocation(ctx, ticketID)
+	})
+	if err != nil {
+		return nil, txError(err, "failed to create tier")
+	}
+	created.SaleStatus = created.SaleStatusAt(rc.clock.Now())
+
+	return created, nil
+}
+
+// Update applies a partial update to a tier of a ticket, allocation changes are carried over to the ticket.
+func (rc *TierUC) Update(ctx context.Context, ticketID, tierID string, request *models.UpdateTierRequest) (*models.TicketTier, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate tier request", http.StatusBadRequest)
+	}
+
+	var tier *models.TicketTier
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		existTicket, err := rc.ticketRepo.GetByIDForUpdate(ctx, ticketID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+		}
+
+		existTier, err := rc.tierRepo.GetByID(ctx, ticketID, tierID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find tier", http.StatusNotFound)
+		}
+
+		columns := make([]string, 0)
+		if request.Name != nil {
+			existTier.Name = *request.Name
+			columns = append(columns, "name")
+		}
+		if request.Allocation != nil {
+			existTier.Allocation = *request.Allocation
+			columns = append(columns, "allocation")
+		}
+		if request.Price != nil {
+			existTier.Price = *request.Price
+			columns = append(columns, "price")
+		}
+		if request.SalesStart != nil {
+			existTier.SalesStart = request.SalesStart.UTC()
+			columns = append(columns, "sales_start")
+		}
+		if request.SalesEnd != nil {
+			existTier.SalesEnd = request.SalesEnd.UTC()
+			columns = append(columns, "sales_end")
+		}
+		if request.Position != nil {
+			existTier.Position = *request.Position
+			columns = append(columns, "position")
+		}
+
+		if err := validateTier(existTicket, existTier); err != nil {
+			return err
+		}
+
+		if len(columns) == 0 {
+			tier = existTier
+			return nil
+		}
+
+		tier, err = rc.tierRepo.Update(ctx, existTier, columns...)
+		if err != nil {
+			return pkg.NewError(err, "failed to update tier", http.StatusInternalServerError)
+		}
+
+		if request.Allocation == nil {
+			return nil
+		}
+
+		return rc.syncAllocation(ctx, ticketID)
+	})
+	if err != nil {
+		return nil, txError(err, "failed to update tier")
+	}
+	tier.SaleStatus = tier.SaleStatusAt(rc.clock.Now())
+
+	return tier, nil
+}
+
+// ListByTicketID retrieves the tiers of a ticket in their order.
+func (rc *TierUC) ListByTicketID(ctx context.Context, ticketID string) ([]models.TicketTier, error) {
+	if _, err := rc.ticketRepo.GetByID(ctx, ticketID); err != nil {
+		return nil, pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+	}
+
+	tiers, err := rc.tierRepo.ListByTicketID(ctx, ticketID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to list tiers", http.StatusInternalServerError)
+	}
+
+	now := rc.clock.Now()
+	for i := range tiers {
+		tiers[i].SaleStatus = tiers[i].SaleStatusAt(now)
+	}
+
+	return tiers, nil
+}
+
+// Delete removes a tier of a draft ticket. Tiers of tickets that were on sale may have sold seats,
+// they are closed by setting their allocation to 0 instead.
+func (rc *TierUC) Delete(ctx context.Context, ticketID, tierID string) error {
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		existTicket, err := rc.ticketRepo.GetByIDForUpdate(ctx, ticketID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+		}
+
+		if existTicket.Status != models.TicketStatusDraft {
+			return pkg.NewError(errors.New("ticket is not a draft"), "tiers can only be deleted from draft tickets", http.StatusConflict)
+		}
+
+		if err := rc.tierRepo.Delete(ctx, ticketID, tierID); err != nil {
+			return pkg.NewError(err, "failed to delete tier", http.StatusNotFound)
+		}
+
+		return rc.syncAllocation(ctx, ticketID)
+	})
+	if err != nil {
+		return txError(err, "failed to delete tier")
+	}
+
+	return nil
+}
+
+// syncAllocation derives the ticket's allocation from its tiers and offers added seats to the waitlist.
+func (rc *TierUC) syncAllocation(ctx context.Context, ticketID string) error {
+	if _, err := rc.ticketRepo.SyncAllocation(ctx, ticketID); err != nil {
+		return pkg.NewError(err, "failed to update ticket", http.StatusInternalServerError)
+	}
+
+	if err := rc.waitlistUC.OfferSeats(ctx, ticketID); err != nil {
+		return pkg.NewError(err, "failed to offer seats to the waitlist", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// validateTier fails for tiers whose sales window ends before it starts and priced tiers of tickets without a currency.
+func validateTier(ticket *models.Ticket, tier *models.TicketTier) error {
+	if !tier.SalesStart.IsZero() && !tier.SalesEnd.IsZero() && !tier.SalesEnd.After(tier.SalesStart) {
+		return pkg.NewError(errors.New("sales end before sales start"), "sales_end must be after sales_start", http.StatusBadRequest)
+	}
+
+	if tier.Price > 0 && ticket.Currency == "" {
+		return pkg.NewError(errors.New("price without currency"), "the ticket needs a currency before its tiers can be priced", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// resolveTier finds the tier a purchase or hold takes its seats from. Tickets with tiers require one,
+// tickets without tiers take their seats from the ticket itself and get a nil tier.
+func resolveTier(ctx context.Context, tierRepo interfaces.TierInterfaces, ticketID string, tierID int64, now time.Time) (*models.TicketTier, error) {
+	if tierID == 0 {
+		tiered, err := tierRepo.ExistsByTicketID(ctx, ticketID)
+		if err != nil {
+			return nil, pkg.NewError(err, "failed to check ticket tiers", http.StatusInternalServerError)
+		}
+		if tiered {
+			return nil, pkg.NewError(errors.New("tier is missing"), "tier_id is required for tickets with tiers", http.StatusBadRequest)
+		}
+		return nil, nil
+	}
+
+	tier, err := tierRepo.GetByID(ctx, ticketID, strconv.FormatInt(tierID, 10))
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find tier", http.StatusNotFound)
+	}
+
+	if err := checkSalesWindow(tier.SaleStatusAt(now)); err != nil {
+		return nil, err
+	}
+
+	if tier.Allocation == 0 {
+		return nil, pkg.NewError(errors.New("tier is sold out"), "there is no available ticket in this tier now", http.StatusBadRequest)
+	}
+
+	return tier, nil
+}
+
+// takeSeats removes quantity seats from the ticket and, when a tier is given, from the tier.
+// Both decrements are conditional on the seats left, so a concurrent buyer may still win the race.
+func takeSeats(ctx context.Context, ticketRepo interfaces.TicketInterfaces, tierRepo interfaces.TierInterfaces, ticketID string, tier *models.TicketTier, quantity int) (*models.Ticket, error) {
+	t, err := ticketRepo.DecreaseAllocation(ctx, ticketID, quantity)
+	if err != nil {
+		if errors.Is(err, pkg.ErrInsufficientAllocation) {
+			return nil, pkg.NewError(err, "cannot afford this quantity", http.StatusBadRequest)
+		}
+		if errors.Is(err, pkg.ErrTicketNotOnSale) {
+			return nil, pkg.NewError(err, "ticket is not on sale", http.StatusConflict)
+		}
+		return nil, pkg.NewError(err, "failed to update ticket", http.StatusInternalServerError)
+	}
+
+	if tier == nil {
+		return t, nil
+	}
+
+	if _, err := tierRepo.DecreaseAllocation(ctx, tier.ID, quantity); err != nil {
+		if errors.Is(err, pkg.ErrInsufficientAllocation) {
+			return nil, pkg.NewError(err, "cannot afford this quantity in this tier", http.StatusBadRequest)
+		}
+		return nil, pkg.NewError(err, "failed to update tier", http.StatusInternalServerError)
+	}
+
+	return t, nil
+}
+
+// returnSeats gives quantity seats back to the ticket and, for seats taken from a tier, to the tier.
+func returnSeats(ctx context.Context, ticketRepo interfaces.TicketInterfaces, tierRepo interfaces.TierInterfaces, ticketID string, tierID int64, quantity int) error {
+	if _, err := ticketRepo.IncreaseAllocation(ctx, ticketID, quantity); err != nil {
+		return err
+	}
+
+	if tierID == 0 {
+		return nil
+	}
+
+	_, err := tierRepo.IncreaseAllocation(ctx, tierID, quantity)
+
+	return err
+}
+
+// unitPrice returns the price of one seat, the tier's price for seats taken from a tier.
+func unitPrice(ticket *models.Ticket, tier *models.TicketTier) int64 {
+	if tier != nil {
+		return tier.Price
+	}
+
+	return ticket.Price
+}
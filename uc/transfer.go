@@ -0,0 +1,352 @@
+package uc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+)
+
+type TransferUC struct {
+	transferRepo interfaces.TransferInterfaces
+	purchaseRepo interfaces.PurchaseInterfaces
+	ticketRepo   interfaces.TicketInterfaces
+	holdRepo     interfaces.HoldInterfaces
+	txManager    interfaces.TxInterfaces
+	credentialUC *CredentialUC
+	clock        pkg.Clock
+	validator    *pkg.CustomValidator
+	cutoff       time.Duration
+}
+
+// NewTransferUC creates a TransferUC. Transfers close cutoff before the event starts,
+// tickets without an event start can be transferred until they are cancelled.
+func NewTransferUC(transferRepo interfaces.TransferInterfaces, purchaseRepo interfaces.PurchaseInterfaces, ticketRepo interfaces.TicketInterfaces, holdRepo interfaces.HoldInterfaces, txManager interfaces.TxInterfaces, credentialUC *CredentialUC, clock pkg.Clock, validator *pkg.CustomValidator, cutoff time.Duration) *TransferUC {
+	return &TransferUC{
+		transferRepo: transferRepo,
+		purchaseRepo: purchaseRepo,
+		ticketRepo:   ticketRepo,
+		holdRepo:     holdRepo,
+		txManager:    txManager,
+		credentialUC: credentialUC,
+		clock:        clock,
+		validator:    validator,
+		cutoff:       cutoff,
+	}
+}
+
+// Create offers the remaining seats of a purchase to another user, the purchase keeps its owner until they accept.
+func (rc *TransferUC) Create(ctx context.Context, purchaseID string, request *models.CreateTransferRequest) (*models.Transfer, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate transfer request", http.StatusBadRequest)
+	}
+
+	var transfer *models.Transfer
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		// the row lock keeps refunds and other transfers of the purchase out until the transfer is written
+		purchase, err := rc.purchaseRepo.GetByIDForUpdate(ctx, purchaseID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find purchase", http.StatusNotFound)
+		}
+
+		if request.UserID != "" && purchase.UserID != request.UserID {
+			return pkg.NewError(errors.New("purchase belongs to another user"), "purchase does not belong to the user", http.StatusForbidden)
+		}
+
+		if request.ToUserID == purchase.UserID {
+			return pkg.NewError(errors.New("transfer to the owner"), "purchase already belongs to the recipient", http.StatusBadRequest)
+		}
+
+		if err := rc.checkTransferable(ctx, purchase); err != nil {
+			return err
+		}
+
+		pending, err := rc.transferRepo.ExistsPending(ctx, purchase.ID)
+		if err != nil {
+			return pkg.NewError(err, "failed to check transfers", http.StatusInternalServerError)
+		}
+		if pending {
+			return pkg.NewError(pkg.ErrTransferPending, "purchase already has a pending transfer", http.StatusConflict)
+		}
+
+		transfer, err = rc.transferRepo.Create(ctx, &models.Transfer{
+			PurchaseID: purchase.ID,
+			TicketID:   purchase.TicketID,
+			FromUserID: purchase.UserID,
+			ToUserID:   request.ToUserID,
+			ToEmail:    strings.ToLower(request.ToEmail),
+			Status:     models.TransferStatusPending,
+		})
+		if err != nil {
+			return pkg.NewError(err, "failed to create transfer", http.StatusInternalServerError)
+		}
+
+		details := fmt.Sprintf("%d seats offered to %s", purchase.Remaining(), recipientOf(transfer))
+
+		return rc.audit(ctx, transfer, models.TransferActionInitiated, request.ActorID, details)
+	})
+	if err != nil {
+		return nil, txError(err, "failed to create transfer")
+	}
+
+	if err := rc.loadEvents(ctx, transfer); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// Accept completes a transfer addressed to the caller: the purchase changes hands, its old credentials are revoked
+// and the seats get new ones. The ticket's allocation doesn't change, the seats stay sold.
+func (rc *TransferUC) Accept(ctx context.Context, transferID string, request *models.AcceptTransferRequest) (*models.Transfer, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate accept request", http.StatusBadRequest)
+	}
+
+	var transfer *models.Transfer
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		var err error
+		transfer, err = rc.transferRepo.GetByIDForUpdate(ctx, transferID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find transfer", http.StatusNotFound)
+		}
+
+		if !addressedTo(transfer, request) {
+			return pkg.NewError(errors.New("transfer is addressed to another user"), "transfer is not addressed to the user", http.StatusForbidden)
+		}
+
+		if transfer.Status != models.TransferStatusPending {
+			return pkg.NewError(pkg.ErrTransferNotPending, "transfer was already "+string(transfer.Status), http.StatusConflict)
+		}
+
+		purchase, err := rc.purchaseRepo.GetByIDForUpdate(ctx, strconv.FormatInt(transfer.PurchaseID, 10))
+		if err != nil {
+			return pkg.NewError(err, "failed to find purchase", http.StatusNotFound)
+		}
+
+		if purchase.UserID == request.UserID {
+			return pkg.NewError(errors.New("transfer to the owner"), "purchase already belongs to the recipient", http.StatusBadRequest)
+		}
+
+		if err := rc.checkTransferable(ctx, purchase); err != nil {
+			return err
+		}
+
+		// the seats count against the recipient's limit as if they bought them
+		remaining := purchase.Remaining()
+		ticketID := strconv.FormatInt(purchase.TicketID, 10)
+		if err := checkPurchaseLimit(ctx, rc.ticketRepo, rc.purchaseRepo, rc.holdRepo, ticketID, request.UserID, remaining, rc.clock.Now()); err != nil {
+			return err
+		}
+
+		from := purchase.UserID
+		purchase, err = rc.purchaseRepo.SetOwner(ctx, purchase.ID, request.UserID)
+		if err != nil {
+			return pkg.NewError(err, "failed to transfer purchase", http.StatusInternalServerError)
+		}
+
+		revoked, issued, err := rc.credentialUC.Reissue(ctx, purchase)
+		if err != nil {
+			return err
+		}
+
+		transfer.Status = models.TransferStatusAccepted
+		transfer.AcceptedBy = request.UserID
+		transfer, err = rc.transferRepo.UpdateStatus(ctx, transfer)
+		if err != nil {
+			return pkg.NewError(err, "failed to accept transfer", http.StatusInternalServerError)
+		}
+		transfer.Purchase = purchase
+
+		details := fmt.Sprintf("purchase moved from %s to %s, revoked credentials %v, issued credentials %v",
+			from, request.UserID, credentialIDs(revoked), credentialIDs(issued))
+
+		return rc.audit(ctx, transfer, models.TransferActionAccepted, request.UserID, details)
+	})
+	if err != nil {
+		return nil, txError(err, "failed to accept transfer")
+	}
+
+	if err := rc.loadEvents(ctx, transfer); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// Cancel withdraws a pending transfer, the purchase stays with its owner.
+func (rc *TransferUC) Cancel(ctx context.Context, transferID string, request *models.CancelTransferRequest) (*models.Transfer, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate cancel request", http.StatusBadRequest)
+	}
+
+	var transfer *models.Transfer
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		var err error
+		transfer, err = rc.transferRepo.GetByIDForUpdate(ctx, transferID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find transfer", http.StatusNotFound)
+		}
+
+		if request.UserID != "" && transfer.FromUserID != request.UserID {
+			return pkg.NewError(errors.New("transfer belongs to another user"), "transfer was not sent by the user", http.StatusForbidden)
+		}
+
+		if transfer.Status != models.TransferStatusPending {
+			return pkg.NewError(pkg.ErrTransferNotPending, "transfer was already "+string(transfer.Status), http.StatusConflict)
+		}
+
+		transfer.Status = models.TransferStatusCancelled
+		transfer, err = rc.transferRepo.UpdateStatus(ctx, transfer)
+		if err != nil {
+			return pkg.NewError(err, "failed to cancel transfer", http.StatusInternalServerError)
+		}
+
+		return rc.audit(ctx, transfer, models.TransferActionCancelled, request.ActorID, "")
+	})
+	if err != nil {
+		return nil, txError(err, "failed to cancel transfer")
+	}
+
+	if err := rc.loadEvents(ctx, transfer); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// ListByPurchaseID returns the transfers of a purchase with their audit trail, oldest first.
+// A non-empty userID restricts the listing to the owner of the purchase.
+func (rc *TransferUC) ListByPurchaseID(ctx context.Context, purchaseID, userID string) ([]models.Transfer, error) {
+	purchase, err := rc.purchaseRepo.GetByID(ctx, purchaseID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find purchase", http.StatusNotFound)
+	}
+
+	if userID != "" && purchase.UserID != userID {
+		return nil, pkg.NewError(errors.New("purchase belongs to another user"), "purchase does not belong to the user", http.StatusForbidden)
+	}
+
+	transfers, err := rc.transferRepo.ListByPurchaseID(ctx, purchase.ID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to list transfers", http.StatusInternalServerError)
+	}
+
+	events, err := rc.transferRepo.ListEventsByPurchaseID(ctx, purchase.ID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to list transfer events", http.StatusInternalServerError)
+	}
+
+	byTransfer := make(map[int64][]models.TransferEvent, len(transfers))
+	for _, event := range events {
+		byTransfer[event.TransferID] = append(byTransfer[event.TransferID], event)
+	}
+	for i := range transfers {
+		transfers[i].Events = byTransfer[transfers[i].ID]
+	}
+
+	return transfers, nil
+}
+
+// checkTransferable fails for purchases whose seats can't change hands anymore: nothing left after refunds and resales,
+// the ticket was cancelled or the event is closer than the cutoff, or someone already got in with them.
+func (rc *TransferUC) checkTransferable(ctx context.Context, purchase *models.Purchase) error {
+	if purchase.Status == models.PurchaseStatusPending || purchase.Status == models.PurchaseStatusFailed {
+		return pkg.NewError(pkg.ErrPurchaseNotCompleted, "purchase was not completed", http.StatusConflict)
+	}
+
+	if purchase.Remaining() == 0 {
+		return pkg.NewError(pkg.ErrAlreadyRefunded, "purchase has no seats left", http.StatusConflict)
+	}
+
+	ticket, err := rc.ticketRepo.GetByID(ctx, strconv.FormatInt(purchase.TicketID, 10))
+	if err != nil {
+		return pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+	}
+
+	if ticket.Status == models.TicketStatusCancelled {
+		return pkg.NewError(pkg.ErrTransferClosed, "ticket was cancelled", http.StatusConflict)
+	}
+
+	if !ticket.EventStart.IsZero() && !rc.clock.Now().Before(ticket.EventStart.Add(-rc.cutoff)) {
+		return pkg.NewError(pkg.ErrTransferClosed, fmt.Sprintf("transfers close %s before the event", rc.cutoff), http.StatusConflict)
+	}
+
+	credentials, err := rc.credentialUC.ListByPurchaseID(ctx, purchase.ID)
+	if err != nil {
+		return err
+	}
+	for _, credential := range credentials {
+		if credential.Status == models.CredentialStatusValid && !credential.UsedAt.IsZero() {
+			return pkg.NewError(errors.New("credential was used"), "seats of the purchase were already checked in", http.StatusConflict)
+		}
+	}
+
+	return nil
+}
+
+// audit appends an entry to the audit trail of the transfer, in the transaction of the change it records.
+func (rc *TransferUC) audit(ctx context.Context, transfer *models.Transfer, action models.TransferAction, actorID, details string) error {
+	event := models.TransferEvent{
+		TransferID: transfer.ID,
+		PurchaseID: transfer.PurchaseID,
+		Action:     action,
+		ActorID:    actorID,
+		Details:    details,
+	}
+	if err := rc.transferRepo.CreateEvent(ctx, &event); err != nil {
+		return pkg.NewError(err, "failed to record transfer event", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// loadEvents puts the audit trail on the transfer.
+func (rc *TransferUC) loadEvents(ctx context.Context, transfer *models.Transfer) error {
+	events, err := rc.transferRepo.ListEventsByPurchaseID(ctx, transfer.PurchaseID)
+	if err != nil {
+		return pkg.NewError(err, "failed to list transfer events", http.StatusInternalServerError)
+	}
+
+	transfer.Events = make([]models.TransferEvent, 0, len(events))
+	for _, event := range events {
+		if event.TransferID == transfer.ID {
+			transfer.Events = append(transfer.Events, event)
+		}
+	}
+
+	return nil
+}
+
+// addressedTo reports whether the caller is the recipient of the transfer, by user ID or by the email of their token.
+func addressedTo(transfer *models.Transfer, request *models.AcceptTransferRequest) bool {
+	if transfer.ToUserID != "" {
+		return transfer.ToUserID == request.UserID
+	}
+
+	return request.Email != "" && strings.EqualFold(transfer.ToEmail, request.Email)
+}
+
+func recipientOf(transfer *models.Transfer) string {
+	if transfer.ToUserID != "" {
+		return transfer.ToUserID
+	}
+
+	return transfer.ToEmail
+}
+
+func credentialIDs(credentials []models.Credential) []int64 {
+	ids := make([]int64, 0, len(credentials))
+	for _, credential := range credentials {
+		ids = append(ids, credential.ID)
+	}
+
+	return ids
+}
@@ -0,0 +1,354 @@
+package uc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+
+	"go.uber.org/zap"
+)
+
+type WaitlistUC struct {
+	waitlistRepo interfaces.WaitlistInterfaces
+	ticketRepo   interfaces.TicketInterfaces
+	purchaseRepo interfaces.PurchaseInterfaces
+	tierRepo     interfaces.TierInterfaces
+	holdRepo     interfaces.HoldInterfaces
+	txManager    interfaces.TxInterfaces
+	checkoutUC   *CheckoutUC
+	outboxUC     *OutboxUC
+	clock        pkg.Clock
+	validator    *pkg.CustomValidator
+	offerTTL     time.Duration
+}
+
+// NewWaitlistUC creates a WaitlistUC. Offered seats are reserved for offerTTL before they go to the next user.
+func NewWaitlistUC(waitlistRepo interfaces.WaitlistInterfaces, ticketRepo interfaces.TicketInterfaces, purchaseRepo interfaces.PurchaseInterfaces, tierRepo interfaces.TierInterfaces, holdRepo interfaces.HoldInterfaces, txManager interfaces.TxInterfaces, checkoutUC *CheckoutUC, outboxUC *OutboxUC, clock pkg.Clock, validator *pkg.CustomValidator, offerTTL time.Duration) *WaitlistUC {
+	return &WaitlistUC{
+		waitlistRepo: waitlistRepo,
+		ticketRepo:   ticketRepo,
+		purchaseRepo: purchaseRepo,
+		tierRepo:     tierRepo,
+		holdRepo:     holdRepo,
+		txManager:    txManager,
+		checkoutUC:   checkoutUC,
+		outboxUC:     outboxUC,
+		clock:        clock,
+		validator:    validator,
+		offerTTL:     offerTTL,
+	}
+}
+
+// Join queues the user for seats of a ticket that can't serve the requested quantity right now.
+// It returns the new entry and its 1-based queue position.
+func (rc *WaitlistUC) Join(ctx context.Context, ticketID string, request *models.WaitlistRequest) (*models.WaitlistEntry, int, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, 0, pkg.NewError(err, "failed to validate waitlist request", http.StatusBadRequest)
+	}
+
+	var entry *models.WaitlistEntry
+	var position int
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		// the row lock orders the user's join after any purchase that may still free up the ticket
+		existTicket, err := rc.ticketRepo.GetByIDForUpdate(ctx, ticketID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+		}
+
+		switch {
+		case existTicket.Status == models.TicketStatusDraft || existTicket.Status == models.TicketStatusCancelled:
+			return pkg.NewError(pkg.ErrTicketNotOnSale, "ticket is not on sale", http.StatusConflict)
+		case existTicket.Status == models.TicketStatusOnSale && existTicket.Allocation >= request.Quantity:
+			return pkg.NewError(pkg.ErrTicketAvailable, "ticket can be bought right away", http.StatusConflict)
+		}
+
+		// seats the user already bought or holds count against the limit, Accept checks it again
+		if err := checkPurchaseLimit(ctx, rc.ticketRepo, rc.purchaseRepo, rc.holdRepo, ticketID, request.UserID, request.Quantity, rc.clock.Now()); err != nil {
+			return err
+		}
+
+		queued, err := rc.waitlistRepo.ExistsActive(ctx, ticketID, request.UserID)
+		if err != nil {
+			return pkg.NewError(err, "failed to check waitlist", http.StatusInternalServerError)
+		}
+		if queued {
+			return pkg.NewError(pkg.ErrAlreadyWaitlisted, "user is already on the waitlist of this ticket", http.StatusConflict)
+		}
+
+		entry, err = rc.waitlistRepo.Create(ctx, &models.WaitlistEntry{
+			TicketID: existTicket.ID,
+			UserID:   request.UserID,
+			Quantity: request.Quantity,
+			Status:   models.WaitlistStatusWaiting,
+		})
+		if err != nil {
+			return pkg.NewError(err, "failed to join waitlist", http.StatusInternalServerError)
+		}
+
+		ahead, err := rc.waitlistRepo.CountAhead(ctx, entry)
+		if err != nil {
+			return pkg.NewError(err, "failed to find queue position", http.StatusInternalServerError)
+		}
+		position = ahead + 1
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, txError(err, "failed to join waitlist")
+	}
+
+	return entry, position, nil
+}
+
+// GetByID retrieves a waitlist entry and the 1-based queue position of waiting entries, 0 otherwise.
+func (rc *WaitlistUC) GetByID(ctx context.Context, entryID string) (*models.WaitlistEntry, int, error) {
+	entry, err := rc.waitlistRepo.GetByID(ctx, entryID)
+	if err != nil {
+		return nil, 0, pkg.NewError(err, "failed to find waitlist entry", http.StatusNotFound)
+	}
+
+	if entry.Status != models.WaitlistStatusWaiting {
+		return entry, 0, nil
+	}
+
+	ahead, err := rc.waitlistRepo.CountAhead(ctx, entry)
+	if err != nil {
+		return nil, 0, pkg.NewError(err, "failed to find queue position", http.StatusInternalServerError)
+	}
+
+	return entry, ahead + 1, nil
+}
+
+// Accept turns an open offer into a purchase once it is paid. The seats were already taken from the allocation when
+// they were offered, the offer is paying while the purchase is paid and a failed payment opens it again until it
+// expires. Offers of paused or cancelled tickets
+// can't be accepted, and the seats count against the per-user limit like any other purchase.
+func (rc *WaitlistUC) Accept(ctx context.Context, entryID string, request *models.AcceptOfferRequest) (*models.Purchase, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate accept request", http.StatusBadRequest)
+	}
+
+	existEntry, err := rc.waitlistRepo.GetByID(ctx, entryID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find waitlist entry", http.StatusNotFound)
+	}
+
+	if existEntry.UserID != request.UserID {
+		return nil, pkg.NewError(errors.New("entry belongs to another user"), "waitlist entry belongs to another user", http.StatusForbidden)
+	}
+
+	if existEntry.Status != models.WaitlistStatusOffered || !rc.clock.Now().Before(existEntry.OfferExpiresAt) {
+		return nil, pkg.NewError(pkg.ErrOfferNotActive, "there is no open offer for this waitlist entry", http.StatusConflict)
+	}
+
+	var purchase *models.Purchase
+	err = rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		ticketID := strconv.FormatInt(existEntry.TicketID, 10)
+
+		// offered seats are sold at the current price of the ticket, the offer may have taken its last seats
+		ticket, err := rc.ticketRepo.GetByIDForUpdate(ctx, ticketID)
+		if err != nil {
+			return pkg.NewError(err, "failed to find ticket", http.StatusNotFound)
+		}
+		if ticket.Status == models.TicketStatusPaused || ticket.Status == models.TicketStatusCancelled {
+			return pkg.NewError(pkg.ErrTicketNotOnSale, "ticket is not on sale", http.StatusConflict)
+		}
+
+		if err := checkPurchaseLimit(ctx, rc.ticketRepo, rc.purchaseRepo, rc.holdRepo, ticketID, existEntry.UserID, existEntry.Quantity, rc.clock.Now()); err != nil {
+			return err
+		}
+
+		price := ticket.Price
+		if existEntry.TierID != 0 {
+			tier, err := rc.tierRepo.GetByID(ctx, ticketID, strconv.FormatInt(existEntry.TierID, 10))
+			if err != nil {
+				return pkg.NewError(err, "failed to find tier", http.StatusNotFound)
+			}
+			price = tier.Price
+		}
+
+		newPurchase := models.Purchase{
+			TicketID: existEntry.TicketID,
+			TierID:   existEntry.TierID,
+			UserID:   existEntry.UserID,
+			Quantity: existEntry.Quantity,
+			Status:   models.PurchaseStatusPending,
+		}
+		newPurchase.SetPrice(price, ticket.Currency, nil)
+
+		purchase, err = rc.purchaseRepo.Create(ctx, &newPurchase)
+		if err != nil {
+			return pkg.NewError(err, "failed to create purchase", http.StatusInternalServerError)
+		}
+
+		// the offer may have been expired by the sweeper since it was read
+		if _, err := rc.waitlistRepo.Pay(ctx, entryID, purchase.ID, rc.clock.Now()); err != nil {
+			if errors.Is(err, pkg.ErrOfferNotActive) {
+				return pkg.NewError(err, "there is no open offer for this waitlist entry", http.StatusConflict)
+			}
+			return pkg.NewError(err, "failed to accept offer", http.StatusInternalServerError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, txError(err, "failed to accept offer")
+	}
+
+	return rc.checkoutUC.Settle(ctx, purchase)
+}
+
+// OfferSeats reserves the ticket's available seats for the oldest waiting entries, in order, until the next entry
+// wants more seats than are left. Seats of tickets with tiers come from the first tier, in tier order, that is on sale
+// and can serve the whole entry. Callers that return seats to a ticket run it in the same transaction,
+// so restored seats reach the waitlist before they go back on public sale.
+func (rc *WaitlistUC) OfferSeats(ctx context.Context, ticketID string) error {
+	next, err := rc.waitlistRepo.NextWaiting(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+	if next == nil {
+		return nil
+	}
+
+	return rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		ticket, err := rc.ticketRepo.GetByIDForUpdate(ctx, ticketID)
+		if err != nil {
+			// a deleted ticket has nothing left to offer
+			return nil
+		}
+
+		now := rc.clock.Now()
+		if ticket.Status != models.TicketStatusOnSale || ticket.SaleStatusAt(now) != models.SaleStatusOnSale {
+			return nil
+		}
+
+		available := ticket.Allocation
+		for {
+			entry, err := rc.waitlistRepo.NextWaiting(ctx, ticketID)
+			if err != nil {
+				return err
+			}
+			if entry == nil || entry.Quantity > available {
+				return nil
+			}
+
+			tier, ok, err := rc.offeredTier(ctx, ticketID, entry.Quantity, now)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+
+			var tierID int64
+			if tier != nil {
+				if _, err := rc.tierRepo.DecreaseAllocation(ctx, tier.ID, entry.Quantity); err != nil {
+					return err
+				}
+				tierID = tier.ID
+			}
+
+			t, err := rc.ticketRepo.DecreaseAllocation(ctx, ticketID, entry.Quantity)
+			if err != nil {
+				return err
+			}
+			available -= entry.Quantity
+
+			if err := rc.outboxUC.RecordSoldOut(ctx, t); err != nil {
+				return err
+			}
+
+			if _, err := rc.waitlistRepo.Offer(ctx, entry.ID, tierID, now.Add(rc.offerTTL)); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// offeredTier returns the tier the seats of an offer are taken from, nil for tickets without tiers.
+// It reports false when no tier on sale can serve the quantity.
+func (rc *WaitlistUC) offeredTier(ctx context.Context, ticketID string, quantity int, now time.Time) (*models.TicketTier, bool, error) {
+	tiers, err := rc.tierRepo.ListByTicketID(ctx, ticketID)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(tiers) == 0 {
+		return nil, true, nil
+	}
+
+	for _, tier := range tiers {
+		if tier.Allocation >= quantity && tier.SaleStatusAt(now) == models.SaleStatusOnSale {
+			return &tier, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// ExpireOffers releases every offer that has expired, returns its seats and offers them to the next users in line.
+// It reports how many offers expired.
+func (rc *WaitlistUC) ExpireOffers(ctx context.Context) (int, error) {
+	var expired int
+	err := rc.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		entries, err := rc.waitlistRepo.ExpireOffers(ctx, rc.clock.Now())
+		if err != nil {
+			return err
+		}
+
+		ticketIDs := make([]string, 0)
+		for _, entry := range entries {
+			ticketID := strconv.FormatInt(entry.TicketID, 10)
+			if err := returnSeats(ctx, rc.ticketRepo, rc.tierRepo, ticketID, entry.TierID, entry.Quantity); err != nil {
+				return err
+			}
+			if !slices.Contains(ticketIDs, ticketID) {
+				ticketIDs = append(ticketIDs, ticketID)
+			}
+		}
+
+		for _, ticketID := range ticketIDs {
+			if err := rc.OfferSeats(ctx, ticketID); err != nil {
+				return err
+			}
+		}
+
+		expired = len(entries)
+
+		return nil
+	})
+	if err != nil {
+		return 0, pkg.NewError(err, "failed to expire waitlist offers", http.StatusInternalServerError)
+	}
+
+	return expired, nil
+}
+
+// RunSweeper expires waitlist offers every interval until the context is cancelled.
+func (rc *WaitlistUC) RunSweeper(ctx context.Context, interval time.Duration, logger *zap.SugaredLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := rc.ExpireOffers(ctx)
+			if err != nil {
+				logger.Errorf("failed to sweep expired waitlist offers: %v", err)
+				continue
+			}
+			if expired > 0 {
+				logger.Infof("released %d expired waitlist offers", expired)
+			}
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package uc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fleimkeipa/tickets-api/models"
+	"github.com/fleimkeipa/tickets-api/pkg"
+	"github.com/fleimkeipa/tickets-api/repositories/interfaces"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// webhookBatchSize is how many due deliveries a dispatcher run sends at most.
+	webhookBatchSize = 50
+	// webhookLease keeps a claimed delivery away from other dispatchers while it is sent, it outlasts the send timeout.
+	webhookLease = 5 * time.Minute
+	// webhookMaxBackoff caps the wait between two attempts.
+	webhookMaxBackoff = 6 * time.Hour
+)
+
+type WebhookUC struct {
+	webhookRepo interfaces.WebhookInterfaces
+	sender      *pkg.WebhookSender
+	clock       pkg.Clock
+	validator   *pkg.CustomValidator
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// NewWebhookUC creates a WebhookUC. Failed deliveries are retried after backoff, doubling with every attempt,
+// until maxAttempts requests were made.
+func NewWebhookUC(webhookRepo interfaces.WebhookInterfaces, sender *pkg.WebhookSender, clock pkg.Clock, validator *pkg.CustomValidator, maxAttempts int, backoff time.Duration) *WebhookUC {
+	return &WebhookUC{
+		webhookRepo: webhookRepo,
+		sender:      sender,
+		clock:       clock,
+		validator:   validator,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+	}
+}
+
+// Create adds a webhook subscription, it is active right away.
+func (rc *WebhookUC) Create(ctx context.Context, request *models.CreateWebhookRequest) (*models.WebhookSubscription, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate webhook request", http.StatusBadRequest)
+	}
+
+	subscription, err := rc.webhookRepo.CreateSubscription(ctx, &models.WebhookSubscription{
+		URL:        request.URL,
+		Secret:     request.Secret,
+		EventTypes: request.EventTypes,
+		Active:     true,
+	})
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to create webhook", http.StatusInternalServerError)
+	}
+
+	return subscription, nil
+}
+
+// Update changes the given fields of a webhook subscription. Deliveries already queued keep their payload and are
+// sent to the new URL with the new secret.
+func (rc *WebhookUC) Update(ctx context.Context, subscriptionID string, request *models.UpdateWebhookRequest) (*models.WebhookSubscription, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate webhook request", http.StatusBadRequest)
+	}
+
+	subscription, err := rc.webhookRepo.GetSubscriptionByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find webhook", http.StatusNotFound)
+	}
+
+	columns := make([]string, 0, 4)
+	if request.URL != nil {
+		subscription.URL = *request.URL
+		columns = append(columns, "url")
+	}
+	if request.Secret != nil {
+		subscription.Secret = *request.Secret
+		columns = append(columns, "secret")
+	}
+	if request.EventTypes != nil {
+		subscription.EventTypes = *request.EventTypes
+		columns = append(columns, "event_types")
+	}
+	if request.Active != nil {
+		subscription.Active = *request.Active
+		columns = append(columns, "active")
+	}
+
+	if len(columns) == 0 {
+		return subscription, nil
+	}
+
+	updated, err := rc.webhookRepo.UpdateSubscription(ctx, subscription, columns...)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to update webhook", http.StatusInternalServerError)
+	}
+
+	return updated, nil
+}
+
+// GetByID retrieves a webhook subscription by the provided subscription ID.
+func (rc *WebhookUC) GetByID(ctx context.Context, subscriptionID string) (*models.WebhookSubscription, error) {
+	subscription, err := rc.webhookRepo.GetSubscriptionByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find webhook", http.StatusNotFound)
+	}
+
+	return subscription, nil
+}
+
+// List retrieves every webhook subscription, newest first.
+func (rc *WebhookUC) List(ctx context.Context) ([]models.WebhookSubscription, error) {
+	subscriptions, err := rc.webhookRepo.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to list webhooks", http.StatusInternalServerError)
+	}
+
+	return subscriptions, nil
+}
+
+// Delete removes a webhook subscription, its pending deliveries are dropped when they come due.
+func (rc *WebhookUC) Delete(ctx context.Context, subscriptionID string) error {
+	if err := rc.webhookRepo.DeleteSubscription(ctx, subscriptionID); err != nil {
+		return pkg.NewError(err, "failed to delete webhook", http.StatusNotFound)
+	}
+
+	return nil
+}
+
+// Publish queues a delivery of the outbox event for every active subscription to its type, the outbox relay hands it
+// every event it publishes. It runs in the relay's transaction, so the deliveries are only queued when the event is
+// marked published. An event the relay publishes again is not queued twice, its ID is the event ID of the deliveries.
+func (rc *WebhookUC) Publish(ctx context.Context, event *pkg.Event) error {
+	eventID := "evt_" + strconv.FormatInt(event.ID, 10)
+	queued, err := rc.webhookRepo.ExistsDeliveryByEventID(ctx, eventID)
+	if err != nil {
+		return pkg.NewError(err, "failed to queue webhooks", http.StatusInternalServerError)
+	}
+	if queued {
+		return nil
+	}
+
+	subscriptions, err := rc.webhookRepo.ListSubscriptionsByEvent(ctx, event.Type)
+	if err != nil {
+		return pkg.NewError(err, "failed to find webhooks", http.StatusInternalServerError)
+	}
+
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:        eventID,
+		Type:      event.Type,
+		CreatedAt: event.OccurredAt.UTC(),
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return pkg.NewError(err, "failed to queue webhooks", http.StatusInternalServerError)
+	}
+
+	now := rc.clock.Now()
+	deliveries := make([]models.WebhookDelivery, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			EventType:      event.Type,
+			Payload:        string(payload),
+			Status:         models.WebhookDeliveryStatusPending,
+			NextAttemptAt:  now,
+		})
+	}
+
+	if err := rc.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		return pkg.NewError(err, "failed to queue webhooks", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// ListDeliveries retrieves the delivery log of a webhook subscription, newest first.
+func (rc *WebhookUC) ListDeliveries(ctx context.Context, subscriptionID string, request *models.WebhookDeliveryListRequest) ([]models.WebhookDelivery, error) {
+	if err := rc.validator.Validate(request); err != nil {
+		return nil, pkg.NewError(err, "failed to validate delivery list request", http.StatusBadRequest)
+	}
+
+	if _, err := rc.GetByID(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	limit := request.Limit
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+
+	deliveries, err := rc.webhookRepo.ListDeliveries(ctx, subscriptionID, request.Status, limit)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to list webhook deliveries", http.StatusInternalServerError)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver sends the event of a delivery to its subscription once more, right away and whatever the outcome of the
+// original delivery. The attempt is logged as a new delivery, which is retried like any other if it fails.
+func (rc *WebhookUC) Redeliver(ctx context.Context, subscriptionID, deliveryID string) (*models.WebhookDelivery, error) {
+	subscription, err := rc.GetByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := rc.webhookRepo.GetDeliveryByID(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to find webhook delivery", http.StatusNotFound)
+	}
+
+	// the lease keeps the dispatcher off the new delivery while it is sent here
+	deliveries := []models.WebhookDelivery{{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         models.WebhookDeliveryStatusPending,
+		NextAttemptAt:  rc.clock.Now().Add(webhookLease),
+		RedeliveryOf:   original.ID,
+	}}
+	if err := rc.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		return nil, pkg.NewError(err, "failed to create webhook delivery", http.StatusInternalServerError)
+	}
+
+	delivery, err := rc.send(ctx, subscription, &deliveries[0])
+	if err != nil {
+		return nil, pkg.NewError(err, "failed to record webhook delivery", http.StatusInternalServerError)
+	}
+
+	return delivery, nil
+}
+
+// DeliverDue sends the deliveries whose attempt is due and returns how many were attempted.
+func (rc *WebhookUC) DeliverDue(ctx context.Context) (int, error) {
+	now := rc.clock.Now()
+	deliveries, err := rc.webhookRepo.ClaimDueDeliveries(ctx, now, now.Add(webhookLease), webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		subscription, err := rc.webhookRepo.GetSubscriptionByID(ctx, strconv.FormatInt(delivery.SubscriptionID, 10))
+		if err != nil {
+			// the subscription was deleted since the event was queued
+			delivery.Status = models.WebhookDeliveryStatusFailed
+			delivery.LastError = "webhook subscription was deleted"
+			if _, err := rc.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+				return i, err
+			}
+			continue
+		}
+
+		if _, err := rc.send(ctx, subscription, delivery); err != nil {
+			return i, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// RunDispatcher sends due deliveries every interval until the context is cancelled.
+func (rc *WebhookUC) RunDispatcher(ctx context.Context, interval time.Duration, logger *zap.SugaredLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, err := rc.DeliverDue(ctx)
+			if err != nil {
+				logger.Errorf("failed to dispatch webhooks: %v", err)
+				continue
+			}
+			if sent > 0 {
+				logger.Infof("attempted %d webhook deliveries", sent)
+			}
+		}
+	}
+}
+
+// send makes one attempt of the delivery and records its outcome. A failed attempt is scheduled again after the
+// backoff, or marks the delivery failed once every attempt is used. Only recording the outcome can fail.
+func (rc *WebhookUC) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	now := rc.clock.Now()
+	status, err := rc.sender.Send(ctx, &pkg.WebhookRequest{
+		URL:        subscription.URL,
+		Secret:     subscription.Secret,
+		EventType:  delivery.EventType,
+		DeliveryID: delivery.ID,
+		Payload:    []byte(delivery.Payload),
+		SentAt:     now,
+	})
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = now
+	delivery.ResponseStatus = status
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliveryStatusSucceeded
+		delivery.DeliveredAt = now
+		delivery.NextAttemptAt = time.Time{}
+	case delivery.Attempts >= rc.maxAttempts:
+		delivery.Status = models.WebhookDeliveryStatusFailed
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = time.Time{}
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(rc.retryDelay(delivery.Attempts))
+	}
+
+	return rc.webhookRepo.UpdateDelivery(ctx, delivery)
+}
+
+// retryDelay is the wait after the given number of failed attempts, doubling from the backoff up to webhookMaxBackoff.
+func (rc *WebhookUC) retryDelay(attempts int) time.Duration {
+	delay := rc.backoff
+	for i := 1; i < attempts && delay < webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, webhookMaxBackoff)
+}